@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+var (
+	opCreateRegistration = operation{"createRegistration", http.MethodPost, "/api/v1/registrations"}
+	opCreateSession      = operation{"createSession", http.MethodPost, "/api/v1/sessions"}
+	opDestroySession     = operation{"destroySession", http.MethodDelete, "/api/v1/sessions"}
+	opGetCurrentSession  = operation{"getCurrentSession", http.MethodGet, "/api/v1/sessions/me"}
+)
+
+type userBody struct {
+	User User `json:"user"`
+}
+
+// Register creates an account. It does not log in.
+func (c *Client) Register(ctx context.Context, params RegisterParams) (*User, error) {
+	var out userBody
+	if _, err := c.do(ctx, request{op: opCreateRegistration, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.User, nil
+}
+
+// Login starts a session and authenticates later requests with its token.
+func (c *Client) Login(ctx context.Context, email, password string) (*User, error) {
+	body := map[string]string{"email": email, "password": password}
+	var out userBody
+	resp, err := c.do(ctx, request{op: opCreateSession, body: body}, &out)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range resp.cookies {
+		if cookie.Name == sessionCookieName {
+			c.setSessionToken(cookie.Value)
+			return &out.User, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: response has no %s cookie", opCreateSession.ID, sessionCookieName)
+}
+
+// Logout ends the session and forgets its token.
+func (c *Client) Logout(ctx context.Context) error {
+	if _, err := c.do(ctx, request{op: opDestroySession}, nil); err != nil {
+		return err
+	}
+	c.setSessionToken("")
+	return nil
+}
+
+// CurrentUser returns the user of the session.
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	var out userBody
+	if _, err := c.do(ctx, request{op: opGetCurrentSession}, &out); err != nil {
+		return nil, err
+	}
+	return &out.User, nil
+}
@@ -0,0 +1,205 @@
+// Package client is a typed Go client for the Loomio API.
+//
+// A Client authenticates with the session token of Login, or with one passed
+// to WithToken; either is sent as a bearer token:
+//
+//	c, err := client.New("https://loomio.example.com")
+//	user, err := c.Login(ctx, "alice@example.com", "secret")
+//	group, err := c.CreateGroup(ctx, client.CreateGroupParams{Name: "Climate"})
+//
+// Error responses are returned as *Error, which matches the sentinel errors
+// such as ErrNotFound with errors.Is. List methods return a Page that can
+// fetch the following pages.
+//
+// The request and response types mirror the server's; a test keeps them and
+// the operation paths in sync with the API's Huma operations.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sessionCookieName is the cookie the server sets to the session token.
+const sessionCookieName = "loomio_session"
+
+// Client calls the Loomio API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with h instead of http.DefaultClient.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.http = h }
+}
+
+// WithToken authenticates requests with a session token, e.g. one saved from
+// SessionToken, without logging in.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// New returns a client for the API at baseURL, e.g. "https://example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base URL %q must be absolute", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{baseURL: u}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+	return c, nil
+}
+
+// SessionToken returns the session token requests are authenticated with,
+// or "" if there is none.
+func (c *Client) SessionToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+func (c *Client) setSessionToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// operation is an API operation the client calls. ID is the Huma
+// operation ID; path is the server's path template.
+type operation struct {
+	ID     string
+	Method string
+	Path   string
+}
+
+// request is one call of an operation.
+type request struct {
+	op         operation
+	pathParams []any
+	query      url.Values
+	body       any
+}
+
+// response is the part of an HTTP response the typed methods use.
+type response struct {
+	cookies []*http.Cookie
+}
+
+// do sends req and decodes a successful response body into out, which may
+// be nil. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, req request, out any) (*response, error) {
+	u := *c.baseURL
+	u.Path += expandPath(req.op.Path, req.pathParams)
+	if len(req.query) > 0 {
+		u.RawQuery = req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		data, err := json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: encode request: %w", req.op.ID, err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.op.Method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", req.op.ID, err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.SessionToken(); token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", req.op.ID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, decodeError(resp)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("%s: decode response: %w", req.op.ID, err)
+		}
+	}
+	return &response{cookies: resp.Cookies()}, nil
+}
+
+// expandPath substitutes params, in order, for the {name} segments of a
+// path template.
+func expandPath(template string, params []any) string {
+	var b strings.Builder
+	rest := template
+	for _, p := range params {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			break
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(fmt.Sprint(p)))
+		rest = rest[end+1:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// setQuery adds a query parameter unless value is the zero value.
+func setQuery(q url.Values, name string, value any) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			q.Set(name, v)
+		}
+	case int32:
+		if v != 0 {
+			q.Set(name, strconv.FormatInt(int64(v), 10))
+		}
+	case bool:
+		if v {
+			q.Set(name, "true")
+		}
+	}
+}
+
+// operations are the API operations the client calls, for the test that
+// checks them against the server's.
+var operations = []operation{
+	opCreateRegistration, opCreateSession, opDestroySession, opGetCurrentSession,
+	opCreateGroup, opListGroups, opGetGroup, opGetGroupByHandle, opUpdateGroup,
+	opCreateSubgroup, opListSubgroups, opArchiveGroup, opUnarchiveGroup,
+	opListMemberships, opInviteMember, opGetMembership, opAcceptInvitation, opListMyInvitations,
+	opPromoteMember, opDemoteMember, opRemoveMember, opExtendMembership,
+}
@@ -0,0 +1,357 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+
+	"github.com/zacaytion/llmio/internal/api"
+	"github.com/zacaytion/llmio/internal/auth"
+)
+
+// TestOperationsMatchAPI checks every operation the client calls against
+// the server's Huma registration.
+func TestOperationsMatchAPI(t *testing.T) {
+	humaAPI := humago.New(http.NewServeMux(), huma.DefaultConfig("Test API", "1.0.0"))
+	sessions := auth.NewSessionStore()
+	api.UseAuthentication(humaAPI, sessions)
+	api.NewAuthHandler(nil, sessions).RegisterRoutes(humaAPI)
+	api.NewGroupHandler(nil, nil).RegisterRoutes(humaAPI)
+	api.NewMembershipHandler(nil, nil).RegisterRoutes(humaAPI)
+
+	paths := humaAPI.OpenAPI().Paths
+	for _, op := range operations {
+		t.Run(op.ID, func(t *testing.T) {
+			item, ok := paths[op.Path]
+			if !ok {
+				t.Fatalf("path %s is not registered", op.Path)
+			}
+			byMethod := map[string]*huma.Operation{
+				http.MethodGet:    item.Get,
+				http.MethodPost:   item.Post,
+				http.MethodPatch:  item.Patch,
+				http.MethodDelete: item.Delete,
+			}
+			registered := byMethod[op.Method]
+			if registered == nil {
+				t.Fatalf("%s %s is not registered", op.Method, op.Path)
+			}
+			if registered.OperationID != op.ID {
+				t.Errorf("%s %s is operation %q, want %q", op.Method, op.Path, registered.OperationID, op.ID)
+			}
+		})
+	}
+}
+
+// TestTypesMatchAPI checks that the client's types have the JSON fields and
+// types of the server's DTOs and request bodies.
+func TestTypesMatchAPI(t *testing.T) {
+	tests := []struct {
+		client, server any
+	}{
+		{User{}, api.UserDTO{}},
+		{UserSummary{}, api.UserSummaryDTO{}},
+		{Group{}, api.GroupDTO{}},
+		{GroupDetail{}, api.GroupDetailDTO{}},
+		{Membership{}, api.MembershipDTO{}},
+		{Invitation{}, api.InvitationDTO{}},
+		{RegisterParams{}, api.RegistrationInput{}.Body},
+		{CreateGroupParams{}, api.CreateGroupInput{}.Body},
+		{CreateSubgroupParams{}, api.CreateSubgroupInput{}.Body},
+		{UpdateGroupParams{}, api.UpdateGroupInput{}.Body},
+		{InviteMemberParams{}, api.InviteMemberInput{}.Body},
+		{ExtendMembershipParams{}, api.ExtendMembershipInput{}.Body},
+	}
+	for _, tt := range tests {
+		name := reflect.TypeOf(tt.client).Name()
+		t.Run(name, func(t *testing.T) {
+			got := jsonShape(reflect.TypeOf(tt.client))
+			want := jsonShape(reflect.TypeOf(tt.server))
+			if got != want {
+				t.Errorf("%s does not match %T:\n got %s\nwant %s", name, tt.server, got, want)
+			}
+		})
+	}
+}
+
+// jsonShape describes the JSON encoding of t: field names and types, with
+// pointers marking nullable values. Tag options such as omitempty are
+// ignored, since the client omits optional request fields the server
+// defaults.
+func jsonShape(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + jsonShape(t.Elem())
+	case reflect.Slice:
+		return "[]" + jsonShape(t.Elem())
+	case reflect.Struct:
+		if t == reflect.TypeFor[time.Time]() {
+			return "time"
+		}
+		fields := structFields(t)
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, ",") + "}"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// structFields returns the "name:shape" of each encoded field of a struct,
+// including the fields of embedded structs.
+func structFields(t reflect.Type) []string {
+	var fields []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case f.Anonymous && name == "":
+			fields = append(fields, structFields(f.Type)...)
+		case f.IsExported() && name != "-":
+			fields = append(fields, name+":"+jsonShape(f.Type))
+		}
+	}
+	return fields
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestNew_RequiresAbsoluteURL(t *testing.T) {
+	if _, err := New("/api"); err == nil {
+		t.Error("New(\"/api\") succeeded, want error")
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantIs     error
+		wantDetail string
+	}{
+		{
+			name:       "problem document",
+			status:     http.StatusNotFound,
+			body:       `{"status":404,"title":"Not Found","detail":"Group not found"}`,
+			wantIs:     ErrNotFound,
+			wantDetail: "Group not found",
+		},
+		{
+			name:       "validation errors",
+			status:     http.StatusUnprocessableEntity,
+			body:       `{"status":422,"title":"Unprocessable Entity","detail":"validation failed","errors":[{"message":"expected length >= 1","location":"body.name","value":""}]}`,
+			wantIs:     ErrValidation,
+			wantDetail: "validation failed",
+		},
+		{
+			name:       "not a problem document",
+			status:     http.StatusBadGateway,
+			body:       "upstream unavailable\n",
+			wantDetail: "upstream unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			_, err := c.GetGroup(context.Background(), 1)
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *Error", err)
+			}
+			if apiErr.Status != tt.status {
+				t.Errorf("Status = %d, want %d", apiErr.Status, tt.status)
+			}
+			if apiErr.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", apiErr.Detail, tt.wantDetail)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+			if errors.Is(err, ErrConflict) {
+				t.Errorf("errors.Is(%v, ErrConflict) = true", err)
+			}
+		})
+	}
+}
+
+func TestErrorDecoding_Details(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"status":422,"detail":"validation failed","errors":[{"message":"expected length >= 1","location":"body.name"}]}`))
+	})
+	_, err := c.CreateGroup(context.Background(), CreateGroupParams{})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || len(apiErr.Errors) != 1 {
+		t.Fatalf("error = %v, want *Error with one detail", err)
+	}
+	if got := apiErr.Errors[0].Location; got != "body.name" {
+		t.Errorf("Location = %q, want body.name", got)
+	}
+	if want := "422 validation failed; expected length >= 1 (body.name)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLogin_AuthenticatesLaterRequests(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/sessions":
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["password"] != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "token-1", Secure: true})
+			_, _ = w.Write([]byte(`{"user":{"id":7,"email":"alice@example.com"}}`))
+		case "GET /api/v1/sessions/me":
+			if r.Header.Get("Authorization") != "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"user":{"id":7}}`))
+		case "DELETE /api/v1/sessions":
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := c.CurrentUser(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("CurrentUser before Login error = %v, want ErrUnauthorized", err)
+	}
+	user, err := c.Login(ctx, "alice@example.com", "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if user.ID != 7 || c.SessionToken() != "token-1" {
+		t.Errorf("Login = user %d, token %q; want user 7, token-1", user.ID, c.SessionToken())
+	}
+	if _, err := c.CurrentUser(ctx); err != nil {
+		t.Errorf("CurrentUser after Login: %v", err)
+	}
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if c.SessionToken() != "" {
+		t.Errorf("SessionToken after Logout = %q, want empty", c.SessionToken())
+	}
+}
+
+func TestWithToken(t *testing.T) {
+	var got string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"user":{"id":1}}`))
+	}, WithToken("saved"))
+
+	if _, err := c.CurrentUser(context.Background()); err != nil {
+		t.Fatalf("CurrentUser: %v", err)
+	}
+	if got != "Bearer saved" {
+		t.Errorf("Authorization = %q, want Bearer saved", got)
+	}
+}
+
+func TestPage_All(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/groups/5/memberships" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = w.Write([]byte(`{"memberships":[{"id":1},{"id":2}],"next_cursor":"c2"}`))
+		case "c2":
+			_, _ = w.Write([]byte(`{"memberships":[{"id":3}],"next_cursor":"c3"}`))
+		default:
+			_, _ = w.Write([]byte(`{"memberships":[]}`))
+		}
+	})
+	ctx := context.Background()
+
+	page, err := c.ListMemberships(ctx, 5, ListMembershipsOptions{
+		ListOptions: ListOptions{Limit: 2, Sort: "role"},
+		Status:      "active",
+	})
+	if err != nil {
+		t.Fatalf("ListMemberships: %v", err)
+	}
+	if !page.HasNext() || len(page.Items) != 2 {
+		t.Fatalf("first page = %d items, next %q", len(page.Items), page.NextCursor)
+	}
+
+	var ids []int64
+	for m, err := range page.All(ctx) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		ids = append(ids, m.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("ids = %v, want [1 2 3]", ids)
+	}
+
+	want := []string{
+		"limit=2&sort=role&status=active",
+		"cursor=c2&limit=2&sort=role&status=active",
+		"cursor=c3&limit=2&sort=role&status=active",
+	}
+	if fmt.Sprint(queries) != fmt.Sprint(want) {
+		t.Errorf("queries =\n%v\nwant\n%v", queries, want)
+	}
+}
+
+func TestPage_AllStopsOnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"groups":[{"id":1}],"next_cursor":"c2"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":400,"detail":"invalid cursor"}`))
+	})
+	ctx := context.Background()
+
+	page, err := c.ListGroups(ctx, ListGroupsOptions{})
+	if err != nil {
+		t.Fatalf("ListGroups: %v", err)
+	}
+	var n int
+	var lastErr error
+	for _, err := range page.All(ctx) {
+		n++
+		lastErr = err
+	}
+	if n != 2 || !errors.Is(lastErr, ErrBadRequest) {
+		t.Errorf("All yielded %d values ending in %v, want 2 ending in ErrBadRequest", n, lastErr)
+	}
+}
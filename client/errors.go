@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by an *Error with the same status, for use with
+// errors.Is:
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+var (
+	ErrBadRequest         = &Error{Status: http.StatusBadRequest}
+	ErrUnauthorized       = &Error{Status: http.StatusUnauthorized}
+	ErrForbidden          = &Error{Status: http.StatusForbidden}
+	ErrNotFound           = &Error{Status: http.StatusNotFound}
+	ErrConflict           = &Error{Status: http.StatusConflict}
+	ErrPreconditionFailed = &Error{Status: http.StatusPreconditionFailed}
+	ErrValidation         = &Error{Status: http.StatusUnprocessableEntity}
+	ErrTooManyRequests    = &Error{Status: http.StatusTooManyRequests}
+)
+
+// Error is an error response of the API, an RFC 9457 problem document.
+type Error struct {
+	Status int           `json:"status"`
+	Title  string        `json:"title,omitempty"`
+	Detail string        `json:"detail,omitempty"`
+	Errors []ErrorDetail `json:"errors,omitempty"`
+}
+
+// ErrorDetail describes one invalid part of a request.
+type ErrorDetail struct {
+	Message string `json:"message,omitempty"`
+	// Location is the invalid part, e.g. "body.name" or "query.limit".
+	Location string `json:"location,omitempty"`
+	Value    any    `json:"value,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	s := fmt.Sprintf("%d %s", e.Status, msg)
+	for _, d := range e.Errors {
+		s += "; " + d.Message
+		if d.Location != "" {
+			s += " (" + d.Location + ")"
+		}
+	}
+	return s
+}
+
+// Is reports whether target is the sentinel error for e's status.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Status == e.Status && t.Title == "" && t.Detail == ""
+}
+
+// decodeError reads an error response. Bodies that are not problem
+// documents, e.g. from a proxy, keep the status and use the body as detail.
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &Error{}
+	if err := json.Unmarshal(data, e); err != nil || e.Status == 0 {
+		e = &Error{Detail: strings.TrimSpace(string(data))}
+	}
+	e.Status = resp.StatusCode
+	return e
+}
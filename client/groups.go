@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+var (
+	opCreateGroup      = operation{"createGroup", http.MethodPost, "/api/v1/groups"}
+	opListGroups       = operation{"listGroups", http.MethodGet, "/api/v1/groups"}
+	opGetGroup         = operation{"getGroup", http.MethodGet, "/api/v1/groups/{id}"}
+	opGetGroupByHandle = operation{"getGroupByHandle", http.MethodGet, "/api/v1/group-by-handle/{handle}"}
+	opUpdateGroup      = operation{"updateGroup", http.MethodPatch, "/api/v1/groups/{id}"}
+	opCreateSubgroup   = operation{"createSubgroup", http.MethodPost, "/api/v1/groups/{id}/subgroups"}
+	opListSubgroups    = operation{"listSubgroups", http.MethodGet, "/api/v1/groups/{id}/subgroups"}
+	opArchiveGroup     = operation{"archiveGroup", http.MethodPost, "/api/v1/groups/{id}/archive"}
+	opUnarchiveGroup   = operation{"unarchiveGroup", http.MethodPost, "/api/v1/groups/{id}/unarchive"}
+)
+
+type groupBody struct {
+	Group Group `json:"group"`
+}
+
+type groupDetailBody struct {
+	Group GroupDetail `json:"group"`
+}
+
+type archiveBody struct {
+	Group     Group   `json:"group"`
+	Subgroups []Group `json:"subgroups"`
+}
+
+// ListGroupsOptions select the groups of ListGroups and ListSubgroups.
+// Sort is "name" (the default) or "created_at".
+type ListGroupsOptions struct {
+	ListOptions
+	IncludeArchived bool
+}
+
+// CreateGroup creates a top-level group with the current user as its admin.
+func (c *Client) CreateGroup(ctx context.Context, params CreateGroupParams) (*Group, error) {
+	var out groupBody
+	if _, err := c.do(ctx, request{op: opCreateGroup, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Group, nil
+}
+
+// ListGroups lists the groups the current user is a member of.
+func (c *Client) ListGroups(ctx context.Context, opts ListGroupsOptions) (*Page[Group], error) {
+	q := opts.query()
+	setQuery(q, "include_archived", opts.IncludeArchived)
+	return list[Group](ctx, c, request{op: opListGroups, query: q}, "groups", opts.Cursor)
+}
+
+// GetGroup returns a group the current user can see.
+func (c *Client) GetGroup(ctx context.Context, id int64) (*GroupDetail, error) {
+	var out groupDetailBody
+	if _, err := c.do(ctx, request{op: opGetGroup, pathParams: []any{id}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Group, nil
+}
+
+// GetGroupByHandle returns the group with a handle, or with a retired
+// handle of it.
+func (c *Client) GetGroupByHandle(ctx context.Context, handle string) (*GroupDetail, error) {
+	var out groupDetailBody
+	if _, err := c.do(ctx, request{op: opGetGroupByHandle, pathParams: []any{handle}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Group, nil
+}
+
+// UpdateGroup changes the non-nil fields of params. It requires admin
+// permission.
+func (c *Client) UpdateGroup(ctx context.Context, id int64, params UpdateGroupParams) (*GroupDetail, error) {
+	var out groupDetailBody
+	if _, err := c.do(ctx, request{op: opUpdateGroup, pathParams: []any{id}, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Group, nil
+}
+
+// CreateSubgroup creates a subgroup of parentID.
+func (c *Client) CreateSubgroup(ctx context.Context, parentID int64, params CreateSubgroupParams) (*GroupDetail, error) {
+	var out groupDetailBody
+	if _, err := c.do(ctx, request{op: opCreateSubgroup, pathParams: []any{parentID}, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Group, nil
+}
+
+// ListSubgroups lists the direct subgroups of parentID.
+func (c *Client) ListSubgroups(ctx context.Context, parentID int64, opts ListGroupsOptions) (*Page[Group], error) {
+	q := opts.query()
+	setQuery(q, "include_archived", opts.IncludeArchived)
+	req := request{op: opListSubgroups, pathParams: []any{parentID}, query: q}
+	return list[Group](ctx, c, req, "groups", opts.Cursor)
+}
+
+// ArchiveGroup archives a group and its subgroups, returning both.
+func (c *Client) ArchiveGroup(ctx context.Context, id int64) (*Group, []Group, error) {
+	var out archiveBody
+	if _, err := c.do(ctx, request{op: opArchiveGroup, pathParams: []any{id}}, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out.Group, out.Subgroups, nil
+}
+
+// UnarchiveGroup restores an archived group and the subgroups archived with
+// it, returning both.
+func (c *Client) UnarchiveGroup(ctx context.Context, id int64) (*Group, []Group, error) {
+	var out archiveBody
+	if _, err := c.do(ctx, request{op: opUnarchiveGroup, pathParams: []any{id}}, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out.Group, out.Subgroups, nil
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+var (
+	opListMemberships   = operation{"listMemberships", http.MethodGet, "/api/v1/groups/{groupId}/memberships"}
+	opInviteMember      = operation{"inviteMember", http.MethodPost, "/api/v1/groups/{groupId}/memberships"}
+	opGetMembership     = operation{"getMembership", http.MethodGet, "/api/v1/memberships/{id}"}
+	opAcceptInvitation  = operation{"acceptInvitation", http.MethodPost, "/api/v1/memberships/{id}/accept"}
+	opListMyInvitations = operation{"listMyInvitations", http.MethodGet, "/api/v1/users/me/invitations"}
+	opPromoteMember     = operation{"promoteMember", http.MethodPost, "/api/v1/memberships/{id}/promote"}
+	opDemoteMember      = operation{"demoteMember", http.MethodPost, "/api/v1/memberships/{id}/demote"}
+	opRemoveMember      = operation{"removeMember", http.MethodDelete, "/api/v1/memberships/{id}"}
+	opExtendMembership  = operation{"extendMembership", http.MethodPost, "/api/v1/memberships/{id}/extend"}
+)
+
+type membershipBody struct {
+	Membership Membership `json:"membership"`
+}
+
+// ListMembershipsOptions select the memberships of ListMemberships. Status
+// is "all" (the default), "active" or "pending"; Query searches members'
+// names and usernames. Sort is "role" (the default: admins, then members,
+// then guests, each by name), "name" or "created_at".
+type ListMembershipsOptions struct {
+	ListOptions
+	Status string
+	Query  string
+}
+
+// ListMemberships lists the memberships of a group.
+func (c *Client) ListMemberships(ctx context.Context, groupID int64, opts ListMembershipsOptions) (*Page[Membership], error) {
+	q := opts.query()
+	setQuery(q, "status", opts.Status)
+	setQuery(q, "q", opts.Query)
+	req := request{op: opListMemberships, pathParams: []any{groupID}, query: q}
+	return list[Membership](ctx, c, req, "memberships", opts.Cursor)
+}
+
+// InviteMember invites a user to a group. The membership is pending until
+// the user accepts it.
+func (c *Client) InviteMember(ctx context.Context, groupID int64, params InviteMemberParams) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opInviteMember, pathParams: []any{groupID}, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
+
+// GetMembership returns a membership.
+func (c *Client) GetMembership(ctx context.Context, id int64) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opGetMembership, pathParams: []any{id}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
+
+// AcceptInvitation accepts an invitation of the current user.
+func (c *Client) AcceptInvitation(ctx context.Context, id int64) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opAcceptInvitation, pathParams: []any{id}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
+
+// ListMyInvitations lists the pending invitations of the current user. Sort
+// is "created_at" (the default) or "name", the group's name.
+func (c *Client) ListMyInvitations(ctx context.Context, opts ListOptions) (*Page[Invitation], error) {
+	req := request{op: opListMyInvitations, query: opts.query()}
+	return list[Invitation](ctx, c, req, "invitations", opts.Cursor)
+}
+
+// PromoteMember makes a member an admin. It requires admin permission.
+func (c *Client) PromoteMember(ctx context.Context, id int64) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opPromoteMember, pathParams: []any{id}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
+
+// DemoteMember makes an admin a member. The last admin of a group cannot be
+// demoted.
+func (c *Client) DemoteMember(ctx context.Context, id int64) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opDemoteMember, pathParams: []any{id}}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
+
+// RemoveMember removes a membership. The last admin of a group cannot be
+// removed.
+func (c *Client) RemoveMember(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, request{op: opRemoveMember, pathParams: []any{id}}, nil)
+	return err
+}
+
+// ExtendMembership sets the expiry of a membership.
+func (c *Client) ExtendMembership(ctx context.Context, id int64, params ExtendMembershipParams) (*Membership, error) {
+	var out membershipBody
+	if _, err := c.do(ctx, request{op: opExtendMembership, pathParams: []any{id}, body: params}, &out); err != nil {
+		return nil, err
+	}
+	return &out.Membership, nil
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+)
+
+// ListOptions select a page of a list. The zero value is the first page in
+// the server's default order and size.
+type ListOptions struct {
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Limit is the page size, 1-200; the server defaults to 50.
+	Limit int32
+	// Order is "asc" or "desc".
+	Order string
+	// Sort is the field to sort by; the fields allowed depend on the list.
+	Sort string
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	setQuery(q, "cursor", o.Cursor)
+	setQuery(q, "limit", o.Limit)
+	setQuery(q, "order", o.Order)
+	setQuery(q, "sort", o.Sort)
+	return q
+}
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items []T
+	// NextCursor fetches the following page; it is empty on the last page.
+	NextCursor string
+
+	next func(ctx context.Context, cursor string) (*Page[T], error)
+}
+
+// HasNext reports whether there is a page after p.
+func (p *Page[T]) HasNext() bool {
+	return p.NextCursor != ""
+}
+
+// Next fetches the page after p with the same options. It returns nil and
+// no error on the last page.
+func (p *Page[T]) Next(ctx context.Context) (*Page[T], error) {
+	if !p.HasNext() {
+		return nil, nil
+	}
+	return p.next(ctx, p.NextCursor)
+}
+
+// All iterates over the items of p and all following pages, fetching them
+// as needed. It stops after yielding an error.
+func (p *Page[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page := p; page != nil; {
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			next, err := page.Next(ctx)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			page = next
+		}
+	}
+}
+
+// list fetches the page of req at cursor; the items are the body's field
+// named key. Following pages are fetched with the same request.
+func list[T any](ctx context.Context, c *Client, req request, key, cursor string) (*Page[T], error) {
+	q := url.Values{}
+	for k, v := range req.query {
+		q[k] = v
+	}
+	q.Del("cursor")
+	setQuery(q, "cursor", cursor)
+	req.query = q
+
+	var body map[string]json.RawMessage
+	if _, err := c.do(ctx, req, &body); err != nil {
+		return nil, err
+	}
+	page := &Page[T]{
+		next: func(ctx context.Context, cursor string) (*Page[T], error) {
+			return list[T](ctx, c, req, key, cursor)
+		},
+	}
+	if err := json.Unmarshal(body[key], &page.Items); err != nil {
+		return nil, fmt.Errorf("%s: decode %s: %w", req.op.ID, key, err)
+	}
+	if raw, ok := body["next_cursor"]; ok {
+		if err := json.Unmarshal(raw, &page.NextCursor); err != nil {
+			return nil, fmt.Errorf("%s: decode next_cursor: %w", req.op.ID, err)
+		}
+	}
+	return page, nil
+}
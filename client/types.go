@@ -0,0 +1,152 @@
+package client
+
+import "time"
+
+// User is an account, as returned to the user themselves.
+type User struct {
+	ID            int64     `json:"id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Username      string    `json:"username"`
+	EmailVerified bool      `json:"email_verified"`
+	Key           string    `json:"key"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UserSummary is the public part of another user's account.
+type UserSummary struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// Group is a group as listed.
+type Group struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Handle      string     `json:"handle"`
+	Description *string    `json:"description,omitempty"`
+	ParentID    *int64     `json:"parent_id,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// GroupDetail is a group with its permission flags, member counts and the
+// caller's role, as returned for a single group.
+type GroupDetail struct {
+	Group
+
+	MembersCanAddMembers           bool `json:"members_can_add_members"`
+	MembersCanAddGuests            bool `json:"members_can_add_guests"`
+	MembersCanStartDiscussions     bool `json:"members_can_start_discussions"`
+	MembersCanRaiseMotions         bool `json:"members_can_raise_motions"`
+	MembersCanEditDiscussions      bool `json:"members_can_edit_discussions"`
+	MembersCanEditComments         bool `json:"members_can_edit_comments"`
+	MembersCanDeleteComments       bool `json:"members_can_delete_comments"`
+	MembersCanAnnounce             bool `json:"members_can_announce"`
+	MembersCanCreateSubgroups      bool `json:"members_can_create_subgroups"`
+	AdminsCanEditUserContent       bool `json:"admins_can_edit_user_content"`
+	ParentMembersCanSeeDiscussions bool `json:"parent_members_can_see_discussions"`
+	ParentAdminsCanManage          bool `json:"parent_admins_can_manage"`
+
+	MemberCount int64 `json:"member_count"`
+	AdminCount  int64 `json:"admin_count"`
+	// CurrentUserRole is "admin" or "member".
+	CurrentUserRole string `json:"current_user_role"`
+
+	// ParentArchived is nil for top-level groups.
+	ParentArchived *bool `json:"parent_archived,omitempty"`
+	// ParentArchiveStatusUnknown is set when the parent's status could not
+	// be determined.
+	ParentArchiveStatusUnknown *bool `json:"parent_archive_status_unknown,omitempty"`
+}
+
+// Membership is a user's membership of a group. AcceptedAt is nil while the
+// invitation is pending.
+type Membership struct {
+	ID          int64        `json:"id"`
+	GroupID     int64        `json:"group_id"`
+	UserID      int64        `json:"user_id"`
+	Role        string       `json:"role"`
+	GroupRoleID *int64       `json:"group_role_id,omitempty"`
+	AcceptedAt  *time.Time   `json:"accepted_at,omitempty"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	User        *UserSummary `json:"user,omitempty"`
+	Inviter     *UserSummary `json:"inviter,omitempty"`
+}
+
+// Invitation is a pending invitation of the current user.
+type Invitation struct {
+	ID        int64       `json:"id"`
+	Group     Group       `json:"group"`
+	Inviter   UserSummary `json:"inviter"`
+	Role      string      `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// RegisterParams are the fields of a new account.
+type RegisterParams struct {
+	Email                string `json:"email"`
+	Name                 string `json:"name"`
+	Password             string `json:"password"`
+	PasswordConfirmation string `json:"password_confirmation"`
+}
+
+// CreateGroupParams are the fields of a new top-level group. Handle is
+// generated from Name if empty.
+type CreateGroupParams struct {
+	Name        string  `json:"name"`
+	Handle      string  `json:"handle,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// CreateSubgroupParams are the fields of a new subgroup.
+type CreateSubgroupParams struct {
+	Name                  string  `json:"name"`
+	Handle                string  `json:"handle,omitempty"`
+	Description           *string `json:"description,omitempty"`
+	InheritPermissions    *bool   `json:"inherit_permissions,omitempty"`
+	ParentAdminsCanManage *bool   `json:"parent_admins_can_manage,omitempty"`
+}
+
+// UpdateGroupParams are the group fields to change; nil fields are left as
+// they are.
+type UpdateGroupParams struct {
+	Name                           *string `json:"name,omitempty"`
+	Handle                         *string `json:"handle,omitempty"`
+	Description                    *string `json:"description,omitempty"`
+	MembersCanAddMembers           *bool   `json:"members_can_add_members,omitempty"`
+	MembersCanAddGuests            *bool   `json:"members_can_add_guests,omitempty"`
+	MembersCanStartDiscussions     *bool   `json:"members_can_start_discussions,omitempty"`
+	MembersCanRaiseMotions         *bool   `json:"members_can_raise_motions,omitempty"`
+	MembersCanEditDiscussions      *bool   `json:"members_can_edit_discussions,omitempty"`
+	MembersCanEditComments         *bool   `json:"members_can_edit_comments,omitempty"`
+	MembersCanDeleteComments       *bool   `json:"members_can_delete_comments,omitempty"`
+	MembersCanAnnounce             *bool   `json:"members_can_announce,omitempty"`
+	MembersCanCreateSubgroups      *bool   `json:"members_can_create_subgroups,omitempty"`
+	AdminsCanEditUserContent       *bool   `json:"admins_can_edit_user_content,omitempty"`
+	ParentMembersCanSeeDiscussions *bool   `json:"parent_members_can_see_discussions,omitempty"`
+	ParentAdminsCanManage          *bool   `json:"parent_admins_can_manage,omitempty"`
+}
+
+// InviteMemberParams are the fields of an invitation. Role defaults to
+// "member"; ExpiresAt makes the membership temporary.
+type InviteMemberParams struct {
+	UserID    int64      `json:"user_id"`
+	Role      string     `json:"role,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Force invites a user who declined a previous invitation.
+	Force bool `json:"force,omitempty"`
+}
+
+// ExtendMembershipParams set a membership's expiry; a nil ExpiresAt makes
+// it permanent.
+type ExtendMembershipParams struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Ptr returns a pointer to v, for the optional fields of params.
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zacaytion/llmio/internal/audit"
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// auditCmd groups the audit hash chain commands.
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Verify and checkpoint the audit log hash chain",
+	}
+	cmd.AddCommand(auditVerifyCmd())
+	cmd.AddCommand(auditCheckpointCmd())
+	return cmd
+}
+
+func auditVerifyCmd() *cobra.Command {
+	var publicKeyFile string
+	var batchSize int32
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Walk the audit hash chain and report the first broken link",
+		Long: "Recomputes every audit.record_version hash from the stored row and checks each link to the previous row. " +
+			"Archived rows are skipped; the first remaining row must link to the last archived hash. " +
+			"With --public-key, also checks the signatures of stored checkpoints and that the chain still matches them. " +
+			"Exits non-zero if anything fails.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			queries, closePool, err := openQueries(ctx)
+			if err != nil {
+				return err
+			}
+			defer closePool()
+
+			result, err := audit.VerifyChain(ctx, queries, batchSize)
+			if err != nil {
+				return fmt.Errorf("verify audit chain: %w", err)
+			}
+			out := cmd.OutOrStdout()
+			if !result.OK() {
+				_, _ = fmt.Fprintf(out, "audit chain BROKEN after %d intact rows: %s\n", result.Checked, result.Break)
+				return errors.New("audit chain verification failed")
+			}
+			_, _ = fmt.Fprintf(out, "audit chain OK: %d rows, head chain_seq=%d row_hash=%s\n",
+				result.Checked, result.HeadSeq, hex.EncodeToString(result.HeadHash))
+			if result.ArchivedSeq > 0 {
+				_, _ = fmt.Fprintf(out, "rows up to chain_seq %d are archived and were not checked\n", result.ArchivedSeq)
+			}
+
+			if publicKeyFile == "" {
+				return nil
+			}
+			pub, err := audit.LoadPublicKey(publicKeyFile)
+			if err != nil {
+				return err
+			}
+			checked, problems, err := audit.VerifyCheckpoints(ctx, queries, pub)
+			if err != nil {
+				return fmt.Errorf("verify checkpoints: %w", err)
+			}
+			for _, p := range problems {
+				_, _ = fmt.Fprintf(out, "checkpoint %d (chain_seq %d) FAILED: %s\n", p.ID, p.ChainSeq, p.Reason)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%d of %d checkpoints failed verification", len(problems), checked)
+			}
+			_, _ = fmt.Fprintf(out, "checkpoints OK: %d verified with key %s\n", checked, audit.KeyID(pub))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&publicKeyFile, "public-key", "", "Ed25519 PEM public key to verify stored checkpoints with")
+	cmd.Flags().Int32Var(&batchSize, "batch-size", audit.DefaultVerifyBatchSize, "rows read per query")
+	return cmd
+}
+
+func auditCheckpointCmd() *cobra.Command {
+	var keyFile string
+
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Sign the current audit chain head and print it for publication",
+		Long: "Signs the head of the audit hash chain with an Ed25519 key, stores the checkpoint, and prints it as JSON. " +
+			"If the head has not moved since the latest checkpoint, that checkpoint is printed instead. " +
+			"The key defaults to audit.signing_key_file from the config.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keyFile == "" {
+				keyFile = cfg.Audit.SigningKeyFile
+			}
+			if keyFile == "" {
+				return errors.New("no signing key: set --key-file or audit.signing_key_file")
+			}
+			key, err := audit.LoadPrivateKey(keyFile)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			queries, closePool, err := openQueries(ctx)
+			if err != nil {
+				return err
+			}
+			defer closePool()
+
+			cp, _, err := audit.NewCheckpointer(queries, key).Checkpoint(ctx, time.Now())
+			if err != nil {
+				return fmt.Errorf("checkpoint audit chain: %w", err)
+			}
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(cp)
+		},
+	}
+
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "Ed25519 PKCS#8 PEM private key (defaults to audit.signing_key_file)")
+	return cmd
+}
+
+// openQueries connects a pool for commands that read through sqlc queries.
+func openQueries(ctx context.Context) (*db.Queries, func(), error) {
+	pool, err := db.NewPoolFromConfig(ctx, cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db.New(pool), pool.Close, nil
+}
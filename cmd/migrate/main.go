@@ -6,6 +6,8 @@
 //   - status: Show migration status
 //   - version: Show current database version
 //   - create: Create a new migration file
+//   - audit verify: Check the audit log hash chain and signed checkpoints
+//   - audit checkpoint: Sign and store the current audit chain head
 //
 // Advanced goose commands (up-by-one, up-to, down-to, redo, reset) are omitted
 // for simplicity. For advanced use cases, use goose CLI directly:
@@ -77,6 +79,7 @@ func main() {
 	rootCmd.AddCommand(statusCmd())
 	rootCmd.AddCommand(versionCmd())
 	rootCmd.AddCommand(createCmd())
+	rootCmd.AddCommand(auditCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -18,10 +19,16 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/zacaytion/llmio/internal/api"
+	"github.com/zacaytion/llmio/internal/audit"
 	"github.com/zacaytion/llmio/internal/auth"
+	"github.com/zacaytion/llmio/internal/certs"
 	"github.com/zacaytion/llmio/internal/config"
 	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/jobs"
 	"github.com/zacaytion/llmio/internal/logging"
+	"github.com/zacaytion/llmio/internal/metrics"
+	"github.com/zacaytion/llmio/internal/tracing"
+	"github.com/zacaytion/llmio/migrations"
 )
 
 var (
@@ -57,6 +64,28 @@ func main() {
 	rootCmd.Flags().Duration("http-read-timeout", 15*time.Second, "HTTP read timeout")
 	rootCmd.Flags().Duration("http-write-timeout", 15*time.Second, "HTTP write timeout")
 	rootCmd.Flags().Duration("http-idle-timeout", 60*time.Second, "HTTP idle timeout")
+	rootCmd.Flags().Int("metrics-port", 9090, "admin port serving /metrics (0 disables)")
+	rootCmd.Flags().Duration("shutdown-delay", 5*time.Second, "how long /readyz fails before the listeners close on shutdown")
+	rootCmd.Flags().StringSlice("trusted-proxies", nil, "IPs or CIDRs of reverse proxies whose X-Forwarded-For is trusted")
+
+	// CORS flags
+	rootCmd.Flags().StringSlice("cors-allowed-origins", nil, "origins allowed to call the API from a browser, e.g. https://app.example.com (* allows any, without credentials)")
+	rootCmd.Flags().Bool("cors-allow-credentials", false, "let allowed origins send the session cookie")
+	rootCmd.Flags().Duration("cors-max-age", 10*time.Minute, "how long browsers may cache preflight responses")
+
+	// Security header flags
+	rootCmd.Flags().Duration("hsts-max-age", 365*24*time.Hour, "Strict-Transport-Security max-age (0 omits the header)")
+	rootCmd.Flags().String("frame-options", "DENY", "X-Frame-Options value (DENY, SAMEORIGIN, or empty to omit)")
+	rootCmd.Flags().String("content-security-policy", config.DefaultContentSecurityPolicy, "Content-Security-Policy value (empty omits the header)")
+
+	// TLS flags
+	rootCmd.Flags().String("tls-cert-file", "", "PEM certificate chain for HTTPS (empty disables TLS)")
+	rootCmd.Flags().String("tls-key-file", "", "PEM private key for HTTPS")
+	rootCmd.Flags().Int("tls-port", 8443, "HTTPS port")
+	rootCmd.Flags().String("tls-min-version", "1.2", "minimum TLS version (1.2, 1.3)")
+	rootCmd.Flags().String("tls-client-ca-file", "", "PEM CA bundle that client certificates must chain to (empty disables mTLS)")
+	rootCmd.Flags().Bool("tls-redirect-http", false, "serve only redirects to HTTPS on the HTTP port")
+	rootCmd.Flags().Duration("tls-watch-interval", time.Minute, "how often to check the TLS files for changes (0 reloads on SIGHUP only)")
 
 	// Database flags
 	rootCmd.Flags().String("db-host", "localhost", "database host")
@@ -80,6 +109,35 @@ func main() {
 	rootCmd.Flags().String("log-format", "json", "log format (json, text)")
 	rootCmd.Flags().String("log-output", "stdout", "log output (stdout, stderr, or file path)")
 
+	// Retention flags
+	rootCmd.Flags().Int("retention-archived-group-days", 0, "hard-delete groups archived longer than this many days (0 disables)")
+	rootCmd.Flags().Duration("retention-purge-interval", time.Hour, "interval between archived group purge runs")
+
+	// Membership expiry flags
+	rootCmd.Flags().Duration("memberships-expiry-interval", 5*time.Minute, "interval between membership expiry runs")
+	rootCmd.Flags().Duration("memberships-expiry-notice", 72*time.Hour, "notify members this long before their membership expires (0 disables)")
+
+	// Audit checkpoint flags
+	rootCmd.Flags().String("audit-signing-key-file", "", "Ed25519 PKCS#8 PEM key for signing audit chain checkpoints (empty disables)")
+	rootCmd.Flags().Duration("audit-checkpoint-interval", 24*time.Hour, "interval between signed audit chain checkpoints")
+
+	// Audit partition flags
+	rootCmd.Flags().Int("audit-partition-months-ahead", 3, "monthly audit partitions to create ahead of time")
+	rootCmd.Flags().Int("audit-retention-months", 0, "archive and drop audit partitions older than this many months (0 disables)")
+	rootCmd.Flags().String("audit-archive-dir", "./audit-archive", "directory for archived audit partitions")
+	rootCmd.Flags().Duration("audit-maintenance-interval", 24*time.Hour, "interval between audit partition maintenance runs")
+
+	// Tracing flags
+	rootCmd.Flags().String("tracing-exporter", "none", "trace exporter (none, stdout, otlp)")
+	rootCmd.Flags().String("tracing-otlp-endpoint", "", "OTLP/HTTP endpoint URL (empty uses OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.Flags().Float64("tracing-sample-ratio", 1.0, "fraction of new traces to sample (0-1)")
+
+	// Idempotency flags
+	rootCmd.Flags().Duration("idempotency-ttl", 24*time.Hour, "how long responses to POSTs with an Idempotency-Key are replayed")
+	rootCmd.Flags().Duration("idempotency-cleanup-interval", time.Hour, "interval between expired idempotency key cleanups")
+
+	rootCmd.AddCommand(openapiCmd())
+
 	// Bind flags to viper for priority override.
 	// Note: We use fmt.Fprintln to stderr for errors here because logging
 	// isn't configured yet - config must be loaded first to know log settings.
@@ -103,6 +161,28 @@ func bindFlags(cmd *cobra.Command) error {
 	b.bind("server.read_timeout", "http-read-timeout")
 	b.bind("server.write_timeout", "http-write-timeout")
 	b.bind("server.idle_timeout", "http-idle-timeout")
+	b.bind("server.metrics_port", "metrics-port")
+	b.bind("server.shutdown_delay", "shutdown-delay")
+	b.bind("server.trusted_proxies", "trusted-proxies")
+
+	// Bind CORS flags
+	b.bind("server.cors.allowed_origins", "cors-allowed-origins")
+	b.bind("server.cors.allow_credentials", "cors-allow-credentials")
+	b.bind("server.cors.max_age", "cors-max-age")
+
+	// Bind security header flags
+	b.bind("server.security_headers.hsts_max_age", "hsts-max-age")
+	b.bind("server.security_headers.frame_options", "frame-options")
+	b.bind("server.security_headers.content_security_policy", "content-security-policy")
+
+	// Bind TLS flags
+	b.bind("server.tls.cert_file", "tls-cert-file")
+	b.bind("server.tls.key_file", "tls-key-file")
+	b.bind("server.tls.port", "tls-port")
+	b.bind("server.tls.min_version", "tls-min-version")
+	b.bind("server.tls.client_ca_file", "tls-client-ca-file")
+	b.bind("server.tls.redirect_http", "tls-redirect-http")
+	b.bind("server.tls.watch_interval", "tls-watch-interval")
 
 	// Bind database flags
 	b.bind("database.host", "db-host")
@@ -126,6 +206,33 @@ func bindFlags(cmd *cobra.Command) error {
 	b.bind("logging.format", "log-format")
 	b.bind("logging.output", "log-output")
 
+	// Bind retention flags
+	b.bind("retention.archived_group_days", "retention-archived-group-days")
+	b.bind("retention.purge_interval", "retention-purge-interval")
+
+	// Bind membership expiry flags
+	b.bind("memberships.expiry_interval", "memberships-expiry-interval")
+	b.bind("memberships.expiry_notice", "memberships-expiry-notice")
+
+	// Bind audit checkpoint flags
+	b.bind("audit.signing_key_file", "audit-signing-key-file")
+	b.bind("audit.checkpoint_interval", "audit-checkpoint-interval")
+
+	// Bind audit partition flags
+	b.bind("audit.partition_months_ahead", "audit-partition-months-ahead")
+	b.bind("audit.retention_months", "audit-retention-months")
+	b.bind("audit.archive_dir", "audit-archive-dir")
+	b.bind("audit.maintenance_interval", "audit-maintenance-interval")
+
+	// Bind tracing flags
+	b.bind("tracing.exporter", "tracing-exporter")
+	b.bind("tracing.otlp_endpoint", "tracing-otlp-endpoint")
+	b.bind("tracing.sample_ratio", "tracing-sample-ratio")
+
+	// Bind idempotency flags
+	b.bind("idempotency.ttl", "idempotency-ttl")
+	b.bind("idempotency.cleanup_interval", "idempotency-cleanup-interval")
+
 	return b.err()
 }
 
@@ -169,6 +276,22 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	// Setup tracing before the pool so query spans use the configured provider
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+	if cfg.Tracing.Exporter != string(config.TraceExporterNone) {
+		slog.Info("tracing enabled", "exporter", cfg.Tracing.Exporter, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
 	// Connect to database using config
 	pool, err := db.NewPoolFromConfig(ctx, cfg.Database)
 	if err != nil {
@@ -184,46 +307,172 @@ func runServer(cmd *cobra.Command, args []string) error {
 	defer cancelCleanup()
 	go startSessionCleanup(cleanupCtx, sessionStore, cfg.Session.CleanupInterval)
 
+	// Expose pool and session gauges on /metrics
+	if err := metrics.RegisterPool(pool); err != nil {
+		return fmt.Errorf("failed to register pool metrics: %w", err)
+	}
+	if err := metrics.RegisterSessionStore(sessionStore); err != nil {
+		return fmt.Errorf("failed to register session metrics: %w", err)
+	}
+
 	// Create router using stdlib ServeMux
 	mux := http.NewServeMux()
 
-	// Create Huma API with stdlib adapter
-	humaAPI := humago.New(mux, huma.DefaultConfig("Loomio API", "1.0.0"))
-
 	// Create queries instance
 	queries := db.New(pool)
 
+	// Create Huma API with stdlib adapter
+	humaAPI := newAPI(mux, sessionStore, queries, cfg.Idempotency.TTL)
+
+	// Drop stored responses to retried POSTs once expired
+	idempotencyCleaner := jobs.NewIdempotencyKeyCleaner(queries)
+	go idempotencyCleaner.Run(cleanupCtx, cfg.Idempotency.CleanupInterval)
+
+	// Start archived group purge job if retention is enabled
+	if retention := cfg.Retention.ArchivedGroupRetention(); retention > 0 {
+		purger := jobs.NewGroupPurger(pool, queries, retention)
+		go purger.Run(cleanupCtx, cfg.Retention.PurgeInterval)
+		slog.Info("archived group purge enabled", "retention_days", cfg.Retention.ArchivedGroupDays)
+	}
+
+	// Start membership expiry job
+	expirer := jobs.NewMembershipExpirer(pool, queries, cfg.Memberships.ExpiryNotice)
+	go expirer.Run(cleanupCtx, cfg.Memberships.ExpiryInterval)
+
+	// Start audit chain checkpoints if a signing key is configured
+	if cfg.Audit.SigningKeyFile != "" {
+		key, err := audit.LoadPrivateKey(cfg.Audit.SigningKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load audit signing key: %w", err)
+		}
+		checkpointer := jobs.NewAuditCheckpointer(queries, key)
+		go checkpointer.Run(cleanupCtx, cfg.Audit.CheckpointInterval)
+		slog.Info("audit chain checkpoints enabled", "key_id", audit.KeyID(key.Public().(ed25519.PublicKey)))
+	}
+
+	// Start audit partition maintenance (archiving only with a retention period)
+	maintainer := jobs.NewAuditMaintainer(pool, queries, cfg.Audit.ArchiveDir,
+		cfg.Audit.PartitionMonthsAhead, cfg.Audit.RetentionMonths)
+	go maintainer.Run(cleanupCtx, cfg.Audit.MaintenanceInterval)
+	if cfg.Audit.RetentionMonths > 0 {
+		slog.Info("audit partition archiving enabled",
+			"retention_months", cfg.Audit.RetentionMonths, "archive_dir", cfg.Audit.ArchiveDir)
+	}
+
+	// Readiness requires the schema to have every embedded migration
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+
 	// Create app with dependencies
 	app := &App{
 		Pool:         pool,
 		Queries:      queries,
 		SessionStore: sessionStore,
+		Health:       api.NewHealthHandler(pool, schemaVersion),
 	}
 
 	// Register routes
 	app.RegisterRoutes(humaAPI)
 
+	// Security headers and CORS apply to every response, including CSRF
+	// rejections, so browsers can read them
+	var handler http.Handler = api.CSRFMiddleware(mux)
+	handler = api.CORSMiddleware(cfg.Server.CORS)(handler)
+	handler = api.SecurityHeadersMiddleware(cfg.Server.SecurityHeaders)(handler)
+	if len(cfg.Server.CORS.AllowedOrigins) > 0 {
+		slog.Info("CORS enabled", "origins", cfg.Server.CORS.AllowedOrigins,
+			"allow_credentials", cfg.Server.CORS.AllowCredentials)
+	}
+	handler = tracing.Middleware(api.RequestIDMiddleware(cfg.Server.TrustedProxies)(api.LoggingMiddleware(handler)))
+
+	// Serve HTTPS with a certificate reloaded on SIGHUP and file changes
+	tlsCfg := cfg.Server.TLS
+	var tlsServer *http.Server
+	if tlsCfg.Enabled() {
+		minVersion, err := certs.MinVersion(tlsCfg.MinVersion)
+		if err != nil {
+			return err
+		}
+		reloader, err := certs.Load(tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		go reloadOnSIGHUP(cleanupCtx, reloader)
+		if tlsCfg.WatchInterval > 0 {
+			go jobs.RunPeriodically(cleanupCtx, "tls_certificate_reloader", tlsCfg.WatchInterval, reloader.ReloadIfChanged)
+		}
+		tlsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", tlsCfg.Port),
+			Handler:      handler,
+			TLSConfig:    reloader.TLSConfig(minVersion),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+	}
+
+	// The HTTP port serves the API too, unless it only redirects to HTTPS.
+	// Either way it answers health probes, which cannot present the client
+	// certificates the HTTPS port may require
+	httpHandler := handler
+	if tlsCfg.RedirectHTTP {
+		httpHandler = tracing.Middleware(api.RequestIDMiddleware(cfg.Server.TrustedProxies)(api.LoggingMiddleware(api.HTTPSRedirectHandler(tlsCfg.Port, mux))))
+	}
+
 	// Create server with config values
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      api.LoggingMiddleware(mux),
+		Handler:      httpHandler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	// Error channel for server goroutine
-	serverErr := make(chan error, 1)
+	// Error channel for server goroutines
+	serverErr := make(chan error, 3)
 
 	// Start server in goroutine
 	go func() {
-		slog.Info("server starting", "port", cfg.Server.Port)
+		slog.Info("server starting", "port", cfg.Server.Port, "redirect_to_https", tlsCfg.RedirectHTTP)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
 
+	if tlsServer != nil {
+		go func() {
+			slog.Info("TLS server starting", "port", tlsCfg.Port, "min_version", tlsCfg.MinVersion,
+				"client_certificates", tlsCfg.ClientCAFile != "")
+			// The certificate comes from TLSConfig
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				serverErr <- fmt.Errorf("TLS server: %w", err)
+			}
+		}()
+	}
+
+	// Start admin server for metrics, kept off the public port
+	var adminServer *http.Server
+	if cfg.Server.MetricsPort > 0 {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("GET /metrics", metrics.Handler())
+		adminServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.MetricsPort),
+			Handler:      adminMux,
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+		}
+		go func() {
+			slog.Info("metrics server starting", "port", cfg.Server.MetricsPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serverErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal or server error
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -235,15 +484,44 @@ func runServer(cmd *cobra.Command, args []string) error {
 		slog.Info("shutting down server")
 	}
 
+	if err := drainAndShutdown(app.Health, cfg.Server.ShutdownDelay, quit, server, adminServer, tlsServer); err != nil {
+		return err
+	}
+
+	slog.Info("server exited")
+	return nil
+}
+
+// drainAndShutdown reports not-ready, keeps serving for delay so readiness
+// probes see it and stop routing new traffic here, then shuts the servers
+// down gracefully. Another signal on quit ends the delay early. Errors
+// shutting down the extra servers, which may be nil, are only logged.
+func drainAndShutdown(health *api.HealthHandler, delay time.Duration, quit <-chan os.Signal, server *http.Server, extra ...*http.Server) error {
+	health.SetShuttingDown()
+	if delay > 0 {
+		slog.Info("draining before shutdown", "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-quit:
+			slog.Info("second signal received, skipping drain delay")
+		}
+	}
+
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	for _, s := range extra {
+		if s == nil {
+			continue
+		}
+		if err := s.Shutdown(ctx); err != nil {
+			slog.Error("server forced to shutdown", "addr", s.Addr, "error", err)
+		}
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
-
-	slog.Info("server exited")
 	return nil
 }
 
@@ -265,16 +543,52 @@ func startSessionCleanup(ctx context.Context, store *auth.SessionStore, interval
 	}
 }
 
+// reloadOnSIGHUP reloads the TLS certificate whenever the process receives
+// SIGHUP, e.g. from a certificate renewal hook. A failed reload keeps the
+// current certificate.
+func reloadOnSIGHUP(ctx context.Context, reloader *certs.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := reloader.Reload(); err != nil {
+				slog.ErrorContext(ctx, "failed to reload TLS certificate", "error", err)
+			}
+		}
+	}
+}
+
+// newAPI creates the Huma API on mux with the response formats and
+// middleware every route relies on. Clients of the Loomio Vue app can ask for
+// Loomio's records format, and retried POSTs with an Idempotency-Key are
+// answered from responses stored for idempotencyTTL.
+func newAPI(mux *http.ServeMux, sessionStore *auth.SessionStore, queries *db.Queries, idempotencyTTL time.Duration) huma.API {
+	humaConfig := huma.DefaultConfig("Loomio API", "1.0.0")
+	api.UseRecordsFormat(&humaConfig)
+	humaAPI := humago.New(mux, humaConfig)
+	humaAPI.UseMiddleware(tracing.HumaMiddleware, metrics.HumaMiddleware, api.RecordsFormatMiddleware)
+	api.UseAuthentication(humaAPI, sessionStore)
+	api.UseIdempotency(humaAPI, queries, idempotencyTTL)
+	return humaAPI
+}
+
 // App holds application dependencies for handler registration.
 type App struct {
 	Pool         *pgxpool.Pool
 	Queries      *db.Queries
 	SessionStore *auth.SessionStore
+	Health       *api.HealthHandler
 }
 
 // RegisterRoutes registers all API routes.
 func (a *App) RegisterRoutes(humaAPI huma.API) {
-	// Health check
+	// Health check (kept for existing monitors; orchestrators should use
+	// /livez and /readyz)
 	huma.Get(humaAPI, "/health", func(ctx context.Context, input *struct{}) (*struct {
 		Body struct {
 			Status string `json:"status"`
@@ -289,17 +603,32 @@ func (a *App) RegisterRoutes(humaAPI huma.API) {
 		}{Status: "ok"}}, nil
 	})
 
+	// Liveness and readiness probes
+	a.Health.RegisterRoutes(humaAPI)
+
 	// Auth routes
 	authHandler := api.NewAuthHandler(a.Queries, a.SessionStore)
 	authHandler.RegisterRoutes(humaAPI)
 
 	// Group routes (Feature 004)
-	groupHandler := api.NewGroupHandler(a.Pool, a.Queries, a.SessionStore)
+	groupHandler := api.NewGroupHandler(a.Pool, a.Queries)
 	groupHandler.RegisterRoutes(humaAPI)
 
 	// Membership routes (Feature 004)
-	membershipHandler := api.NewMembershipHandler(a.Pool, a.Queries, a.SessionStore)
+	membershipHandler := api.NewMembershipHandler(a.Pool, a.Queries)
 	membershipHandler.RegisterRoutes(humaAPI)
 
+	// Custom role routes
+	roleHandler := api.NewRoleHandler(a.Pool, a.Queries)
+	roleHandler.RegisterRoutes(humaAPI)
+
+	// Invitation decline/revoke/resend and reporting routes
+	invitationHandler := api.NewInvitationHandler(a.Pool, a.Queries)
+	invitationHandler.RegisterRoutes(humaAPI)
+
+	// Notification routes
+	notificationHandler := api.NewNotificationHandler(a.Pool, a.Queries)
+	notificationHandler.RegisterRoutes(humaAPI)
+
 	slog.Debug("routes registered")
 }
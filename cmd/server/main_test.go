@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+
+	"github.com/zacaytion/llmio/internal/api"
+)
+
+// TestDrainAndShutdown verifies /readyz fails while the server still serves
+// during the drain delay, and that a second signal ends the delay early.
+func TestDrainAndShutdown(t *testing.T) {
+	health := api.NewHealthHandler(nil, 1)
+	mux := http.NewServeMux()
+	health.RegisterRoutes(humago.New(mux, huma.DefaultConfig("Test API", "1.0.0")))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(ln) }()
+	readyz := "http://" + ln.Addr().String() + "/readyz"
+
+	quit := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- drainAndShutdown(health, time.Minute, quit, server, nil) }()
+
+	// The listener stays open and reports not-ready during the delay
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(readyz)
+		if err != nil {
+			t.Fatalf("server stopped serving during the drain delay: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected /readyz to return 503 while draining, got %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown finished before the drain delay: %v", err)
+	default:
+	}
+
+	quit <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("drainAndShutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a second signal should end the drain delay")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Minute {
+		t.Errorf("expected the drain delay to be cut short, took %v", elapsed)
+	}
+
+	if resp, err := http.Get(readyz); err == nil {
+		_ = resp.Body.Close()
+		t.Error("expected the listener to be closed after shutdown")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/zacaytion/llmio/internal/api"
+	"github.com/zacaytion/llmio/internal/auth"
+)
+
+// openapiCmd writes the OpenAPI document the server serves at /openapi.
+func openapiCmd() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Write the OpenAPI spec of the API",
+		Long: "Builds the API without connecting to the database and writes its OpenAPI 3.1 document, " +
+			"the same one the server serves at /openapi.",
+		Args: cobra.NoArgs,
+		// The spec does not depend on configuration
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := marshalOpenAPI(specAPI().OpenAPI(), format)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err = cmd.OutOrStdout().Write(spec)
+				return err
+			}
+			if err := os.WriteFile(output, spec, 0o644); err != nil {
+				return fmt.Errorf("write spec: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "yaml", "output format (yaml, json)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+	return cmd
+}
+
+// specAPI registers every route on an API without dependencies, for its
+// OpenAPI document only; its handlers must not be called.
+func specAPI() huma.API {
+	sessionStore := auth.NewSessionStore()
+	humaAPI := newAPI(http.NewServeMux(), sessionStore, nil, 0)
+	app := &App{
+		SessionStore: sessionStore,
+		Health:       api.NewHealthHandler(nil, 0),
+	}
+	app.RegisterRoutes(humaAPI)
+	return humaAPI
+}
+
+// marshalOpenAPI encodes the document as YAML or indented JSON.
+func marshalOpenAPI(oapi *huma.OpenAPI, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		spec, err := oapi.YAML()
+		if err != nil {
+			return nil, fmt.Errorf("encode spec: %w", err)
+		}
+		return spec, nil
+	case "json":
+		spec, err := json.MarshalIndent(oapi, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("encode spec: %w", err)
+		}
+		return append(spec, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q (want yaml or json)", format)
+	}
+}
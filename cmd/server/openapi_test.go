@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/contract"
+)
+
+// contractFiles are the OpenAPI contracts of the specified features.
+var contractFiles = []string{
+	"../../specs/001-user-auth/contracts/auth.yaml",
+	"../../specs/004-groups-memberships/contracts/groups.yaml",
+}
+
+// knownContractChanges are accepted divergences from the contracts, with
+// the reason they were accepted.
+var knownContractChanges = map[string]string{
+	// Subgroups are created with POST /groups/{id}/subgroups, which takes
+	// inherit_permissions; the contract shares one request schema for both.
+	"POST /api/v1/groups: removed field request body.inherit_permissions": "subgroup-only field",
+}
+
+// TestOpenAPIContracts compares the generated spec with the feature
+// contracts and fails on breaking changes.
+func TestOpenAPIContracts(t *testing.T) {
+	spec, err := marshalOpenAPI(specAPI().OpenAPI(), "json")
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+	current, err := contract.Parse(spec)
+	if err != nil {
+		t.Fatalf("parse generated spec: %v", err)
+	}
+
+	for _, file := range contractFiles {
+		t.Run(file, func(t *testing.T) {
+			base, err := contract.Load(file)
+			if err != nil {
+				t.Fatalf("load contract: %v", err)
+			}
+			for _, change := range contract.Breaking(base, current) {
+				if _, ok := knownContractChanges[change.String()]; !ok {
+					t.Errorf("breaking change: %s", change)
+				}
+			}
+		})
+	}
+}
+
+// TestOpenAPICmd verifies the spec is written in both formats.
+func TestOpenAPICmd(t *testing.T) {
+	run := func(args ...string) (string, error) {
+		cmd := openapiCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("--format", "json")
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Paths["/api/v1/groups"] == nil {
+		t.Errorf("unexpected spec: openapi %q, %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+
+	out, err = run()
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	if !strings.Contains(out, "\nopenapi: 3.1.0\n") {
+		t.Errorf("expected YAML by default, got %.100s", out)
+	}
+
+	if _, err := run("--format", "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
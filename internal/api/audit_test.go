@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -58,11 +59,12 @@ func setupAuditTest(t *testing.T) *testAuditSetup {
 	queries := db.New(pool)
 	sessions := auth.NewSessionStore()
 
-	groupHandler := NewGroupHandler(pool, queries, sessions)
-	membershipHandler := NewMembershipHandler(pool, queries, sessions)
+	groupHandler := NewGroupHandler(pool, queries)
+	membershipHandler := NewMembershipHandler(pool, queries)
 
 	mux := http.NewServeMux()
 	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	UseAuthentication(api, sessions)
 	groupHandler.RegisterRoutes(api)
 	membershipHandler.RegisterRoutes(api)
 
@@ -828,3 +830,97 @@ func TestAudit_RecordJSONBContents(t *testing.T) {
 		t.Errorf("expected archived_at to be nil, got %v", groupInsert.Record["archived_at"])
 	}
 }
+
+// TestAudit_RequestMetadata verifies audited writes record the request ID,
+// client IP, and user agent, and that they are covered by the row hash.
+func TestAudit_RequestMetadata(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	admin := setup.createTestUser(t, "admin@example.com", "Admin")
+	adminToken := setup.createTestSession(t, admin.ID)
+
+	body, _ := json.Marshal(map[string]any{"name": "Request Metadata Group"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/groups", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(RequestIDHeader, "lb-req-42")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	req.Header.Set("User-Agent", "audit-test/1.0")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w := httptest.NewRecorder()
+	// httptest requests come from 192.0.2.1, here the load balancer
+	RequestIDMiddleware([]string{"192.0.2.1", "10.0.0.0/8"})(setup.mux).ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create group: %d: %s", w.Code, w.Body.String())
+	}
+
+	rows, err := setup.pool.Query(ctx,
+		`SELECT table_name, client_ip, user_agent,
+		        row_hash = audit.record_version_hash(
+		            prev_hash, chain_seq, id, record_id, old_record_id, op, ts, xact_id,
+		            table_schema, table_name, record, old_record, actor_id,
+		            request_id, client_ip, user_agent) AS hash_ok
+		 FROM audit.record_version WHERE request_id = 'lb-req-42'`)
+	if err != nil {
+		t.Fatalf("failed to query audit records: %v", err)
+	}
+	defer rows.Close()
+
+	tables := map[string]bool{}
+	for rows.Next() {
+		var table, clientIP, userAgent string
+		var hashOK bool
+		if err := rows.Scan(&table, &clientIP, &userAgent, &hashOK); err != nil {
+			t.Fatalf("failed to scan audit record: %v", err)
+		}
+		tables[table] = true
+		if clientIP != "203.0.113.7" || userAgent != "audit-test/1.0" {
+			t.Errorf("%s: expected client 203.0.113.7 with audit-test/1.0, got %q with %q", table, clientIP, userAgent)
+		}
+		if !hashOK {
+			t.Errorf("%s: row_hash does not cover the request metadata", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to read audit records: %v", err)
+	}
+	if !tables["groups"] || !tables["memberships"] {
+		t.Errorf("expected group and membership rows tagged with the request ID, got %v", tables)
+	}
+}
+
+// TestAudit_UserRedaction verifies users are audited with password_hash
+// hashed rather than stored.
+func TestAudit_UserRedaction(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	user := setup.createTestUser(t, "redact@example.com", "Redact")
+	if _, err := setup.pool.Exec(ctx, "UPDATE users SET password_hash = 'new-hash' WHERE id = $1", user.ID); err != nil {
+		t.Fatalf("failed to change password: %v", err)
+	}
+
+	records := setup.getAuditRecords(t, "users")
+	if len(records) != 3 {
+		t.Fatalf("expected insert, verification, and password change records, got %d", len(records))
+	}
+	insert, change := records[0], records[2]
+	if insert.Op != "INSERT" || insert.Record["email"] != "redact@example.com" {
+		t.Errorf("expected an INSERT with the email, got %s %v", insert.Op, insert.Record)
+	}
+
+	for _, snapshot := range []map[string]any{insert.Record, change.OldRecord, change.Record} {
+		hashed, _ := snapshot["password_hash"].(string)
+		if !strings.HasPrefix(hashed, "sha256:") {
+			t.Errorf("expected password_hash to be hashed, got %q", hashed)
+		}
+	}
+	if insert.Record["password_hash"] == user.PasswordHash || change.Record["password_hash"] == "new-hash" {
+		t.Error("password_hash was stored in the audit log")
+	}
+	if change.OldRecord["password_hash"] == change.Record["password_hash"] {
+		t.Error("expected the password change to be visible as a different hash")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/zacaytion/llmio/internal/auth"
 	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/metrics"
 )
 
 // dummyPasswordHash is a valid Argon2id hash used for timing-safe comparisons
@@ -69,6 +70,7 @@ func (h *AuthHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Log out (destroy session)",
 		Description: "Logs out the current user by invalidating their session.",
 		Tags:        []string{"Authentication"},
+		Security:    SessionSecurity,
 	}, h.handleLogout)
 
 	// Get current user
@@ -79,6 +81,7 @@ func (h *AuthHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Get current user",
 		Description: "Returns the currently authenticated user's information.",
 		Tags:        []string{"Authentication"},
+		Security:    SessionSecurity,
 	}, h.handleGetCurrentSession)
 }
 
@@ -204,6 +207,7 @@ func (h *AuthHandler) handleRegistration(ctx context.Context, input *Registratio
 		LogDBError(ctx, "CreateUser", err)
 		return nil, huma.Error500InternalServerError("Failed to create user")
 	}
+	metrics.RecordRegistration()
 
 	// Build response
 	output := &RegistrationOutput{}
@@ -281,11 +285,12 @@ func (h *AuthHandler) handleLogin(ctx context.Context, input *LoginInput) (*Logi
 		LogDBError(ctx, "sessions.Create", err)
 		return nil, huma.Error500InternalServerError("Failed to create session")
 	}
+	metrics.RecordLogin(metrics.LoginSuccess)
 
 	// Build response with cookie
 	output := &LoginOutput{
 		SetCookie: http.Cookie{
-			Name:     "loomio_session",
+			Name:     SessionCookieName,
 			Value:    session.Token,
 			Path:     "/",
 			MaxAge:   int(auth.SessionDuration.Seconds()),
@@ -299,10 +304,8 @@ func (h *AuthHandler) handleLogin(ctx context.Context, input *LoginInput) (*Logi
 	return output, nil
 }
 
-// LogoutInput is the request for logout (requires session cookie).
-type LogoutInput struct {
-	Cookie string `cookie:"loomio_session"`
-}
+// LogoutInput is the request for logout (requires a session).
+type LogoutInput struct{}
 
 // LogoutOutput is the response for logout.
 type LogoutOutput struct {
@@ -313,23 +316,18 @@ type LogoutOutput struct {
 }
 
 func (h *AuthHandler) handleLogout(ctx context.Context, input *LogoutInput) (*LogoutOutput, error) {
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Validate session exists
-	_, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Delete session
-	h.sessions.Delete(input.Cookie)
+	h.sessions.Delete(principal.SessionToken)
 
 	// Return success with cleared cookie
 	output := &LogoutOutput{
 		SetCookie: http.Cookie{
-			Name:     "loomio_session",
+			Name:     SessionCookieName,
 			Value:    "",
 			Path:     "/",
 			MaxAge:   -1, // Instructs browser to delete cookie
@@ -343,9 +341,7 @@ func (h *AuthHandler) handleLogout(ctx context.Context, input *LogoutInput) (*Lo
 }
 
 // GetCurrentSessionInput is the request for getting current user.
-type GetCurrentSessionInput struct {
-	Cookie string `cookie:"loomio_session"`
-}
+type GetCurrentSessionInput struct{}
 
 // GetCurrentSessionOutput is the response for get current user.
 type GetCurrentSessionOutput struct {
@@ -355,22 +351,17 @@ type GetCurrentSessionOutput struct {
 }
 
 func (h *AuthHandler) handleGetCurrentSession(ctx context.Context, input *GetCurrentSessionInput) (*GetCurrentSessionOutput, error) {
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Validate session exists and not expired
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Look up user by ID
-	user, err := h.queries.GetUserByID(ctx, session.UserID)
+	user, err := h.queries.GetUserByID(ctx, principal.UserID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			// User was deleted but session still exists - clean up
-			h.sessions.Delete(input.Cookie)
+			h.sessions.Delete(principal.SessionToken)
 			return nil, huma.Error401Unauthorized("Not authenticated")
 		}
 		// Actual database error
@@ -380,7 +371,7 @@ func (h *AuthHandler) handleGetCurrentSession(ctx context.Context, input *GetCur
 
 	// Check if user is deactivated
 	if user.DeactivatedAt.Valid {
-		h.sessions.Delete(input.Cookie)
+		h.sessions.Delete(principal.SessionToken)
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -222,8 +222,11 @@ func newTestAuthHandler() *testAuthHandler {
 	}
 }
 
-// RegisterRoutes registers auth routes for testing.
+// RegisterRoutes installs the authentication middleware and registers auth
+// routes for testing.
 func (h *testAuthHandler) RegisterRoutes(api huma.API) {
+	UseAuthentication(api, h.sessions)
+
 	huma.Register(api, huma.Operation{
 		OperationID:   "createRegistration",
 		Method:        http.MethodPost,
@@ -244,6 +247,7 @@ func (h *testAuthHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodDelete,
 		Path:        "/api/v1/sessions",
 		Summary:     "Log out",
+		Security:    SessionSecurity,
 	}, h.handleLogout)
 
 	huma.Register(api, huma.Operation{
@@ -251,6 +255,7 @@ func (h *testAuthHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/sessions/me",
 		Summary:     "Get current user",
+		Security:    SessionSecurity,
 	}, h.handleGetCurrentUser)
 }
 
@@ -579,18 +584,13 @@ func TestLoginDeactivatedAccount(t *testing.T) {
 }
 
 func (h *testAuthHandler) handleLogout(ctx context.Context, input *LogoutInput) (*LogoutOutput, error) {
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Validate session exists
-	_, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Delete session
-	h.sessions.Delete(input.Cookie)
+	h.sessions.Delete(principal.SessionToken)
 
 	// Return success with cleared cookie
 	output := &LogoutOutput{
@@ -609,24 +609,13 @@ func (h *testAuthHandler) handleLogout(ctx context.Context, input *LogoutInput)
 }
 
 func (h *testAuthHandler) handleGetCurrentUser(ctx context.Context, input *GetCurrentSessionInput) (*GetCurrentSessionOutput, error) {
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Validate session exists and not expired
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Check expiry
-	if time.Now().After(session.ExpiresAt) {
-		h.sessions.Delete(input.Cookie)
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Look up user by ID
-	user, exists := h.getUserByID(session.UserID)
+	user, exists := h.getUserByID(principal.UserID)
 	if !exists {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
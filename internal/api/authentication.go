@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/zacaytion/llmio/internal/auth"
+	"github.com/zacaytion/llmio/internal/logging"
+)
+
+// SessionCookieName is the cookie holding the session token.
+const SessionCookieName = "loomio_session"
+
+// Security scheme names in the OpenAPI spec.
+const (
+	CookieAuthScheme = "sessionCookie"
+	BearerAuthScheme = "sessionBearer"
+)
+
+// SessionSecurity is the security requirement of operations that need an
+// authenticated user: a session token in either the loomio_session cookie
+// or an "Authorization: Bearer" header.
+var SessionSecurity = []map[string][]string{
+	{CookieAuthScheme: {}},
+	{BearerAuthScheme: {}},
+}
+
+// Principal is the authenticated user of a request.
+type Principal struct {
+	UserID       int64
+	SessionToken string
+}
+
+// principalKey is the context key of the request's Principal.
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal resolved by AuthMiddleware.
+// Handlers of operations declaring SessionSecurity always have one; the ok
+// result guards handlers registered on an API without the middleware.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// UseAuthentication declares the session security schemes in the OpenAPI
+// spec of api and installs AuthMiddleware. Call it before registering
+// operations.
+func UseAuthentication(api huma.API, sessions *auth.SessionStore) {
+	components := api.OpenAPI().Components
+	if components.SecuritySchemes == nil {
+		components.SecuritySchemes = make(map[string]*huma.SecurityScheme)
+	}
+	components.SecuritySchemes[CookieAuthScheme] = &huma.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        SessionCookieName,
+		Description: "Session cookie set by POST /api/v1/sessions.",
+	}
+	components.SecuritySchemes[BearerAuthScheme] = &huma.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "The session token from the loomio_session cookie, for clients that do not keep cookies.",
+	}
+	api.UseMiddleware(AuthMiddleware(api, sessions))
+}
+
+// AuthMiddleware resolves the session token of each request into a
+// Principal stored in the context, and adds user_id to the request's log
+// records. An Authorization header takes precedence over the cookie.
+// Operations declaring a security requirement get 401 without a valid
+// session; others run either way.
+func AuthMiddleware(api huma.API, sessions *auth.SessionStore) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if token := sessionToken(ctx); token != "" {
+			if session, found := sessions.Get(token); found {
+				logging.AddAttrs(ctx.Context(), slog.Int64("user_id", session.UserID))
+				next(huma.WithContext(ctx, WithPrincipal(ctx.Context(), &Principal{
+					UserID:       session.UserID,
+					SessionToken: token,
+				})))
+				return
+			}
+		}
+
+		if op := ctx.Operation(); op != nil && len(op.Security) > 0 {
+			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, "Not authenticated")
+			return
+		}
+		next(ctx)
+	}
+}
+
+// sessionToken reads the session token from the Authorization header or,
+// without one, the session cookie.
+func sessionToken(ctx huma.Context) string {
+	if header := ctx.Header("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := huma.ReadCookie(ctx, SessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+
+	"github.com/zacaytion/llmio/internal/auth"
+)
+
+// whoamiOutput reports the principal seen by a handler.
+type whoamiOutput struct {
+	Body struct {
+		UserID int64 `json:"user_id"`
+	}
+}
+
+// setupAuthenticationTest registers a secured and a public operation that
+// echo the resolved principal.
+func setupAuthenticationTest(t *testing.T) (*http.ServeMux, huma.API, string) {
+	t.Helper()
+	sessions := auth.NewSessionStore()
+	session, err := sessions.Create(42, "", "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	UseAuthentication(api, sessions)
+
+	whoami := func(ctx context.Context, input *struct{}) (*whoamiOutput, error) {
+		output := &whoamiOutput{}
+		if principal, ok := PrincipalFromContext(ctx); ok {
+			output.Body.UserID = principal.UserID
+		}
+		return output, nil
+	}
+	huma.Register(api, huma.Operation{
+		OperationID: "securedWhoami",
+		Method:      http.MethodGet,
+		Path:        "/secured",
+		Security:    SessionSecurity,
+	}, whoami)
+	huma.Register(api, huma.Operation{
+		OperationID: "publicWhoami",
+		Method:      http.MethodGet,
+		Path:        "/public",
+	}, whoami)
+
+	return mux, api, session.Token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	mux, _, token := setupAuthenticationTest(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		cookie     string
+		header     string
+		wantStatus int
+		wantUserID int64
+	}{
+		{"secured without credentials", "/secured", "", "", http.StatusUnauthorized, 0},
+		{"secured with cookie", "/secured", token, "", http.StatusOK, 42},
+		{"secured with bearer", "/secured", "", "Bearer " + token, http.StatusOK, 42},
+		{"bearer scheme is case-insensitive", "/secured", "", "bearer " + token, http.StatusOK, 42},
+		{"secured with unknown token", "/secured", "not-a-session", "", http.StatusUnauthorized, 0},
+		{"header takes precedence over cookie", "/secured", token, "Bearer not-a-session", http.StatusUnauthorized, 0},
+		{"non-bearer header is rejected", "/secured", "", "Basic " + token, http.StatusUnauthorized, 0},
+		{"public without credentials", "/public", "", "", http.StatusOK, 0},
+		{"public with cookie", "/public", token, "", http.StatusOK, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var resp struct {
+				UserID int64 `json:"user_id"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp.UserID != tt.wantUserID {
+				t.Errorf("expected user %d, got %d", tt.wantUserID, resp.UserID)
+			}
+		})
+	}
+}
+
+// TestUseAuthentication_OpenAPI verifies the security schemes and
+// requirements appear in the spec.
+func TestUseAuthentication_OpenAPI(t *testing.T) {
+	_, api, _ := setupAuthenticationTest(t)
+	oapi := api.OpenAPI()
+
+	cookie := oapi.Components.SecuritySchemes[CookieAuthScheme]
+	if cookie == nil || cookie.In != "cookie" || cookie.Name != SessionCookieName {
+		t.Errorf("unexpected cookie scheme %+v", cookie)
+	}
+	bearer := oapi.Components.SecuritySchemes[BearerAuthScheme]
+	if bearer == nil || bearer.Type != "http" || bearer.Scheme != "bearer" {
+		t.Errorf("unexpected bearer scheme %+v", bearer)
+	}
+
+	if security := oapi.Paths["/secured"].Get.Security; len(security) != 2 {
+		t.Errorf("expected cookie or bearer requirement, got %v", security)
+	}
+	if security := oapi.Paths["/public"].Get.Security; len(security) != 0 {
+		t.Errorf("expected no requirement on public operation, got %v", security)
+	}
+}
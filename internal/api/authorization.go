@@ -2,13 +2,16 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/zacaytion/llmio/internal/db"
 )
 
 // Role represents a membership role in a group.
 // T205: Create proper Role type with Valid() method.
-// Valid roles are "admin" and "member".
+// Valid roles are "admin", "member", and "guest".
+// Guests are scoped to the discussions/polls listed in their guest grants and
+// are never treated as group members.
 type Role string
 
 // Role constants to avoid magic strings scattered across the codebase.
@@ -16,12 +19,64 @@ type Role string
 const (
 	RoleAdmin  Role = "admin"
 	RoleMember Role = "member"
+	RoleGuest  Role = "guest"
 )
 
 // Valid returns true if the role is one of the known valid roles.
 // T205: Role.Valid() method for validation.
 func (r Role) Valid() bool {
-	return r == RoleAdmin || r == RoleMember
+	return r == RoleAdmin || r == RoleMember || r == RoleGuest
+}
+
+// GuestResourceType identifies the kind of resource a guest grant refers to.
+type GuestResourceType string
+
+// Guest resource types accepted by the guest_grants_resource_type_valid constraint.
+const (
+	GuestResourceDiscussion GuestResourceType = "discussion"
+	GuestResourcePoll       GuestResourceType = "poll"
+)
+
+// Permission names an ability that a custom group role can grant.
+// Custom roles (see group_roles) layer permissions on top of the member role;
+// admins hold every permission implicitly.
+type Permission string
+
+// Permission constants accepted by the group_roles_permissions_valid constraint.
+// Role management, archiving, and promote/demote/remove stay admin-only so a
+// custom role can never be used to escalate to admin.
+const (
+	PermissionUpdateGroup     Permission = "update_group"
+	PermissionInviteMembers   Permission = "invite_members"
+	PermissionAddGuests       Permission = "add_guests"
+	PermissionCreateSubgroups Permission = "create_subgroups"
+	PermissionManageComments  Permission = "manage_comments"
+	PermissionManagePolls     Permission = "manage_polls"
+)
+
+// AllPermissions lists every permission a custom role may grant.
+var AllPermissions = []Permission{
+	PermissionUpdateGroup,
+	PermissionInviteMembers,
+	PermissionAddGuests,
+	PermissionCreateSubgroups,
+	PermissionManageComments,
+	PermissionManagePolls,
+}
+
+// Valid returns true if the permission is one of the known permissions.
+func (p Permission) Valid() bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the string representation of the permission.
+func (p Permission) String() string {
+	return string(p)
 }
 
 // String returns the string representation of the role.
@@ -51,16 +106,30 @@ func ParseRoleStrict(s string) Role {
 
 // AuthorizationContext holds authorization-related data for a request.
 // Note: The fields are exported for read access in handlers.
+// IsGuest and IsMember are mutually exclusive: an accepted guest membership
+// only grants access to the resources in GuestGrants.
+// IsInheritedAdmin is set when admin rights come from an ancestor group rather
+// than a direct membership; IsAdmin is true in that case as well.
+// GroupRole is the custom role of an accepted member, if one is assigned.
 type AuthorizationContext struct {
-	UserID     int64
-	Membership *db.Membership
-	Group      *db.Group
-	IsAdmin    bool
-	IsMember   bool
+	UserID           int64
+	Membership       *db.Membership
+	Group            *db.Group
+	IsAdmin          bool
+	IsInheritedAdmin bool
+	IsMember         bool
+	IsGuest          bool
+	GuestGrants      []*db.GuestGrant
+	GroupRole        *db.GroupRole
 }
 
 // NewAuthorizationContext creates an AuthorizationContext by loading the user's
-// membership in the specified group. Returns nil membership if not a member.
+// membership in the specified group. Returns nil membership if not a member,
+// which includes a membership past its expiry (see membershipLapsed).
+// Guest grants are only loaded for accepted guest memberships and custom roles
+// only for members that have one, so plain members and admins still cost two queries.
+// For subgroups, users who are not direct admins are checked for admin rights
+// inherited from ancestors (see groups.parent_admins_can_manage).
 func NewAuthorizationContext(ctx context.Context, queries *db.Queries, userID, groupID int64) (*AuthorizationContext, error) {
 	// Load group first
 	group, err := queries.GetGroupByID(ctx, groupID)
@@ -83,26 +152,76 @@ func NewAuthorizationContext(ctx context.Context, queries *db.Queries, userID, g
 		Group:  group,
 	}
 
-	if membership != nil && membership.AcceptedAt.Valid {
+	if membership != nil && membership.AcceptedAt.Valid && !membershipLapsed(membership, time.Now()) {
 		authCtx.Membership = membership
-		authCtx.IsMember = true
 		// T207: Compare using Role type for type safety
-		authCtx.IsAdmin = Role(membership.Role) == RoleAdmin
+		if Role(membership.Role) == RoleGuest {
+			authCtx.IsGuest = true
+			authCtx.GuestGrants, err = queries.ListGuestGrantsByMembership(ctx, membership.ID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			authCtx.IsMember = true
+			authCtx.IsAdmin = Role(membership.Role) == RoleAdmin
+			if membership.GroupRoleID.Valid {
+				authCtx.GroupRole, err = queries.GetGroupRoleByID(ctx, membership.GroupRoleID.Int64)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if !authCtx.IsAdmin && group.ParentID.Valid && group.ParentAdminsCanManage {
+		inherited, err := queries.IsInheritedAdmin(ctx, db.IsInheritedAdminParams{
+			UserID:  userID,
+			GroupID: groupID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		authCtx.IsAdmin = inherited
+		authCtx.IsInheritedAdmin = inherited
 	}
 
 	return authCtx, nil
 }
 
+// membershipLapsed reports whether a membership is past its expiry but not
+// yet removed by MembershipExpirer. It grants nothing in the meantime, unless
+// its expiry was blocked because it holds the group's last admin.
+func membershipLapsed(m *db.Membership, now time.Time) bool {
+	return m.ExpiresAt.Valid && !m.ExpiresAt.Time.After(now) && !m.ExpiryBlockedAt.Valid
+}
+
 // CanViewGroup checks if the user can view the group.
-// Currently requires membership.
+// Requires membership or inherited admin rights; guests cannot see the group itself.
 func (ac *AuthorizationContext) CanViewGroup() bool {
-	return ac.IsMember
+	return ac.IsMember || ac.IsAdmin
+}
+
+// HasPermission checks if the user holds a permission in the group.
+// Admins hold every permission; members hold those granted by their custom role.
+func (ac *AuthorizationContext) HasPermission(p Permission) bool {
+	if ac.IsAdmin {
+		return true
+	}
+	if !ac.IsMember || ac.GroupRole == nil {
+		return false
+	}
+	for _, granted := range ac.GroupRole.Permissions {
+		if Permission(granted) == p {
+			return true
+		}
+	}
+	return false
 }
 
 // CanUpdateGroup checks if the user can update group settings.
-// Requires admin role.
+// Requires admin role OR the update_group permission.
 func (ac *AuthorizationContext) CanUpdateGroup() bool {
-	return ac.IsAdmin
+	return ac.HasPermission(PermissionUpdateGroup)
 }
 
 // CanArchiveGroup checks if the user can archive/unarchive the group.
@@ -111,11 +230,18 @@ func (ac *AuthorizationContext) CanArchiveGroup() bool {
 	return ac.IsAdmin
 }
 
+// CanMoveGroup checks if the user can move the group within the hierarchy.
+// Requires admin role, since moving changes who inherits admin rights.
+func (ac *AuthorizationContext) CanMoveGroup() bool {
+	return ac.IsAdmin
+}
+
 // CanInviteMembers checks if the user can invite new members.
-// Requires admin role OR (member role AND members_can_add_members flag).
+// Requires admin role, the invite_members permission, OR (member role AND
+// members_can_add_members flag).
 // Per FR-022, admins bypass permission flags.
 func (ac *AuthorizationContext) CanInviteMembers() bool {
-	if ac.IsAdmin {
+	if ac.HasPermission(PermissionInviteMembers) {
 		return true
 	}
 	if ac.IsMember && ac.Group.MembersCanAddMembers {
@@ -125,16 +251,17 @@ func (ac *AuthorizationContext) CanInviteMembers() bool {
 }
 
 // CanManageMembers checks if the user can promote/demote/remove members.
-// Requires admin role.
+// Requires admin role. Custom roles cannot grant this (see Permission).
 func (ac *AuthorizationContext) CanManageMembers() bool {
 	return ac.IsAdmin
 }
 
 // CanCreateSubgroups checks if the user can create subgroups.
-// Requires admin role OR (member role AND members_can_create_subgroups flag).
+// Requires admin role, the create_subgroups permission, OR (member role AND
+// members_can_create_subgroups flag).
 // Per FR-022, admins bypass permission flags.
 func (ac *AuthorizationContext) CanCreateSubgroups() bool {
-	if ac.IsAdmin {
+	if ac.HasPermission(PermissionCreateSubgroups) {
 		return true
 	}
 	if ac.IsMember && ac.Group.MembersCanCreateSubgroups {
@@ -143,8 +270,69 @@ func (ac *AuthorizationContext) CanCreateSubgroups() bool {
 	return false
 }
 
-// GetRole returns the user's role string ("admin", "member", or empty).
+// CanAddGuests checks if the user can invite guests to discussions and polls.
+// Requires admin role, the add_guests permission, OR (member role AND
+// members_can_add_guests flag).
+// Per FR-022, admins bypass permission flags.
+func (ac *AuthorizationContext) CanAddGuests() bool {
+	if ac.HasPermission(PermissionAddGuests) {
+		return true
+	}
+	if ac.IsMember && ac.Group.MembersCanAddGuests {
+		return true
+	}
+	return false
+}
+
+// CanManageRoles checks if the user can define custom roles and assign them.
+// Requires admin role.
+func (ac *AuthorizationContext) CanManageRoles() bool {
+	return ac.IsAdmin
+}
+
+// CanManageComments checks if the user can moderate comments in the group.
+// Requires admin role OR the manage_comments permission.
+func (ac *AuthorizationContext) CanManageComments() bool {
+	return ac.HasPermission(PermissionManageComments)
+}
+
+// CanManagePolls checks if the user can start, close, and edit polls in the group.
+// Requires admin role OR the manage_polls permission.
+func (ac *AuthorizationContext) CanManagePolls() bool {
+	return ac.HasPermission(PermissionManagePolls)
+}
+
+// CanAccessDiscussion checks if the user can see a discussion in the group.
+// Members and admins see every discussion; guests only those they were granted.
+func (ac *AuthorizationContext) CanAccessDiscussion(discussionID int64) bool {
+	return ac.CanViewGroup() || ac.hasGuestGrant(GuestResourceDiscussion, discussionID)
+}
+
+// CanAccessPoll checks if the user can see a poll in the group.
+// Members and admins see every poll; guests only those they were granted.
+func (ac *AuthorizationContext) CanAccessPoll(pollID int64) bool {
+	return ac.CanViewGroup() || ac.hasGuestGrant(GuestResourcePoll, pollID)
+}
+
+// hasGuestGrant reports whether a guest membership was granted the resource.
+func (ac *AuthorizationContext) hasGuestGrant(resourceType GuestResourceType, resourceID int64) bool {
+	if !ac.IsGuest {
+		return false
+	}
+	for _, grant := range ac.GuestGrants {
+		if GuestResourceType(grant.ResourceType) == resourceType && grant.ResourceID == resourceID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRole returns the user's role string ("admin", "member", "guest", or empty).
+// Inherited admins report "admin" since that is the role they act with.
 func (ac *AuthorizationContext) GetRole() string {
+	if ac.IsInheritedAdmin {
+		return RoleAdmin.String()
+	}
 	if ac.Membership == nil {
 		return ""
 	}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestAuthorizationContext_GuestAccess verifies guests only reach granted resources
+// and never gain member permissions.
+func TestAuthorizationContext_GuestAccess(t *testing.T) {
+	group := &db.Group{MembersCanAddGuests: true}
+
+	guest := &AuthorizationContext{
+		Group:   group,
+		IsGuest: true,
+		GuestGrants: []*db.GuestGrant{
+			{ResourceType: "discussion", ResourceID: 10},
+			{ResourceType: "poll", ResourceID: 20},
+		},
+	}
+	member := &AuthorizationContext{Group: group, IsMember: true}
+	outsider := &AuthorizationContext{Group: group}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"guest sees granted discussion", guest.CanAccessDiscussion(10), true},
+		{"guest cannot see other discussion", guest.CanAccessDiscussion(11), false},
+		{"guest sees granted poll", guest.CanAccessPoll(20), true},
+		{"poll grant does not cover discussion with same id", guest.CanAccessDiscussion(20), false},
+		{"guest cannot view group", guest.CanViewGroup(), false},
+		{"guest cannot add guests", guest.CanAddGuests(), false},
+		{"guest cannot invite members", guest.CanInviteMembers(), false},
+		{"member sees any discussion", member.CanAccessDiscussion(99), true},
+		{"member sees any poll", member.CanAccessPoll(99), true},
+		{"member can add guests when flag set", member.CanAddGuests(), true},
+		{"outsider cannot see discussion", outsider.CanAccessDiscussion(10), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuthorizationContext_CanAddGuestsFlag verifies members_can_add_guests is
+// honoured for members and bypassed for admins (FR-022).
+func TestAuthorizationContext_CanAddGuestsFlag(t *testing.T) {
+	group := &db.Group{MembersCanAddGuests: false}
+
+	member := &AuthorizationContext{Group: group, IsMember: true}
+	if member.CanAddGuests() {
+		t.Error("member should not add guests when members_can_add_guests is false")
+	}
+
+	admin := &AuthorizationContext{Group: group, IsMember: true, IsAdmin: true}
+	if !admin.CanAddGuests() {
+		t.Error("admin should add guests regardless of members_can_add_guests")
+	}
+}
+
+// TestRole_ValidIncludesGuest verifies guest is a known role.
+func TestRole_ValidIncludesGuest(t *testing.T) {
+	if !RoleGuest.Valid() {
+		t.Error("RoleGuest should be valid")
+	}
+	if ParseRole("guest") != RoleGuest {
+		t.Errorf("ParseRole(guest) = %q, want %q", ParseRole("guest"), RoleGuest)
+	}
+}
+
+// TestAuthorizationContext_CustomRolePermissions verifies custom role permissions
+// extend the member role without granting admin-only abilities.
+func TestAuthorizationContext_CustomRolePermissions(t *testing.T) {
+	group := &db.Group{}
+
+	moderator := &AuthorizationContext{
+		Group:     group,
+		IsMember:  true,
+		GroupRole: &db.GroupRole{Name: "moderator", Permissions: []string{"manage_comments"}},
+	}
+	facilitator := &AuthorizationContext{
+		Group:     group,
+		IsMember:  true,
+		GroupRole: &db.GroupRole{Name: "facilitator", Permissions: []string{"manage_polls", "invite_members"}},
+	}
+	member := &AuthorizationContext{Group: group, IsMember: true}
+	admin := &AuthorizationContext{Group: group, IsMember: true, IsAdmin: true}
+	// A role left on a non-member (e.g. stale data) must not grant anything
+	outsider := &AuthorizationContext{
+		Group:     group,
+		GroupRole: &db.GroupRole{Permissions: []string{"manage_comments"}},
+	}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"moderator manages comments", moderator.CanManageComments(), true},
+		{"moderator cannot update settings", moderator.CanUpdateGroup(), false},
+		{"moderator cannot manage polls", moderator.CanManagePolls(), false},
+		{"facilitator manages polls", facilitator.CanManagePolls(), true},
+		{"facilitator invites despite group flag", facilitator.CanInviteMembers(), true},
+		{"facilitator cannot manage comments", facilitator.CanManageComments(), false},
+		{"custom role never manages members", facilitator.CanManageMembers(), false},
+		{"custom role never manages roles", facilitator.CanManageRoles(), false},
+		{"custom role never archives", moderator.CanArchiveGroup(), false},
+		{"plain member cannot manage comments", member.CanManageComments(), false},
+		{"admin holds every permission", admin.CanManageComments() && admin.CanManagePolls(), true},
+		{"outsider role is ignored", outsider.CanManageComments(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPermission_Valid verifies only known permissions are accepted.
+func TestPermission_Valid(t *testing.T) {
+	for _, p := range AllPermissions {
+		if !p.Valid() {
+			t.Errorf("%q should be valid", p)
+		}
+	}
+	for _, p := range []Permission{"", "manage_members", "admin"} {
+		if p.Valid() {
+			t.Errorf("%q should not be valid", p)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/zacaytion/llmio/internal/db"
@@ -99,6 +100,14 @@ func GroupDTOFromGroup(g *db.Group) GroupDTO {
 	return dto
 }
 
+// GroupTreeNodeDTO is a group with its subgroups nested beneath it.
+// Depth is 0 for the requested group and increases by one per level.
+type GroupTreeNodeDTO struct {
+	GroupDTO
+	Depth    int32               `json:"depth"`
+	Children []*GroupTreeNodeDTO `json:"children"`
+}
+
 // GroupDetailDTO extends GroupDTO with permission flags and member counts.
 // Used for getGroup responses where full detail is needed.
 // T173: CurrentUserRole indicates the requesting user's role in this group:
@@ -120,6 +129,7 @@ type GroupDetailDTO struct {
 	MembersCanCreateSubgroups      bool `json:"members_can_create_subgroups"`
 	AdminsCanEditUserContent       bool `json:"admins_can_edit_user_content"`
 	ParentMembersCanSeeDiscussions bool `json:"parent_members_can_see_discussions"`
+	ParentAdminsCanManage          bool `json:"parent_admins_can_manage"`
 	// Counts
 	MemberCount     int64  `json:"member_count"`
 	AdminCount      int64  `json:"admin_count"`
@@ -147,6 +157,7 @@ func GroupDetailDTOFromGroup(g *db.Group, memberCount, adminCount int64, current
 		MembersCanCreateSubgroups:      g.MembersCanCreateSubgroups,
 		AdminsCanEditUserContent:       g.AdminsCanEditUserContent,
 		ParentMembersCanSeeDiscussions: g.ParentMembersCanSeeDiscussions,
+		ParentAdminsCanManage:          g.ParentAdminsCanManage,
 		MemberCount:                    memberCount,
 		AdminCount:                     adminCount,
 		CurrentUserRole:                currentUserRole,
@@ -180,14 +191,16 @@ func UserSummaryDTOFromUser(u *db.User) UserSummaryDTO {
 //   - Only accepted members (AcceptedAt != nil) are counted as active members
 //   - Pending members have limited permissions (cannot view group, cannot invite others)
 type MembershipDTO struct {
-	ID         int64           `json:"id"`
-	GroupID    int64           `json:"group_id"`
-	UserID     int64           `json:"user_id"`
-	Role       string          `json:"role"`
-	AcceptedAt *time.Time      `json:"accepted_at,omitempty"`
-	CreatedAt  time.Time       `json:"created_at"`
-	User       *UserSummaryDTO `json:"user,omitempty"`
-	Inviter    *UserSummaryDTO `json:"inviter,omitempty"`
+	ID          int64           `json:"id"`
+	GroupID     int64           `json:"group_id"`
+	UserID      int64           `json:"user_id"`
+	Role        string          `json:"role"`
+	GroupRoleID *int64          `json:"group_role_id,omitempty"`
+	AcceptedAt  *time.Time      `json:"accepted_at,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	User        *UserSummaryDTO `json:"user,omitempty"`
+	Inviter     *UserSummaryDTO `json:"inviter,omitempty"`
 }
 
 // MembershipDTOFromMembership converts a db.Membership to MembershipDTO.
@@ -199,9 +212,15 @@ func MembershipDTOFromMembership(m *db.Membership) MembershipDTO {
 		Role:      m.Role,
 		CreatedAt: m.CreatedAt.Time,
 	}
+	if m.GroupRoleID.Valid {
+		dto.GroupRoleID = &m.GroupRoleID.Int64
+	}
 	if m.AcceptedAt.Valid {
 		dto.AcceptedAt = &m.AcceptedAt.Time
 	}
+	if m.ExpiresAt.Valid {
+		dto.ExpiresAt = &m.ExpiresAt.Time
+	}
 	return dto
 }
 
@@ -224,12 +243,97 @@ func MembershipDTOFromMembershipWithUsers(m *db.ListMembershipsWithUsersRow) Mem
 			Username: m.InviterUsername,
 		},
 	}
+	if m.GroupRoleID.Valid {
+		dto.GroupRoleID = &m.GroupRoleID.Int64
+	}
 	if m.AcceptedAt.Valid {
 		dto.AcceptedAt = &m.AcceptedAt.Time
 	}
+	if m.ExpiresAt.Valid {
+		dto.ExpiresAt = &m.ExpiresAt.Time
+	}
+	return dto
+}
+
+// GroupRoleDTO represents a custom role defined in a group.
+type GroupRoleDTO struct {
+	ID          int64     `json:"id"`
+	GroupID     int64     `json:"group_id"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GroupRoleDTOFromGroupRole converts a db.GroupRole to GroupRoleDTO.
+func GroupRoleDTOFromGroupRole(r *db.GroupRole) GroupRoleDTO {
+	return GroupRoleDTO{
+		ID:          r.ID,
+		GroupID:     r.GroupID,
+		Name:        r.Name,
+		Permissions: r.Permissions,
+		CreatedAt:   r.CreatedAt.Time,
+		UpdatedAt:   r.UpdatedAt.Time,
+	}
+}
+
+// NotificationDTO represents a notification in the current user's inbox.
+type NotificationDTO struct {
+	ID           int64          `json:"id"`
+	Kind         string         `json:"kind"`
+	GroupID      *int64         `json:"group_id,omitempty"`
+	MembershipID *int64         `json:"membership_id,omitempty"`
+	Data         map[string]any `json:"data"`
+	ReadAt       *time.Time     `json:"read_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// NotificationDTOFromNotification converts a db.Notification to NotificationDTO.
+// Malformed data is returned as an empty object rather than failing the request.
+func NotificationDTOFromNotification(n *db.Notification) NotificationDTO {
+	dto := NotificationDTO{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Data:      map[string]any{},
+		CreatedAt: n.CreatedAt.Time,
+	}
+	if n.GroupID.Valid {
+		dto.GroupID = &n.GroupID.Int64
+	}
+	if n.MembershipID.Valid {
+		dto.MembershipID = &n.MembershipID.Int64
+	}
+	if len(n.Data) > 0 {
+		_ = json.Unmarshal(n.Data, &dto.Data)
+	}
+	if n.ReadAt.Valid {
+		dto.ReadAt = &n.ReadAt.Time
+	}
 	return dto
 }
 
+// GuestGrantDTO represents a discussion or poll a guest membership can access.
+type GuestGrantDTO struct {
+	ID           int64     `json:"id"`
+	MembershipID int64     `json:"membership_id"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   int64     `json:"resource_id"`
+	GrantedByID  int64     `json:"granted_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GuestGrantDTOFromGrant converts a db.GuestGrant to GuestGrantDTO.
+func GuestGrantDTOFromGrant(g *db.GuestGrant) GuestGrantDTO {
+	return GuestGrantDTO{
+		ID:           g.ID,
+		MembershipID: g.MembershipID,
+		ResourceType: g.ResourceType,
+		ResourceID:   g.ResourceID,
+		GrantedByID:  g.GrantedByID,
+		CreatedAt:    g.CreatedAt.Time,
+	}
+}
+
 // InvitationDTO represents a pending invitation in API responses.
 // Includes group and inviter context for user-facing display.
 type InvitationDTO struct {
@@ -317,3 +421,77 @@ type InvitationListResponse struct {
 		Invitations []InvitationDTO `json:"invitations"`
 	}
 }
+
+// InvitationStatsDTO summarises invitation outcomes for a group.
+// Rates are fractions of resolved invitations (accepted + declined) and are
+// 0 when none have been resolved yet.
+type InvitationStatsDTO struct {
+	Invited        int64      `json:"invited"`
+	Accepted       int64      `json:"accepted"`
+	Declined       int64      `json:"declined"`
+	Revoked        int64      `json:"revoked"`
+	Resent         int64      `json:"resent"`
+	Pending        int64      `json:"pending"`
+	AcceptanceRate float64    `json:"acceptance_rate"`
+	DeclineRate    float64    `json:"decline_rate"`
+	Since          *time.Time `json:"since,omitempty"`
+}
+
+// InvitationStatsDTOFromRow converts invitation stats counts to InvitationStatsDTO.
+func InvitationStatsDTOFromRow(r *db.GetInvitationStatsRow) InvitationStatsDTO {
+	dto := InvitationStatsDTO{
+		Invited:  r.Invited,
+		Accepted: r.Accepted,
+		Declined: r.Declined,
+		Revoked:  r.Revoked,
+		Resent:   r.Resent,
+		Pending:  r.Pending,
+	}
+	if resolved := r.Accepted + r.Declined; resolved > 0 {
+		dto.AcceptanceRate = float64(r.Accepted) / float64(resolved)
+		dto.DeclineRate = float64(r.Declined) / float64(resolved)
+	}
+	return dto
+}
+
+// FieldChangeDTO is one field changed by an audited write.
+// From is null for created records and To is null for deleted ones.
+type FieldChangeDTO struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// HistoryEntryDTO is one entry in a group's change log.
+type HistoryEntryDTO struct {
+	ID            int64            `json:"id"`
+	Table         string           `json:"table"`
+	Operation     string           `json:"operation"`
+	RecordID      int64            `json:"record_id"`
+	TransactionID int64            `json:"transaction_id"`
+	Actor         *UserSummaryDTO  `json:"actor,omitempty"`
+	RequestID     string           `json:"request_id,omitempty" doc:"ID of the HTTP request that made the change"`
+	Summary       string           `json:"summary"`
+	Changes       []FieldChangeDTO `json:"changes"`
+	At            time.Time        `json:"at"`
+}
+
+// RestoreActionDTO is one change a point-in-time restore makes.
+// Conflict is set when the action cannot be applied; it is then skipped.
+type RestoreActionDTO struct {
+	Table    string           `json:"table"`
+	RecordID int64            `json:"record_id"`
+	Action   string           `json:"action" enum:"update,recreate,delete"`
+	UserID   int64            `json:"user_id,omitempty" doc:"Member the membership belongs to"`
+	Changes  []FieldChangeDTO `json:"changes"`
+	Conflict string           `json:"conflict,omitempty"`
+}
+
+// RestorePlanDTO is the preview or result of a point-in-time restore.
+type RestorePlanDTO struct {
+	GroupID      int64              `json:"group_id"`
+	MembershipID int64              `json:"membership_id,omitempty"`
+	Applied      bool               `json:"applied"`
+	Actions      []RestoreActionDTO `json:"actions"`
+	Conflicts    int                `json:"conflicts"`
+}
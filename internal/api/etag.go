@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// Entity tags let clients revalidate cached groups and memberships with
+// If-None-Match (304 Not Modified) and make updates conditional with
+// If-Match (412 Precondition Failed when someone else wrote first).
+//
+// Tags are derived from the updated_at columns, which the update_updated_at
+// triggers bump on every write, plus any value a representation computes per
+// request. The response format is part of every tag, since JSON and records
+// bodies of the same entity are different representations. They are opaque
+// to clients and compared as strong validators.
+
+// entityTag hashes the parts that determine a representation into an
+// unquoted tag, the form conditional.Params compares against.
+func entityTag(parts ...any) string {
+	h := sha256.New()
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(h, "%v\x1f", part)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// versionOf renders an updated_at timestamp at the microsecond precision
+// Postgres stores.
+func versionOf(ts pgtype.Timestamptz) int64 {
+	if !ts.Valid {
+		return 0
+	}
+	return ts.Time.UnixMicro()
+}
+
+// quoteETag formats a tag for the ETag response header.
+func quoteETag(tag string) string {
+	return `"` + tag + `"`
+}
+
+// groupETag is the tag of a GroupDetailDTO. Member and admin counts and the
+// caller's role are not covered by the group's updated_at, so they are part
+// of the tag: a new member changes it and two users with different roles
+// see different tags. format is the response format from responseFormat.
+func groupETag(g *db.Group, memberCount, adminCount int64, currentUserRole, format string) string {
+	return entityTag("group", g.ID, versionOf(g.UpdatedAt), memberCount, adminCount, currentUserRole, format)
+}
+
+// groupModifiedAt returns when anything groupETag covers last changed, for
+// If-Modified-Since and If-Unmodified-Since. The counts and the caller's role
+// (possibly inherited) depend on the memberships of the group and its
+// ancestors, which leave no updated_at behind when deleted, so it reads the
+// audit log. The lookup is skipped when cond has no date preconditions.
+func groupModifiedAt(ctx context.Context, q *db.Queries, cond *conditional.Params, group *db.Group) (time.Time, error) {
+	modified := group.UpdatedAt.Time
+	if cond.IfModifiedSince.IsZero() && cond.IfUnmodifiedSince.IsZero() {
+		return modified, nil
+	}
+	changedAt, err := q.GetGroupLineageChangedAt(ctx, group.ID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("GetGroupLineageChangedAt: %w", err)
+	}
+	if changedAt.Valid && changedAt.Time.After(modified) {
+		modified = changedAt.Time
+	}
+	return modified, nil
+}
+
+// membershipVersions are the updated_at values a membership tag covers
+// besides the group's: the membership's and those of the user and inviter
+// GET /memberships/{id} embeds, so renaming either changes the tag.
+type membershipVersions struct {
+	membership, user, inviter pgtype.Timestamptz
+}
+
+// loadMembershipVersions reads the versions of m's embedded users.
+func loadMembershipVersions(ctx context.Context, q *db.Queries, m *db.Membership) (membershipVersions, error) {
+	users, err := q.GetMembershipUsersUpdatedAt(ctx, m.ID)
+	if err != nil {
+		return membershipVersions{}, fmt.Errorf("GetMembershipUsersUpdatedAt: %w", err)
+	}
+	return membershipVersions{membership: m.UpdatedAt, user: users.UserUpdatedAt, inviter: users.InviterUpdatedAt}, nil
+}
+
+// modified returns the latest of the versions and the group's updated_at,
+// the modification time matching membershipETag.
+func (v membershipVersions) modified(group *db.Group) time.Time {
+	latest := group.UpdatedAt.Time
+	for _, ts := range []pgtype.Timestamptz{v.membership, v.user, v.inviter} {
+		if ts.Valid && ts.Time.After(latest) {
+			latest = ts.Time
+		}
+	}
+	return latest
+}
+
+// membershipETag is the tag of a membership. The group's updated_at is part
+// of it because GET /memberships/{id} embeds the group.
+func membershipETag(id int64, v membershipVersions, group *db.Group, format string) string {
+	return entityTag("membership", id, versionOf(v.membership), versionOf(v.user), versionOf(v.inviter),
+		versionOf(group.UpdatedAt), format)
+}
+
+// checkNotModified evaluates the conditional headers of a read, returning
+// 304 Not Modified with the current tag when the client's copy is fresh.
+func checkNotModified(cond *conditional.Params, tag string, modified time.Time) error {
+	if failed := cond.PreconditionFailed(tag, modified); failed != nil {
+		headers := http.Header{}
+		headers.Set("ETag", quoteETag(tag))
+		return huma.ErrorWithHeaders(failed, headers)
+	}
+	return nil
+}
+
+// checkGroupPrecondition evaluates the conditional headers of a group update
+// against the group's current tag. It locks the group row so the tag cannot
+// change before the write; call it inside the update's transaction. It does
+// nothing when the request has no conditional headers.
+func checkGroupPrecondition(ctx context.Context, q *db.Queries, cond *conditional.Params, groupID int64, currentUserRole string) error {
+	if !cond.HasConditionalParams() {
+		return nil
+	}
+
+	group, err := q.LockGroupByID(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("LockGroupByID: %w", err)
+	}
+	stats, err := q.CountGroupMembershipStats(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("CountGroupMembershipStats: %w", err)
+	}
+
+	modified, err := groupModifiedAt(ctx, q, cond, group)
+	if err != nil {
+		return err
+	}
+
+	tag := groupETag(group, stats.MemberCount, stats.AdminCount, currentUserRole, responseFormat(ctx))
+	if failed := cond.PreconditionFailed(tag, modified); failed != nil {
+		return failed
+	}
+	return nil
+}
+
+// checkMembershipPrecondition evaluates the conditional headers of a
+// membership update against the membership's current tag, locking the
+// membership row like checkGroupPrecondition.
+func checkMembershipPrecondition(ctx context.Context, q *db.Queries, cond *conditional.Params, membershipID int64, group *db.Group) error {
+	if !cond.HasConditionalParams() {
+		return nil
+	}
+
+	membership, err := q.LockMembershipByID(ctx, membershipID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			// Removed between the lookup and the transaction
+			return huma.Error404NotFound("Membership not found")
+		}
+		return fmt.Errorf("LockMembershipByID: %w", err)
+	}
+
+	versions, err := loadMembershipVersions(ctx, q, membership)
+	if err != nil {
+		return err
+	}
+
+	tag := membershipETag(membership.ID, versions, group, responseFormat(ctx))
+	if failed := cond.PreconditionFailed(tag, versions.modified(group)); failed != nil {
+		return failed
+	}
+	return nil
+}
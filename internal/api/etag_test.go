@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestGroupETag verifies the tag changes with everything GroupDetailDTO shows.
+func TestGroupETag(t *testing.T) {
+	updatedAt := pgtype.Timestamptz{Time: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC), Valid: true}
+	group := &db.Group{ID: 1, UpdatedAt: updatedAt}
+	base := groupETag(group, 3, 1, "admin", "json")
+
+	if again := groupETag(&db.Group{ID: 1, UpdatedAt: updatedAt}, 3, 1, "admin", "json"); again != base {
+		t.Errorf("tag is not stable: %s != %s", again, base)
+	}
+
+	later := pgtype.Timestamptz{Time: updatedAt.Time.Add(time.Microsecond), Valid: true}
+	variants := map[string]string{
+		"updated_at":   groupETag(&db.Group{ID: 1, UpdatedAt: later}, 3, 1, "admin", "json"),
+		"id":           groupETag(&db.Group{ID: 2, UpdatedAt: updatedAt}, 3, 1, "admin", "json"),
+		"member count": groupETag(group, 4, 1, "admin", "json"),
+		"admin count":  groupETag(group, 3, 2, "admin", "json"),
+		"role":         groupETag(group, 3, 1, "member", "json"),
+		"format":       groupETag(group, 3, 1, "admin", RecordsFormatValue),
+	}
+	for name, tag := range variants {
+		if tag == base {
+			t.Errorf("changing %s did not change the tag", name)
+		}
+	}
+}
+
+// TestMembershipETag verifies the tag covers the membership, its embedded
+// users and group, and the response format.
+func TestMembershipETag(t *testing.T) {
+	ts := func(usec int64) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.UnixMicro(usec), Valid: true}
+	}
+	versions := membershipVersions{membership: ts(100), user: ts(30), inviter: ts(20)}
+	group := &db.Group{UpdatedAt: ts(50)}
+	base := membershipETag(1, versions, group, "json")
+
+	variants := map[string]string{
+		"membership update": membershipETag(1, membershipVersions{membership: ts(101), user: ts(30), inviter: ts(20)}, group, "json"),
+		"user rename":       membershipETag(1, membershipVersions{membership: ts(100), user: ts(31), inviter: ts(20)}, group, "json"),
+		"inviter rename":    membershipETag(1, membershipVersions{membership: ts(100), user: ts(30), inviter: ts(21)}, group, "json"),
+		"group update":      membershipETag(1, versions, &db.Group{UpdatedAt: ts(51)}, "json"),
+		"records format":    membershipETag(1, versions, group, RecordsFormatValue),
+	}
+	for name, tag := range variants {
+		if tag == base {
+			t.Errorf("%s did not change the tag", name)
+		}
+	}
+	if tag := membershipETag(1, versions, &db.Group{UpdatedAt: ts(50)}, "json"); tag != base {
+		t.Errorf("tag is not stable: %s != %s", tag, base)
+	}
+
+	// The modification time is the latest of everything the tag covers
+	if got := (membershipVersions{membership: ts(100), user: ts(300), inviter: ts(20)}).modified(group); !got.Equal(time.UnixMicro(300)) {
+		t.Errorf("expected the user's updated_at as modified time, got %v", got)
+	}
+	if got := versions.modified(&db.Group{UpdatedAt: ts(500)}); !got.Equal(time.UnixMicro(500)) {
+		t.Errorf("expected the group's updated_at as modified time, got %v", got)
+	}
+}
+
+// TestCheckNotModified verifies reads get 304 with the current ETag only
+// when If-None-Match matches.
+func TestCheckNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch []string
+		wantStatus  int
+	}{
+		{"no header", nil, 0},
+		{"matching tag", []string{`"abc"`}, http.StatusNotModified},
+		{"weak matching tag", []string{`W/"abc"`}, http.StatusNotModified},
+		{"one of several", []string{`"old"`, `"abc"`}, http.StatusNotModified},
+		{"stale tag", []string{`"old"`}, 0},
+		{"wildcard", []string{"*"}, http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNotModified(&conditional.Params{IfNoneMatch: tt.ifNoneMatch}, "abc", time.Now())
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var statusErr huma.StatusError
+			if !errors.As(err, &statusErr) || statusErr.GetStatus() != tt.wantStatus {
+				t.Fatalf("expected status %d, got %v", tt.wantStatus, err)
+			}
+			var headersErr huma.HeadersError
+			if !errors.As(err, &headersErr) || headersErr.GetHeaders().Get("ETag") != `"abc"` {
+				t.Errorf("expected the current ETag on the 304, got %v", err)
+			}
+		})
+	}
+}
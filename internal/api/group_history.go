@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// historyTableNouns names each group-scoped audited table in change summaries.
+// The keys are also the accepted values of the history table filter.
+var historyTableNouns = map[string]string{
+	"groups":               "group settings",
+	"memberships":          "a membership",
+	"group_roles":          "a custom role",
+	"group_handle_history": "a handle reservation",
+}
+
+// historyOperationVerbs maps audit operations to summary verbs.
+var historyOperationVerbs = map[db.AuditOperation]string{
+	db.AuditOperationINSERT: "created",
+	db.AuditOperationUPDATE: "updated",
+	db.AuditOperationDELETE: "deleted",
+}
+
+// historyIgnoredFields are bookkeeping columns left out of change lists.
+// id and created_at are only meaningful on the record itself, and
+// updated_at changes on every write.
+var historyIgnoredFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// diffAuditRecords lists the fields that differ between the old and new JSONB
+// snapshots of an audited row, sorted by field name. For an INSERT only the
+// non-null fields of the new row are listed, and for a DELETE only the
+// non-null fields of the old row.
+func diffAuditRecords(oldRecord, newRecord []byte) ([]FieldChangeDTO, error) {
+	var before, after map[string]any
+	if len(oldRecord) > 0 {
+		if err := json.Unmarshal(oldRecord, &before); err != nil {
+			return nil, fmt.Errorf("decode old_record: %w", err)
+		}
+	}
+	if len(newRecord) > 0 {
+		if err := json.Unmarshal(newRecord, &after); err != nil {
+			return nil, fmt.Errorf("decode record: %w", err)
+		}
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	changes := []FieldChangeDTO{}
+	for _, field := range fields {
+		if historyIgnoredFields[field] {
+			continue
+		}
+		from, to := before[field], after[field]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		changes = append(changes, FieldChangeDTO{Field: field, From: from, To: to})
+	}
+	return changes, nil
+}
+
+// summarizeHistoryEntry builds a one-line description such as
+// "Jane Doe updated group settings (description, name)".
+func summarizeHistoryEntry(actor *UserSummaryDTO, table string, op db.AuditOperation, changes []FieldChangeDTO) string {
+	who := "System"
+	if actor != nil {
+		who = actor.Name
+		if who == "" {
+			who = fmt.Sprintf("Deleted user %d", actor.ID)
+		}
+	}
+
+	noun, ok := historyTableNouns[table]
+	if !ok {
+		noun = table
+	}
+	verb, ok := historyOperationVerbs[op]
+	if !ok {
+		verb = strings.ToLower(string(op))
+	}
+	if table == "groups" && op != db.AuditOperationUPDATE {
+		noun = "the group"
+	}
+
+	summary := fmt.Sprintf("%s %s %s", who, verb, noun)
+	if op == db.AuditOperationUPDATE && len(changes) > 0 {
+		fields := make([]string, len(changes))
+		for i, c := range changes {
+			fields[i] = c.Field
+		}
+		summary += " (" + strings.Join(fields, ", ") + ")"
+	}
+	return summary
+}
+
+// historyEntryFromRow converts an audit row into a change log entry.
+func historyEntryFromRow(row *db.ListGroupHistoryRow) (HistoryEntryDTO, error) {
+	changes, err := diffAuditRecords(row.OldRecord, row.Record)
+	if err != nil {
+		return HistoryEntryDTO{}, err
+	}
+
+	entry := HistoryEntryDTO{
+		ID:            row.ID,
+		Table:         row.TableName,
+		Operation:     strings.ToLower(string(row.Op)),
+		TransactionID: row.XactID,
+		RequestID:     row.RequestID.String,
+		Changes:       changes,
+		At:            row.Ts.Time,
+	}
+	entry.RecordID, _ = strconv.ParseInt(row.RecordID, 10, 64)
+	if row.ActorID.Valid {
+		// Name and username are empty if the actor has since been deleted
+		entry.Actor = &UserSummaryDTO{
+			ID:       row.ActorID.Int64,
+			Name:     row.ActorName.String,
+			Username: row.ActorUsername.String,
+		}
+	}
+	entry.Summary = summarizeHistoryEntry(entry.Actor, row.TableName, row.Op, changes)
+	return entry, nil
+}
+
+// GetGroupHistoryInput is the request for a group's change log.
+type GetGroupHistoryInput struct {
+	PageParams
+	ID        int64     `path:"id" doc:"Group ID"`
+	Table     string    `query:"table" enum:"groups,memberships,group_roles,group_handle_history" doc:"Only changes to this table"`
+	Operation string    `query:"operation" enum:"insert,update,delete" doc:"Only this kind of change"`
+	ActorID   int64     `query:"actor_id" minimum:"0" doc:"Only changes made by this user"`
+	Since     time.Time `query:"since" doc:"Only changes at or after this time (RFC 3339)"`
+	Until     time.Time `query:"until" doc:"Only changes before this time (RFC 3339)"`
+}
+
+// GetGroupHistoryOutput is the response for a group's change log.
+// Link and next_cursor are only set when another page exists.
+type GetGroupHistoryOutput struct {
+	Link string `header:"Link"`
+	Body struct {
+		Entries    []HistoryEntryDTO `json:"entries"`
+		NextCursor string            `json:"next_cursor,omitempty"`
+	}
+}
+
+func (h *GroupHandler) handleGetGroupHistory(ctx context.Context, input *GetGroupHistoryInput) (*GetGroupHistoryOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: the audit trail is for admins only (archived groups included)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.IsAdmin {
+		return nil, huma.Error403Forbidden("Only admins can view group history")
+	}
+
+	if !input.Since.IsZero() && !input.Until.IsZero() && !input.Until.After(input.Since) {
+		return nil, huma.Error422UnprocessableEntity("Invalid time range",
+			&huma.ErrorDetail{
+				Location: "query.until",
+				Message:  "until must be after since",
+				Value:    input.Until,
+			})
+	}
+
+	page, err := newPageQuery(input.PageParams, SortByCreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.queries.ListGroupHistory(ctx, db.ListGroupHistoryParams{
+		GroupID:     strconv.FormatInt(input.ID, 10),
+		TableFilter: input.Table,
+		OpFilter:    strings.ToUpper(input.Operation),
+		ActorID:     pgtype.Int8{Int64: input.ActorID, Valid: input.ActorID > 0},
+		Since:       pgtype.Timestamptz{Time: input.Since, Valid: !input.Since.IsZero()},
+		Until:       pgtype.Timestamptz{Time: input.Until, Valid: !input.Until.IsZero()},
+		SortDesc:    page.Desc,
+		AfterID:     page.After.ID,
+		AfterTs:     page.AfterCreatedAt(),
+		PageLimit:   page.FetchLimit(),
+	})
+	if err != nil {
+		LogDBError(ctx, "ListGroupHistory", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	rows, hasMore := trimPage(rows, page)
+
+	// Build response
+	output := &GetGroupHistoryOutput{}
+	output.Body.Entries = make([]HistoryEntryDTO, len(rows))
+	for i, row := range rows {
+		entry, err := historyEntryFromRow(row)
+		if err != nil {
+			LogDBError(ctx, "DecodeAuditRecord", err)
+			return nil, huma.Error500InternalServerError("Database error")
+		}
+		output.Body.Entries[i] = entry
+	}
+	if hasMore {
+		last := rows[len(rows)-1]
+		output.Body.NextCursor = page.Cursor(last.ID, "", "", last.Ts.Time)
+
+		filters := url.Values{}
+		if input.Table != "" {
+			filters.Set("table", input.Table)
+		}
+		if input.Operation != "" {
+			filters.Set("operation", input.Operation)
+		}
+		if input.ActorID > 0 {
+			filters.Set("actor_id", strconv.FormatInt(input.ActorID, 10))
+		}
+		if !input.Since.IsZero() {
+			filters.Set("since", input.Since.Format(time.RFC3339Nano))
+		}
+		if !input.Until.IsZero() {
+			filters.Set("until", input.Until.Format(time.RFC3339Nano))
+		}
+		output.Link = nextPageLink(fmt.Sprintf("/api/v1/groups/%d/history", input.ID), filters, page, output.Body.NextCursor)
+	}
+	return output, nil
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestDiffAuditRecords verifies field-level diffs for each operation.
+func TestDiffAuditRecords(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldRecord string
+		newRecord string
+		want      []FieldChangeDTO
+	}{
+		{
+			name:      "update lists changed fields only",
+			oldRecord: `{"id": 1, "name": "Old", "description": "same", "updated_at": "2025-01-01"}`,
+			newRecord: `{"id": 1, "name": "New", "description": "same", "updated_at": "2025-01-02"}`,
+			want:      []FieldChangeDTO{{Field: "name", From: "Old", To: "New"}},
+		},
+		{
+			name:      "insert lists non-null fields",
+			newRecord: `{"id": 1, "role": "member", "accepted_at": null, "created_at": "2025-01-01"}`,
+			want:      []FieldChangeDTO{{Field: "role", From: nil, To: "member"}},
+		},
+		{
+			name:      "delete lists previous values",
+			oldRecord: `{"id": 1, "role": "admin", "group_id": 7}`,
+			want: []FieldChangeDTO{
+				{Field: "group_id", From: float64(7), To: nil},
+				{Field: "role", From: "admin", To: nil},
+			},
+		},
+		{
+			name:      "nested values compare deeply",
+			oldRecord: `{"permissions": ["manage_polls"]}`,
+			newRecord: `{"permissions": ["manage_polls"]}`,
+			want:      []FieldChangeDTO{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffAuditRecords([]byte(tt.oldRecord), []byte(tt.newRecord))
+			if err != nil {
+				t.Fatalf("diffAuditRecords: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// TestSummarizeHistoryEntry verifies the human-readable summaries.
+func TestSummarizeHistoryEntry(t *testing.T) {
+	jane := &UserSummaryDTO{ID: 1, Name: "Jane Doe"}
+	changes := []FieldChangeDTO{{Field: "description"}, {Field: "name"}}
+
+	tests := []struct {
+		name    string
+		actor   *UserSummaryDTO
+		table   string
+		op      db.AuditOperation
+		changes []FieldChangeDTO
+		want    string
+	}{
+		{"group update", jane, "groups", db.AuditOperationUPDATE, changes, "Jane Doe updated group settings (description, name)"},
+		{"group insert", jane, "groups", db.AuditOperationINSERT, nil, "Jane Doe created the group"},
+		{"membership delete", jane, "memberships", db.AuditOperationDELETE, nil, "Jane Doe deleted a membership"},
+		{"no actor", nil, "group_roles", db.AuditOperationINSERT, nil, "System created a custom role"},
+		{"deleted actor", &UserSummaryDTO{ID: 9}, "memberships", db.AuditOperationINSERT, nil, "Deleted user 9 created a membership"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeHistoryEntry(tt.actor, tt.table, tt.op, tt.changes); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGroupHistory verifies the history endpoint over real audit records.
+func TestGroupHistory(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	admin := setup.createTestUser(t, "alice@example.com", "Alice")
+	adminToken := setup.createTestSession(t, admin.ID)
+	member := setup.createTestUser(t, "bob@example.com", "Bob")
+	memberToken := setup.createTestSession(t, member.ID)
+
+	w := setup.makeRequest(http.MethodPost, "/api/v1/groups", map[string]any{"name": "History Group"}, adminToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create group: %d: %s", w.Code, w.Body.String())
+	}
+	var createResp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &createResp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	groupID := int64(createResp["group"].(map[string]any)["id"].(float64))
+
+	w = setup.makeRequest(http.MethodPatch, fmt.Sprintf("/api/v1/groups/%d", groupID),
+		map[string]any{"description": "Now with a description"}, adminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to update group: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = setup.makeRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID),
+		map[string]any{"user_id": member.ID}, adminToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to invite: %d: %s", w.Code, w.Body.String())
+	}
+
+	historyPath := fmt.Sprintf("/api/v1/groups/%d/history", groupID)
+
+	t.Run("non-admin is forbidden", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodGet, historyPath, nil, memberToken)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("update shows who changed what", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodGet, historyPath+"?table=groups&operation=update", nil, adminToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("history failed: %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Entries []HistoryEntryDTO `json:"entries"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if len(resp.Entries) != 1 {
+			t.Fatalf("expected 1 group update, got %d", len(resp.Entries))
+		}
+		entry := resp.Entries[0]
+		if entry.Actor == nil || entry.Actor.Name != "Alice" {
+			t.Errorf("expected actor Alice, got %+v", entry.Actor)
+		}
+		if len(entry.Changes) != 1 || entry.Changes[0].Field != "description" || entry.Changes[0].To != "Now with a description" {
+			t.Errorf("unexpected changes: %+v", entry.Changes)
+		}
+	})
+
+	t.Run("pages newest first across tables", func(t *testing.T) {
+		var entries []HistoryEntryDTO
+		path := historyPath + "?limit=1"
+		for pages := 0; path != ""; pages++ {
+			if pages > 10 {
+				t.Fatal("pagination did not terminate")
+			}
+			w := setup.makeRequest(http.MethodGet, path, nil, adminToken)
+			if w.Code != http.StatusOK {
+				t.Fatalf("history failed: %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Entries    []HistoryEntryDTO `json:"entries"`
+				NextCursor string            `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			entries = append(entries, resp.Entries...)
+			path = ""
+			if resp.NextCursor != "" {
+				path = historyPath + "?limit=1&cursor=" + resp.NextCursor
+			}
+		}
+
+		// group insert, creator membership insert, group update, invite insert
+		if len(entries) != 4 {
+			t.Fatalf("expected 4 entries, got %d", len(entries))
+		}
+		if entries[0].Table != "memberships" || entries[0].Operation != "insert" {
+			t.Errorf("expected newest entry to be the invitation, got %s %s", entries[0].Table, entries[0].Operation)
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].At.After(entries[i-1].At) {
+				t.Errorf("entries not in newest-first order at %d", i)
+			}
+		}
+	})
+
+	t.Run("actor filter", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodGet, fmt.Sprintf("%s?actor_id=%d", historyPath, member.ID), nil, adminToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("history failed: %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Entries []HistoryEntryDTO `json:"entries"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if len(resp.Entries) != 0 {
+			t.Errorf("expected no changes by the invitee, got %d", len(resp.Entries))
+		}
+	})
+
+	t.Run("inverted time range is rejected", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodGet,
+			historyPath+"?since=2025-02-01T00:00:00Z&until=2025-01-01T00:00:00Z", nil, adminToken)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected 422, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,697 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// Actions in a point-in-time restore plan.
+const (
+	RestoreActionUpdate   = "update"
+	RestoreActionRecreate = "recreate"
+	RestoreActionDelete   = "delete"
+)
+
+// restoreGroupFields are the group columns a restore sets back. Handle,
+// parent, and archive state are left alone: they have their own endpoints
+// with their own checks (handle reservations, hierarchy permissions).
+var restoreGroupFields = map[string]bool{
+	"name":                               true,
+	"description":                        true,
+	"members_can_add_members":            true,
+	"members_can_add_guests":             true,
+	"members_can_start_discussions":      true,
+	"members_can_raise_motions":          true,
+	"members_can_edit_discussions":       true,
+	"members_can_edit_comments":          true,
+	"members_can_delete_comments":        true,
+	"members_can_announce":               true,
+	"members_can_create_subgroups":       true,
+	"admins_can_edit_user_content":       true,
+	"parent_members_can_see_discussions": true,
+	"parent_admins_can_manage":           true,
+}
+
+// restoreMembershipFields are the membership columns listed in a restore
+// plan. Expiry bookkeeping is restored along with expires_at but not listed.
+var restoreMembershipFields = map[string]bool{
+	"user_id":       true,
+	"role":          true,
+	"group_role_id": true,
+	"accepted_at":   true,
+	"expires_at":    true,
+}
+
+// errGroupNotYetCreated is returned when the restore point predates the group.
+var errGroupNotYetCreated = errors.New("group did not exist at the restore point")
+
+// restoreStep is one planned action with the row state it restores.
+type restoreStep struct {
+	action     RestoreActionDTO
+	group      *db.Group      // Target state of a group update
+	membership *db.Membership // Target state of a membership update or recreate
+	replaces   int64          // Membership deleted by the plan that holds this one's user
+}
+
+// restorePlan lists the steps of a restore in display order: the group
+// first, then memberships by ID.
+type restorePlan struct {
+	steps []*restoreStep
+}
+
+// DTO converts the plan for the response.
+func (p *restorePlan) DTO(groupID, membershipID int64, applied bool) RestorePlanDTO {
+	dto := RestorePlanDTO{
+		GroupID:      groupID,
+		MembershipID: membershipID,
+		Applied:      applied,
+		Actions:      make([]RestoreActionDTO, len(p.steps)),
+	}
+	for i, step := range p.steps {
+		dto.Actions[i] = step.action
+		if step.action.Conflict != "" {
+			dto.Conflicts++
+		}
+	}
+	return dto
+}
+
+// conflicts counts the steps that cannot be applied.
+func (p *restorePlan) conflicts() int {
+	n := 0
+	for _, step := range p.steps {
+		if step.action.Conflict != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// applyOrder returns the steps in the order they must run to get past
+// last-admin protection and the one-membership-per-user constraint:
+// steps that add admins (promotions and recreated admins) before any step
+// that demotes or removes one, other updates and recreations before
+// deletions, and recreations whose user holds a membership the plan deletes
+// last.
+func (p *restorePlan) applyOrder() []*restoreStep {
+	rank := func(s *restoreStep) int {
+		switch {
+		case s.group != nil:
+			return 0
+		case s.action.Action == RestoreActionUpdate && s.membership.Role == string(RoleAdmin):
+			return 1
+		case s.action.Action == RestoreActionRecreate && s.replaces == 0 && s.membership.Role == string(RoleAdmin):
+			return 1
+		case s.action.Action == RestoreActionUpdate:
+			return 2
+		case s.action.Action == RestoreActionRecreate && s.replaces == 0:
+			return 3
+		case s.action.Action == RestoreActionDelete:
+			return 4
+		default:
+			return 5
+		}
+	}
+	ordered := slices.Clone(p.steps)
+	slices.SortStableFunc(ordered, func(a, b *restoreStep) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+	return ordered
+}
+
+// apply runs the plan, skipping conflicting steps. The caller provides the
+// transaction and audit context, so the restore is audited like any write.
+func (p *restorePlan) apply(ctx context.Context, q *db.Queries) error {
+	for _, step := range p.applyOrder() {
+		if step.action.Conflict != "" {
+			continue
+		}
+		var err error
+		switch {
+		case step.group != nil:
+			g := step.group
+			_, err = q.RestoreGroupSettings(ctx, db.RestoreGroupSettingsParams{
+				ID:                             g.ID,
+				Name:                           g.Name,
+				Description:                    g.Description,
+				MembersCanAddMembers:           g.MembersCanAddMembers,
+				MembersCanAddGuests:            g.MembersCanAddGuests,
+				MembersCanStartDiscussions:     g.MembersCanStartDiscussions,
+				MembersCanRaiseMotions:         g.MembersCanRaiseMotions,
+				MembersCanEditDiscussions:      g.MembersCanEditDiscussions,
+				MembersCanEditComments:         g.MembersCanEditComments,
+				MembersCanDeleteComments:       g.MembersCanDeleteComments,
+				MembersCanAnnounce:             g.MembersCanAnnounce,
+				MembersCanCreateSubgroups:      g.MembersCanCreateSubgroups,
+				AdminsCanEditUserContent:       g.AdminsCanEditUserContent,
+				ParentMembersCanSeeDiscussions: g.ParentMembersCanSeeDiscussions,
+				ParentAdminsCanManage:          g.ParentAdminsCanManage,
+			})
+		case step.action.Action == RestoreActionUpdate:
+			m := step.membership
+			_, err = q.RestoreMembership(ctx, db.RestoreMembershipParams{
+				ID:               m.ID,
+				Role:             m.Role,
+				GroupRoleID:      m.GroupRoleID,
+				AcceptedAt:       m.AcceptedAt,
+				ExpiresAt:        m.ExpiresAt,
+				ExpiryNotifiedAt: m.ExpiryNotifiedAt,
+				ExpiryBlockedAt:  m.ExpiryBlockedAt,
+			})
+		case step.action.Action == RestoreActionRecreate:
+			m := step.membership
+			_, err = q.RecreateMembership(ctx, db.RecreateMembershipParams{
+				ID:               m.ID,
+				GroupID:          m.GroupID,
+				UserID:           m.UserID,
+				Role:             m.Role,
+				InviterID:        m.InviterID,
+				AcceptedAt:       m.AcceptedAt,
+				CreatedAt:        m.CreatedAt,
+				GroupRoleID:      m.GroupRoleID,
+				ExpiresAt:        m.ExpiresAt,
+				ExpiryNotifiedAt: m.ExpiryNotifiedAt,
+				ExpiryBlockedAt:  m.ExpiryBlockedAt,
+			})
+		case step.action.Action == RestoreActionDelete:
+			err = q.DeleteMembership(ctx, step.action.RecordID)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s %d: %w", step.action.Action, step.action.Table, step.action.RecordID, err)
+		}
+	}
+	return nil
+}
+
+// planGroupRestore works out how to bring a group and its memberships (or a
+// single membership) back to their state at an audit chain position.
+//
+// For each row changed after the cutoff, the old_record of the first later
+// change is the row as it was at the cutoff. Replaying those values over the
+// current rows gives the plan: rows that changed are updated, rows deleted
+// since are recreated with their original IDs, and rows created since are
+// deleted. Rows without later changes are already in their restored state.
+func planGroupRestore(ctx context.Context, q *db.Queries, groupID, membershipID, cutoffSeq int64) (*restorePlan, error) {
+	rows, err := q.ListGroupChangesAfter(ctx, db.ListGroupChangesAfterParams{
+		GroupID:      strconv.FormatInt(groupID, 10),
+		AfterSeq:     cutoffSeq,
+		MembershipID: pgtype.Int8{Int64: membershipID, Valid: membershipID > 0},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListGroupChangesAfter: %w", err)
+	}
+
+	plan := &restorePlan{}
+	membershipChanges := map[int64]*db.ListGroupChangesAfterRow{}
+	var membershipIDs []int64
+	for _, row := range rows {
+		id, err := strconv.ParseInt(row.RecordID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse audit record_id %q: %w", row.RecordID, err)
+		}
+		switch row.TableName {
+		case "groups":
+			if row.Op == db.AuditOperationINSERT {
+				return nil, errGroupNotYetCreated
+			}
+			step, err := planGroupStep(ctx, q, id, row.OldRecord)
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				plan.steps = append(plan.steps, step)
+			}
+		case "memberships":
+			membershipChanges[id] = row
+			membershipIDs = append(membershipIDs, id)
+		}
+	}
+	if len(membershipIDs) == 0 {
+		return plan, nil
+	}
+	slices.Sort(membershipIDs)
+
+	current, err := q.ListMembershipsByIDs(ctx, membershipIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ListMembershipsByIDs: %w", err)
+	}
+	currentByID := make(map[int64]*db.Membership, len(current))
+	for _, m := range current {
+		currentByID[m.ID] = m
+	}
+
+	// Memberships created after the cutoff are deleted; note whose they are
+	// so a recreated membership for the same user can replace them
+	deletedByUser := map[int64]int64{}
+	for _, id := range membershipIDs {
+		if cur := currentByID[id]; cur != nil && membershipChanges[id].Op == db.AuditOperationINSERT {
+			deletedByUser[cur.UserID] = id
+		}
+	}
+
+	for _, id := range membershipIDs {
+		change, cur := membershipChanges[id], currentByID[id]
+		var step *restoreStep
+		switch {
+		case change.Op == db.AuditOperationINSERT && cur != nil:
+			step, err = planMembershipDelete(cur)
+		case change.Op == db.AuditOperationINSERT:
+			// Created and deleted again after the cutoff: nothing to do
+		case cur != nil:
+			step, err = planMembershipUpdate(ctx, q, cur, change.OldRecord)
+		default:
+			step, err = planMembershipRecreate(ctx, q, change.OldRecord, deletedByUser)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			plan.steps = append(plan.steps, step)
+		}
+	}
+	return plan, nil
+}
+
+// planGroupStep plans setting a group's settings back to a snapshot,
+// or returns nil if they already match.
+func planGroupStep(ctx context.Context, q *db.Queries, groupID int64, snapshot []byte) (*restoreStep, error) {
+	var target db.Group
+	if err := json.Unmarshal(snapshot, &target); err != nil {
+		return nil, fmt.Errorf("decode group snapshot: %w", err)
+	}
+	current, err := q.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroupByID: %w", err)
+	}
+
+	changes, err := restoreChanges(current, &target, restoreGroupFields)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return &restoreStep{
+		action: RestoreActionDTO{
+			Table:    "groups",
+			RecordID: groupID,
+			Action:   RestoreActionUpdate,
+			Changes:  changes,
+		},
+		group: &target,
+	}, nil
+}
+
+// planMembershipDelete plans removing a membership created after the cutoff.
+func planMembershipDelete(current *db.Membership) (*restoreStep, error) {
+	changes, err := restoreChanges(utcMembership(current), nil, restoreMembershipFields)
+	if err != nil {
+		return nil, err
+	}
+	return &restoreStep{
+		action: RestoreActionDTO{
+			Table:    "memberships",
+			RecordID: current.ID,
+			Action:   RestoreActionDelete,
+			UserID:   current.UserID,
+			Changes:  changes,
+		},
+	}, nil
+}
+
+// planMembershipUpdate plans setting a membership back to a snapshot,
+// or returns nil if it already matches.
+func planMembershipUpdate(ctx context.Context, q *db.Queries, current *db.Membership, snapshot []byte) (*restoreStep, error) {
+	target, err := decodeMembershipSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := restoreChanges(utcMembership(current), target, restoreMembershipFields)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	step := &restoreStep{
+		action: RestoreActionDTO{
+			Table:    "memberships",
+			RecordID: current.ID,
+			Action:   RestoreActionUpdate,
+			UserID:   current.UserID,
+			Changes:  changes,
+		},
+		membership: target,
+	}
+	if target.GroupRoleID.Valid && target.GroupRoleID != current.GroupRoleID {
+		step.action.Conflict, err = checkRestoreGroupRole(ctx, q, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return step, nil
+}
+
+// planMembershipRecreate plans re-inserting a membership deleted after the
+// cutoff, flagging a conflict if its user, custom role, or slot in the group
+// is gone.
+func planMembershipRecreate(ctx context.Context, q *db.Queries, snapshot []byte, deletedByUser map[int64]int64) (*restoreStep, error) {
+	target, err := decodeMembershipSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := restoreChanges(nil, target, restoreMembershipFields)
+	if err != nil {
+		return nil, err
+	}
+
+	step := &restoreStep{
+		action: RestoreActionDTO{
+			Table:    "memberships",
+			RecordID: target.ID,
+			Action:   RestoreActionRecreate,
+			UserID:   target.UserID,
+			Changes:  changes,
+		},
+		membership: target,
+		replaces:   deletedByUser[target.UserID],
+	}
+
+	if _, err := q.GetUserByID(ctx, target.UserID); err != nil {
+		if !db.IsNotFound(err) {
+			return nil, fmt.Errorf("GetUserByID: %w", err)
+		}
+		step.action.Conflict = "user no longer exists"
+		return step, nil
+	}
+	if step.replaces == 0 {
+		existing, err := q.GetMembershipByGroupAndUser(ctx, db.GetMembershipByGroupAndUserParams{
+			GroupID: target.GroupID,
+			UserID:  target.UserID,
+		})
+		if err != nil && !db.IsNotFound(err) {
+			return nil, fmt.Errorf("GetMembershipByGroupAndUser: %w", err)
+		}
+		if err == nil {
+			step.action.Conflict = fmt.Sprintf("user already has membership %d in this group", existing.ID)
+			return step, nil
+		}
+	}
+	if target.GroupRoleID.Valid {
+		step.action.Conflict, err = checkRestoreGroupRole(ctx, q, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return step, nil
+}
+
+// checkRestoreGroupRole returns a conflict if a restored membership's custom
+// role has since been deleted.
+func checkRestoreGroupRole(ctx context.Context, q *db.Queries, target *db.Membership) (string, error) {
+	role, err := q.GetGroupRoleByID(ctx, target.GroupRoleID.Int64)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return "custom role no longer exists", nil
+		}
+		return "", fmt.Errorf("GetGroupRoleByID: %w", err)
+	}
+	if role.GroupID != target.GroupID {
+		return "custom role no longer exists", nil
+	}
+	return "", nil
+}
+
+// decodeMembershipSnapshot decodes an audit JSONB snapshot of a membership.
+// The JSON tags of db.Membership match the column names.
+func decodeMembershipSnapshot(snapshot []byte) (*db.Membership, error) {
+	var m db.Membership
+	if err := json.Unmarshal(snapshot, &m); err != nil {
+		return nil, fmt.Errorf("decode membership snapshot: %w", err)
+	}
+	return utcMembership(&m), nil
+}
+
+// utcMembership returns a copy with its timestamps in UTC, so snapshots
+// and current rows encode identically when diffed.
+func utcMembership(m *db.Membership) *db.Membership {
+	c := *m
+	for _, ts := range []*pgtype.Timestamptz{&c.AcceptedAt, &c.CreatedAt, &c.UpdatedAt, &c.ExpiresAt, &c.ExpiryNotifiedAt, &c.ExpiryBlockedAt} {
+		ts.Time = ts.Time.UTC()
+	}
+	return &c
+}
+
+// restoreChanges diffs the current and restored state of a row, keeping only
+// the listed fields. Either side may be nil for recreated or deleted rows.
+func restoreChanges[T any](current, target *T, fields map[string]bool) ([]FieldChangeDTO, error) {
+	var before, after []byte
+	var err error
+	if current != nil {
+		if before, err = json.Marshal(current); err != nil {
+			return nil, fmt.Errorf("encode current row: %w", err)
+		}
+	}
+	if target != nil {
+		if after, err = json.Marshal(target); err != nil {
+			return nil, fmt.Errorf("encode restored row: %w", err)
+		}
+	}
+
+	all, err := diffAuditRecords(before, after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []FieldChangeDTO{}
+	for _, c := range all {
+		if fields[c.Field] {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// RestoreTarget selects the point to restore to: exactly one of at or
+// xact_id.
+type RestoreTarget struct {
+	At           *time.Time `json:"at,omitempty" doc:"Restore to the state at this time (RFC 3339)"`
+	XactID       int64      `json:"xact_id,omitempty" minimum:"1" doc:"Restore to the state right after this transaction (transaction_id in the group history)"`
+	MembershipID int64      `json:"membership_id,omitempty" minimum:"1" doc:"Only restore this membership of the group"`
+}
+
+// PreviewGroupRestoreInput is the request for previewing a restore.
+type PreviewGroupRestoreInput struct {
+	ID   int64 `path:"id" doc:"Group ID"`
+	Body RestoreTarget
+}
+
+// RestoreGroupInput is the request for restoring a group.
+type RestoreGroupInput struct {
+	ID   int64 `path:"id" doc:"Group ID"`
+	Body struct {
+		RestoreTarget
+		SkipConflicts bool `json:"skip_conflicts,omitempty" doc:"Apply the rest of the plan when some actions conflict"`
+	}
+}
+
+// RestoreGroupOutput is the response for a restore or its preview.
+type RestoreGroupOutput struct {
+	Body RestorePlanDTO
+}
+
+func (h *GroupHandler) handlePreviewGroupRestore(ctx context.Context, input *PreviewGroupRestoreInput) (*RestoreGroupOutput, error) {
+	if _, err := h.authorizeRestore(ctx, input.ID); err != nil {
+		return nil, err
+	}
+
+	cutoff, err := resolveRestoreCutoff(ctx, h.queries, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planGroupRestore(ctx, h.queries, input.ID, input.Body.MembershipID, cutoff)
+	if err != nil {
+		return nil, restorePlanError(ctx, err)
+	}
+
+	return &RestoreGroupOutput{Body: plan.DTO(input.ID, input.Body.MembershipID, false)}, nil
+}
+
+func (h *GroupHandler) handleRestoreGroup(ctx context.Context, input *RestoreGroupInput) (*RestoreGroupOutput, error) {
+	userID, err := h.authorizeRestore(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	target := input.Body.RestoreTarget
+	cutoff, err := resolveRestoreCutoff(ctx, h.queries, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plan and apply in one audited transaction so the plan matches what is applied
+	plan, err := db.WithAuditContext(ctx, h.pool, userID, func(tx pgx.Tx) (*restorePlan, error) {
+		txQueries := h.queries.WithTx(tx)
+		plan, planErr := planGroupRestore(ctx, txQueries, input.ID, target.MembershipID, cutoff)
+		if planErr != nil {
+			return nil, restorePlanError(ctx, planErr)
+		}
+		if n := plan.conflicts(); n > 0 && !input.Body.SkipConflicts {
+			return nil, huma.Error409Conflict(fmt.Sprintf(
+				"Restore has %d conflicting actions; preview it and retry with skip_conflicts to apply the rest", n))
+		}
+		return plan, plan.apply(ctx, txQueries)
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		if db.IsLastAdminViolation(err) {
+			return nil, huma.Error409Conflict("Restore would leave the group without an administrator")
+		}
+		if isUniqueViolation(err, "memberships_unique_user_group") {
+			return nil, huma.Error409Conflict("Memberships changed during the restore; preview it again")
+		}
+		LogDBError(ctx, "RestoreGroup", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	return &RestoreGroupOutput{Body: plan.DTO(input.ID, target.MembershipID, true)}, nil
+}
+
+// authorizeRestore checks the caller is an admin of an unarchived group and
+// returns their user ID.
+func (h *GroupHandler) authorizeRestore(ctx context.Context, groupID int64) (int64, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return 0, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: restores can undo any admin action, so only admins may run them
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, groupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return 0, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return 0, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.IsAdmin {
+		return 0, huma.Error403Forbidden("Only admins can restore a group")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return 0, huma.Error409Conflict("Cannot restore an archived group; unarchive it first")
+	}
+
+	return principal.UserID, nil
+}
+
+// resolveRestoreCutoff validates the restore target and converts it to the
+// last audit chain position to keep.
+func resolveRestoreCutoff(ctx context.Context, q *db.Queries, target RestoreTarget) (int64, error) {
+	if (target.At == nil) == (target.XactID == 0) {
+		return 0, huma.Error422UnprocessableEntity("Invalid restore point",
+			&huma.ErrorDetail{
+				Location: "body",
+				Message:  "Exactly one of at or xact_id is required",
+			})
+	}
+
+	if target.At != nil {
+		if target.At.After(time.Now()) {
+			return 0, huma.Error422UnprocessableEntity("Invalid restore point",
+				&huma.ErrorDetail{
+					Location: "body.at",
+					Message:  "Restore point must be in the past",
+					Value:    *target.At,
+				})
+		}
+		seq, err := q.GetAuditChainSeqAt(ctx, pgtype.Timestamptz{Time: *target.At, Valid: true})
+		if err != nil {
+			LogDBError(ctx, "GetAuditChainSeqAt", err)
+			return 0, huma.Error500InternalServerError("Database error")
+		}
+		if err := checkRestoreRetained(ctx, q, seq, "body.at", *target.At); err != nil {
+			return 0, err
+		}
+		return seq, nil
+	}
+
+	seq, err := q.GetAuditChainSeqForXact(ctx, target.XactID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			oldest, oldestErr := q.GetOldestAuditXact(ctx)
+			if oldestErr != nil {
+				LogDBError(ctx, "GetOldestAuditXact", oldestErr)
+				return 0, huma.Error500InternalServerError("Database error")
+			}
+			// Older than every retained transaction: possibly archived
+			if target.XactID < oldest {
+				if err := checkRestoreRetained(ctx, q, 0, "body.xact_id", target.XactID); err != nil {
+					return 0, err
+				}
+			}
+			return 0, huma.Error422UnprocessableEntity("Invalid restore point",
+				&huma.ErrorDetail{
+					Location: "body.xact_id",
+					Message:  "No audited changes were made in this transaction",
+					Value:    target.XactID,
+				})
+		}
+		LogDBError(ctx, "GetAuditChainSeqForXact", err)
+		return 0, huma.Error500InternalServerError("Database error")
+	}
+	return seq, nil
+}
+
+// checkRestoreRetained rejects a cutoff at or before the last archived chain
+// position. The rows changed since were last recorded in dropped partitions,
+// so the first retained change no longer shows their state at the cutoff.
+func checkRestoreRetained(ctx context.Context, q *db.Queries, seq int64, location string, value any) error {
+	anchor, err := q.GetAuditChainAnchor(ctx)
+	if db.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		LogDBError(ctx, "GetAuditChainAnchor", err)
+		return huma.Error500InternalServerError("Database error")
+	}
+	if seq <= anchor.LastSeq {
+		return huma.Error422UnprocessableEntity("Invalid restore point",
+			&huma.ErrorDetail{
+				Location: location,
+				Message:  "Restore point is older than the retained audit log",
+				Value:    value,
+			})
+	}
+	return nil
+}
+
+// restorePlanError converts a planning error to an API error.
+func restorePlanError(ctx context.Context, err error) error {
+	if errors.Is(err, errGroupNotYetCreated) {
+		return huma.Error422UnprocessableEntity("Invalid restore point",
+			&huma.ErrorDetail{
+				Location: "body",
+				Message:  "The group did not exist yet at the restore point",
+			})
+	}
+	LogDBError(ctx, "PlanGroupRestore", err)
+	return huma.Error500InternalServerError("Database error")
+}
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestRestorePlanApplyOrder verifies steps run in an order that passes
+// last-admin protection and the one-membership-per-user constraint.
+func TestRestorePlanApplyOrder(t *testing.T) {
+	step := func(id int64, action, role string, replaces int64) *restoreStep {
+		s := &restoreStep{action: RestoreActionDTO{Table: "memberships", RecordID: id, Action: action}, replaces: replaces}
+		if action != RestoreActionDelete {
+			s.membership = &db.Membership{ID: id, Role: role}
+		}
+		return s
+	}
+	plan := &restorePlan{steps: []*restoreStep{
+		step(1, RestoreActionDelete, "", 0),
+		step(2, RestoreActionRecreate, "admin", 1),
+		step(3, RestoreActionUpdate, "member", 0),
+		step(4, RestoreActionRecreate, "member", 0),
+		step(5, RestoreActionUpdate, "admin", 0),
+		step(6, RestoreActionRecreate, "admin", 0),
+		{action: RestoreActionDTO{Table: "groups", RecordID: 9, Action: RestoreActionUpdate}, group: &db.Group{ID: 9}},
+	}}
+
+	var got []int64
+	for _, s := range plan.applyOrder() {
+		got = append(got, s.action.RecordID)
+	}
+	want := []int64{9, 5, 6, 3, 4, 1, 2}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+// TestRestoreChanges verifies snapshots decoded from audit JSONB diff cleanly
+// against current rows.
+func TestRestoreChanges(t *testing.T) {
+	// to_jsonb renders timestamps with a +00:00 offset
+	snapshot := []byte(`{"id": 7, "group_id": 1, "user_id": 3, "role": "admin", "inviter_id": 2,
+		"accepted_at": "2025-03-01T10:00:00.123456+00:00", "created_at": "2025-03-01T09:00:00+00:00",
+		"updated_at": "2025-03-01T10:00:00.123456+00:00", "group_role_id": null, "expires_at": null,
+		"expiry_notified_at": null, "expiry_blocked_at": null}`)
+	target, err := decodeMembershipSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("decodeMembershipSnapshot: %v", err)
+	}
+
+	acceptedAt := time.Date(2025, 3, 1, 11, 0, 0, 123456000, time.FixedZone("CET", 3600))
+	current := &db.Membership{
+		ID:         7,
+		GroupID:    1,
+		UserID:     3,
+		Role:       "member",
+		InviterID:  2,
+		AcceptedAt: pgtype.Timestamptz{Time: acceptedAt, Valid: true},
+		UpdatedAt:  pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	changes, err := restoreChanges(utcMembership(current), target, restoreMembershipFields)
+	if err != nil {
+		t.Fatalf("restoreChanges: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Field != "role" || changes[0].From != "member" || changes[0].To != "admin" {
+		t.Errorf("expected only the role to change, got %+v", changes)
+	}
+
+	changes, err = restoreChanges(nil, target, restoreMembershipFields)
+	if err != nil {
+		t.Fatalf("restoreChanges: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected user_id, role, and accepted_at for a recreate, got %+v", changes)
+	}
+}
+
+// TestGroupRestore verifies preview and restore against real audit records.
+func TestGroupRestore(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	admin := setup.createTestUser(t, "alice@example.com", "Alice")
+	adminToken := setup.createTestSession(t, admin.ID)
+	bob := setup.createTestUser(t, "bob@example.com", "Bob")
+	bobToken := setup.createTestSession(t, bob.ID)
+	carol := setup.createTestUser(t, "carol@example.com", "Carol")
+	dave := setup.createTestUser(t, "dave@example.com", "Dave")
+
+	w := setup.makeRequest(http.MethodPost, "/api/v1/groups", map[string]any{"name": "Restore Group"}, adminToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create group: %d: %s", w.Code, w.Body.String())
+	}
+	var createResp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &createResp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	groupID := int64(createResp["group"].(map[string]any)["id"].(float64))
+
+	invite := func(userID int64) int64 {
+		t.Helper()
+		w := setup.makeRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID),
+			map[string]any{"user_id": userID}, adminToken)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("failed to invite: %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return int64(resp["membership"].(map[string]any)["id"].(float64))
+	}
+	remove := func(membershipID int64) {
+		t.Helper()
+		w := setup.makeRequest(http.MethodDelete, fmt.Sprintf("/api/v1/memberships/%d", membershipID), nil, adminToken)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("failed to remove member: %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	bobMembership := invite(bob.ID)
+	if _, err := setup.pool.Exec(ctx, "UPDATE memberships SET accepted_at = NOW() WHERE id = $1", bobMembership); err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	carolMembership := invite(carol.ID)
+
+	restorePoint := time.Now()
+
+	// The mistakes: rename the group, remove Bob and Carol, invite Dave
+	w = setup.makeRequest(http.MethodPatch, fmt.Sprintf("/api/v1/groups/%d", groupID),
+		map[string]any{"name": "Oops"}, adminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to update group: %d: %s", w.Code, w.Body.String())
+	}
+	remove(bobMembership)
+	remove(carolMembership)
+	daveMembership := invite(dave.ID)
+
+	restorePath := fmt.Sprintf("/api/v1/groups/%d/restore", groupID)
+	target := map[string]any{"at": restorePoint.Format(time.RFC3339Nano)}
+
+	decodePlan := func(w interface{ Bytes() []byte }) RestorePlanDTO {
+		t.Helper()
+		var plan RestorePlanDTO
+		if err := json.Unmarshal(w.Bytes(), &plan); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return plan
+	}
+
+	t.Run("non-admin is forbidden", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodPost, restorePath+"/preview", target, bobToken)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("target must be exactly one of at or xact_id", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodPost, restorePath+"/preview",
+			map[string]any{"at": restorePoint.Format(time.RFC3339Nano), "xact_id": 1}, adminToken)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected 422, got %d", w.Code)
+		}
+		w = setup.makeRequest(http.MethodPost, restorePath+"/preview", map[string]any{}, adminToken)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("single membership conflicts when the user was re-invited", func(t *testing.T) {
+		// Carol's old membership cannot come back while her new one exists
+		newCarol := invite(carol.ID)
+		defer remove(newCarol)
+
+		body := map[string]any{"at": target["at"], "membership_id": carolMembership}
+		w := setup.makeRequest(http.MethodPost, restorePath+"/preview", body, adminToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("preview failed: %d: %s", w.Code, w.Body.String())
+		}
+		plan := decodePlan(w.Body)
+		if len(plan.Actions) != 1 || plan.Conflicts != 1 || plan.Actions[0].Action != RestoreActionRecreate {
+			t.Fatalf("expected one conflicting recreate, got %+v", plan)
+		}
+
+		w = setup.makeRequest(http.MethodPost, restorePath, body, adminToken)
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected 409 for a conflicting restore, got %d", w.Code)
+		}
+	})
+
+	t.Run("preview lists changes without applying them", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodPost, restorePath+"/preview", target, adminToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("preview failed: %d: %s", w.Code, w.Body.String())
+		}
+		plan := decodePlan(w.Body)
+		if plan.Applied || plan.Conflicts != 0 {
+			t.Errorf("expected an unapplied plan without conflicts, got %+v", plan)
+		}
+
+		actions := map[int64]string{}
+		for _, a := range plan.Actions {
+			actions[a.RecordID] = a.Table + " " + a.Action
+		}
+		want := map[int64]string{
+			bobMembership:   "memberships recreate",
+			carolMembership: "memberships recreate",
+			daveMembership:  "memberships delete",
+		}
+		if len(actions) != 4 || actions[groupID] != "groups update" {
+			t.Errorf("expected group update plus three membership actions, got %v", actions)
+		}
+		for id, action := range want {
+			if actions[id] != action {
+				t.Errorf("membership %d: got %q, want %q", id, actions[id], action)
+			}
+		}
+
+		group, err := setup.queries.GetGroupByID(ctx, groupID)
+		if err != nil {
+			t.Fatalf("GetGroupByID: %v", err)
+		}
+		if group.Name != "Oops" {
+			t.Errorf("preview changed the group name to %q", group.Name)
+		}
+	})
+
+	t.Run("restore replays the old state and is audited", func(t *testing.T) {
+		w := setup.makeRequest(http.MethodPost, restorePath, target, adminToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("restore failed: %d: %s", w.Code, w.Body.String())
+		}
+		if plan := decodePlan(w.Body); !plan.Applied || len(plan.Actions) != 4 {
+			t.Errorf("expected 4 applied actions, got %+v", plan)
+		}
+
+		group, err := setup.queries.GetGroupByID(ctx, groupID)
+		if err != nil {
+			t.Fatalf("GetGroupByID: %v", err)
+		}
+		if group.Name != "Restore Group" {
+			t.Errorf("expected name to be restored, got %q", group.Name)
+		}
+
+		restored, err := setup.queries.GetMembershipByID(ctx, bobMembership)
+		if err != nil {
+			t.Fatalf("Bob's membership was not recreated: %v", err)
+		}
+		if restored.UserID != bob.ID || !restored.AcceptedAt.Valid {
+			t.Errorf("unexpected restored membership: %+v", restored)
+		}
+		if _, err := setup.queries.GetMembershipByID(ctx, daveMembership); !db.IsNotFound(err) {
+			t.Errorf("expected Dave's membership to be removed, got err=%v", err)
+		}
+
+		var unattributed int
+		if err := setup.pool.QueryRow(ctx,
+			`SELECT COUNT(*) FROM audit.record_version
+			 WHERE xact_id = (SELECT xact_id FROM audit.record_version ORDER BY chain_seq DESC LIMIT 1)
+			   AND actor_id IS DISTINCT FROM $1`, admin.ID,
+		).Scan(&unattributed); err != nil {
+			t.Fatalf("failed to query audit log: %v", err)
+		}
+		if unattributed != 0 {
+			t.Errorf("expected every restore write to be attributed to the admin, %d were not", unattributed)
+		}
+
+		// Restoring again is a no-op
+		w = setup.makeRequest(http.MethodPost, restorePath+"/preview", target, adminToken)
+		if plan := decodePlan(w.Body); len(plan.Actions) != 0 {
+			t.Errorf("expected nothing left to restore, got %+v", plan.Actions)
+		}
+	})
+
+	t.Run("restore point older than the retained audit log", func(t *testing.T) {
+		// Pretend everything up to now was archived and dropped
+		if _, err := setup.pool.Exec(ctx,
+			`INSERT INTO audit.chain_archive (partition_name, range_start, range_end, row_count, first_seq, last_seq, last_hash)
+			 SELECT 'record_version_test', NOW() - INTERVAL '1 month', NOW(), 0, 1, MAX(chain_seq), '\x00'::bytea
+			 FROM audit.record_version`,
+		); err != nil {
+			t.Fatalf("insert chain_archive: %v", err)
+		}
+
+		for name, body := range map[string]map[string]any{
+			"at":      target,
+			"xact_id": {"xact_id": 1},
+		} {
+			w := setup.makeRequest(http.MethodPost, restorePath+"/preview", body, adminToken)
+			if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "older than the retained audit log") {
+				t.Errorf("%s: expected 422 for an archived restore point, got %d: %s", name, w.Code, w.Body.String())
+			}
+		}
+	})
+}
+
+// TestGroupRestore_AdminHandover tests restoring past a handover of the
+// admin role: the removed admin is recreated before the new one is demoted,
+// so the group never lacks an administrator.
+func TestGroupRestore_AdminHandover(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	alice := setup.createTestUser(t, "alice@example.com", "Alice")
+	aliceToken := setup.createTestSession(t, alice.ID)
+	bob := setup.createTestUser(t, "bob@example.com", "Bob")
+	bobToken := setup.createTestSession(t, bob.ID)
+
+	w := setup.makeRequest(http.MethodPost, "/api/v1/groups", map[string]any{"name": "Handover Group"}, aliceToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create group: %d: %s", w.Code, w.Body.String())
+	}
+	var createResp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &createResp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	groupID := int64(createResp["group"].(map[string]any)["id"].(float64))
+
+	w = setup.makeRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID),
+		map[string]any{"user_id": bob.ID}, aliceToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to invite: %d: %s", w.Code, w.Body.String())
+	}
+	var inviteResp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &inviteResp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	bobMembership := int64(inviteResp["membership"].(map[string]any)["id"].(float64))
+	if _, err := setup.pool.Exec(ctx, "UPDATE memberships SET accepted_at = NOW() WHERE id = $1", bobMembership); err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	aliceMembership, err := setup.queries.GetMembershipByGroupAndUser(ctx, db.GetMembershipByGroupAndUserParams{GroupID: groupID, UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetMembershipByGroupAndUser: %v", err)
+	}
+
+	restorePoint := time.Now()
+
+	// Bob becomes admin and removes Alice
+	w = setup.makeRequest(http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/promote", bobMembership), nil, aliceToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to promote: %d: %s", w.Code, w.Body.String())
+	}
+	w = setup.makeRequest(http.MethodDelete, fmt.Sprintf("/api/v1/memberships/%d", aliceMembership.ID), nil, bobToken)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("failed to remove Alice: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = setup.makeRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/restore", groupID),
+		map[string]any{"at": restorePoint.Format(time.RFC3339Nano)}, bobToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	restored, err := setup.queries.GetMembershipByID(ctx, aliceMembership.ID)
+	if err != nil {
+		t.Fatalf("Alice's membership was not recreated: %v", err)
+	}
+	if restored.Role != string(RoleAdmin) {
+		t.Errorf("expected Alice to be admin again, got %q", restored.Role)
+	}
+	demoted, err := setup.queries.GetMembershipByID(ctx, bobMembership)
+	if err != nil {
+		t.Fatalf("GetMembershipByID(bob): %v", err)
+	}
+	if demoted.Role != string(RoleMember) {
+		t.Errorf("expected Bob to be a member again, got %q", demoted.Role)
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,23 +24,20 @@ import (
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 
-	"github.com/zacaytion/llmio/internal/auth"
 	"github.com/zacaytion/llmio/internal/db"
 )
 
 // GroupHandler handles group-related HTTP requests.
 type GroupHandler struct {
-	pool     *pgxpool.Pool
-	queries  *db.Queries
-	sessions *auth.SessionStore
+	pool    *pgxpool.Pool
+	queries *db.Queries
 }
 
 // NewGroupHandler creates a new group handler.
-func NewGroupHandler(pool *pgxpool.Pool, queries *db.Queries, sessions *auth.SessionStore) *GroupHandler {
+func NewGroupHandler(pool *pgxpool.Pool, queries *db.Queries) *GroupHandler {
 	return &GroupHandler{
-		pool:     pool,
-		queries:  queries,
-		sessions: sessions,
+		pool:    pool,
+		queries: queries,
 	}
 }
 
@@ -50,6 +52,7 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Description:   "Creates a new group with the authenticated user as the first admin.",
 		Tags:          []string{"Groups"},
 		DefaultStatus: http.StatusCreated,
+		Security:      SessionSecurity,
 	}, h.handleCreateGroup)
 
 	// Get group
@@ -60,6 +63,7 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Get group details",
 		Description: "Returns detailed group information including permission flags and counts.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleGetGroup)
 
 	// Update group
@@ -70,6 +74,7 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Update group",
 		Description: "Updates group settings and permission flags. Requires admin role.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleUpdateGroup)
 
 	// Create subgroup
@@ -81,6 +86,7 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Description:   "Creates a subgroup under the specified parent group. Requires admin role or members_can_create_subgroups permission.",
 		Tags:          []string{"Groups"},
 		DefaultStatus: http.StatusCreated,
+		Security:      SessionSecurity,
 	}, h.handleCreateSubgroup)
 
 	// List subgroups
@@ -91,16 +97,40 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Summary:     "List subgroups",
 		Description: "Returns all subgroups under the specified parent group.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleListSubgroups)
 
+	// Get subgroup tree
+	huma.Register(api, huma.Operation{
+		OperationID: "getGroupTree",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/groups/{id}/tree",
+		Summary:     "Get group tree",
+		Description: "Returns the group with all of its descendants nested by level, plus its ancestors from nearest parent to root.",
+		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
+	}, h.handleGetGroupTree)
+
+	// Move group
+	huma.Register(api, huma.Operation{
+		OperationID: "moveGroup",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/groups/{id}/move",
+		Summary:     "Move group",
+		Description: "Moves a group under a new parent, or to the top level when parent_id is null. Requires admin role in the group and in the new parent. Moves that would create a cycle are rejected.",
+		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
+	}, h.handleMoveGroup)
+
 	// Archive group
 	huma.Register(api, huma.Operation{
 		OperationID: "archiveGroup",
 		Method:      http.MethodPost,
 		Path:        "/api/v1/groups/{id}/archive",
 		Summary:     "Archive group",
-		Description: "Archives a group. Requires admin role.",
+		Description: "Archives a group and all of its active descendants. Requires admin role; an archived group cannot be archived again.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleArchiveGroup)
 
 	// Unarchive group
@@ -109,10 +139,43 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodPost,
 		Path:        "/api/v1/groups/{id}/unarchive",
 		Summary:     "Unarchive group",
-		Description: "Restores an archived group. Requires admin role.",
+		Description: "Restores an archived group and the descendants that were archived with it. Requires admin role.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleUnarchiveGroup)
 
+	// Group change history (audit log)
+	huma.Register(api, huma.Operation{
+		OperationID: "getGroupHistory",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/groups/{id}/history",
+		Summary:     "Get group history",
+		Description: "Returns the group's change log from the audit trail, newest first: who changed which field from what to what. Covers the group, its memberships, custom roles, and handle history. Requires admin role.",
+		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
+	}, h.handleGetGroupHistory)
+
+	// Point-in-time restore from the audit log
+	huma.Register(api, huma.Operation{
+		OperationID: "previewGroupRestore",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/groups/{id}/restore/preview",
+		Summary:     "Preview group restore",
+		Description: "Lists what restoring the group's settings and memberships (or one membership) to an earlier time or transaction would change, without changing anything. Requires admin role.",
+		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
+	}, h.handlePreviewGroupRestore)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restoreGroup",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/groups/{id}/restore",
+		Summary:     "Restore group",
+		Description: "Restores the group's settings and memberships (or one membership) to their state at an earlier time or transaction by replaying the audit log. Deleted memberships are recreated with their original IDs and memberships added since are removed. Fails with 409 if any action conflicts unless skip_conflicts is set. The restore is itself audited. Requires admin role.",
+		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
+	}, h.handleRestoreGroup)
+
 	// List groups (user's memberships)
 	huma.Register(api, huma.Operation{
 		OperationID: "listGroups",
@@ -121,6 +184,7 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Summary:     "List groups",
 		Description: "Returns all groups the current user is a member of.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleListGroups)
 
 	// Get group by handle - using separate path to avoid conflict with /groups/{id} pattern
@@ -129,15 +193,15 @@ func (h *GroupHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/group-by-handle/{handle}",
 		Summary:     "Get group by handle",
-		Description: "Returns detailed group information by handle.",
+		Description: "Returns detailed group information by handle. Retired handles resolve to the renamed group with redirected_from set.",
 		Tags:        []string{"Groups"},
+		Security:    SessionSecurity,
 	}, h.handleGetGroupByHandle)
 }
 
 // CreateGroupInput is the request body for creating a group.
 type CreateGroupInput struct {
-	Cookie string `cookie:"loomio_session"`
-	Body   struct {
+	Body struct {
 		Name        string  `json:"name" required:"true" minLength:"1" maxLength:"255" doc:"Group name (1-255 chars)"`
 		Handle      string  `json:"handle,omitempty" minLength:"3" maxLength:"100" doc:"URL-safe handle (auto-generated if not provided)"`
 		Description *string `json:"description,omitempty" doc:"Optional group description"`
@@ -153,12 +217,8 @@ type CreateGroupOutput struct {
 
 func (h *GroupHandler) handleCreateGroup(ctx context.Context, input *CreateGroupInput) (*CreateGroupOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -208,6 +268,10 @@ func (h *GroupHandler) handleCreateGroup(ctx context.Context, input *CreateGroup
 					Value:    handle,
 				})
 		}
+		// Retired handles stay reserved for their previous group
+		if err := h.checkHandleAvailable(ctx, handle, 0); err != nil {
+			return nil, err
+		}
 	}
 
 	// Build description as pgtype.Text
@@ -220,7 +284,7 @@ func (h *GroupHandler) handleCreateGroup(ctx context.Context, input *CreateGroup
 	var group *db.Group
 	err := pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		// Set audit context for triggers
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
@@ -232,7 +296,7 @@ func (h *GroupHandler) handleCreateGroup(ctx context.Context, input *CreateGroup
 			Name:        name,
 			Handle:      handle,
 			Description: description,
-			CreatedByID: session.UserID,
+			CreatedByID: principal.UserID,
 			// Permission flags use database defaults (COALESCE in query)
 		})
 		if createErr != nil {
@@ -251,9 +315,9 @@ func (h *GroupHandler) handleCreateGroup(ctx context.Context, input *CreateGroup
 		// Create admin membership for creator (auto-accepted)
 		_, membershipErr := txQueries.CreateMembership(ctx, db.CreateMembershipParams{
 			GroupID:   group.ID,
-			UserID:    session.UserID,
+			UserID:    principal.UserID,
 			Role:      RoleAdmin.String(), // T207: Use String() for DB interaction
-			InviterID: session.UserID,     // Self-invited
+			InviterID: principal.UserID,   // Self-invited
 			AcceptedAt: pgtype.Timestamptz{
 				Time:  group.CreatedAt.Time, // Same timestamp as group creation
 				Valid: true,
@@ -372,6 +436,32 @@ func GenerateHandle(name string) string {
 	return result
 }
 
+// HandleReservationPeriod is how long a retired handle stays reserved for the
+// group that retired it. Other groups cannot claim it until the period ends.
+const HandleReservationPeriod = 90 * 24 * time.Hour
+
+// checkHandleAvailable returns a 409 if the handle belongs to or is reserved by
+// a group other than groupID (use 0 for groups not yet created).
+func (h *GroupHandler) checkHandleAvailable(ctx context.Context, handle string, groupID int64) error {
+	available, err := h.queries.HandleAvailableForGroup(ctx, db.HandleAvailableForGroupParams{
+		Handle:  handle,
+		GroupID: groupID,
+	})
+	if err != nil {
+		LogDBError(ctx, "HandleAvailableForGroup", err)
+		return huma.Error500InternalServerError("Database error")
+	}
+	if !available {
+		return huma.Error409Conflict("Handle already taken",
+			&huma.ErrorDetail{
+				Location: "body.handle",
+				Message:  "Handle already taken or reserved",
+				Value:    handle,
+			})
+	}
+	return nil
+}
+
 // GenerateUniqueHandle generates a handle and appends a numeric suffix if needed
 // to ensure uniqueness. The checkExists function should return true if the handle
 // already exists in the database.
@@ -441,12 +531,13 @@ func itoa(i int) string {
 
 // GetGroupInput is the request for getting a group.
 type GetGroupInput struct {
-	Cookie string `cookie:"loomio_session"`
-	ID     int64  `path:"id" doc:"Group ID"`
+	conditional.Params
+	ID int64 `path:"id" doc:"Group ID"`
 }
 
 // GetGroupOutput is the response for getting a group.
 type GetGroupOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the group, for If-None-Match and If-Match"`
 	Body struct {
 		Group GroupDetailDTO `json:"group"`
 	}
@@ -454,17 +545,13 @@ type GetGroupOutput struct {
 
 func (h *GroupHandler) handleGetGroup(ctx context.Context, input *GetGroupInput) (*GetGroupOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be a member of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -484,8 +571,19 @@ func (h *GroupHandler) handleGetGroup(ctx context.Context, input *GetGroupInput)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
+	// Not Modified when the client's cached copy is current
+	modified, err := groupModifiedAt(ctx, h.queries, &input.Params, authCtx.Group)
+	if err != nil {
+		LogDBError(ctx, "groupModifiedAt", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	tag := groupETag(authCtx.Group, stats.MemberCount, stats.AdminCount, authCtx.GetRole(), responseFormat(ctx))
+	if err := checkNotModified(&input.Params, tag, modified); err != nil {
+		return nil, err
+	}
+
 	// Build response with full details
-	output := &GetGroupOutput{}
+	output := &GetGroupOutput{ETag: quoteETag(tag)}
 	output.Body.Group = GroupDetailDTOFromGroup(authCtx.Group, stats.MemberCount, stats.AdminCount, authCtx.GetRole())
 
 	// Check if parent is archived (for subgroups - T103a)
@@ -518,10 +616,11 @@ func (h *GroupHandler) handleGetGroup(ctx context.Context, input *GetGroupInput)
 
 // UpdateGroupInput is the request for updating a group.
 type UpdateGroupInput struct {
-	Cookie string `cookie:"loomio_session"`
-	ID     int64  `path:"id" doc:"Group ID"`
-	Body   struct {
+	conditional.Params
+	ID   int64 `path:"id" doc:"Group ID"`
+	Body struct {
 		Name                           *string `json:"name,omitempty" minLength:"1" maxLength:"255" doc:"Group name"`
+		Handle                         *string `json:"handle,omitempty" minLength:"3" maxLength:"100" doc:"New URL-safe handle; the old handle keeps redirecting and stays reserved for this group"`
 		Description                    *string `json:"description,omitempty" doc:"Group description"`
 		MembersCanAddMembers           *bool   `json:"members_can_add_members,omitempty" doc:"Members can invite others"`
 		MembersCanAddGuests            *bool   `json:"members_can_add_guests,omitempty" doc:"Members can add discussion guests"`
@@ -534,11 +633,13 @@ type UpdateGroupInput struct {
 		MembersCanCreateSubgroups      *bool   `json:"members_can_create_subgroups,omitempty" doc:"Members can create subgroups"`
 		AdminsCanEditUserContent       *bool   `json:"admins_can_edit_user_content,omitempty" doc:"Admins can edit any content"`
 		ParentMembersCanSeeDiscussions *bool   `json:"parent_members_can_see_discussions,omitempty" doc:"Parent members see subgroup content"`
+		ParentAdminsCanManage          *bool   `json:"parent_admins_can_manage,omitempty" doc:"Parent group admins have admin rights over this group"`
 	}
 }
 
 // UpdateGroupOutput is the response for updating a group.
 type UpdateGroupOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the updated group"`
 	Body struct {
 		Group GroupDetailDTO `json:"group"`
 	}
@@ -546,17 +647,13 @@ type UpdateGroupOutput struct {
 
 func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroupInput) (*UpdateGroupOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be an admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -566,7 +663,13 @@ func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroup
 	}
 
 	if !authCtx.CanUpdateGroup() {
-		return nil, huma.Error403Forbidden("Admin role required to update group")
+		return nil, huma.Error403Forbidden("Permission to update group settings required")
+	}
+
+	// Changing who inherits admin rights is itself an admin decision,
+	// even for custom roles holding update_group
+	if input.Body.ParentAdminsCanManage != nil && !authCtx.IsAdmin {
+		return nil, huma.Error403Forbidden("Admin role required to change parent admin rights")
 	}
 
 	// T142: Check if group is archived before allowing updates
@@ -592,6 +695,27 @@ func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroup
 		updateParams.Name = pgtype.Text{String: name, Valid: true}
 	}
 
+	// Handle update: the previous handle is recorded in group_handle_history
+	var retiredHandle string
+	if input.Body.Handle != nil {
+		handle := strings.ToLower(strings.TrimSpace(*input.Body.Handle))
+		if !isValidHandle(handle) {
+			return nil, huma.Error422UnprocessableEntity("Invalid handle format",
+				&huma.ErrorDetail{
+					Location: "body.handle",
+					Message:  "Handle must be 3-100 characters, start and end with alphanumeric, contain only lowercase letters, numbers, and hyphens",
+					Value:    handle,
+				})
+		}
+		if !strings.EqualFold(handle, authCtx.Group.Handle) {
+			if err := h.checkHandleAvailable(ctx, handle, input.ID); err != nil {
+				return nil, err
+			}
+			updateParams.Handle = pgtype.Text{String: handle, Valid: true}
+			retiredHandle = authCtx.Group.Handle
+		}
+	}
+
 	// Description update
 	if input.Body.Description != nil {
 		updateParams.Description = pgtype.Text{String: *input.Body.Description, Valid: true}
@@ -631,22 +755,61 @@ func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroup
 	if input.Body.ParentMembersCanSeeDiscussions != nil {
 		updateParams.ParentMembersCanSeeDiscussions = pgtype.Bool{Bool: *input.Body.ParentMembersCanSeeDiscussions, Valid: true}
 	}
+	if input.Body.ParentAdminsCanManage != nil {
+		updateParams.ParentAdminsCanManage = pgtype.Bool{Bool: *input.Body.ParentAdminsCanManage, Valid: true}
+	}
 
 	// Execute update in transaction with audit context
 	var group *db.Group
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		// Set audit context for triggers
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
 		txQueries := h.queries.WithTx(tx)
+
+		// If-Match: refuse to overwrite changes the client has not seen
+		if condErr := checkGroupPrecondition(ctx, txQueries, &input.Params, input.ID, authCtx.GetRole()); condErr != nil {
+			return condErr
+		}
+
+		if retiredHandle != "" {
+			// Drop any history row for the new handle (e.g. reclaiming our own
+			// old handle), then reserve the handle being retired
+			if releaseErr := txQueries.ReleaseHandle(ctx, updateParams.Handle.String); releaseErr != nil {
+				return fmt.Errorf("ReleaseHandle: %w", releaseErr)
+			}
+			if _, recordErr := txQueries.RecordHandleChange(ctx, db.RecordHandleChangeParams{
+				GroupID:       input.ID,
+				Handle:        retiredHandle,
+				ChangedByID:   pgtype.Int8{Int64: principal.UserID, Valid: true},
+				ReservedUntil: pgtype.Timestamptz{Time: time.Now().Add(HandleReservationPeriod), Valid: true},
+			}); recordErr != nil {
+				return fmt.Errorf("RecordHandleChange: %w", recordErr)
+			}
+		}
+
 		var updateErr error
 		group, updateErr = txQueries.UpdateGroup(ctx, updateParams)
-		return updateErr
+		if updateErr != nil {
+			if isUniqueViolation(updateErr, "groups_handle_key") {
+				return huma.Error409Conflict("Handle already taken",
+					&huma.ErrorDetail{
+						Location: "body.handle",
+						Message:  "Handle already taken",
+						Value:    updateParams.Handle.String,
+					})
+			}
+			return fmt.Errorf("UpdateGroup: %w", updateErr)
+		}
+		return nil
 	})
 
 	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
 		LogDBError(ctx, "UpdateGroup", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
@@ -665,7 +828,7 @@ func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroup
 	}
 
 	// Build response
-	output := &UpdateGroupOutput{}
+	output := &UpdateGroupOutput{ETag: quoteETag(groupETag(group, memberCount, adminCount, authCtx.GetRole(), responseFormat(ctx)))}
 	output.Body.Group = GroupDetailDTOFromGroup(group, memberCount, adminCount, authCtx.GetRole())
 	return output, nil
 }
@@ -676,13 +839,13 @@ func (h *GroupHandler) handleUpdateGroup(ctx context.Context, input *UpdateGroup
 
 // CreateSubgroupInput is the request for creating a subgroup.
 type CreateSubgroupInput struct {
-	Cookie   string `cookie:"loomio_session"`
-	ParentID int64  `path:"id" doc:"Parent group ID"`
+	ParentID int64 `path:"id" doc:"Parent group ID"`
 	Body     struct {
-		Name               string `json:"name" required:"true" minLength:"1" maxLength:"255" doc:"Subgroup name"`
-		Handle             string `json:"handle,omitempty" minLength:"3" maxLength:"100" doc:"URL-safe handle (auto-generated if not provided)"`
-		Description        *string `json:"description,omitempty" doc:"Optional description"`
-		InheritPermissions *bool  `json:"inherit_permissions,omitempty" doc:"Copy parent's permission flags (defaults to false)"`
+		Name                  string  `json:"name" required:"true" minLength:"1" maxLength:"255" doc:"Subgroup name"`
+		Handle                string  `json:"handle,omitempty" minLength:"3" maxLength:"100" doc:"URL-safe handle (auto-generated if not provided)"`
+		Description           *string `json:"description,omitempty" doc:"Optional description"`
+		InheritPermissions    *bool   `json:"inherit_permissions,omitempty" doc:"Copy parent's permission flags (defaults to false)"`
+		ParentAdminsCanManage *bool   `json:"parent_admins_can_manage,omitempty" doc:"Parent group admins have admin rights over the subgroup (defaults to true)"`
 	}
 }
 
@@ -695,17 +858,13 @@ type CreateSubgroupOutput struct {
 
 func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSubgroupInput) (*CreateSubgroupOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be able to create subgroups in the parent group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ParentID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ParentID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Parent group not found")
@@ -768,6 +927,10 @@ func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSu
 					Value:    handle,
 				})
 		}
+		// Retired handles stay reserved for their previous group
+		if err := h.checkHandleAvailable(ctx, handle, 0); err != nil {
+			return nil, err
+		}
 	}
 
 	// Build description
@@ -782,7 +945,7 @@ func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSu
 		Handle:      handle,
 		Description: description,
 		ParentID:    pgtype.Int8{Int64: input.ParentID, Valid: true},
-		CreatedByID: session.UserID,
+		CreatedByID: principal.UserID,
 	}
 
 	// If inheriting permissions, copy from parent
@@ -801,12 +964,15 @@ func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSu
 		createParams.ParentMembersCanSeeDiscussions = pgtype.Bool{Bool: parent.ParentMembersCanSeeDiscussions, Valid: true}
 	}
 	// If not inheriting, the COALESCE in the SQL query will use database defaults
+	if input.Body.ParentAdminsCanManage != nil {
+		createParams.ParentAdminsCanManage = pgtype.Bool{Bool: *input.Body.ParentAdminsCanManage, Valid: true}
+	}
 
 	// Execute in transaction
 	var group *db.Group
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		// Set audit context
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
@@ -830,9 +996,9 @@ func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSu
 		// Create admin membership for creator
 		_, membershipErr := txQueries.CreateMembership(ctx, db.CreateMembershipParams{
 			GroupID:   group.ID,
-			UserID:    session.UserID,
+			UserID:    principal.UserID,
 			Role:      RoleAdmin.String(), // T207: Use String() for DB interaction
-			InviterID: session.UserID,
+			InviterID: principal.UserID,
 			AcceptedAt: pgtype.Timestamptz{
 				Time:  group.CreatedAt.Time,
 				Valid: true,
@@ -860,31 +1026,31 @@ func (h *GroupHandler) handleCreateSubgroup(ctx context.Context, input *CreateSu
 
 // ListSubgroupsInput is the request for listing subgroups.
 type ListSubgroupsInput struct {
-	Cookie          string `cookie:"loomio_session"`
+	PageParams
 	ParentID        int64  `path:"id" doc:"Parent group ID"`
 	IncludeArchived bool   `query:"include_archived" default:"false" doc:"Include archived subgroups"`
+	Sort            string `query:"sort" enum:"name,created_at" default:"name" doc:"Sort field"`
 }
 
 // ListSubgroupsOutput is the response for listing subgroups.
+// Link and next_cursor are only set when another page exists.
 type ListSubgroupsOutput struct {
+	Link string `header:"Link"`
 	Body struct {
-		Groups []GroupDTO `json:"groups"`
+		Groups     []GroupDTO `json:"groups"`
+		NextCursor string     `json:"next_cursor,omitempty"`
 	}
 }
 
 func (h *GroupHandler) handleListSubgroups(ctx context.Context, input *ListSubgroupsInput) (*ListSubgroupsOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be a member of the parent group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ParentID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ParentID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Parent group not found")
@@ -897,15 +1063,27 @@ func (h *GroupHandler) handleListSubgroups(ctx context.Context, input *ListSubgr
 		return nil, huma.Error403Forbidden("Not a member of this group")
 	}
 
-	// List subgroups
+	page, err := newPageQuery(input.PageParams, input.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	// List one page of subgroups
 	subgroups, err := h.queries.ListSubgroupsByParent(ctx, db.ListSubgroupsByParentParams{
 		ParentID:        pgtype.Int8{Int64: input.ParentID, Valid: true},
 		IncludeArchived: input.IncludeArchived,
+		SortBy:          page.SortBy,
+		SortDesc:        page.Desc,
+		AfterID:         page.After.ID,
+		AfterName:       page.After.Name,
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		PageLimit:       page.FetchLimit(),
 	})
 	if err != nil {
 		LogDBError(ctx, "ListSubgroupsByParent", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
+	subgroups, hasMore := trimPage(subgroups, page)
 
 	// Build response
 	output := &ListSubgroupsOutput{}
@@ -913,6 +1091,238 @@ func (h *GroupHandler) handleListSubgroups(ctx context.Context, input *ListSubgr
 	for i, g := range subgroups {
 		output.Body.Groups[i] = GroupDTOFromGroup(g)
 	}
+	if hasMore {
+		last := subgroups[len(subgroups)-1]
+		output.Body.NextCursor = page.Cursor(last.ID, last.Name, "", last.CreatedAt.Time)
+		output.Link = nextPageLink(fmt.Sprintf("/api/v1/groups/%d/subgroups", input.ParentID),
+			url.Values{"include_archived": {strconv.FormatBool(input.IncludeArchived)}},
+			page, output.Body.NextCursor)
+	}
+	return output, nil
+}
+
+// ============================================================
+// Hierarchy handlers (tree and move)
+// ============================================================
+
+// isHierarchyCycleError checks if the error is from the hierarchy cycle trigger.
+// The trigger raises PostgreSQL error P0001 with message "Group hierarchy cycle: ...".
+func isHierarchyCycleError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "P0001" && strings.Contains(pgErr.Message, "hierarchy cycle")
+	}
+	return false
+}
+
+// buildGroupTree nests descendants (ordered by depth) under root.
+// Rows whose parent is not part of the tree are dropped, which keeps the
+// result consistent if a group is moved between the two reads.
+func buildGroupTree(root *db.Group, descendants []*db.ListGroupDescendantsRow) GroupTreeNodeDTO {
+	tree := GroupTreeNodeDTO{GroupDTO: GroupDTOFromGroup(root), Children: []*GroupTreeNodeDTO{}}
+	nodes := map[int64]*GroupTreeNodeDTO{root.ID: &tree}
+
+	for _, row := range descendants {
+		if !row.Group.ParentID.Valid {
+			continue
+		}
+		parent, ok := nodes[row.Group.ParentID.Int64]
+		if !ok {
+			continue
+		}
+		node := &GroupTreeNodeDTO{
+			GroupDTO: GroupDTOFromGroup(&row.Group),
+			Depth:    row.Depth,
+			Children: []*GroupTreeNodeDTO{},
+		}
+		parent.Children = append(parent.Children, node)
+		nodes[row.Group.ID] = node
+	}
+	return tree
+}
+
+// GetGroupTreeInput is the request for getting a group's hierarchy.
+type GetGroupTreeInput struct {
+	ID              int64 `path:"id" doc:"Group ID"`
+	IncludeArchived bool  `query:"include_archived" default:"false" doc:"Include archived descendants"`
+}
+
+// GetGroupTreeOutput is the response for getting a group's hierarchy.
+type GetGroupTreeOutput struct {
+	Body struct {
+		Group     GroupTreeNodeDTO `json:"group"`
+		Ancestors []GroupDTO       `json:"ancestors" doc:"Ancestors ordered from nearest parent to root"`
+	}
+}
+
+func (h *GroupHandler) handleGetGroupTree(ctx context.Context, input *GetGroupTreeInput) (*GetGroupTreeOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: user must be a member (or inherited admin) of the group
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanViewGroup() {
+		return nil, huma.Error403Forbidden("Not a member of this group")
+	}
+
+	descendants, err := h.queries.ListGroupDescendants(ctx, db.ListGroupDescendantsParams{
+		GroupID:         input.ID,
+		IncludeArchived: input.IncludeArchived,
+	})
+	if err != nil {
+		LogDBError(ctx, "ListGroupDescendants", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	ancestors, err := h.queries.ListGroupAncestors(ctx, input.ID)
+	if err != nil {
+		LogDBError(ctx, "ListGroupAncestors", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &GetGroupTreeOutput{}
+	output.Body.Group = buildGroupTree(authCtx.Group, descendants)
+	output.Body.Ancestors = make([]GroupDTO, len(ancestors))
+	for i, a := range ancestors {
+		output.Body.Ancestors[i] = GroupDTOFromGroup(&a.Group)
+	}
+	return output, nil
+}
+
+// MoveGroupInput is the request for moving a group within the hierarchy.
+type MoveGroupInput struct {
+	ID   int64 `path:"id" doc:"Group ID"`
+	Body struct {
+		ParentID *int64 `json:"parent_id" required:"true" nullable:"true" doc:"New parent group ID, or null to make the group top-level"`
+	}
+}
+
+// MoveGroupOutput is the response for moving a group.
+type MoveGroupOutput struct {
+	Body struct {
+		Group GroupDTO `json:"group"`
+	}
+}
+
+func (h *GroupHandler) handleMoveGroup(ctx context.Context, input *MoveGroupInput) (*MoveGroupOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: user must be an admin of the group being moved
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanMoveGroup() {
+		return nil, huma.Error403Forbidden("Admin role required to move group")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return nil, huma.Error409Conflict("Cannot move an archived group")
+	}
+
+	var newParentID pgtype.Int8
+	if input.Body.ParentID != nil {
+		parentID := *input.Body.ParentID
+		if parentID == input.ID {
+			return nil, huma.Error422UnprocessableEntity("Group cannot be its own parent",
+				&huma.ErrorDetail{
+					Location: "body.parent_id",
+					Message:  "Group cannot be its own parent",
+					Value:    parentID,
+				})
+		}
+
+		// Authorize: user must also be an admin of the new parent
+		parentCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, parentID)
+		if err != nil {
+			if db.IsNotFound(err) {
+				return nil, huma.Error404NotFound("Parent group not found")
+			}
+			LogDBError(ctx, "NewAuthorizationContext", err)
+			return nil, huma.Error500InternalServerError("Database error")
+		}
+
+		if !parentCtx.CanMoveGroup() {
+			return nil, huma.Error403Forbidden("Admin role required in the new parent group")
+		}
+
+		if parentCtx.Group.ArchivedAt.Valid {
+			return nil, huma.Error409Conflict("Cannot move group under an archived group")
+		}
+
+		// Reject moving a group under one of its own descendants
+		ancestors, err := h.queries.ListGroupAncestors(ctx, parentID)
+		if err != nil {
+			LogDBError(ctx, "ListGroupAncestors", err)
+			return nil, huma.Error500InternalServerError("Database error")
+		}
+		for _, a := range ancestors {
+			if a.Group.ID == input.ID {
+				return nil, huma.Error422UnprocessableEntity("Move would create a cycle in the group hierarchy",
+					&huma.ErrorDetail{
+						Location: "body.parent_id",
+						Message:  "New parent is a descendant of this group",
+						Value:    parentID,
+					})
+			}
+		}
+
+		newParentID = pgtype.Int8{Int64: parentID, Valid: true}
+	}
+
+	// Execute move in transaction with audit context
+	// The groups_prevent_hierarchy_cycle trigger re-checks under lock for concurrent moves
+	var group *db.Group
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+		var moveErr error
+		group, moveErr = txQueries.MoveGroup(ctx, db.MoveGroupParams{
+			ID:       input.ID,
+			ParentID: newParentID,
+		})
+		if moveErr != nil {
+			if isHierarchyCycleError(moveErr) {
+				return huma.Error422UnprocessableEntity("Move would create a cycle in the group hierarchy")
+			}
+			return fmt.Errorf("MoveGroup: %w", moveErr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "MoveGroup", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &MoveGroupOutput{}
+	output.Body.Group = GroupDTOFromGroup(group)
 	return output, nil
 }
 
@@ -922,30 +1332,45 @@ func (h *GroupHandler) handleListSubgroups(ctx context.Context, input *ListSubgr
 
 // ArchiveGroupInput is the request for archiving a group.
 type ArchiveGroupInput struct {
-	Cookie string `cookie:"loomio_session"`
-	ID     int64  `path:"id" doc:"Group ID"`
+	ID int64 `path:"id" doc:"Group ID"`
 }
 
 // ArchiveGroupOutput is the response for archiving a group.
 type ArchiveGroupOutput struct {
 	Body struct {
-		Group GroupDTO `json:"group"`
+		Group     GroupDTO   `json:"group"`
+		Subgroups []GroupDTO `json:"subgroups" doc:"Descendants archived along with the group"`
 	}
 }
 
-func (h *GroupHandler) handleArchiveGroup(ctx context.Context, input *ArchiveGroupInput) (*ArchiveGroupOutput, error) {
-	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
+// splitGroupTreeResult separates the root group from the descendants returned
+// by ArchiveGroupTree/UnarchiveGroupTree, which come back in no particular order.
+func splitGroupTreeResult(rootID int64, groups []*db.Group) (GroupDTO, []GroupDTO) {
+	var root GroupDTO
+	subgroups := make([]GroupDTO, 0, len(groups))
+	for _, g := range groups {
+		if g.ID == rootID {
+			root = GroupDTOFromGroup(g)
+			continue
+		}
+		subgroups = append(subgroups, GroupDTOFromGroup(g))
 	}
+	return root, subgroups
+}
+
+// errGroupAlreadyArchived rolls back an archive that found the group
+// already archived.
+var errGroupAlreadyArchived = errors.New("group is already archived")
 
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+func (h *GroupHandler) handleArchiveGroup(ctx context.Context, input *ArchiveGroupInput) (*ArchiveGroupOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be an admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -958,55 +1383,64 @@ func (h *GroupHandler) handleArchiveGroup(ctx context.Context, input *ArchiveGro
 		return nil, huma.Error403Forbidden("Admin role required to archive group")
 	}
 
-	// Execute archive in transaction
-	var group *db.Group
+	// Archiving again would give the group a newer archived_at than its
+	// subgroups, which unarchive would then leave behind
+	if authCtx.Group.ArchivedAt.Valid {
+		return nil, huma.Error409Conflict("Group is already archived")
+	}
+
+	// Execute archive in transaction; descendants are archived with the group
+	var archived []*db.Group
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
 		txQueries := h.queries.WithTx(tx)
 		var archiveErr error
-		group, archiveErr = txQueries.ArchiveGroup(ctx, input.ID)
+		archived, archiveErr = txQueries.ArchiveGroupTree(ctx, input.ID)
+		if archiveErr == nil && !slices.ContainsFunc(archived, func(g *db.Group) bool { return g.ID == input.ID }) {
+			// Archived by a concurrent request since the check above
+			return errGroupAlreadyArchived
+		}
 		return archiveErr
 	})
 
+	if errors.Is(err, errGroupAlreadyArchived) {
+		return nil, huma.Error409Conflict("Group is already archived")
+	}
 	if err != nil {
-		LogDBError(ctx, "ArchiveGroup", err)
+		LogDBError(ctx, "ArchiveGroupTree", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
 	output := &ArchiveGroupOutput{}
-	output.Body.Group = GroupDTOFromGroup(group)
+	output.Body.Group, output.Body.Subgroups = splitGroupTreeResult(input.ID, archived)
 	return output, nil
 }
 
 // UnarchiveGroupInput is the request for unarchiving a group.
 type UnarchiveGroupInput struct {
-	Cookie string `cookie:"loomio_session"`
-	ID     int64  `path:"id" doc:"Group ID"`
+	ID int64 `path:"id" doc:"Group ID"`
 }
 
 // UnarchiveGroupOutput is the response for unarchiving a group.
 type UnarchiveGroupOutput struct {
 	Body struct {
-		Group GroupDTO `json:"group"`
+		Group     GroupDTO   `json:"group"`
+		Subgroups []GroupDTO `json:"subgroups" doc:"Descendants restored along with the group"`
 	}
 }
 
 func (h *GroupHandler) handleUnarchiveGroup(ctx context.Context, input *UnarchiveGroupInput) (*UnarchiveGroupOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be an admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.ID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.ID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -1019,62 +1453,75 @@ func (h *GroupHandler) handleUnarchiveGroup(ctx context.Context, input *Unarchiv
 		return nil, huma.Error403Forbidden("Admin role required to unarchive group")
 	}
 
-	// Execute unarchive in transaction
-	var group *db.Group
+	// Execute unarchive in transaction; descendants archived by the same
+	// cascade are restored with the group
+	var unarchived []*db.Group
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
 		txQueries := h.queries.WithTx(tx)
 		var unarchiveErr error
-		group, unarchiveErr = txQueries.UnarchiveGroup(ctx, input.ID)
+		unarchived, unarchiveErr = txQueries.UnarchiveGroupTree(ctx, input.ID)
 		return unarchiveErr
 	})
 
 	if err != nil {
-		LogDBError(ctx, "UnarchiveGroup", err)
+		LogDBError(ctx, "UnarchiveGroupTree", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
 	output := &UnarchiveGroupOutput{}
-	output.Body.Group = GroupDTOFromGroup(group)
+	output.Body.Group, output.Body.Subgroups = splitGroupTreeResult(input.ID, unarchived)
 	return output, nil
 }
 
 // ListGroupsInput is the request for listing groups.
 type ListGroupsInput struct {
-	Cookie          string `cookie:"loomio_session"`
+	PageParams
 	IncludeArchived bool   `query:"include_archived" default:"false" doc:"Include archived groups"`
+	Sort            string `query:"sort" enum:"name,created_at" default:"name" doc:"Sort field"`
 }
 
 // ListGroupsOutput is the response for listing groups.
+// Link and next_cursor are only set when another page exists.
 type ListGroupsOutput struct {
+	Link string `header:"Link"`
 	Body struct {
-		Groups []GroupDTO `json:"groups"`
+		Groups     []GroupDTO `json:"groups"`
+		NextCursor string     `json:"next_cursor,omitempty"`
 	}
 }
 
 func (h *GroupHandler) handleListGroups(ctx context.Context, input *ListGroupsInput) (*ListGroupsOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
-		return nil, huma.Error401Unauthorized("Not authenticated")
+	page, err := newPageQuery(input.PageParams, input.Sort)
+	if err != nil {
+		return nil, err
 	}
 
-	// List groups the user is a member of
+	// List one page of the groups the user is a member of
 	groups, err := h.queries.ListGroupsByUser(ctx, db.ListGroupsByUserParams{
-		UserID:          session.UserID,
+		UserID:          principal.UserID,
 		IncludeArchived: input.IncludeArchived,
+		SortBy:          page.SortBy,
+		SortDesc:        page.Desc,
+		AfterID:         page.After.ID,
+		AfterName:       page.After.Name,
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		PageLimit:       page.FetchLimit(),
 	})
 	if err != nil {
 		LogDBError(ctx, "ListGroupsByUser", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
+	groups, hasMore := trimPage(groups, page)
 
 	// Build response
 	output := &ListGroupsOutput{}
@@ -1082,35 +1529,46 @@ func (h *GroupHandler) handleListGroups(ctx context.Context, input *ListGroupsIn
 	for i, g := range groups {
 		output.Body.Groups[i] = GroupDTOFromGroup(g)
 	}
+	if hasMore {
+		last := groups[len(groups)-1]
+		output.Body.NextCursor = page.Cursor(last.ID, last.Name, "", last.CreatedAt.Time)
+		output.Link = nextPageLink("/api/v1/groups",
+			url.Values{"include_archived": {strconv.FormatBool(input.IncludeArchived)}},
+			page, output.Body.NextCursor)
+	}
 	return output, nil
 }
 
 // GetGroupByHandleInput is the request for getting a group by handle.
 type GetGroupByHandleInput struct {
-	Cookie string `cookie:"loomio_session"`
 	Handle string `path:"handle" doc:"Group handle (URL-safe)"`
 }
 
 // GetGroupByHandleOutput is the response for getting a group by handle.
+// When the requested handle is a retired one, RedirectedFrom holds it and
+// Content-Location points at the group's current handle.
 type GetGroupByHandleOutput struct {
-	Body struct {
-		Group GroupDetailDTO `json:"group"`
+	ContentLocation string `header:"Content-Location"`
+	Body            struct {
+		Group          GroupDetailDTO `json:"group"`
+		RedirectedFrom *string        `json:"redirected_from,omitempty" doc:"Retired handle that was requested, if it differs from the current handle"`
 	}
 }
 
 func (h *GroupHandler) handleGetGroupByHandle(ctx context.Context, input *GetGroupByHandleInput) (*GetGroupByHandleOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	// Get group by handle
+	// Get group by handle, falling back to retired handles
+	redirected := false
 	group, err := h.queries.GetGroupByHandle(ctx, input.Handle)
+	if err != nil && db.IsNotFound(err) {
+		group, err = h.queries.GetGroupByHistoricalHandle(ctx, input.Handle)
+		redirected = err == nil
+	}
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -1120,7 +1578,7 @@ func (h *GroupHandler) handleGetGroupByHandle(ctx context.Context, input *GetGro
 	}
 
 	// Authorize: user must be a member of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, group.ID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, group.ID)
 	if err != nil {
 		LogDBError(ctx, "NewAuthorizationContext", err)
 		return nil, huma.Error500InternalServerError("Database error")
@@ -1146,6 +1604,11 @@ func (h *GroupHandler) handleGetGroupByHandle(ctx context.Context, input *GetGro
 	// Build response with full details
 	output := &GetGroupByHandleOutput{}
 	output.Body.Group = GroupDetailDTOFromGroup(group, memberCount, adminCount, authCtx.GetRole())
+	if redirected {
+		requested := input.Handle
+		output.Body.RedirectedFrom = &requested
+		output.ContentLocation = "/api/v1/group-by-handle/" + group.Handle
+	}
 
 	// Check if parent is archived (for subgroups)
 	// T122: Log error when parent fetch fails, don't silently suppress
@@ -9,10 +9,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humago"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/zacaytion/llmio/internal/auth"
@@ -49,12 +51,13 @@ func setupGroupsTest(t *testing.T) *testGroupsSetup {
 	sessions := auth.NewSessionStore()
 
 	// Create handlers
-	groupHandler := NewGroupHandler(pool, queries, sessions)
-	membershipHandler := NewMembershipHandler(pool, queries, sessions)
+	groupHandler := NewGroupHandler(pool, queries)
+	membershipHandler := NewMembershipHandler(pool, queries)
 
 	// Create Huma API
 	mux := http.NewServeMux()
 	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	UseAuthentication(api, sessions)
 	groupHandler.RegisterRoutes(api)
 	membershipHandler.RegisterRoutes(api)
 
@@ -576,6 +579,110 @@ func TestUpdateGroup_NonAdmin(t *testing.T) {
 	}
 }
 
+// TestGroup_ConditionalRequests tests ETags on group reads and updates:
+// If-None-Match revalidates a cached group and If-Match rejects an update
+// based on a stale copy.
+func TestGroup_ConditionalRequests(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	groupID := setup.createTestGroupAndGetID(t, adminToken, "ETag Group")
+
+	do := func(method string, body any, headers map[string]string) *httptest.ResponseRecorder {
+		var reqBody *bytes.Buffer
+		if body != nil {
+			bodyBytes, _ := json.Marshal(body)
+			reqBody = bytes.NewBuffer(bodyBytes)
+		} else {
+			reqBody = &bytes.Buffer{}
+		}
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/v1/groups/%d", groupID), reqBody)
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+		w := httptest.NewRecorder()
+		setup.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	get := do(http.MethodGet, nil, nil)
+	if get.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", get.Code, get.Body.String())
+	}
+	etag := get.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag on GET")
+	}
+
+	// Revalidating an unchanged group
+	notModified := do(http.MethodGet, nil, map[string]string{"If-None-Match": etag})
+	if notModified.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 Not Modified, got %d: %s", notModified.Code, notModified.Body.String())
+	}
+	if notModified.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 body, got %s", notModified.Body.String())
+	}
+	if got := notModified.Header().Get("ETag"); got != etag {
+		t.Errorf("expected ETag %s on 304, got %s", etag, got)
+	}
+
+	// Conditional update with the current tag
+	updated := do(http.MethodPatch, map[string]any{"name": "Renamed"}, map[string]string{"If-Match": etag})
+	if updated.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", updated.Code, updated.Body.String())
+	}
+	newETag := updated.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Fatalf("expected a new ETag after update, got %q (was %q)", newETag, etag)
+	}
+
+	// The old tag is stale for reads and writes
+	stale := do(http.MethodGet, nil, map[string]string{"If-None-Match": etag})
+	if stale.Code != http.StatusOK {
+		t.Errorf("expected 200 OK for a stale If-None-Match, got %d", stale.Code)
+	}
+	if got := stale.Header().Get("ETag"); got != newETag {
+		t.Errorf("expected GET to return the update's ETag %s, got %s", newETag, got)
+	}
+
+	conflict := do(http.MethodPatch, map[string]any{"name": "Lost Update"}, map[string]string{"If-Match": etag})
+	if conflict.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 Precondition Failed, got %d: %s", conflict.Code, conflict.Body.String())
+	}
+	group, err := setup.queries.GetGroupByID(context.Background(), groupID)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if group.Name != "Renamed" {
+		t.Errorf("expected the rejected update not to apply, name is %q", group.Name)
+	}
+
+	// Unconditional updates still work
+	if w := do(http.MethodPatch, map[string]any{"name": "Plain"}, nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200 OK without If-Match, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// If-Modified-Since covers the member counts, not just the group row
+	since := time.Now().Add(time.Second).Truncate(time.Second)
+	time.Sleep(time.Until(since))
+	ifModifiedSince := map[string]string{"If-Modified-Since": since.UTC().Format(http.TimeFormat)}
+	if w := do(http.MethodGet, nil, ifModifiedSince); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 Not Modified for an unchanged group, got %d", w.Code)
+	}
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+	setup.inviteMember(t, adminToken, groupID, memberUser.ID)
+	setup.acceptInvitation(t, memberToken, memberUser.ID, groupID)
+	if w := do(http.MethodGet, nil, ifModifiedSince); w.Code != http.StatusOK {
+		t.Errorf("expected 200 OK after the member count changed, got %d", w.Code)
+	}
+}
+
 // TestInviteMember_PermissionFlag tests that members_can_add_members flag is enforced.
 // T069-T070: Test members_can_add_members enforcement
 func TestInviteMember_PermissionFlag(t *testing.T) {
@@ -1992,3 +2099,493 @@ func TestListGroupsByUserWithCounts_IntegrationTest(t *testing.T) {
 
 	t.Log("ListGroupsByUserWithCounts query returned correct counts")
 }
+
+// ============================================================
+// Subgroup hierarchy: tree, move, and inherited admin rights
+// ============================================================
+
+// moveGroup posts a move request and returns the response recorder.
+func (s *testGroupsSetup) moveGroup(t *testing.T, token string, groupID int64, parentID *int64) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := map[string]any{"parent_id": parentID}
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/move", groupID), bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: token})
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestGetGroupTree tests that the tree endpoint nests every level of descendants.
+func TestGetGroupTree(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+	setup.createSubgroup(t, adminToken, childID, "Grandchild Group")
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/groups/%d/tree", rootID), nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w := httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	root := resp["group"].(map[string]any)
+	children := root["children"].([]any)
+	if len(children) != 1 {
+		t.Fatalf("expected 1 child, got %d", len(children))
+	}
+	grandchildren := children[0].(map[string]any)["children"].([]any)
+	if len(grandchildren) != 1 {
+		t.Fatalf("expected 1 grandchild, got %d", len(grandchildren))
+	}
+	if depth := grandchildren[0].(map[string]any)["depth"].(float64); depth != 2 {
+		t.Errorf("expected grandchild depth 2, got %v", depth)
+	}
+
+	// Ancestors of the grandchild's parent are reported nearest-first
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/groups/%d/tree", childID), nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w = httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	ancestors := resp["ancestors"].([]any)
+	if len(ancestors) != 1 || int64(ancestors[0].(map[string]any)["id"].(float64)) != rootID {
+		t.Errorf("expected root as only ancestor, got %v", ancestors)
+	}
+}
+
+// TestMoveGroup tests moving subgroups and rejecting cycles.
+func TestMoveGroup(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	otherID := setup.createTestGroupAndGetID(t, adminToken, "Other Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+	grandchildID := setup.createSubgroup(t, adminToken, childID, "Grandchild Group")
+
+	// Moving root under its own grandchild would create a cycle
+	w := setup.moveGroup(t, adminToken, rootID, &grandchildID)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for cycle, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Moving to self is rejected
+	w = setup.moveGroup(t, adminToken, childID, &childID)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for self-parent, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Valid move under another group
+	w = setup.moveGroup(t, adminToken, childID, &otherID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for valid move, got %d: %s", w.Code, w.Body.String())
+	}
+	moved, err := setup.queries.GetGroupByID(context.Background(), childID)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if moved.ParentID.Int64 != otherID {
+		t.Errorf("expected parent %d, got %d", otherID, moved.ParentID.Int64)
+	}
+
+	// Move to top level
+	w = setup.moveGroup(t, adminToken, childID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for move to top level, got %d: %s", w.Code, w.Body.String())
+	}
+	moved, err = setup.queries.GetGroupByID(context.Background(), childID)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if moved.ParentID.Valid {
+		t.Errorf("expected top-level group, got parent %d", moved.ParentID.Int64)
+	}
+
+	// Database trigger rejects cycles even when the API check is bypassed
+	_, err = setup.queries.MoveGroup(context.Background(), db.MoveGroupParams{
+		ID:       rootID,
+		ParentID: pgtype.Int8{Int64: rootID, Valid: true},
+	})
+	if err == nil {
+		t.Error("expected direct self-parent update to fail")
+	}
+}
+
+// TestInheritedAdmin tests that parent admins administer descendants unless disabled.
+func TestInheritedAdmin(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	subAdmin := setup.createTestUser(t, "subadmin@example.com", "Sub Admin")
+	subAdminToken := setup.createTestSession(t, subAdmin.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+
+	// Sub admin creates a grandchild in which the root admin has no membership
+	setup.inviteMember(t, adminToken, childID, subAdmin.ID)
+	setup.acceptInvitation(t, subAdminToken, subAdmin.ID, childID)
+	if _, err := setup.pool.Exec(ctx, "UPDATE memberships SET role = 'admin' WHERE group_id = $1 AND user_id = $2", childID, subAdmin.ID); err != nil {
+		t.Fatalf("promote sub admin: %v", err)
+	}
+	grandchildID := setup.createSubgroup(t, subAdminToken, childID, "Grandchild Group")
+
+	authCtx, err := NewAuthorizationContext(ctx, setup.queries, adminUser.ID, grandchildID)
+	if err != nil {
+		t.Fatalf("NewAuthorizationContext: %v", err)
+	}
+	if !authCtx.IsAdmin || !authCtx.IsInheritedAdmin {
+		t.Errorf("root admin should inherit admin rights over grandchild, got IsAdmin=%v IsInheritedAdmin=%v", authCtx.IsAdmin, authCtx.IsInheritedAdmin)
+	}
+	if authCtx.GetRole() != "admin" {
+		t.Errorf("expected inherited role admin, got %q", authCtx.GetRole())
+	}
+
+	// Disabling the flag on the child breaks the chain above it
+	updateBody, _ := json.Marshal(map[string]any{"parent_admins_can_manage": false})
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/groups/%d", childID), bytes.NewBuffer(updateBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: subAdminToken})
+	w := httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	authCtx, err = NewAuthorizationContext(ctx, setup.queries, adminUser.ID, grandchildID)
+	if err != nil {
+		t.Fatalf("NewAuthorizationContext: %v", err)
+	}
+	if authCtx.IsAdmin {
+		t.Error("root admin should not administer grandchild once child disables parent_admins_can_manage")
+	}
+
+	// Sub admin still administers the grandchild through the child
+	authCtx, err = NewAuthorizationContext(ctx, setup.queries, subAdmin.ID, grandchildID)
+	if err != nil {
+		t.Fatalf("NewAuthorizationContext: %v", err)
+	}
+	if !authCtx.IsInheritedAdmin {
+		t.Error("child admin should still inherit admin rights over grandchild")
+	}
+}
+
+// TestBuildGroupTree verifies nesting and that orphaned rows are dropped.
+func TestBuildGroupTree(t *testing.T) {
+	root := &db.Group{ID: 1, Name: "Root"}
+	rows := []*db.ListGroupDescendantsRow{
+		{Group: db.Group{ID: 2, Name: "A", ParentID: pgtype.Int8{Int64: 1, Valid: true}}, Depth: 1},
+		{Group: db.Group{ID: 3, Name: "B", ParentID: pgtype.Int8{Int64: 1, Valid: true}}, Depth: 1},
+		{Group: db.Group{ID: 4, Name: "A1", ParentID: pgtype.Int8{Int64: 2, Valid: true}}, Depth: 2},
+		{Group: db.Group{ID: 5, Name: "Orphan", ParentID: pgtype.Int8{Int64: 99, Valid: true}}, Depth: 2},
+	}
+
+	tree := buildGroupTree(root, rows)
+
+	if tree.ID != 1 || tree.Depth != 0 {
+		t.Fatalf("unexpected root: id=%d depth=%d", tree.ID, tree.Depth)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(tree.Children))
+	}
+	if len(tree.Children[0].Children) != 1 || tree.Children[0].Children[0].ID != 4 {
+		t.Errorf("expected A1 nested under A, got %+v", tree.Children[0].Children)
+	}
+	if len(tree.Children[1].Children) != 0 {
+		t.Errorf("expected B to have no children, got %d", len(tree.Children[1].Children))
+	}
+}
+
+// postGroupAction posts to /api/v1/groups/{id}/{action} and fails the test on non-200.
+func (s *testGroupsSetup) postGroupAction(t *testing.T, token string, groupID int64, action string) map[string]any {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/%s", groupID, action), nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: token})
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s failed: %d: %s", action, w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return resp
+}
+
+// TestArchiveGroup_CascadesToSubtree tests that archive/unarchive cover all
+// descendants, and that unarchive leaves separately archived subgroups alone.
+func TestArchiveGroup_CascadesToSubtree(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+	grandchildID := setup.createSubgroup(t, adminToken, childID, "Grandchild Group")
+	siblingID := setup.createSubgroup(t, adminToken, rootID, "Sibling Group")
+
+	// Sibling was archived on its own before the cascade
+	setup.postGroupAction(t, adminToken, siblingID, "archive")
+
+	resp := setup.postGroupAction(t, adminToken, rootID, "archive")
+	if subgroups := resp["subgroups"].([]any); len(subgroups) != 2 {
+		t.Errorf("expected 2 cascaded subgroups (child, grandchild), got %d", len(subgroups))
+	}
+
+	for _, id := range []int64{rootID, childID, grandchildID, siblingID} {
+		g, err := setup.queries.GetGroupByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetGroupByID(%d): %v", id, err)
+		}
+		if !g.ArchivedAt.Valid {
+			t.Errorf("group %d should be archived", id)
+		}
+	}
+
+	setup.postGroupAction(t, adminToken, rootID, "unarchive")
+
+	for _, id := range []int64{rootID, childID, grandchildID} {
+		g, err := setup.queries.GetGroupByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetGroupByID(%d): %v", id, err)
+		}
+		if g.ArchivedAt.Valid {
+			t.Errorf("group %d should be unarchived", id)
+		}
+	}
+
+	sibling, err := setup.queries.GetGroupByID(ctx, siblingID)
+	if err != nil {
+		t.Fatalf("GetGroupByID(sibling): %v", err)
+	}
+	if !sibling.ArchivedAt.Valid {
+		t.Error("separately archived sibling should stay archived")
+	}
+}
+
+// TestUnarchiveGroup_KeepsSeparatelyArchivedBranch tests that restoring a
+// group does not reach below a subgroup archived on its own, even where a
+// descendant shares the restored group's archived_at.
+func TestUnarchiveGroup_KeepsSeparatelyArchivedBranch(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+	grandchildID := setup.createSubgroup(t, adminToken, childID, "Grandchild Group")
+
+	setup.postGroupAction(t, adminToken, rootID, "archive")
+
+	// The child now counts as archived separately from the root
+	if _, err := setup.pool.Exec(ctx,
+		`UPDATE groups SET archived_at = archived_at - INTERVAL '1 day' WHERE id = $1`, childID,
+	); err != nil {
+		t.Fatalf("move child archived_at: %v", err)
+	}
+
+	setup.postGroupAction(t, adminToken, rootID, "unarchive")
+
+	root, err := setup.queries.GetGroupByID(ctx, rootID)
+	if err != nil {
+		t.Fatalf("GetGroupByID(root): %v", err)
+	}
+	if root.ArchivedAt.Valid {
+		t.Error("root should be unarchived")
+	}
+	for _, id := range []int64{childID, grandchildID} {
+		g, err := setup.queries.GetGroupByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetGroupByID(%d): %v", id, err)
+		}
+		if !g.ArchivedAt.Valid {
+			t.Errorf("group %d below a separately archived subgroup should stay archived", id)
+		}
+	}
+}
+
+// TestArchiveGroup_AlreadyArchived tests that a repeated archive is refused
+// without restamping the group, so unarchive still restores the subgroups.
+func TestArchiveGroup_AlreadyArchived(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	rootID := setup.createTestGroupAndGetID(t, adminToken, "Root Group")
+	childID := setup.createSubgroup(t, adminToken, rootID, "Child Group")
+	grandchildID := setup.createSubgroup(t, adminToken, childID, "Grandchild Group")
+
+	setup.postGroupAction(t, adminToken, rootID, "archive")
+	before, err := setup.queries.GetGroupByID(ctx, rootID)
+	if err != nil {
+		t.Fatalf("GetGroupByID(root): %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/archive", rootID), nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w := httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 archiving an archived group, got %d: %s", w.Code, w.Body.String())
+	}
+
+	after, err := setup.queries.GetGroupByID(ctx, rootID)
+	if err != nil {
+		t.Fatalf("GetGroupByID(root): %v", err)
+	}
+	if !after.ArchivedAt.Time.Equal(before.ArchivedAt.Time) {
+		t.Errorf("archived_at changed from %v to %v", before.ArchivedAt.Time, after.ArchivedAt.Time)
+	}
+
+	resp := setup.postGroupAction(t, adminToken, rootID, "unarchive")
+	if subgroups := resp["subgroups"].([]any); len(subgroups) != 2 {
+		t.Errorf("expected 2 restored subgroups, got %d", len(subgroups))
+	}
+	for _, id := range []int64{rootID, childID, grandchildID} {
+		g, err := setup.queries.GetGroupByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetGroupByID(%d): %v", id, err)
+		}
+		if g.ArchivedAt.Valid {
+			t.Errorf("group %d should be unarchived", id)
+		}
+	}
+}
+
+// patchGroup sends a PATCH to /api/v1/groups/{id} and returns the response recorder.
+func (s *testGroupsSetup) patchGroup(t *testing.T, token string, groupID int64, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/groups/%d", groupID), bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: token})
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestUpdateGroup_HandleHistory tests renames, redirects from retired handles,
+// reservation against other groups, and reclaiming by the original group.
+func TestUpdateGroup_HandleHistory(t *testing.T) {
+	setup := setupGroupsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	groupID := setup.createTestGroupAndGetID(t, adminToken, "Climate Team")
+
+	// Rename climate-team -> climate-action
+	w := setup.patchGroup(t, adminToken, groupID, map[string]any{"handle": "climate-action"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("rename failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Old handle redirects to the renamed group
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/group-by-handle/climate-team", nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w = httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("lookup by old handle failed: %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Content-Location"); loc != "/api/v1/group-by-handle/climate-action" {
+		t.Errorf("expected Content-Location to current handle, got %q", loc)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["redirected_from"] != "climate-team" {
+		t.Errorf("expected redirected_from climate-team, got %v", resp["redirected_from"])
+	}
+	if handle := resp["group"].(map[string]any)["handle"]; handle != "climate-action" {
+		t.Errorf("expected current handle climate-action, got %v", handle)
+	}
+
+	// Current handle has no redirect indicator
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/group-by-handle/climate-action", nil)
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w = httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if bytes.Contains(w.Body.Bytes(), []byte("redirected_from")) {
+		t.Errorf("current handle should not be redirected: %s", w.Body.String())
+	}
+
+	// Another group cannot claim the reserved handle, explicitly or by generation
+	body, _ := json.Marshal(map[string]any{"name": "Other", "handle": "climate-team"})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/groups", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	w = httptest.NewRecorder()
+	setup.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for reserved handle, got %d: %s", w.Code, w.Body.String())
+	}
+
+	otherID := setup.createTestGroupAndGetID(t, adminToken, "Climate Team")
+	other, err := setup.queries.GetGroupByID(context.Background(), otherID)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if other.Handle == "climate-team" {
+		t.Error("generated handle should skip the reserved climate-team")
+	}
+
+	w = setup.patchGroup(t, adminToken, otherID, map[string]any{"handle": "climate-team"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 renaming onto reserved handle, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The original group may reclaim its old handle
+	w = setup.patchGroup(t, adminToken, groupID, map[string]any{"handle": "climate-team"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reclaim failed: %d: %s", w.Code, w.Body.String())
+	}
+	exists, err := setup.queries.HandleExists(context.Background(), "climate-action")
+	if err != nil {
+		t.Fatalf("HandleExists: %v", err)
+	}
+	if !exists {
+		t.Error("climate-action should now be reserved after being retired")
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/jobs"
+)
+
+// readinessTimeout bounds the dependency checks of a single readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// Check and job states reported by /readyz.
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+	JobPending = "pending"
+	JobFailing = "failing"
+	JobStopped = "stopped"
+)
+
+// Readiness check names.
+const (
+	checkDatabase = "database"
+	checkSchema   = "schema"
+	checkShutdown = "shutdown"
+)
+
+// HealthHandler serves the liveness and readiness probes.
+// /livez succeeds while the process serves HTTP. /readyz succeeds only while
+// the database answers, its schema has every migration this build knows
+// about, and the server is not shutting down. Background job status is reported by /readyz
+// but does not affect readiness: a failing purge should not take the API out
+// of rotation.
+type HealthHandler struct {
+	pool          *pgxpool.Pool
+	schemaVersion int64
+	shuttingDown  atomic.Bool
+}
+
+// NewHealthHandler creates a health handler expecting the database schema to
+// be at least schemaVersion, normally migrations.LatestVersion.
+func NewHealthHandler(pool *pgxpool.Pool, schemaVersion int64) *HealthHandler {
+	return &HealthHandler{
+		pool:          pool,
+		schemaVersion: schemaVersion,
+	}
+}
+
+// SetShuttingDown makes /readyz fail so the orchestrator stops routing new
+// requests while in-flight ones drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// RegisterRoutes registers the probe routes.
+func (h *HealthHandler) RegisterRoutes(api huma.API) {
+	// Liveness probe
+	huma.Register(api, huma.Operation{
+		OperationID: "livez",
+		Method:      http.MethodGet,
+		Path:        "/livez",
+		Summary:     "Liveness probe",
+		Description: "Succeeds while the process is serving HTTP. Does not check dependencies.",
+		Tags:        []string{"Health"},
+	}, h.handleLivez)
+
+	// Readiness probe
+	huma.Register(api, huma.Operation{
+		OperationID: "readyz",
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		Summary:     "Readiness probe",
+		Description: "Returns 200 when the server can handle requests and 503 otherwise, with the result of each check and the status of background jobs.",
+		Tags:        []string{"Health"},
+	}, h.handleReadyz)
+}
+
+// LivezOutput is the response for the liveness probe.
+type LivezOutput struct {
+	Body struct {
+		Status string `json:"status" example:"ok"`
+	}
+}
+
+func (h *HealthHandler) handleLivez(ctx context.Context, input *struct{}) (*LivezOutput, error) {
+	output := &LivezOutput{}
+	output.Body.Status = HealthOK
+	return output, nil
+}
+
+// HealthCheckDTO is the result of one readiness check.
+type HealthCheckDTO struct {
+	Status string `json:"status" enum:"ok,fail"`
+	Error  string `json:"error,omitempty"`
+}
+
+// JobStatusDTO is the status of a background job.
+type JobStatusDTO struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status" enum:"ok,pending,failing,stopped"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// JobStatusDTOFromStatus converts a job status to its DTO.
+func JobStatusDTOFromStatus(s jobs.Status) JobStatusDTO {
+	dto := JobStatusDTO{Name: s.Name, Status: HealthOK}
+	if !s.LastRun.IsZero() {
+		dto.LastRunAt = &s.LastRun
+	}
+	if s.LastError != nil {
+		dto.LastError = s.LastError.Error()
+	}
+	switch {
+	case !s.Running:
+		dto.Status = JobStopped
+	case s.LastError != nil:
+		dto.Status = JobFailing
+	case s.LastRun.IsZero():
+		dto.Status = JobPending
+	}
+	return dto
+}
+
+// ReadyzOutput is the response for the readiness probe.
+type ReadyzOutput struct {
+	Status int
+	Body   struct {
+		Status string                    `json:"status" enum:"ok,fail"`
+		Checks map[string]HealthCheckDTO `json:"checks"`
+		Jobs   []JobStatusDTO            `json:"jobs"`
+	}
+}
+
+func (h *HealthHandler) handleReadyz(ctx context.Context, input *struct{}) (*ReadyzOutput, error) {
+	output := &ReadyzOutput{Status: http.StatusOK}
+	output.Body.Status = HealthOK
+	output.Body.Checks = make(map[string]HealthCheckDTO)
+
+	record := func(name string, err error) {
+		if err != nil {
+			output.Status = http.StatusServiceUnavailable
+			output.Body.Status = HealthFail
+			output.Body.Checks[name] = HealthCheckDTO{Status: HealthFail, Error: err.Error()}
+			return
+		}
+		output.Body.Checks[name] = HealthCheckDTO{Status: HealthOK}
+	}
+
+	// Skip the database while draining: the answer is already "not ready"
+	if h.shuttingDown.Load() {
+		record(checkShutdown, errors.New("server is shutting down"))
+	} else {
+		record(checkShutdown, nil)
+
+		ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		defer cancel()
+		record(checkDatabase, h.pool.Ping(ctx))
+		record(checkSchema, h.checkSchema(ctx))
+	}
+
+	statuses := jobs.Statuses()
+	output.Body.Jobs = make([]JobStatusDTO, len(statuses))
+	for i, s := range statuses {
+		output.Body.Jobs[i] = JobStatusDTOFromStatus(s)
+	}
+	return output, nil
+}
+
+// checkSchema verifies the database has every migration this build expects.
+// A newer schema passes: migrations run before a rollout, and the pods still
+// on the previous build must stay ready until they are replaced.
+func (h *HealthHandler) checkSchema(ctx context.Context) error {
+	version, err := db.SchemaVersion(ctx, h.pool)
+	if err != nil {
+		return err
+	}
+	if version < h.schemaVersion {
+		return fmt.Errorf("schema version is %d, expected %d", version, h.schemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/jobs"
+	"github.com/zacaytion/llmio/internal/testutil"
+	"github.com/zacaytion/llmio/migrations"
+)
+
+// readyzResponse mirrors the /readyz body.
+type readyzResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]HealthCheckDTO `json:"checks"`
+	Jobs   []JobStatusDTO            `json:"jobs"`
+}
+
+func serveHealth(h *HealthHandler, path string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	h.RegisterRoutes(api)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHealth_Livez(t *testing.T) {
+	w := serveHealth(NewHealthHandler(nil, 1), "/livez")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestHealth_ReadyzShuttingDown verifies draining servers report not-ready
+// without touching the database.
+func TestHealth_ReadyzShuttingDown(t *testing.T) {
+	h := NewHealthHandler(nil, 1)
+	h.SetShuttingDown()
+
+	w := serveHealth(h, "/readyz")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp readyzResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Status != HealthFail || resp.Checks["shutdown"].Status != HealthFail {
+		t.Errorf("expected a failed shutdown check, got %+v", resp)
+	}
+}
+
+func TestJobStatusDTOFromStatus(t *testing.T) {
+	lastRun := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status jobs.Status
+		want   string
+	}{
+		{"not run yet", jobs.Status{Running: true}, JobPending},
+		{"last run succeeded", jobs.Status{Running: true, LastRun: lastRun}, HealthOK},
+		{"last run failed", jobs.Status{Running: true, LastRun: lastRun, LastError: errors.New("boom")}, JobFailing},
+		{"stopped", jobs.Status{LastRun: lastRun}, JobStopped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JobStatusDTOFromStatus(tt.status).Status; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHealth_Readyz verifies readiness against a migrated database.
+func TestHealth_Readyz(t *testing.T) {
+	ctx := context.Background()
+	connStr, cleanup := testutil.SetupTestDB(ctx, t)
+	defer cleanup()
+
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	latest, err := migrations.LatestVersion()
+	if err != nil {
+		t.Fatalf("LatestVersion: %v", err)
+	}
+
+	t.Run("migrated database is ready", func(t *testing.T) {
+		w := serveHealth(NewHealthHandler(pool, latest), "/readyz")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp readyzResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		for _, name := range []string{"database", "schema", "shutdown"} {
+			if resp.Checks[name].Status != HealthOK {
+				t.Errorf("expected %s check ok, got %+v", name, resp.Checks[name])
+			}
+		}
+	})
+
+	t.Run("newer schema stays ready", func(t *testing.T) {
+		w := serveHealth(NewHealthHandler(pool, latest-1), "/readyz")
+		if w.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("pending migrations are not ready", func(t *testing.T) {
+		w := serveHealth(NewHealthHandler(pool, latest+1), "/readyz")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp readyzResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if resp.Checks["schema"].Status != HealthFail || resp.Checks["database"].Status != HealthOK {
+			t.Errorf("expected only the schema check to fail, got %+v", resp.Checks)
+		}
+	})
+}
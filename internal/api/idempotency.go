@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// Idempotency-Key support: an authenticated POST sent with the header claims
+// the key for its user, and its response is stored in idempotency_keys.
+// Retries with the same key and request get the stored response back, marked
+// with Idempotent-Replayed; the same key with a different request gets 422,
+// and a retry arriving while the first request still runs gets 409.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength matches the idempotency_keys_key_length constraint.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyClaimTimeout is how long an unfinished claim blocks retries
+// before it is taken over, e.g. after the server died mid-request. It is
+// longer than any request is allowed to run.
+const idempotencyClaimTimeout = time.Minute
+
+// idempotentResponseHeaders are stored with a response and replayed with it.
+var idempotentResponseHeaders = []string{"Content-Type", "Location", "Link", "ETag"}
+
+// UseIdempotency documents the Idempotency-Key header on authenticated POST
+// operations and installs IdempotencyMiddleware, which stores responses for
+// ttl. Call it after UseAuthentication and before registering operations.
+func UseIdempotency(api huma.API, queries *db.Queries, ttl time.Duration) {
+	minLength, maxLength := 1, maxIdempotencyKeyLength
+	oapi := api.OpenAPI()
+	oapi.OnAddOperation = append(oapi.OnAddOperation, func(_ *huma.OpenAPI, op *huma.Operation) {
+		if !idempotentOperation(op) {
+			return
+		}
+		op.Parameters = append(op.Parameters, &huma.Param{
+			Name:        IdempotencyKeyHeader,
+			In:          "header",
+			Description: "Unique key for this request; retries with the same key and body replay the first response instead of running again.",
+			Schema:      &huma.Schema{Type: huma.TypeString, MinLength: &minLength, MaxLength: &maxLength},
+		})
+	})
+	api.UseMiddleware(IdempotencyMiddleware(api, queries, ttl))
+}
+
+// idempotentOperation reports whether op accepts an Idempotency-Key.
+func idempotentOperation(op *huma.Operation) bool {
+	return op != nil && op.Method == http.MethodPost && len(op.Security) > 0
+}
+
+// IdempotencyMiddleware stores and replays responses of authenticated POSTs
+// sent with an Idempotency-Key. Requests without the header, or without a
+// principal, pass through untouched. Server errors are not stored, so the
+// request runs again on retry.
+func IdempotencyMiddleware(api huma.API, queries *db.Queries, ttl time.Duration) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		key := ctx.Header(IdempotencyKeyHeader)
+		principal, ok := PrincipalFromContext(ctx.Context())
+		if key == "" || !ok || !idempotentOperation(ctx.Operation()) {
+			next(ctx)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			_ = huma.WriteErr(api, ctx, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		// The body is part of the request fingerprint, so read it up front
+		limit := ctx.Operation().MaxBodyBytes
+		body, err := io.ReadAll(io.LimitReader(ctx.BodyReader(), limit+1))
+		if err != nil {
+			_ = huma.WriteErr(api, ctx, http.StatusBadRequest, "Unable to read request body", err)
+			return
+		}
+		if limit > 0 && int64(len(body)) > limit {
+			_ = huma.WriteErr(api, ctx, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		hash := idempotencyRequestHash(ctx, body)
+
+		reqCtx := ctx.Context()
+		now := time.Now()
+		_, err = queries.ClaimIdempotencyKey(reqCtx, db.ClaimIdempotencyKeyParams{
+			UserID:      principal.UserID,
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   pgtype.Timestamptz{Time: now.Add(ttl), Valid: true},
+			StaleBefore: pgtype.Timestamptz{Time: now.Add(-idempotencyClaimTimeout), Valid: true},
+		})
+		if db.IsNotFound(err) {
+			replayIdempotentResponse(api, ctx, queries, principal.UserID, key, hash)
+			return
+		}
+		if err != nil {
+			LogDBError(reqCtx, "ClaimIdempotencyKey", err)
+			_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		rec := &recordingContext{humaContext: ctx, body: body, headers: http.Header{}}
+		next(rec)
+
+		// Settle the claim even if the client has gone away
+		storeCtx := context.WithoutCancel(reqCtx)
+		if rec.status >= http.StatusInternalServerError {
+			if err := queries.ReleaseIdempotencyKey(storeCtx, db.ReleaseIdempotencyKeyParams{
+				UserID: principal.UserID,
+				Key:    key,
+			}); err != nil {
+				LogDBError(reqCtx, "ReleaseIdempotencyKey", err)
+			}
+			return
+		}
+		if err := queries.CompleteIdempotencyKey(storeCtx, rec.completeParams(principal.UserID, key)); err != nil {
+			LogDBError(reqCtx, "CompleteIdempotencyKey", err)
+		}
+	}
+}
+
+// replayIdempotentResponse answers a request whose key is already claimed:
+// with the stored response if the request matches and has finished.
+func replayIdempotentResponse(api huma.API, ctx huma.Context, queries *db.Queries, userID int64, key string, hash []byte) {
+	stored, err := queries.GetIdempotencyKey(ctx.Context(), db.GetIdempotencyKeyParams{UserID: userID, Key: key})
+	if err != nil && !db.IsNotFound(err) {
+		LogDBError(ctx.Context(), "GetIdempotencyKey", err)
+		_ = huma.WriteErr(api, ctx, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if err == nil && !bytes.Equal(stored.RequestHash, hash) {
+		_ = huma.WriteErr(api, ctx, http.StatusUnprocessableEntity,
+			"Idempotency-Key was already used for a different request",
+			&huma.ErrorDetail{Location: "headers." + IdempotencyKeyHeader, Value: key})
+		return
+	}
+	// Not found means the first request failed and released the key just now
+	if err != nil || !stored.Status.Valid {
+		_ = huma.WriteErr(api, ctx, http.StatusConflict,
+			"A request with this Idempotency-Key is still in progress; retry later")
+		return
+	}
+
+	var headers http.Header
+	if len(stored.ResponseHeaders) > 0 {
+		if err := json.Unmarshal(stored.ResponseHeaders, &headers); err != nil {
+			slog.ErrorContext(ctx.Context(), "invalid stored idempotency response headers", "error", err)
+		}
+	}
+	for name, values := range headers {
+		for _, value := range values {
+			ctx.AppendHeader(name, value)
+		}
+	}
+	ctx.SetHeader(IdempotentReplayedHeader, "true")
+	ctx.SetStatus(int(stored.Status.Int32))
+	_, _ = ctx.BodyWriter().Write(stored.ResponseBody)
+}
+
+// idempotencyRequestHash fingerprints a request by method, path and query,
+// Accept header and body. Accept selects the response format, so a retry
+// asking for another format is a different request.
+func idempotencyRequestHash(ctx huma.Context, body []byte) []byte {
+	u := ctx.URL()
+	h := sha256.New()
+	_, _ = io.WriteString(h, ctx.Method()+" "+u.RequestURI()+"\n")
+	_, _ = io.WriteString(h, ctx.Header("Accept")+"\n")
+	_, _ = h.Write(body)
+	return h.Sum(nil)
+}
+
+// humaContext allows embedding huma.Context, whose Context method would
+// otherwise clash with the field name.
+type humaContext huma.Context
+
+// recordingContext passes a request through to the handler while recording
+// the response for storage. The body already read for fingerprinting is
+// served again to the handler.
+type recordingContext struct {
+	humaContext
+	body    []byte
+	status  int
+	headers http.Header
+	buf     bytes.Buffer
+}
+
+func (c *recordingContext) BodyReader() io.Reader {
+	return bytes.NewReader(c.body)
+}
+
+func (c *recordingContext) SetStatus(code int) {
+	c.status = code
+	c.humaContext.SetStatus(code)
+}
+
+func (c *recordingContext) SetHeader(name, value string) {
+	c.headers.Set(name, value)
+	c.humaContext.SetHeader(name, value)
+}
+
+func (c *recordingContext) AppendHeader(name, value string) {
+	c.headers.Add(name, value)
+	c.humaContext.AppendHeader(name, value)
+}
+
+func (c *recordingContext) BodyWriter() io.Writer {
+	return io.MultiWriter(c.humaContext.BodyWriter(), &c.buf)
+}
+
+// completeParams builds the stored form of the recorded response.
+func (c *recordingContext) completeParams(userID int64, key string) db.CompleteIdempotencyKeyParams {
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	headers := http.Header{}
+	for _, name := range idempotentResponseHeaders {
+		if values := c.headers.Values(name); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	headerJSON, _ := json.Marshal(headers)
+	return db.CompleteIdempotencyKeyParams{
+		Status:          pgtype.Int4{Int32: int32(status), Valid: true},
+		ResponseHeaders: headerJSON,
+		ResponseBody:    append([]byte{}, c.buf.Bytes()...),
+		UserID:          userID,
+		Key:             key,
+	}
+}
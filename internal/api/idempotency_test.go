@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/auth"
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/testutil"
+)
+
+// TestUseIdempotency_OpenAPI verifies the header is documented on
+// authenticated POST operations only.
+func TestUseIdempotency_OpenAPI(t *testing.T) {
+	api := humago.New(http.NewServeMux(), huma.DefaultConfig("Test API", "1.0.0"))
+	UseIdempotency(api, nil, time.Hour)
+
+	handler := func(ctx context.Context, input *struct{}) (*struct{}, error) { return nil, nil }
+	huma.Register(api, huma.Operation{OperationID: "securedPost", Method: http.MethodPost, Path: "/secured", Security: SessionSecurity}, handler)
+	huma.Register(api, huma.Operation{OperationID: "publicPost", Method: http.MethodPost, Path: "/public"}, handler)
+	huma.Register(api, huma.Operation{OperationID: "securedGet", Method: http.MethodGet, Path: "/secured", Security: SessionSecurity}, handler)
+
+	hasHeader := func(op *huma.Operation) bool {
+		for _, p := range op.Parameters {
+			if p.Name == IdempotencyKeyHeader && p.In == "header" {
+				return true
+			}
+		}
+		return false
+	}
+	paths := api.OpenAPI().Paths
+	if !hasHeader(paths["/secured"].Post) {
+		t.Error("expected Idempotency-Key on the authenticated POST")
+	}
+	if hasHeader(paths["/public"].Post) {
+		t.Error("expected no Idempotency-Key on the public POST")
+	}
+	if hasHeader(paths["/secured"].Get) {
+		t.Error("expected no Idempotency-Key on GET")
+	}
+}
+
+// TestIdempotencyRequestHash verifies requests differing in path, Accept or
+// body get different fingerprints.
+func TestIdempotencyRequestHash(t *testing.T) {
+	hash := func(target, accept, body string) []byte {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return idempotencyRequestHash(humago.NewContext(&huma.Operation{}, req, httptest.NewRecorder()), []byte(body))
+	}
+
+	base := hash("/groups", "", `{"name":"A"}`)
+	if again := hash("/groups", "", `{"name":"A"}`); !bytes.Equal(again, base) {
+		t.Error("hash is not stable")
+	}
+	variants := map[string][]byte{
+		"path":   hash("/groups/1/subgroups", "", `{"name":"A"}`),
+		"query":  hash("/groups?format=records", "", `{"name":"A"}`),
+		"accept": hash("/groups", RecordsMediaType, `{"name":"A"}`),
+		"body":   hash("/groups", "", `{"name":"B"}`),
+	}
+	for name, h := range variants {
+		if bytes.Equal(h, base) {
+			t.Errorf("changing %s did not change the hash", name)
+		}
+	}
+}
+
+// TestIdempotencyKey_CreateGroup retries group creation with an
+// Idempotency-Key and checks the first response is replayed.
+func TestIdempotencyKey_CreateGroup(t *testing.T) {
+	ctx := context.Background()
+	connStr, cleanup := testutil.SetupTestDB(ctx, t)
+	defer cleanup()
+
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	queries := db.New(pool)
+	sessions := auth.NewSessionStore()
+
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	UseAuthentication(api, sessions)
+	UseIdempotency(api, queries, time.Hour)
+	NewGroupHandler(pool, queries).RegisterRoutes(api)
+	NewMembershipHandler(pool, queries).RegisterRoutes(api)
+
+	newUser := func(email, name string) (*db.User, string) {
+		user, err := queries.CreateUser(ctx, db.CreateUserParams{
+			Email:        email,
+			Name:         name,
+			Username:     auth.GenerateUsername(name),
+			PasswordHash: "hash",
+			Key:          auth.GeneratePublicKey(),
+		})
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		if _, err := pool.Exec(ctx, "UPDATE users SET email_verified = true WHERE id = $1", user.ID); err != nil {
+			t.Fatalf("failed to verify user: %v", err)
+		}
+		session, err := sessions.Create(user.ID, "", "")
+		if err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+		return user, session.Token
+	}
+	_, token := newUser("admin@example.com", "Admin User")
+	invitee, _ := newUser("invitee@example.com", "Invitee User")
+
+	post := func(path string, body any, key string) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	countGroups := func() int {
+		var n int
+		if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM groups").Scan(&n); err != nil {
+			t.Fatalf("count groups: %v", err)
+		}
+		return n
+	}
+
+	first := post("/api/v1/groups", map[string]any{"name": "Retried Group"}, "key-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201 Created, got %d: %s", first.Code, first.Body.String())
+	}
+	if first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("first response should not be marked as replayed")
+	}
+
+	retry := post("/api/v1/groups", map[string]any{"name": "Retried Group"}, "key-1")
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("expected replayed 201 Created, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("expected the retry to be marked as replayed")
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the first body to be replayed\nfirst: %s\nretry: %s", first.Body.String(), retry.Body.String())
+	}
+	if retry.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("expected Content-Type %q, got %q", first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	}
+	if n := countGroups(); n != 1 {
+		t.Errorf("expected 1 group after a retry, got %d", n)
+	}
+
+	// Same key, different body
+	mismatch := post("/api/v1/groups", map[string]any{"name": "Another Group"}, "key-1")
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused key, got %d: %s", mismatch.Code, mismatch.Body.String())
+	}
+
+	// Keys are per request, not per endpoint
+	var created struct {
+		Group GroupDTO `json:"group"`
+	}
+	if err := json.Unmarshal(first.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	invitePath := fmt.Sprintf("/api/v1/groups/%d/memberships", created.Group.ID)
+	if w := post(invitePath, map[string]any{"user_id": invitee.ID}, "key-1"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a key reused on another endpoint, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A retried invitation gets the original 201 rather than a 409
+	invite := post(invitePath, map[string]any{"user_id": invitee.ID}, "invite-1")
+	if invite.Code != http.StatusCreated {
+		t.Fatalf("expected 201 Created, got %d: %s", invite.Code, invite.Body.String())
+	}
+	if w := post(invitePath, map[string]any{"user_id": invitee.ID}, "invite-1"); w.Code != http.StatusCreated || w.Body.String() != invite.Body.String() {
+		t.Errorf("expected the invitation to be replayed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(invitePath, map[string]any{"user_id": invitee.ID}, ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 without a key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Without a key, every request runs
+	if w := post("/api/v1/groups", map[string]any{"name": "Retried Group"}, ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 Created, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := countGroups(); n != 2 {
+		t.Errorf("expected 2 groups, got %d", n)
+	}
+
+	// Expired keys can be used again
+	if _, err := pool.Exec(ctx, "UPDATE idempotency_keys SET expires_at = NOW() - INTERVAL '1 second'"); err != nil {
+		t.Fatalf("expire keys: %v", err)
+	}
+	if w := post("/api/v1/groups", map[string]any{"name": "Another Group"}, "key-1"); w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("expected an expired key to run the request again, got %d", w.Code)
+	}
+}
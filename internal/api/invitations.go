@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/metrics"
+)
+
+// InvitationEventKind is an invitation outcome recorded in invitation_events.
+type InvitationEventKind string
+
+// Invitation event kinds accepted by the invitation_events_kind_valid constraint.
+const (
+	InvitationInvited  InvitationEventKind = "invited"
+	InvitationAccepted InvitationEventKind = "accepted"
+	InvitationDeclined InvitationEventKind = "declined"
+	InvitationRevoked  InvitationEventKind = "revoked"
+	InvitationResent   InvitationEventKind = "resent"
+)
+
+// NotificationInvitationResent is the notification kind sent to an invitee
+// when an invitation is resent.
+const NotificationInvitationResent = "invitation_resent"
+
+// InvitationResendCooldown is the minimum time between sending the same
+// invitation again, so invitees are not spammed.
+const InvitationResendCooldown = time.Hour
+
+// recordInvitationEvent appends an invitation outcome for a membership.
+// Call it inside the transaction that changes the membership.
+func recordInvitationEvent(ctx context.Context, queries *db.Queries, m *db.Membership, actorID int64, kind InvitationEventKind) error {
+	if _, err := queries.RecordInvitationEvent(ctx, db.RecordInvitationEventParams{
+		GroupID:      m.GroupID,
+		UserID:       m.UserID,
+		MembershipID: m.ID,
+		ActorID:      pgtype.Int8{Int64: actorID, Valid: true},
+		Kind:         string(kind),
+	}); err != nil {
+		return fmt.Errorf("RecordInvitationEvent: %w", err)
+	}
+	return nil
+}
+
+// checkNotDeclined rejects re-inviting a user whose latest invitation to the
+// group was declined. Callers skip it when the inviter explicitly forces.
+func checkNotDeclined(ctx context.Context, queries *db.Queries, groupID, userID int64) error {
+	latest, err := queries.GetLatestInvitationEvent(ctx, db.GetLatestInvitationEventParams{
+		GroupID: groupID,
+		UserID:  userID,
+	})
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil
+		}
+		LogDBError(ctx, "GetLatestInvitationEvent", err)
+		return huma.Error500InternalServerError("Database error")
+	}
+	if InvitationEventKind(latest.Kind) == InvitationDeclined {
+		return huma.Error409Conflict("User declined a previous invitation to this group; set force to invite again")
+	}
+	return nil
+}
+
+// InvitationHandler handles invitation outcomes other than accepting:
+// declining, revoking, resending, and reporting on them.
+type InvitationHandler struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewInvitationHandler creates a new invitation handler.
+func NewInvitationHandler(pool *pgxpool.Pool, queries *db.Queries) *InvitationHandler {
+	return &InvitationHandler{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// RegisterRoutes registers all invitation routes.
+func (h *InvitationHandler) RegisterRoutes(api huma.API) {
+	// Decline an invitation (invitee)
+	huma.Register(api, huma.Operation{
+		OperationID:   "declineInvitation",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/memberships/{id}/decline",
+		Summary:       "Decline invitation",
+		Description:   "Declines a pending invitation. Only the invited user can decline. The group will not re-invite the user unless the inviter forces it.",
+		Tags:          []string{"Memberships"},
+		DefaultStatus: http.StatusNoContent,
+		Security:      SessionSecurity,
+	}, h.handleDeclineInvitation)
+
+	// Revoke an invitation (inviter or admin)
+	huma.Register(api, huma.Operation{
+		OperationID:   "revokeInvitation",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/memberships/{id}/revoke",
+		Summary:       "Revoke invitation",
+		Description:   "Withdraws a pending invitation. Requires being the inviter or a group admin.",
+		Tags:          []string{"Memberships"},
+		DefaultStatus: http.StatusNoContent,
+		Security:      SessionSecurity,
+	}, h.handleRevokeInvitation)
+
+	// Resend an invitation (inviter or admin)
+	huma.Register(api, huma.Operation{
+		OperationID: "resendInvitation",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/memberships/{id}/resend",
+		Summary:     "Resend invitation",
+		Description: "Notifies the invitee of a pending invitation again. Limited to once per hour per invitation. Requires being the inviter or a group admin.",
+		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
+	}, h.handleResendInvitation)
+
+	// Invitation outcome report
+	huma.Register(api, huma.Operation{
+		OperationID: "getInvitationStats",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/groups/{groupId}/invitations/stats",
+		Summary:     "Get invitation stats",
+		Description: "Returns counts of invitation outcomes and the acceptance and decline rates for a group. Requires admin permission.",
+		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
+	}, h.handleGetInvitationStats)
+}
+
+// getPendingInvitation loads a membership that must still be a pending invitation.
+func (h *InvitationHandler) getPendingInvitation(ctx context.Context, membershipID int64) (*db.Membership, error) {
+	membership, err := h.queries.GetMembershipByID(ctx, membershipID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Invitation not found")
+		}
+		LogDBError(ctx, "GetMembershipByID", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	if membership.AcceptedAt.Valid {
+		return nil, huma.Error409Conflict("Invitation has already been accepted")
+	}
+	return membership, nil
+}
+
+// authorizeInviterOrAdmin allows the user who sent an invitation, or an admin
+// of its group, to act on it. Archived groups are read-only.
+func (h *InvitationHandler) authorizeInviterOrAdmin(ctx context.Context, userID int64, membership *db.Membership, action string) error {
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, userID, membership.GroupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return huma.Error500InternalServerError("Database error")
+	}
+
+	isInviter := membership.InviterID == userID && authCtx.CanInviteMembers()
+	if !isInviter && !authCtx.CanManageMembers() {
+		return huma.Error403Forbidden("Only the inviter or an admin can " + action + " this invitation")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return huma.Error409Conflict("Cannot " + action + " invitations in an archived group")
+	}
+	return nil
+}
+
+// deletePendingInvitation removes a pending invitation and records why.
+func (h *InvitationHandler) deletePendingInvitation(ctx context.Context, actorID int64, membership *db.Membership, kind InvitationEventKind) error {
+	err := pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, actorID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+
+		rows, err := txQueries.DeletePendingMembership(ctx, membership.ID)
+		if err != nil {
+			return fmt.Errorf("DeletePendingMembership: %w", err)
+		}
+		if rows == 0 {
+			// Accepted (or removed) between the lookup and now
+			return huma.Error409Conflict("Invitation is no longer pending")
+		}
+
+		return recordInvitationEvent(ctx, txQueries, membership, actorID, kind)
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return humaErr
+		}
+		LogDBError(ctx, "DeletePendingInvitation", err)
+		return huma.Error500InternalServerError("Database error")
+	}
+	return nil
+}
+
+// DeclineInvitationInput is the request for declining an invitation.
+type DeclineInvitationInput struct {
+	MembershipID int64 `path:"id" doc:"Membership ID"`
+}
+
+// DeclineInvitationOutput is the response for declining an invitation.
+type DeclineInvitationOutput struct{}
+
+func (h *InvitationHandler) handleDeclineInvitation(ctx context.Context, input *DeclineInvitationInput) (*DeclineInvitationOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	membership, err := h.getPendingInvitation(ctx, input.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the current user is the invited user
+	if membership.UserID != principal.UserID {
+		return nil, huma.Error403Forbidden("You can only decline your own invitations")
+	}
+
+	if err := h.deletePendingInvitation(ctx, principal.UserID, membership, InvitationDeclined); err != nil {
+		return nil, err
+	}
+	return &DeclineInvitationOutput{}, nil
+}
+
+// RevokeInvitationInput is the request for revoking an invitation.
+type RevokeInvitationInput struct {
+	MembershipID int64 `path:"id" doc:"Membership ID"`
+}
+
+// RevokeInvitationOutput is the response for revoking an invitation.
+type RevokeInvitationOutput struct{}
+
+func (h *InvitationHandler) handleRevokeInvitation(ctx context.Context, input *RevokeInvitationInput) (*RevokeInvitationOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	membership, err := h.getPendingInvitation(ctx, input.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.authorizeInviterOrAdmin(ctx, principal.UserID, membership, "revoke"); err != nil {
+		return nil, err
+	}
+
+	if err := h.deletePendingInvitation(ctx, principal.UserID, membership, InvitationRevoked); err != nil {
+		return nil, err
+	}
+	return &RevokeInvitationOutput{}, nil
+}
+
+// ResendInvitationInput is the request for resending an invitation.
+type ResendInvitationInput struct {
+	MembershipID int64 `path:"id" doc:"Membership ID"`
+}
+
+// ResendInvitationOutput is the response for resending an invitation.
+type ResendInvitationOutput struct {
+	Body struct {
+		Membership MembershipDTO `json:"membership"`
+	}
+}
+
+func (h *InvitationHandler) handleResendInvitation(ctx context.Context, input *ResendInvitationInput) (*ResendInvitationOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	membership, err := h.getPendingInvitation(ctx, input.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.authorizeInviterOrAdmin(ctx, principal.UserID, membership, "resend"); err != nil {
+		return nil, err
+	}
+
+	// Throttle: the latest event is the original invite or the last resend
+	latest, err := h.queries.GetLatestInvitationEvent(ctx, db.GetLatestInvitationEventParams{
+		GroupID: membership.GroupID,
+		UserID:  membership.UserID,
+	})
+	if err != nil && !db.IsNotFound(err) {
+		LogDBError(ctx, "GetLatestInvitationEvent", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	if err == nil && latest.MembershipID == membership.ID &&
+		time.Since(latest.CreatedAt.Time) < InvitationResendCooldown {
+		return nil, huma.Error429TooManyRequests("Invitation was sent recently; try again later")
+	}
+
+	payload, err := json.Marshal(map[string]any{"role": membership.Role, "inviter_id": membership.InviterID})
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to encode notification")
+	}
+
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+
+		if _, err := txQueries.CreateNotification(ctx, db.CreateNotificationParams{
+			UserID:       membership.UserID,
+			Kind:         NotificationInvitationResent,
+			GroupID:      pgtype.Int8{Int64: membership.GroupID, Valid: true},
+			MembershipID: pgtype.Int8{Int64: membership.ID, Valid: true},
+			Data:         payload,
+		}); err != nil {
+			return fmt.Errorf("CreateNotification: %w", err)
+		}
+
+		return recordInvitationEvent(ctx, txQueries, membership, principal.UserID, InvitationResent)
+	})
+
+	if err != nil {
+		LogDBError(ctx, "ResendInvitation", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	metrics.RecordInvitationSent(metrics.InvitationResend)
+
+	output := &ResendInvitationOutput{}
+	output.Body.Membership = MembershipDTOFromMembership(membership)
+	return output, nil
+}
+
+// GetInvitationStatsInput is the request for a group's invitation report.
+type GetInvitationStatsInput struct {
+	GroupID int64     `path:"groupId" doc:"Group ID"`
+	Since   time.Time `query:"since" doc:"Only count events at or after this time (RFC 3339)"`
+}
+
+// GetInvitationStatsOutput is the response for a group's invitation report.
+type GetInvitationStatsOutput struct {
+	Body struct {
+		Stats InvitationStatsDTO `json:"stats"`
+	}
+}
+
+func (h *InvitationHandler) handleGetInvitationStats(ctx context.Context, input *GetInvitationStatsInput) (*GetInvitationStatsOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: reporting is for admins
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.GroupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanManageMembers() {
+		return nil, huma.Error403Forbidden("Only admins can view invitation stats")
+	}
+
+	since := pgtype.Timestamptz{Time: input.Since, Valid: !input.Since.IsZero()}
+	stats, err := h.queries.GetInvitationStats(ctx, db.GetInvitationStatsParams{
+		GroupID: input.GroupID,
+		Since:   since,
+	})
+	if err != nil {
+		LogDBError(ctx, "GetInvitationStats", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &GetInvitationStatsOutput{}
+	output.Body.Stats = InvitationStatsDTOFromRow(stats)
+	if since.Valid {
+		output.Body.Stats.Since = &input.Since
+	}
+	return output, nil
+}
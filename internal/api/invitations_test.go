@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// invite sends an invitation via API and returns the pending membership ID.
+func (s *testMembershipsSetup) invite(t *testing.T, token string, groupID, userID int64) int64 {
+	t.Helper()
+
+	w := s.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID), token,
+		map[string]any{"user_id": userID, "role": "member"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("invite failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return int64(resp["membership"].(map[string]any)["id"].(float64))
+}
+
+// TestDeclineInvitation tests declining and the re-invite guard.
+func TestDeclineInvitation(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	inviteeUser := setup.createTestUser(t, "invitee@example.com", "Invitee User")
+	inviteeToken := setup.createTestSession(t, inviteeUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.invite(t, adminToken, groupID, inviteeUser.ID)
+	declinePath := fmt.Sprintf("/api/v1/memberships/%d/decline", membershipID)
+
+	// Only the invitee can decline
+	w := setup.sendJSON(t, http.MethodPost, declinePath, adminToken, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for admin declining, got %d", w.Code)
+	}
+
+	w = setup.sendJSON(t, http.MethodPost, declinePath, inviteeToken, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("decline failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := setup.queries.GetMembershipByID(ctx, membershipID); !db.IsNotFound(err) {
+		t.Errorf("expected declined membership to be removed, got err=%v", err)
+	}
+
+	latest, err := setup.queries.GetLatestInvitationEvent(ctx, db.GetLatestInvitationEventParams{
+		GroupID: groupID,
+		UserID:  inviteeUser.ID,
+	})
+	if err != nil {
+		t.Fatalf("GetLatestInvitationEvent: %v", err)
+	}
+	if latest.Kind != string(InvitationDeclined) || latest.MembershipID != membershipID {
+		t.Errorf("expected declined event for membership %d, got %+v", membershipID, latest)
+	}
+
+	// Re-inviting is refused unless forced
+	invitePath := fmt.Sprintf("/api/v1/groups/%d/memberships", groupID)
+	w = setup.sendJSON(t, http.MethodPost, invitePath, adminToken, map[string]any{"user_id": inviteeUser.ID})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 re-inviting a user who declined, got %d", w.Code)
+	}
+
+	// The guard also covers guest invitations
+	if w = setup.inviteGuest(t, adminToken, groupID, inviteeUser.ID, 42); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 inviting a user who declined as a guest, got %d", w.Code)
+	}
+
+	w = setup.sendJSON(t, http.MethodPost, invitePath, adminToken, map[string]any{"user_id": inviteeUser.ID, "force": true})
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected forced re-invite to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestDeclineInvitation_AlreadyAccepted tests that accepted memberships cannot be declined.
+func TestDeclineInvitation_AlreadyAccepted(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+
+	w := setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/decline", membershipID), memberToken, nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRevokeInvitation tests that the inviter or an admin can revoke, and others cannot.
+func TestRevokeInvitation(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+	inviteeUser := setup.createTestUser(t, "invitee@example.com", "Invitee User")
+	inviteeToken := setup.createTestSession(t, inviteeUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+	membershipID := setup.invite(t, adminToken, groupID, inviteeUser.ID)
+	revokePath := fmt.Sprintf("/api/v1/memberships/%d/revoke", membershipID)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{"invitee cannot revoke", inviteeToken, http.StatusForbidden},
+		{"other member cannot revoke", memberToken, http.StatusForbidden},
+		{"admin revokes", adminToken, http.StatusNoContent},
+		{"already revoked", adminToken, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setup.sendJSON(t, http.MethodPost, revokePath, tt.token, nil)
+			if w.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// A revoked user is not treated as having declined
+	w := setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID), adminToken,
+		map[string]any{"user_id": inviteeUser.ID})
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected re-invite after revoke to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestResendInvitation tests resend notifications and the cooldown.
+func TestResendInvitation(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	inviteeUser := setup.createTestUser(t, "invitee@example.com", "Invitee User")
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.invite(t, adminToken, groupID, inviteeUser.ID)
+	resendPath := fmt.Sprintf("/api/v1/memberships/%d/resend", membershipID)
+
+	// Just invited: within the cooldown
+	w := setup.sendJSON(t, http.MethodPost, resendPath, adminToken, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 within cooldown, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Move the original invitation outside the cooldown
+	if _, err := setup.pool.Exec(ctx,
+		"UPDATE invitation_events SET created_at = NOW() - INTERVAL '2 hours' WHERE membership_id = $1",
+		membershipID); err != nil {
+		t.Fatalf("failed to age invitation event: %v", err)
+	}
+
+	w = setup.sendJSON(t, http.MethodPost, resendPath, adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("resend failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	notifications, err := setup.queries.ListNotificationsByUser(ctx, db.ListNotificationsByUserParams{
+		UserID:     inviteeUser.ID,
+		MaxResults: 10,
+	})
+	if err != nil {
+		t.Fatalf("ListNotificationsByUser: %v", err)
+	}
+	if len(notifications) != 1 || notifications[0].Kind != NotificationInvitationResent {
+		t.Errorf("expected one invitation_resent notification, got %+v", notifications)
+	}
+}
+
+// TestGetInvitationStats tests outcome counts and rates.
+func TestGetInvitationStats(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	acceptUser := setup.createTestUser(t, "accept@example.com", "Accept User")
+	acceptToken := setup.createTestSession(t, acceptUser.ID)
+	declineUser := setup.createTestUser(t, "decline@example.com", "Decline User")
+	declineToken := setup.createTestSession(t, declineUser.ID)
+	pendingUser := setup.createTestUser(t, "pending@example.com", "Pending User")
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	setup.inviteAndAccept(t, adminToken, acceptToken, groupID, acceptUser.ID)
+	declinedID := setup.invite(t, adminToken, groupID, declineUser.ID)
+	setup.invite(t, adminToken, groupID, pendingUser.ID)
+
+	w := setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/decline", declinedID), declineToken, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("decline failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	statsPath := fmt.Sprintf("/api/v1/groups/%d/invitations/stats", groupID)
+
+	w = setup.sendJSON(t, http.MethodGet, statsPath, acceptToken, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for member, got %d", w.Code)
+	}
+
+	w = setup.sendJSON(t, http.MethodGet, statsPath, adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("stats failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Stats InvitationStatsDTO `json:"stats"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	// The creator's own admin membership is not an invitation
+	want := InvitationStatsDTO{Invited: 3, Accepted: 1, Declined: 1, Pending: 1, AcceptanceRate: 0.5, DeclineRate: 0.5}
+	if resp.Stats != want {
+		t.Errorf("got %+v, want %+v", resp.Stats, want)
+	}
+}
+
+// TestInvitationStatsDTOFromRow tests rate calculation without resolved invitations.
+func TestInvitationStatsDTOFromRow(t *testing.T) {
+	dto := InvitationStatsDTOFromRow(&db.GetInvitationStatsRow{Invited: 2, Pending: 2})
+	if dto.AcceptanceRate != 0 || dto.DeclineRate != 0 {
+		t.Errorf("expected zero rates with nothing resolved, got %+v", dto)
+	}
+
+	dto = InvitationStatsDTOFromRow(&db.GetInvitationStatsRow{Invited: 4, Accepted: 3, Declined: 1})
+	if dto.AcceptanceRate != 0.75 || dto.DeclineRate != 0.25 {
+		t.Errorf("unexpected rates: %+v", dto)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/metrics"
 )
 
 // AuthFailureReason describes why an authentication attempt failed.
@@ -16,9 +19,11 @@ const (
 	ReasonAccountDeactivated AuthFailureReason = "account_deactivated"
 )
 
-// LogAuthFailure logs an authentication failure for security auditing.
+// LogAuthFailure logs an authentication failure for security auditing and
+// counts it in the login metrics.
 // The response to the client should remain generic to prevent enumeration.
 func LogAuthFailure(ctx context.Context, email string, reason AuthFailureReason) {
+	metrics.RecordLogin(string(reason))
 	slog.WarnContext(ctx, "auth failure",
 		"event", "AUTH_FAILURE",
 		"email", email,
@@ -29,6 +34,7 @@ func LogAuthFailure(ctx context.Context, email string, reason AuthFailureReason)
 // LogAuthFailureWithRequest logs an authentication failure with request details.
 // Use when http.Request is available.
 func LogAuthFailureWithRequest(ctx context.Context, email string, reason AuthFailureReason, r *http.Request) {
+	metrics.RecordLogin(string(reason))
 	slog.WarnContext(ctx, "auth failure",
 		"event", "AUTH_FAILURE",
 		"email", email,
@@ -110,29 +116,14 @@ func LogLastAdminProtection(ctx context.Context, operation string, source string
 }
 
 // getClientIP extracts the client IP from the request.
-// Checks X-Forwarded-For first (for proxied requests), then falls back to RemoteAddr.
+// Uses the IP resolved by RequestIDMiddleware, which only trusts forwarding
+// headers from configured proxies, then falls back to RemoteAddr.
 func getClientIP(r *http.Request) string {
 	if r == nil {
 		return "unknown"
 	}
-
-	// Check X-Forwarded-For header (set by proxies/load balancers)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// X-Forwarded-For can contain multiple IPs; take the first (original client)
-		// Format: "client, proxy1, proxy2"
-		for i := 0; i < len(xff); i++ {
-			if xff[i] == ',' {
-				return xff[:i]
-			}
-		}
-		return xff
+	if ip := db.RequestMetadataFromContext(r.Context()).ClientIP; ip != "" {
+		return ip
 	}
-
-	// Check X-Real-IP header (nginx convention)
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	return r.RemoteAddr
+	return trustedProxies(nil).clientIP(r)
 }
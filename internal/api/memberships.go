@@ -2,45 +2,40 @@ package api
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/zacaytion/llmio/internal/auth"
 	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/metrics"
 )
 
 // isLastAdminTriggerError checks if the error is from the last-admin protection trigger.
 // The trigger raises PostgreSQL error P0001 with message "Cannot remove or demote the last administrator".
-// T157: Uses pgconn.PgError type assertion for reliable error detection.
+// T157: Uses pgconn.PgError type assertion for reliable error detection
+// (shared with background jobs via db.IsLastAdminViolation).
 func isLastAdminTriggerError(err error) bool {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		// P0001 is RAISE EXCEPTION, and we check the message matches our trigger
-		return pgErr.Code == "P0001" && strings.Contains(pgErr.Message, "last administrator")
-	}
-	return false
+	return db.IsLastAdminViolation(err)
 }
 
 // MembershipHandler handles membership-related HTTP requests.
 type MembershipHandler struct {
-	pool     *pgxpool.Pool
-	queries  *db.Queries
-	sessions *auth.SessionStore
+	pool    *pgxpool.Pool
+	queries *db.Queries
 }
 
 // NewMembershipHandler creates a new membership handler.
-func NewMembershipHandler(pool *pgxpool.Pool, queries *db.Queries, sessions *auth.SessionStore) *MembershipHandler {
+func NewMembershipHandler(pool *pgxpool.Pool, queries *db.Queries) *MembershipHandler {
 	return &MembershipHandler{
-		pool:     pool,
-		queries:  queries,
-		sessions: sessions,
+		pool:    pool,
+		queries: queries,
 	}
 }
 
@@ -54,6 +49,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "List group members",
 		Description: "Returns all memberships in a group. Requires membership in the group.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handleListMemberships)
 
 	// Invite a user to a group
@@ -65,8 +61,21 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Description:   "Invites a user to join a group. Requires admin role or members_can_add_members permission.",
 		Tags:          []string{"Memberships"},
 		DefaultStatus: http.StatusCreated,
+		Security:      SessionSecurity,
 	}, h.handleInviteMember)
 
+	// Invite a guest to a discussion or poll
+	huma.Register(api, huma.Operation{
+		OperationID:   "inviteGuest",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/groups/{groupId}/guests",
+		Summary:       "Invite guest to discussion or poll",
+		Description:   "Invites a user as a guest of a single discussion or poll without making them a group member. Requires admin role or members_can_add_guests permission.",
+		Tags:          []string{"Memberships"},
+		DefaultStatus: http.StatusCreated,
+		Security:      SessionSecurity,
+	}, h.handleInviteGuest)
+
 	// Get single membership by ID
 	huma.Register(api, huma.Operation{
 		OperationID: "getMembership",
@@ -75,6 +84,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Get membership details",
 		Description: "Returns a single membership by ID. Requires membership in the associated group.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handleGetMembership)
 
 	// Accept an invitation
@@ -85,6 +95,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Accept invitation",
 		Description: "Accepts a pending invitation. Must be the invited user.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handleAcceptInvitation)
 
 	// List current user's pending invitations
@@ -95,6 +106,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "List my pending invitations",
 		Description: "Returns all pending group invitations for the current user.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handleListMyInvitations)
 
 	// Promote member to admin
@@ -105,6 +117,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Promote member to admin",
 		Description: "Promotes a member to admin role. Requires admin permission.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handlePromoteMember)
 
 	// Demote admin to member
@@ -115,6 +128,7 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Demote admin to member",
 		Description: "Demotes an admin to member role. Cannot demote the last admin. Requires admin permission.",
 		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
 	}, h.handleDemoteMember)
 
 	// Remove member from group
@@ -126,36 +140,66 @@ func (h *MembershipHandler) RegisterRoutes(api huma.API) {
 		Description:   "Removes a membership from a group. Cannot remove the last admin. Requires admin permission.",
 		Tags:          []string{"Memberships"},
 		DefaultStatus: http.StatusNoContent,
+		Security:      SessionSecurity,
 	}, h.handleRemoveMember)
+
+	// Extend or renew a time-bound membership
+	huma.Register(api, huma.Operation{
+		OperationID: "extendMembership",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/memberships/{id}/extend",
+		Summary:     "Extend membership",
+		Description: "Sets a new expiry for a membership, or makes it permanent when expires_at is null. Also renews a membership whose expiry was blocked by last-admin protection. Requires admin permission.",
+		Tags:        []string{"Memberships"},
+		Security:    SessionSecurity,
+	}, h.handleExtendMembership)
+}
+
+// validateExpiresAt checks an optional membership expiry lies in the future
+// and converts it for the database (NULL = permanent).
+func validateExpiresAt(expiresAt *time.Time) (pgtype.Timestamptz, error) {
+	if expiresAt == nil {
+		return pgtype.Timestamptz{}, nil
+	}
+	if !expiresAt.After(time.Now()) {
+		return pgtype.Timestamptz{}, huma.Error422UnprocessableEntity("Expiry must be in the future",
+			&huma.ErrorDetail{
+				Location: "body.expires_at",
+				Message:  "Expiry must be in the future",
+				Value:    *expiresAt,
+			})
+	}
+	return pgtype.Timestamptz{Time: *expiresAt, Valid: true}, nil
 }
 
 // ListMembershipsInput is the request for listing memberships.
 type ListMembershipsInput struct {
-	Cookie  string `cookie:"loomio_session"`
+	PageParams
 	GroupID int64  `path:"groupId" doc:"Group ID"`
 	Status  string `query:"status" enum:"all,active,pending" default:"all" doc:"Filter by membership status"`
+	Query   string `query:"q" maxLength:"100" doc:"Search members by name or username"`
+	Sort    string `query:"sort" enum:"name,created_at,role" default:"role" doc:"Sort field (name is the member's name; role lists admins, then members, then guests, each by name)"`
 }
 
 // ListMembershipsOutput is the response for listing memberships.
+// Link and next_cursor are only set when another page exists.
 type ListMembershipsOutput struct {
+	Link string `header:"Link"`
 	Body struct {
 		Memberships []MembershipDTO `json:"memberships"`
+		NextCursor  string          `json:"next_cursor,omitempty"`
 	}
 }
 
 func (h *MembershipHandler) handleListMemberships(ctx context.Context, input *ListMembershipsInput) (*ListMembershipsOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be a member of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -168,15 +212,29 @@ func (h *MembershipHandler) handleListMemberships(ctx context.Context, input *Li
 		return nil, huma.Error403Forbidden("Not a member of this group")
 	}
 
-	// List memberships with user info
+	page, err := newPageQuery(input.PageParams, input.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	// List one page of memberships with user info
 	rows, err := h.queries.ListMembershipsWithUsers(ctx, db.ListMembershipsWithUsersParams{
-		GroupID: input.GroupID,
-		Status:  input.Status,
+		GroupID:        input.GroupID,
+		Status:         input.Status,
+		Search:         likePattern(input.Query),
+		SortBy:         page.SortBy,
+		SortDesc:       page.Desc,
+		AfterID:        page.After.ID,
+		AfterName:      page.After.Name,
+		AfterRole:      page.After.Role,
+		AfterCreatedAt: page.AfterCreatedAt(),
+		PageLimit:      page.FetchLimit(),
 	})
 	if err != nil {
 		LogDBError(ctx, "ListMembershipsWithUsers", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
+	rows, hasMore := trimPage(rows, page)
 
 	// Convert to DTOs
 	memberships := make([]MembershipDTO, len(rows))
@@ -186,16 +244,27 @@ func (h *MembershipHandler) handleListMemberships(ctx context.Context, input *Li
 
 	output := &ListMembershipsOutput{}
 	output.Body.Memberships = memberships
+	if hasMore {
+		last := rows[len(rows)-1]
+		output.Body.NextCursor = page.Cursor(last.ID, last.UserName, last.Role, last.CreatedAt.Time)
+		query := url.Values{"status": {input.Status}}
+		if input.Query != "" {
+			query.Set("q", input.Query)
+		}
+		output.Link = nextPageLink(fmt.Sprintf("/api/v1/groups/%d/memberships", input.GroupID),
+			query, page, output.Body.NextCursor)
+	}
 	return output, nil
 }
 
 // InviteMemberInput is the request for inviting a user to a group.
 type InviteMemberInput struct {
-	Cookie  string `cookie:"loomio_session"`
-	GroupID int64  `path:"groupId" doc:"Group ID"`
+	GroupID int64 `path:"groupId" doc:"Group ID"`
 	Body    struct {
-		UserID int64  `json:"user_id" required:"true" doc:"ID of the user to invite"`
-		Role   string `json:"role" enum:"admin,member" default:"member" doc:"Role to assign when invitation is accepted"`
+		UserID    int64      `json:"user_id" required:"true" doc:"ID of the user to invite"`
+		Role      string     `json:"role" enum:"admin,member" default:"member" doc:"Role to assign when invitation is accepted"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty" doc:"Optional end of the membership; it is removed automatically afterwards"`
+		Force     bool       `json:"force,omitempty" doc:"Invite even if the user declined a previous invitation"`
 	}
 }
 
@@ -208,17 +277,13 @@ type InviteMemberOutput struct {
 
 func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *InviteMemberInput) (*InviteMemberOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
 	// Authorize: user must be able to invite members
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, input.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -243,6 +308,11 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 		return nil, huma.Error403Forbidden("Only admins can invite with admin role")
 	}
 
+	expiresAt, err := validateExpiresAt(input.Body.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify the user to invite exists
 	invitee, err := h.queries.GetUserByID(ctx, input.Body.UserID)
 	if err != nil {
@@ -253,6 +323,13 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
+	// Respect a previous decline unless the inviter explicitly overrides it
+	if !input.Body.Force {
+		if err := checkNotDeclined(ctx, h.queries, input.GroupID, invitee.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Default role to "member" if not specified
 	// T207: Use ParseRole for safe default handling
 	role := input.Body.Role
@@ -264,7 +341,7 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 	var membership *db.Membership
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		// Set audit context for triggers
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
@@ -276,7 +353,8 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 			GroupID:   input.GroupID,
 			UserID:    input.Body.UserID,
 			Role:      role,
-			InviterID: session.UserID,
+			InviterID: principal.UserID,
+			ExpiresAt: expiresAt,
 			// AcceptedAt is nil for pending invitations
 		})
 		if createErr != nil {
@@ -286,7 +364,7 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 			}
 			return fmt.Errorf("CreateMembership: %w", createErr)
 		}
-		return nil
+		return recordInvitationEvent(ctx, txQueries, membership, principal.UserID, InvitationInvited)
 	})
 
 	if err != nil {
@@ -298,6 +376,8 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
+	metrics.RecordInvitationSent(metrics.InvitationMember)
+
 	// Build response with inviter info
 	output := &InviteMemberOutput{}
 	output.Body.Membership = MembershipDTO{
@@ -314,11 +394,14 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 		// T181: Don't initialize Inviter with partial data - set it only if fetch succeeds
 		Inviter: nil,
 	}
+	if membership.ExpiresAt.Valid {
+		output.Body.Membership.ExpiresAt = &membership.ExpiresAt.Time
+	}
 
 	// Get inviter info for complete response
 	// T134: Log warning when inviter fetch fails (non-blocking)
 	// T181: On error, Inviter remains nil (not half-populated {id, name:"", username:""})
-	inviter, err := h.queries.GetUserByID(ctx, session.UserID)
+	inviter, err := h.queries.GetUserByID(ctx, principal.UserID)
 	if err != nil {
 		// Log but don't fail - inviter info is supplementary
 		LogDBError(ctx, "GetInviterInfo", err)
@@ -334,14 +417,159 @@ func (h *MembershipHandler) handleInviteMember(ctx context.Context, input *Invit
 	return output, nil
 }
 
+// InviteGuestInput is the request for inviting a guest to a discussion or poll.
+type InviteGuestInput struct {
+	GroupID int64 `path:"groupId" doc:"Group ID"`
+	Body    struct {
+		UserID       int64  `json:"user_id" required:"true" doc:"ID of the user to invite as a guest"`
+		ResourceType string `json:"resource_type" required:"true" enum:"discussion,poll" doc:"Kind of resource the guest can access"`
+		ResourceID   int64  `json:"resource_id" required:"true" minimum:"1" doc:"ID of the discussion or poll"`
+		Force        bool   `json:"force,omitempty" doc:"Invite even if the user declined a previous invitation"`
+	}
+}
+
+// InviteGuestOutput is the response for inviting a guest.
+type InviteGuestOutput struct {
+	Body struct {
+		Membership MembershipDTO `json:"membership"`
+		Grant      GuestGrantDTO `json:"grant"`
+	}
+}
+
+func (h *MembershipHandler) handleInviteGuest(ctx context.Context, input *InviteGuestInput) (*InviteGuestOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: user must be able to add guests
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.GroupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanAddGuests() {
+		return nil, huma.Error403Forbidden("Not authorized to add guests")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return nil, huma.Error409Conflict("Cannot invite guests to an archived group")
+	}
+
+	// Verify the user to invite exists
+	invitee, err := h.queries.GetUserByID(ctx, input.Body.UserID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("User not found")
+		}
+		LogDBError(ctx, "GetUserByID", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	// Respect a previous decline unless the inviter explicitly overrides it
+	if !input.Body.Force {
+		if err := checkNotDeclined(ctx, h.queries, input.GroupID, invitee.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Existing members already see everything; existing guests gain another grant
+	existing, err := h.queries.GetMembershipByGroupAndUser(ctx, db.GetMembershipByGroupAndUserParams{
+		GroupID: input.GroupID,
+		UserID:  input.Body.UserID,
+	})
+	if err != nil {
+		if !db.IsNotFound(err) {
+			LogDBError(ctx, "GetMembershipByGroupAndUser", err)
+			return nil, huma.Error500InternalServerError("Database error")
+		}
+		// sqlc returns a zero membership alongside ErrNoRows
+		existing = nil
+	} else if Role(existing.Role) != RoleGuest {
+		return nil, huma.Error409Conflict("User is already a member of this group")
+	}
+
+	// Execute in transaction with audit context
+	var membership *db.Membership
+	var grant *db.GuestGrant
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+
+		membership = existing
+		if membership == nil {
+			// Create pending guest membership (no accepted_at)
+			var createErr error
+			membership, createErr = txQueries.CreateMembership(ctx, db.CreateMembershipParams{
+				GroupID:   input.GroupID,
+				UserID:    input.Body.UserID,
+				Role:      RoleGuest.String(),
+				InviterID: principal.UserID,
+			})
+			if createErr != nil {
+				if isUniqueViolation(createErr, "memberships_unique_user_group") {
+					return huma.Error409Conflict("User is already a member of this group")
+				}
+				return fmt.Errorf("CreateMembership: %w", createErr)
+			}
+			if err := recordInvitationEvent(ctx, txQueries, membership, principal.UserID, InvitationInvited); err != nil {
+				return err
+			}
+		}
+
+		var grantErr error
+		grant, grantErr = txQueries.CreateGuestGrant(ctx, db.CreateGuestGrantParams{
+			MembershipID: membership.ID,
+			ResourceType: input.Body.ResourceType,
+			ResourceID:   input.Body.ResourceID,
+			GrantedByID:  principal.UserID,
+		})
+		if grantErr != nil {
+			if isUniqueViolation(grantErr, "guest_grants_unique_resource") {
+				return huma.Error409Conflict("Guest already has access to this " + input.Body.ResourceType)
+			}
+			return fmt.Errorf("CreateGuestGrant: %w", grantErr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "InviteGuest", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	metrics.RecordInvitationSent(metrics.InvitationGuest)
+
+	output := &InviteGuestOutput{}
+	output.Body.Membership = MembershipDTOFromMembership(membership)
+	output.Body.Membership.User = &UserSummaryDTO{
+		ID:       invitee.ID,
+		Name:     invitee.Name,
+		Username: invitee.Username,
+	}
+	output.Body.Grant = GuestGrantDTOFromGrant(grant)
+	return output, nil
+}
+
 // GetMembershipInput is the request for getting a single membership.
 type GetMembershipInput struct {
-	Cookie       string `cookie:"loomio_session"`
-	MembershipID int64  `path:"id" doc:"Membership ID"`
+	conditional.Params
+	MembershipID int64 `path:"id" doc:"Membership ID"`
 }
 
 // GetMembershipOutput is the response for getting a single membership.
 type GetMembershipOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the membership, for If-None-Match and If-Match"`
 	Body struct {
 		Membership MembershipDTO `json:"membership"`
 		Group      GroupDTO      `json:"group"`
@@ -350,12 +578,8 @@ type GetMembershipOutput struct {
 
 func (h *MembershipHandler) handleGetMembership(ctx context.Context, input *GetMembershipInput) (*GetMembershipOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -370,7 +594,7 @@ func (h *MembershipHandler) handleGetMembership(ctx context.Context, input *GetM
 	}
 
 	// Authorize: user must be a member of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, membershipRow.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, membershipRow.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -393,8 +617,19 @@ func (h *MembershipHandler) handleGetMembership(ctx context.Context, input *GetM
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
+	// Not Modified when the client's cached copy is current
+	versions := membershipVersions{
+		membership: membershipRow.UpdatedAt,
+		user:       membershipRow.UserUpdatedAt,
+		inviter:    membershipRow.InviterUpdatedAt,
+	}
+	tag := membershipETag(membershipRow.ID, versions, group, responseFormat(ctx))
+	if err := checkNotModified(&input.Params, tag, versions.modified(group)); err != nil {
+		return nil, err
+	}
+
 	// Build response
-	output := &GetMembershipOutput{}
+	output := &GetMembershipOutput{ETag: quoteETag(tag)}
 	output.Body.Membership = MembershipDTO{
 		ID:        membershipRow.ID,
 		GroupID:   membershipRow.GroupID,
@@ -412,9 +647,15 @@ func (h *MembershipHandler) handleGetMembership(ctx context.Context, input *GetM
 			Username: membershipRow.InviterUsername,
 		},
 	}
+	if membershipRow.GroupRoleID.Valid {
+		output.Body.Membership.GroupRoleID = &membershipRow.GroupRoleID.Int64
+	}
 	if membershipRow.AcceptedAt.Valid {
 		output.Body.Membership.AcceptedAt = &membershipRow.AcceptedAt.Time
 	}
+	if membershipRow.ExpiresAt.Valid {
+		output.Body.Membership.ExpiresAt = &membershipRow.ExpiresAt.Time
+	}
 	output.Body.Group = GroupDTOFromGroup(group)
 
 	return output, nil
@@ -422,8 +663,7 @@ func (h *MembershipHandler) handleGetMembership(ctx context.Context, input *GetM
 
 // AcceptInvitationInput is the request for accepting an invitation.
 type AcceptInvitationInput struct {
-	Cookie       string `cookie:"loomio_session"`
-	MembershipID int64  `path:"id" doc:"Membership ID"`
+	MembershipID int64 `path:"id" doc:"Membership ID"`
 }
 
 // AcceptInvitationOutput is the response for accepting an invitation.
@@ -435,12 +675,8 @@ type AcceptInvitationOutput struct {
 
 func (h *MembershipHandler) handleAcceptInvitation(ctx context.Context, input *AcceptInvitationInput) (*AcceptInvitationOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -455,7 +691,7 @@ func (h *MembershipHandler) handleAcceptInvitation(ctx context.Context, input *A
 	}
 
 	// Verify the current user is the invited user
-	if membership.UserID != session.UserID {
+	if membership.UserID != principal.UserID {
 		return nil, huma.Error403Forbidden("You can only accept your own invitations")
 	}
 
@@ -468,7 +704,7 @@ func (h *MembershipHandler) handleAcceptInvitation(ctx context.Context, input *A
 	var updatedMembership *db.Membership
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		// Set audit context for triggers
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
@@ -479,7 +715,7 @@ func (h *MembershipHandler) handleAcceptInvitation(ctx context.Context, input *A
 		if acceptErr != nil {
 			return fmt.Errorf("AcceptMembership: %w", acceptErr)
 		}
-		return nil
+		return recordInvitationEvent(ctx, txQueries, updatedMembership, principal.UserID, InvitationAccepted)
 	})
 
 	if err != nil {
@@ -495,33 +731,47 @@ func (h *MembershipHandler) handleAcceptInvitation(ctx context.Context, input *A
 
 // ListMyInvitationsInput is the request for listing current user's invitations.
 type ListMyInvitationsInput struct {
-	Cookie string `cookie:"loomio_session"`
+	PageParams
+	Sort string `query:"sort" enum:"name,created_at" default:"created_at" doc:"Sort field (name is the group's name)"`
 }
 
 // ListMyInvitationsOutput is the response for listing invitations.
+// Link and next_cursor are only set when another page exists.
 type ListMyInvitationsOutput struct {
+	Link string `header:"Link"`
 	Body struct {
 		Invitations []InvitationDTO `json:"invitations"`
+		NextCursor  string          `json:"next_cursor,omitempty"`
 	}
 }
 
 func (h *MembershipHandler) handleListMyInvitations(ctx context.Context, input *ListMyInvitationsInput) (*ListMyInvitationsOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
-	// List invitations with group and inviter info
-	rows, err := h.queries.ListInvitationsWithGroups(ctx, session.UserID)
+	page, err := newPageQuery(input.PageParams, input.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	// List one page of invitations with group and inviter info
+	rows, err := h.queries.ListInvitationsWithGroups(ctx, db.ListInvitationsWithGroupsParams{
+		UserID:         principal.UserID,
+		SortBy:         page.SortBy,
+		SortDesc:       page.Desc,
+		AfterID:        page.After.ID,
+		AfterName:      page.After.Name,
+		AfterCreatedAt: page.AfterCreatedAt(),
+		PageLimit:      page.FetchLimit(),
+	})
 	if err != nil {
 		LogDBError(ctx, "ListInvitationsWithGroups", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
+	rows, hasMore := trimPage(rows, page)
 
 	// Convert to DTOs
 	invitations := make([]InvitationDTO, len(rows))
@@ -531,17 +781,23 @@ func (h *MembershipHandler) handleListMyInvitations(ctx context.Context, input *
 
 	output := &ListMyInvitationsOutput{}
 	output.Body.Invitations = invitations
+	if hasMore {
+		last := rows[len(rows)-1]
+		output.Body.NextCursor = page.Cursor(last.ID, last.GroupName, "", last.CreatedAt.Time)
+		output.Link = nextPageLink("/api/v1/users/me/invitations", nil, page, output.Body.NextCursor)
+	}
 	return output, nil
 }
 
 // PromoteMemberInput is the request for promoting a member to admin.
 type PromoteMemberInput struct {
-	Cookie       string `cookie:"loomio_session"`
-	MembershipID int64  `path:"id" doc:"Membership ID"`
+	conditional.Params
+	MembershipID int64 `path:"id" doc:"Membership ID"`
 }
 
 // PromoteMemberOutput is the response for promoting a member.
 type PromoteMemberOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the updated membership"`
 	Body struct {
 		Membership MembershipDTO `json:"membership"`
 	}
@@ -549,12 +805,8 @@ type PromoteMemberOutput struct {
 
 func (h *MembershipHandler) handlePromoteMember(ctx context.Context, input *PromoteMemberInput) (*PromoteMemberOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -569,7 +821,7 @@ func (h *MembershipHandler) handlePromoteMember(ctx context.Context, input *Prom
 	}
 
 	// Authorize: current user must be admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, membership.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, membership.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -593,14 +845,26 @@ func (h *MembershipHandler) handlePromoteMember(ctx context.Context, input *Prom
 		return nil, huma.Error409Conflict("Member is already an admin")
 	}
 
+	// Guests must be invited as members before they can become admins
+	if Role(membership.Role) == RoleGuest {
+		return nil, huma.Error409Conflict("Guests cannot be promoted; invite them as members instead")
+	}
+
 	// Execute promotion in transaction with audit context
 	var updatedMembership *db.Membership
+	var versions membershipVersions
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
 		txQueries := h.queries.WithTx(tx)
+
+		// If-Match: refuse to overwrite changes the client has not seen
+		if condErr := checkMembershipPrecondition(ctx, txQueries, &input.Params, input.MembershipID, authCtx.Group); condErr != nil {
+			return condErr
+		}
+
 		var updateErr error
 		updatedMembership, updateErr = txQueries.UpdateMembershipRole(ctx, db.UpdateMembershipRoleParams{
 			ID:   input.MembershipID,
@@ -609,27 +873,33 @@ func (h *MembershipHandler) handlePromoteMember(ctx context.Context, input *Prom
 		if updateErr != nil {
 			return fmt.Errorf("UpdateMembershipRole: %w", updateErr)
 		}
-		return nil
+		var versionsErr error
+		versions, versionsErr = loadMembershipVersions(ctx, txQueries, updatedMembership)
+		return versionsErr
 	})
 
 	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
 		LogDBError(ctx, "PromoteMember", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
-	output := &PromoteMemberOutput{}
+	output := &PromoteMemberOutput{ETag: quoteETag(membershipETag(updatedMembership.ID, versions, authCtx.Group, responseFormat(ctx)))}
 	output.Body.Membership = MembershipDTOFromMembership(updatedMembership)
 	return output, nil
 }
 
 // DemoteMemberInput is the request for demoting an admin to member.
 type DemoteMemberInput struct {
-	Cookie       string `cookie:"loomio_session"`
-	MembershipID int64  `path:"id" doc:"Membership ID"`
+	conditional.Params
+	MembershipID int64 `path:"id" doc:"Membership ID"`
 }
 
 // DemoteMemberOutput is the response for demoting a member.
 type DemoteMemberOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the updated membership"`
 	Body struct {
 		Membership MembershipDTO `json:"membership"`
 	}
@@ -637,12 +907,8 @@ type DemoteMemberOutput struct {
 
 func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *DemoteMemberInput) (*DemoteMemberOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -657,7 +923,7 @@ func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *Demot
 	}
 
 	// Authorize: current user must be admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, membership.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, membership.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -680,6 +946,9 @@ func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *Demot
 	if Role(membership.Role) == RoleMember {
 		return nil, huma.Error409Conflict("Member is already a regular member")
 	}
+	if Role(membership.Role) == RoleGuest {
+		return nil, huma.Error409Conflict("Guests cannot be demoted")
+	}
 
 	// Check if this is the last admin
 	adminCount, err := h.queries.CountAdminsByGroup(ctx, membership.GroupID)
@@ -696,12 +965,19 @@ func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *Demot
 
 	// Execute demotion in transaction with audit context
 	var updatedMembership *db.Membership
+	var versions membershipVersions
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
 		txQueries := h.queries.WithTx(tx)
+
+		// If-Match: refuse to overwrite changes the client has not seen
+		if condErr := checkMembershipPrecondition(ctx, txQueries, &input.Params, input.MembershipID, authCtx.Group); condErr != nil {
+			return condErr
+		}
+
 		var updateErr error
 		updatedMembership, updateErr = txQueries.UpdateMembershipRole(ctx, db.UpdateMembershipRoleParams{
 			ID:   input.MembershipID,
@@ -710,7 +986,9 @@ func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *Demot
 		if updateErr != nil {
 			return fmt.Errorf("UpdateMembershipRole: %w", updateErr)
 		}
-		return nil
+		var versionsErr error
+		versions, versionsErr = loadMembershipVersions(ctx, txQueries, updatedMembership)
+		return versionsErr
 	})
 
 	if err != nil {
@@ -720,19 +998,21 @@ func (h *MembershipHandler) handleDemoteMember(ctx context.Context, input *Demot
 			LogLastAdminProtection(ctx, "demote", "db_trigger", membership.GroupID)
 			return nil, huma.Error409Conflict("Cannot demote the last admin of a group")
 		}
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
 		LogDBError(ctx, "DemoteMember", err)
 		return nil, huma.Error500InternalServerError("Database error")
 	}
 
-	output := &DemoteMemberOutput{}
+	output := &DemoteMemberOutput{ETag: quoteETag(membershipETag(updatedMembership.ID, versions, authCtx.Group, responseFormat(ctx)))}
 	output.Body.Membership = MembershipDTOFromMembership(updatedMembership)
 	return output, nil
 }
 
 // RemoveMemberInput is the request for removing a member from a group.
 type RemoveMemberInput struct {
-	Cookie       string `cookie:"loomio_session"`
-	MembershipID int64  `path:"id" doc:"Membership ID"`
+	MembershipID int64 `path:"id" doc:"Membership ID"`
 }
 
 // RemoveMemberOutput is an empty response for member removal.
@@ -740,12 +1020,8 @@ type RemoveMemberOutput struct{}
 
 func (h *MembershipHandler) handleRemoveMember(ctx context.Context, input *RemoveMemberInput) (*RemoveMemberOutput, error) {
 	// Authenticate
-	if input.Cookie == "" {
-		return nil, huma.Error401Unauthorized("Not authenticated")
-	}
-
-	session, found := h.sessions.Get(input.Cookie)
-	if !found {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
 		return nil, huma.Error401Unauthorized("Not authenticated")
 	}
 
@@ -760,7 +1036,7 @@ func (h *MembershipHandler) handleRemoveMember(ctx context.Context, input *Remov
 	}
 
 	// Authorize: current user must be admin of the group
-	authCtx, err := NewAuthorizationContext(ctx, h.queries, session.UserID, membership.GroupID)
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, membership.GroupID)
 	if err != nil {
 		if db.IsNotFound(err) {
 			return nil, huma.Error404NotFound("Group not found")
@@ -796,7 +1072,7 @@ func (h *MembershipHandler) handleRemoveMember(ctx context.Context, input *Remov
 
 	// Execute removal in transaction with audit context
 	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		if auditErr := db.SetAuditContext(ctx, tx, session.UserID); auditErr != nil {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
 			return fmt.Errorf("SetAuditContext: %w", auditErr)
 		}
 
@@ -821,3 +1097,104 @@ func (h *MembershipHandler) handleRemoveMember(ctx context.Context, input *Remov
 	return &RemoveMemberOutput{}, nil
 }
 
+// ExtendMembershipInput is the request for extending a membership.
+type ExtendMembershipInput struct {
+	conditional.Params
+	MembershipID int64 `path:"id" doc:"Membership ID"`
+	Body         struct {
+		ExpiresAt *time.Time `json:"expires_at" required:"false" nullable:"true" doc:"New expiry, or null to make the membership permanent"`
+	}
+}
+
+// ExtendMembershipOutput is the response for extending a membership.
+type ExtendMembershipOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the updated membership"`
+	Body struct {
+		Membership MembershipDTO `json:"membership"`
+	}
+}
+
+func (h *MembershipHandler) handleExtendMembership(ctx context.Context, input *ExtendMembershipInput) (*ExtendMembershipOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Get the membership to extend
+	membership, err := h.queries.GetMembershipByID(ctx, input.MembershipID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Membership not found")
+		}
+		LogDBError(ctx, "GetMembershipByID", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	// Authorize: current user must be admin of the group
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, membership.GroupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanManageMembers() {
+		return nil, huma.Error403Forbidden("Only admins can extend memberships")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return nil, huma.Error409Conflict("Cannot extend memberships in an archived group")
+	}
+
+	expiresAt, err := validateExpiresAt(input.Body.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute extension in transaction with audit context
+	var updatedMembership *db.Membership
+	var versions membershipVersions
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, principal.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+
+		// If-Match: refuse to overwrite changes the client has not seen
+		if condErr := checkMembershipPrecondition(ctx, txQueries, &input.Params, input.MembershipID, authCtx.Group); condErr != nil {
+			return condErr
+		}
+
+		var updateErr error
+		updatedMembership, updateErr = txQueries.ExtendMembership(ctx, db.ExtendMembershipParams{
+			ID:        input.MembershipID,
+			ExpiresAt: expiresAt,
+		})
+		if updateErr != nil {
+			if db.IsNotFound(updateErr) {
+				// Expired and removed between the lookup and the update
+				return huma.Error404NotFound("Membership not found")
+			}
+			return fmt.Errorf("ExtendMembership: %w", updateErr)
+		}
+		var versionsErr error
+		versions, versionsErr = loadMembershipVersions(ctx, txQueries, updatedMembership)
+		return versionsErr
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "ExtendMembership", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &ExtendMembershipOutput{ETag: quoteETag(membershipETag(updatedMembership.ID, versions, authCtx.Group, responseFormat(ctx)))}
+	output.Body.Membership = MembershipDTOFromMembership(updatedMembership)
+	return output, nil
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humago"
@@ -21,13 +23,16 @@ import (
 
 // testMembershipsSetup holds shared test infrastructure for membership tests.
 type testMembershipsSetup struct {
-	pool              *pgxpool.Pool
-	queries           *db.Queries
-	sessions          *auth.SessionStore
-	groupHandler      *GroupHandler
-	membershipHandler *MembershipHandler
-	mux               *http.ServeMux
-	cleanup           func()
+	pool                *pgxpool.Pool
+	queries             *db.Queries
+	sessions            *auth.SessionStore
+	groupHandler        *GroupHandler
+	membershipHandler   *MembershipHandler
+	roleHandler         *RoleHandler
+	notificationHandler *NotificationHandler
+	invitationHandler   *InvitationHandler
+	mux                 *http.ServeMux
+	cleanup             func()
 }
 
 // setupMembershipsTest creates a test environment with a real database container.
@@ -49,22 +54,32 @@ func setupMembershipsTest(t *testing.T) *testMembershipsSetup {
 	sessions := auth.NewSessionStore()
 
 	// Create handlers
-	groupHandler := NewGroupHandler(pool, queries, sessions)
-	membershipHandler := NewMembershipHandler(pool, queries, sessions)
+	groupHandler := NewGroupHandler(pool, queries)
+	membershipHandler := NewMembershipHandler(pool, queries)
+	roleHandler := NewRoleHandler(pool, queries)
+	notificationHandler := NewNotificationHandler(pool, queries)
+	invitationHandler := NewInvitationHandler(pool, queries)
 
 	// Create Huma API
 	mux := http.NewServeMux()
 	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	UseAuthentication(api, sessions)
 	groupHandler.RegisterRoutes(api)
 	membershipHandler.RegisterRoutes(api)
+	roleHandler.RegisterRoutes(api)
+	notificationHandler.RegisterRoutes(api)
+	invitationHandler.RegisterRoutes(api)
 
 	return &testMembershipsSetup{
-		pool:              pool,
-		queries:           queries,
-		sessions:          sessions,
-		groupHandler:      groupHandler,
-		membershipHandler: membershipHandler,
-		mux:               mux,
+		pool:                pool,
+		queries:             queries,
+		sessions:            sessions,
+		groupHandler:        groupHandler,
+		membershipHandler:   membershipHandler,
+		roleHandler:         roleHandler,
+		notificationHandler: notificationHandler,
+		invitationHandler:   invitationHandler,
+		mux:                 mux,
 		cleanup: func() {
 			pool.Close()
 			cleanup()
@@ -566,6 +581,122 @@ func TestListMemberships_StatusFilter(t *testing.T) {
 	}
 }
 
+// TestListMemberships_PaginationAndSearch tests cursor paging and name search.
+func TestListMemberships_PaginationAndSearch(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+
+	// Invited out of name order, so name and role order differ
+	for _, name := range []string{"Bob Smith", "Alice Jones", "Carol Jones"} {
+		user := setup.createTestUser(t, strings.ToLower(strings.Fields(name)[0])+"@example.com", name)
+		w := setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/memberships", groupID), adminToken,
+			map[string]any{"user_id": user.ID, "role": "member"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("invite %s failed: %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+	// A guest whose name sorts first but whose role ranks last
+	guest := setup.createTestUser(t, "aaron@example.com", "Aaron Guest")
+	if w := setup.inviteGuest(t, adminToken, groupID, guest.ID, 42); w.Code != http.StatusCreated {
+		t.Fatalf("invite guest failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	// listNames follows next_cursor from the first page for query
+	listNames := func(t *testing.T, query string) []string {
+		t.Helper()
+		var names []string
+		path := fmt.Sprintf("/api/v1/groups/%d/memberships?limit=2%s", groupID, query)
+		for pages := 0; path != ""; pages++ {
+			if pages > 3 {
+				t.Fatal("pagination did not terminate")
+			}
+			w := setup.sendJSON(t, http.MethodGet, path, adminToken, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("list failed: %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Memberships []struct {
+					User struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				} `json:"memberships"`
+				NextCursor string `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			for _, m := range resp.Memberships {
+				names = append(names, m.User.Name)
+			}
+
+			path = ""
+			if resp.NextCursor != "" {
+				if w.Header().Get("Link") == "" {
+					t.Error("expected Link header when next_cursor is set")
+				}
+				path = fmt.Sprintf("/api/v1/groups/%d/memberships?limit=2%s&cursor=%s", groupID, query, resp.NextCursor)
+			}
+		}
+		return names
+	}
+
+	t.Run("pages follow next_cursor in name order", func(t *testing.T) {
+		names := listNames(t, "&sort=name")
+		want := []string{"Aaron Guest", "Admin User", "Alice Jones", "Bob Smith", "Carol Jones"}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+
+	t.Run("default order ranks admins, members, then guests, by name", func(t *testing.T) {
+		names := listNames(t, "")
+		want := []string{"Admin User", "Alice Jones", "Bob Smith", "Carol Jones", "Aaron Guest"}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+
+	t.Run("descending role order reverses the ranking", func(t *testing.T) {
+		names := listNames(t, "&order=desc")
+		want := []string{"Aaron Guest", "Carol Jones", "Bob Smith", "Alice Jones", "Admin User"}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+
+	t.Run("search matches name substring", func(t *testing.T) {
+		w := setup.sendJSON(t, http.MethodGet, fmt.Sprintf("/api/v1/groups/%d/memberships?q=jones", groupID), adminToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("search failed: %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if got := len(resp["memberships"].([]any)); got != 2 {
+			t.Errorf("expected 2 matches, got %d", got)
+		}
+	})
+
+	t.Run("cursor for another sort is rejected", func(t *testing.T) {
+		w := setup.sendJSON(t, http.MethodGet, fmt.Sprintf("/api/v1/groups/%d/memberships?limit=1", groupID), adminToken, nil)
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		cursor, _ := resp["next_cursor"].(string)
+		w = setup.sendJSON(t, http.MethodGet,
+			fmt.Sprintf("/api/v1/groups/%d/memberships?sort=created_at&cursor=%s", groupID, cursor), adminToken, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 // TestInviteMember_InviterID tests that inviter_id is correctly recorded.
 // T036d: Test inviteMember records correct inviter_id
 func TestInviteMember_InviterID(t *testing.T) {
@@ -1230,6 +1361,86 @@ func TestDemoteMember_AlreadyMember(t *testing.T) {
 	}
 }
 
+// TestMembership_ConditionalRequests tests ETags on memberships: a promotion
+// based on a stale copy is rejected with 412 and a current copy revalidates
+// with 304.
+func TestMembership_ConditionalRequests(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+
+	do := func(method, path, header, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/v1/memberships/%d%s", membershipID, path), nil)
+		if header != "" {
+			req.Header.Set(header, etag)
+		}
+		req.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+		w := httptest.NewRecorder()
+		setup.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	getW := do(http.MethodGet, "", "", "")
+	if getW.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", getW.Code, getW.Body.String())
+	}
+	etag := getW.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag on GET")
+	}
+
+	if w := do(http.MethodGet, "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 Not Modified, got %d: %s", w.Code, w.Body.String())
+	}
+
+	promoteW := do(http.MethodPost, "/promote", "If-Match", etag)
+	if promoteW.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", promoteW.Code, promoteW.Body.String())
+	}
+	newETag := promoteW.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Fatalf("expected a new ETag after promotion, got %q (was %q)", newETag, etag)
+	}
+
+	// A demotion based on the pre-promotion copy is rejected
+	demoteW := do(http.MethodPost, "/demote", "If-Match", etag)
+	if demoteW.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 Precondition Failed, got %d: %s", demoteW.Code, demoteW.Body.String())
+	}
+	membership, err := setup.queries.GetMembershipByID(context.Background(), membershipID)
+	if err != nil {
+		t.Fatalf("GetMembershipByID: %v", err)
+	}
+	if membership.Role != "admin" {
+		t.Errorf("expected the rejected demotion not to apply, role is %q", membership.Role)
+	}
+
+	if w := do(http.MethodGet, "", "If-None-Match", newETag); w.Code != http.StatusNotModified {
+		t.Errorf("expected the promotion's ETag to revalidate, got %d", w.Code)
+	}
+
+	// Renaming the embedded user makes the cached copy stale
+	if _, err := setup.pool.Exec(context.Background(),
+		"UPDATE users SET name = 'Renamed Member' WHERE id = $1", memberUser.ID); err != nil {
+		t.Fatalf("rename user: %v", err)
+	}
+	w := do(http.MethodGet, "", "If-None-Match", newETag)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Renamed Member") {
+		t.Errorf("expected 200 OK with the new name after a rename, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/demote", "If-Match", newETag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a copy taken before the rename, got %d", w.Code)
+	}
+}
+
 // TestRemoveMember_NonAdminNonMember tests that non-members cannot remove members.
 // T125/T146: Write test for non-member cannot remove member
 func TestRemoveMember_NonMemberCannotRemove(t *testing.T) {
@@ -1762,3 +1973,330 @@ func TestInviteMember_InviterFetchFailure_Documented(t *testing.T) {
 	t.Log("Inviter fetch failure handling is verified through code review")
 	t.Log("Expected behavior: On inviter fetch error, Inviter field is nil, not half-populated")
 }
+
+// inviteGuest invites userID as a guest of a discussion and returns the response recorder.
+func (s *testMembershipsSetup) inviteGuest(t *testing.T, token string, groupID, userID, discussionID int64) *httptest.ResponseRecorder {
+	t.Helper()
+	body := map[string]any{"user_id": userID, "resource_type": "discussion", "resource_id": discussionID}
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/guests", groupID), bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: token})
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestInviteGuest tests guest invitations, repeat grants, and scoped access.
+func TestInviteGuest(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	guestUser := setup.createTestUser(t, "guest@example.com", "Guest User")
+	guestToken := setup.createTestSession(t, guestUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+
+	// First grant creates a pending guest membership
+	w := setup.inviteGuest(t, adminToken, groupID, guestUser.ID, 42)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("guest invitation failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	membership := resp["membership"].(map[string]any)
+	if membership["role"] != "guest" {
+		t.Errorf("expected role guest, got %v", membership["role"])
+	}
+	membershipID := int64(membership["id"].(float64))
+
+	// Second grant on a different discussion reuses the membership
+	w = setup.inviteGuest(t, adminToken, groupID, guestUser.ID, 43)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("second guest grant failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Duplicate grant is a conflict
+	w = setup.inviteGuest(t, adminToken, groupID, guestUser.ID, 42)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate grant, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Accept as the guest
+	acceptReq := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/accept", membershipID), nil)
+	acceptReq.AddCookie(&http.Cookie{Name: "loomio_session", Value: guestToken})
+	acceptW := httptest.NewRecorder()
+	setup.mux.ServeHTTP(acceptW, acceptReq)
+	if acceptW.Code != http.StatusOK {
+		t.Fatalf("accept guest invitation failed: %d: %s", acceptW.Code, acceptW.Body.String())
+	}
+
+	authCtx, err := NewAuthorizationContext(ctx, setup.queries, guestUser.ID, groupID)
+	if err != nil {
+		t.Fatalf("NewAuthorizationContext: %v", err)
+	}
+	if !authCtx.IsGuest || authCtx.IsMember {
+		t.Errorf("expected guest-only context, got IsGuest=%v IsMember=%v", authCtx.IsGuest, authCtx.IsMember)
+	}
+	if !authCtx.CanAccessDiscussion(42) || !authCtx.CanAccessDiscussion(43) {
+		t.Error("guest should access granted discussions")
+	}
+	if authCtx.CanAccessDiscussion(44) {
+		t.Error("guest should not access ungranted discussion")
+	}
+
+	// Guests cannot view the group
+	getReq := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/groups/%d", groupID), nil)
+	getReq.AddCookie(&http.Cookie{Name: "loomio_session", Value: guestToken})
+	getW := httptest.NewRecorder()
+	setup.mux.ServeHTTP(getW, getReq)
+	if getW.Code != http.StatusForbidden {
+		t.Errorf("guest viewing group should return 403, got %d", getW.Code)
+	}
+
+	// Guests are excluded from member counts
+	count, err := setup.queries.CountGroupMembers(ctx, groupID)
+	if err != nil {
+		t.Fatalf("CountGroupMembers: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected member count 1 (admin only), got %d", count)
+	}
+
+	// Guests cannot be promoted
+	promoteReq := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/promote", membershipID), nil)
+	promoteReq.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	promoteW := httptest.NewRecorder()
+	setup.mux.ServeHTTP(promoteW, promoteReq)
+	if promoteW.Code != http.StatusConflict {
+		t.Errorf("promoting guest should return 409, got %d: %s", promoteW.Code, promoteW.Body.String())
+	}
+}
+
+// TestInviteGuest_Permissions tests members_can_add_guests and existing-member handling.
+func TestInviteGuest_Permissions(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	outsider := setup.createTestUser(t, "outsider@example.com", "Outsider")
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+
+	// Inviting an existing member as a guest is a conflict
+	w := setup.inviteGuest(t, adminToken, groupID, memberUser.ID, 1)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for existing member, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Disable members_can_add_guests; members are then forbidden
+	patchBody, _ := json.Marshal(map[string]any{"members_can_add_guests": false})
+	patchReq := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/groups/%d", groupID), bytes.NewBuffer(patchBody))
+	patchReq.Header.Set("Content-Type", "application/json")
+	patchReq.AddCookie(&http.Cookie{Name: "loomio_session", Value: adminToken})
+	patchW := httptest.NewRecorder()
+	setup.mux.ServeHTTP(patchW, patchReq)
+	if patchW.Code != http.StatusOK {
+		t.Fatalf("update group failed: %d: %s", patchW.Code, patchW.Body.String())
+	}
+
+	w = setup.inviteGuest(t, memberToken, groupID, outsider.ID, 1)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for member without permission, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestExtendMembership tests time-bound invitations and extending or clearing expiry.
+func TestExtendMembership(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+	contractor := setup.createTestUser(t, "contractor@example.com", "Contractor")
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	memberMembershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+	invitePath := fmt.Sprintf("/api/v1/groups/%d/memberships", groupID)
+
+	// Expiry in the past is rejected
+	w := setup.sendJSON(t, http.MethodPost, invitePath, adminToken, map[string]any{
+		"user_id":    contractor.ID,
+		"expires_at": time.Now().Add(-time.Hour),
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for past expiry, got %d: %s", w.Code, w.Body.String())
+	}
+
+	expiresAt := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	w = setup.sendJSON(t, http.MethodPost, invitePath, adminToken, map[string]any{
+		"user_id":    contractor.ID,
+		"expires_at": expiresAt,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("time-bound invite failed: %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	membership := resp["membership"].(map[string]any)
+	if membership["expires_at"] == nil {
+		t.Fatal("expected expires_at in response")
+	}
+	contractorMembershipID := int64(membership["id"].(float64))
+	extendPath := fmt.Sprintf("/api/v1/memberships/%d/extend", contractorMembershipID)
+
+	// Only admins can extend
+	w = setup.sendJSON(t, http.MethodPost, extendPath, memberToken, map[string]any{"expires_at": nil})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for member extending, got %d", w.Code)
+	}
+
+	// Mark as notified/blocked to check extension resets both markers
+	if _, err := setup.pool.Exec(ctx,
+		`UPDATE memberships SET expiry_notified_at = NOW(), expiry_blocked_at = NOW() WHERE id = $1`,
+		contractorMembershipID); err != nil {
+		t.Fatalf("failed to mark membership: %v", err)
+	}
+
+	newExpiry := expiresAt.Add(30 * 24 * time.Hour)
+	w = setup.sendJSON(t, http.MethodPost, extendPath, adminToken, map[string]any{"expires_at": newExpiry})
+	if w.Code != http.StatusOK {
+		t.Fatalf("extend failed: %d: %s", w.Code, w.Body.String())
+	}
+	extended, err := setup.queries.GetMembershipByID(ctx, contractorMembershipID)
+	if err != nil {
+		t.Fatalf("GetMembershipByID: %v", err)
+	}
+	if !extended.ExpiresAt.Time.Equal(newExpiry) {
+		t.Errorf("expected expires_at %v, got %v", newExpiry, extended.ExpiresAt.Time)
+	}
+	if extended.ExpiryNotifiedAt.Valid || extended.ExpiryBlockedAt.Valid {
+		t.Error("extension should reset notified and blocked markers")
+	}
+
+	// null makes a membership permanent
+	w = setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/extend", memberMembershipID), adminToken,
+		map[string]any{"expires_at": nil})
+	if w.Code != http.StatusOK {
+		t.Fatalf("clearing expiry failed: %d: %s", w.Code, w.Body.String())
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("expires_at")) {
+		t.Errorf("permanent membership should not report expires_at: %s", w.Body.String())
+	}
+}
+
+// TestExpiredMembership_NoAccess tests that a membership past its expiry
+// grants nothing before MembershipExpirer removes it, unless its expiry was
+// blocked by last-admin protection.
+func TestExpiredMembership_NoAccess(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	memberMembershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+	groupPath := fmt.Sprintf("/api/v1/groups/%d", groupID)
+
+	if w := setup.sendJSON(t, http.MethodGet, groupPath, memberToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected member to view the group, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := setup.pool.Exec(ctx,
+		`UPDATE memberships SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, memberMembershipID,
+	); err != nil {
+		t.Fatalf("failed to expire membership: %v", err)
+	}
+	if w := setup.sendJSON(t, http.MethodGet, groupPath, memberToken, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an expired member, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The last admin's expiry is blocked, so they keep access
+	if _, err := setup.pool.Exec(ctx,
+		`UPDATE memberships SET expires_at = NOW() - INTERVAL '1 minute', expiry_blocked_at = NOW()
+		 WHERE group_id = $1 AND user_id = $2`, groupID, adminUser.ID,
+	); err != nil {
+		t.Fatalf("failed to expire admin membership: %v", err)
+	}
+	if w := setup.sendJSON(t, http.MethodGet, groupPath, adminToken, nil); w.Code != http.StatusOK {
+		t.Errorf("expected the blocked last admin to keep access, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestListMyNotifications tests the notification inbox is scoped to the current user.
+func TestListMyNotifications(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	user := setup.createTestUser(t, "user@example.com", "User")
+	userToken := setup.createTestSession(t, user.ID)
+	other := setup.createTestUser(t, "other@example.com", "Other")
+	otherToken := setup.createTestSession(t, other.ID)
+
+	notification, err := setup.queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID: user.ID,
+		Kind:   "membership_expiring",
+		Data:   []byte(`{"expires_at":"2030-01-01T00:00:00Z"}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	w := setup.sendJSON(t, http.MethodGet, "/api/v1/users/me/notifications?unread=true", userToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list failed: %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	items := resp["notifications"].([]any)
+	if len(items) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(items))
+	}
+	if data := items[0].(map[string]any)["data"].(map[string]any); data["expires_at"] != "2030-01-01T00:00:00Z" {
+		t.Errorf("unexpected data: %v", data)
+	}
+
+	readPath := fmt.Sprintf("/api/v1/users/me/notifications/%d/read", notification.ID)
+	w = setup.sendJSON(t, http.MethodPost, readPath, otherToken, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another user's notification, got %d", w.Code)
+	}
+	w = setup.sendJSON(t, http.MethodPost, readPath, userToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("mark read failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = setup.sendJSON(t, http.MethodGet, "/api/v1/users/me/notifications?unread=true", userToken, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if items := resp["notifications"].([]any); len(items) != 0 {
+		t.Errorf("expected no unread notifications, got %d", len(items))
+	}
+}
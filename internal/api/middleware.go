@@ -1,15 +1,165 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/logging"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	// maxRequestIDLength bounds client-supplied request IDs
+	maxRequestIDLength = 128
+	// maxUserAgentLength bounds the user agent stored in audit rows
+	maxUserAgentLength = 512
 )
 
+// RequestIDMiddleware assigns each request an ID and stores it, with the
+// client IP and user agent, in the request context for audit logging.
+// A well-formed incoming X-Request-ID (e.g. from a load balancer) is kept;
+// otherwise a random one is generated. The ID is echoed in the response.
+// X-Forwarded-For and X-Real-IP are only believed from trustedProxies, IPs
+// or CIDRs validated by config; otherwise the client IP is the peer address.
+func RequestIDMiddleware(trustedProxies []string) func(http.Handler) http.Handler {
+	proxies := parseTrustedProxies(trustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			// Header values may hold any byte (obs-text); Postgres only stores UTF-8
+			userAgent := truncateUTF8(strings.ToValidUTF8(r.UserAgent(), "\uFFFD"), maxUserAgentLength)
+			ctx := db.WithRequestMetadata(r.Context(), db.RequestMetadata{
+				RequestID: requestID,
+				ClientIP:  proxies.clientIP(r),
+				UserAgent: userAgent,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// trustedProxies are the networks allowed to report the client address in
+// forwarding headers.
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies parses IPs and CIDRs, skipping anything else.
+func parseTrustedProxies(entries []string) trustedProxies {
+	var proxies trustedProxies
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			proxies = append(proxies, network)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return proxies
+}
+
+func (p trustedProxies) contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client behind r, or "" if RemoteAddr
+// is not an IP. Forwarding headers are only read when the peer is a trusted
+// proxy. X-Forwarded-For is walked from the right, past trusted proxies, so
+// entries a client prepends are never reached.
+func (p trustedProxies) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	client := net.ParseIP(host)
+	if client == nil {
+		return ""
+	}
+	if !p.contains(client) {
+		return client.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0 && p.contains(client); i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			client = ip
+		}
+		return client.String()
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return client.String()
+}
+
+// validRequestID accepts short IDs of letters, digits, and -_.:
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// truncateUTF8 shortens valid UTF-8 s to at most n bytes without splitting
+// a multi-byte rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// newRequestID generates a random 128-bit request ID in hex.
+func newRequestID() string {
+	bytes := make([]byte, 16)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
 // LoggingMiddleware logs request details for debugging and monitoring.
+// Attributes added with logging.AddAttrs while the request is handled, such
+// as the authenticated user, appear on the access log line too.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		r = r.WithContext(logging.WithRequestAttrs(r.Context()))
 
 		// Create response writer wrapper to capture status code
 		wrapper := &responseWriterWrapper{ResponseWriter: w, statusCode: http.StatusOK}
@@ -19,7 +169,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log request details
 		duration := time.Since(start)
-		slog.Info("http request",
+		slog.InfoContext(r.Context(), "http request",
+			"request_id", db.RequestMetadataFromContext(r.Context()).RequestID,
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", wrapper.statusCode,
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestRequestIDMiddleware verifies request IDs are kept or generated and the
+// request metadata reaches the handler's context.
+func TestRequestIDMiddleware(t *testing.T) {
+	var got db.RequestMetadata
+	handler := RequestIDMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = db.RequestMetadataFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"well-formed ID is kept", "req-01HZX:abc_1.2", true},
+		{"missing ID is generated", "", false},
+		{"ID with spaces is replaced", "not valid", false},
+		{"overlong ID is replaced", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("User-Agent", "test-agent")
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if tt.keep && got.RequestID != tt.incoming {
+				t.Errorf("expected %q to be kept, got %q", tt.incoming, got.RequestID)
+			}
+			if !tt.keep && (got.RequestID == tt.incoming || len(got.RequestID) != 32) {
+				t.Errorf("expected a generated 32-character ID, got %q", got.RequestID)
+			}
+			if w.Header().Get(RequestIDHeader) != got.RequestID {
+				t.Errorf("response header %q does not match %q", w.Header().Get(RequestIDHeader), got.RequestID)
+			}
+			if got.ClientIP != "192.0.2.1" || got.UserAgent != "test-agent" {
+				t.Errorf("unexpected client metadata: %+v", got)
+			}
+		})
+	}
+
+	t.Run("long user agent is truncated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", strings.Repeat("x", maxUserAgentLength+100))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if len(got.UserAgent) != maxUserAgentLength {
+			t.Errorf("expected user agent truncated to %d, got %d", maxUserAgentLength, len(got.UserAgent))
+		}
+	})
+
+	t.Run("user agent is made valid UTF-8", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		// A 3-byte rune straddling the limit, and obs-text bytes that are not UTF-8
+		req.Header.Set("User-Agent", strings.Repeat("x", maxUserAgentLength-1)+"€\xff")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if !utf8.ValidString(got.UserAgent) || got.UserAgent != strings.Repeat("x", maxUserAgentLength-1) {
+			t.Errorf("expected the split rune to be dropped, got %d bytes ending %q",
+				len(got.UserAgent), got.UserAgent[max(0, len(got.UserAgent)-8):])
+		}
+
+		req.Header.Set("User-Agent", "agent/\xe9\xff1.0")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if got.UserAgent != "agent/\uFFFD1.0" {
+			t.Errorf("expected invalid bytes to be replaced, got %q", got.UserAgent)
+		}
+	})
+}
+
+// TestRequestIDMiddleware_ClientIP verifies forwarding headers are only
+// believed from trusted proxies and cannot be forged by prepending entries.
+func TestRequestIDMiddleware_ClientIP(t *testing.T) {
+	var got db.RequestMetadata
+	handler := RequestIDMiddleware([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = db.RequestMetadataFromContext(r.Context())
+		}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "198.51.100.5:4000", "", "", "198.51.100.5"},
+		{"untrusted peer's headers are ignored", "198.51.100.5:4000", "203.0.113.7", "203.0.113.8", "198.51.100.5"},
+		{"trusted proxy", "192.0.2.1:4000", "203.0.113.7", "", "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:4000", "203.0.113.7, 10.1.2.3", "", "203.0.113.7"},
+		{"prepended entries are not reached", "192.0.2.1:4000", "1.2.3.4, 203.0.113.7", "", "203.0.113.7"},
+		{"garbage entry stops the walk", "192.0.2.1:4000", "203.0.113.7, '; DROP TABLE", "", "192.0.2.1"},
+		{"X-Real-IP from a trusted proxy", "192.0.2.1:4000", "", "203.0.113.8", "203.0.113.8"},
+		{"invalid X-Real-IP", "192.0.2.1:4000", "", "not an ip", "192.0.2.1"},
+		{"IPv6 proxy", "[2001:db8::1]:4000", "2001:db8::99", "", "2001:db8::99"},
+		{"unparseable peer", "unix", "203.0.113.7", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if got.ClientIP != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got.ClientIP, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// NotificationHandler handles the current user's notification inbox.
+// Notifications are written by background jobs (see internal/jobs).
+type NotificationHandler struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewNotificationHandler creates a new notification handler.
+func NewNotificationHandler(pool *pgxpool.Pool, queries *db.Queries) *NotificationHandler {
+	return &NotificationHandler{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// RegisterRoutes registers all notification routes.
+func (h *NotificationHandler) RegisterRoutes(api huma.API) {
+	// List the current user's notifications
+	huma.Register(api, huma.Operation{
+		OperationID: "listMyNotifications",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/me/notifications",
+		Summary:     "List my notifications",
+		Description: "Returns the current user's notifications, newest first.",
+		Tags:        []string{"Notifications"},
+		Security:    SessionSecurity,
+	}, h.handleListMyNotifications)
+
+	// Mark a notification as read
+	huma.Register(api, huma.Operation{
+		OperationID: "markNotificationRead",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/me/notifications/{id}/read",
+		Summary:     "Mark notification read",
+		Description: "Marks one of the current user's notifications as read.",
+		Tags:        []string{"Notifications"},
+		Security:    SessionSecurity,
+	}, h.handleMarkNotificationRead)
+}
+
+// ListMyNotificationsInput is the request for listing notifications.
+type ListMyNotificationsInput struct {
+	UnreadOnly bool  `query:"unread" doc:"Only return unread notifications"`
+	Limit      int32 `query:"limit" minimum:"1" maximum:"200" default:"50" doc:"Maximum number of notifications"`
+}
+
+// ListMyNotificationsOutput is the response for listing notifications.
+type ListMyNotificationsOutput struct {
+	Body struct {
+		Notifications []NotificationDTO `json:"notifications"`
+	}
+}
+
+func (h *NotificationHandler) handleListMyNotifications(ctx context.Context, input *ListMyNotificationsInput) (*ListMyNotificationsOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	notifications, err := h.queries.ListNotificationsByUser(ctx, db.ListNotificationsByUserParams{
+		UserID:     principal.UserID,
+		UnreadOnly: input.UnreadOnly,
+		MaxResults: input.Limit,
+	})
+	if err != nil {
+		LogDBError(ctx, "ListNotificationsByUser", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &ListMyNotificationsOutput{}
+	output.Body.Notifications = make([]NotificationDTO, len(notifications))
+	for i, n := range notifications {
+		output.Body.Notifications[i] = NotificationDTOFromNotification(n)
+	}
+	return output, nil
+}
+
+// MarkNotificationReadInput is the request for marking a notification read.
+type MarkNotificationReadInput struct {
+	NotificationID int64 `path:"id" doc:"Notification ID"`
+}
+
+// MarkNotificationReadOutput is the response for marking a notification read.
+type MarkNotificationReadOutput struct {
+	Body struct {
+		Notification NotificationDTO `json:"notification"`
+	}
+}
+
+func (h *NotificationHandler) handleMarkNotificationRead(ctx context.Context, input *MarkNotificationReadInput) (*MarkNotificationReadOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Scoped to the current user: other users' notifications are not found
+	notification, err := h.queries.MarkNotificationRead(ctx, db.MarkNotificationReadParams{
+		ID:     input.NotificationID,
+		UserID: principal.UserID,
+	})
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Notification not found")
+		}
+		LogDBError(ctx, "MarkNotificationRead", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &MarkNotificationReadOutput{}
+	output.Body.Notification = NotificationDTOFromNotification(notification)
+	return output, nil
+}
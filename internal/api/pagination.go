@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Page size limits shared by every paginated list endpoint.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// Sort fields accepted by paginated list endpoints. Each endpoint documents
+// which of them it supports in its sort query parameter enum.
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "created_at"
+	SortByRole      = "role"
+)
+
+// PageParams are the query parameters shared by paginated list endpoints.
+// Embed it in a handler input next to an endpoint-specific Sort field.
+type PageParams struct {
+	Cursor string `query:"cursor" doc:"Opaque cursor from a previous page's next_cursor"`
+	Limit  int32  `query:"limit" minimum:"1" maximum:"200" default:"50" doc:"Maximum number of items per page"`
+	Order  string `query:"order" enum:"asc,desc" doc:"Sort direction (defaults to asc, or desc when sorting by created_at)"`
+}
+
+// pageCursor is the decoded form of an opaque cursor: the sort key and id of
+// the last row of the previous page. Sort and Desc pin the cursor to the
+// ordering it was issued for.
+type pageCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	ID        int64     `json:"i"`
+	Name      string    `json:"n,omitempty"`
+	Role      string    `json:"r,omitempty"`
+	CreatedAt time.Time `json:"c,omitzero"`
+}
+
+// encodeCursor serialises a cursor as unpadded URL-safe base64 JSON.
+// The format is opaque to clients and may change between releases.
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.ID <= 0 {
+		return c, fmt.Errorf("cursor has no position")
+	}
+	return c, nil
+}
+
+// pageQuery holds the resolved keyset parameters for one page.
+// After is the zero cursor (ID 0) for the first page.
+type pageQuery struct {
+	SortBy string
+	Desc   bool
+	Limit  int32
+	After  pageCursor
+}
+
+// newPageQuery validates the page parameters for the given sort field and
+// decodes the cursor. A cursor issued for a different sort or direction is
+// rejected rather than silently restarting from the first page.
+func newPageQuery(params PageParams, sortBy string) (pageQuery, error) {
+	desc := sortBy == SortByCreatedAt
+	switch params.Order {
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	q := pageQuery{SortBy: sortBy, Desc: desc, Limit: limit}
+	if params.Cursor == "" {
+		return q, nil
+	}
+
+	cursor, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return q, huma.Error400BadRequest("Invalid cursor",
+			&huma.ErrorDetail{
+				Location: "query.cursor",
+				Message:  "Invalid cursor",
+				Value:    params.Cursor,
+			})
+	}
+	if cursor.Sort != sortBy || cursor.Desc != desc {
+		return q, huma.Error400BadRequest("Cursor does not match the requested sort order",
+			&huma.ErrorDetail{
+				Location: "query.cursor",
+				Message:  "Cursor was issued for a different sort or order",
+				Value:    params.Cursor,
+			})
+	}
+	q.After = cursor
+	return q, nil
+}
+
+// FetchLimit is the row count to request: one extra row tells whether a
+// next page exists without a separate COUNT query.
+func (q pageQuery) FetchLimit() int32 {
+	return q.Limit + 1
+}
+
+// AfterCreatedAt returns the cursor's created_at for sqlc params.
+func (q pageQuery) AfterCreatedAt() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: q.After.CreatedAt, Valid: !q.After.CreatedAt.IsZero()}
+}
+
+// Order returns the effective sort direction ("asc" or "desc").
+func (q pageQuery) Order() string {
+	if q.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// Cursor builds the cursor pointing after a row with the given sort keys.
+func (q pageQuery) Cursor(id int64, name, role string, createdAt time.Time) string {
+	return encodeCursor(pageCursor{
+		Sort:      q.SortBy,
+		Desc:      q.Desc,
+		ID:        id,
+		Name:      name,
+		Role:      role,
+		CreatedAt: createdAt,
+	})
+}
+
+// trimPage drops the look-ahead row fetched with FetchLimit and reports
+// whether there is another page.
+func trimPage[T any](rows []T, q pageQuery) ([]T, bool) {
+	if len(rows) > int(q.Limit) {
+		return rows[:q.Limit], true
+	}
+	return rows, false
+}
+
+// nextPageLink builds an RFC 8288 Link header value for the next page.
+// query holds the endpoint's own filters; paging parameters are added here.
+func nextPageLink(path string, query url.Values, q pageQuery, cursor string) string {
+	values := url.Values{}
+	for k, v := range query {
+		values[k] = v
+	}
+	values.Set("sort", q.SortBy)
+	values.Set("order", q.Order())
+	values.Set("limit", strconv.Itoa(int(q.Limit)))
+	values.Set("cursor", cursor)
+	return fmt.Sprintf("<%s?%s>; rel=\"next\"", path, values.Encode())
+}
+
+// likePattern turns a free-text search into an ILIKE substring pattern,
+// escaping the LIKE wildcards so they match literally. Empty input yields ""
+// (no filter).
+func likePattern(search string) string {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(search) + "%"
+}
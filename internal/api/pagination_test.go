@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// TestPageCursor_RoundTrip verifies cursors survive encoding and pin their sort.
+func TestPageCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	q := pageQuery{SortBy: SortByCreatedAt, Desc: true, Limit: 10}
+	encoded := q.Cursor(42, "Climate Team", "member", createdAt)
+
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Errorf("cursor should be URL-safe, got %q", encoded)
+	}
+
+	next, err := newPageQuery(PageParams{Cursor: encoded, Limit: 10}, SortByCreatedAt)
+	if err != nil {
+		t.Fatalf("newPageQuery: %v", err)
+	}
+	if next.After.ID != 42 || next.After.Name != "Climate Team" || next.After.Role != "member" {
+		t.Errorf("unexpected cursor: %+v", next.After)
+	}
+	if !next.AfterCreatedAt().Time.Equal(createdAt) || !next.AfterCreatedAt().Valid {
+		t.Errorf("created_at not preserved: %v", next.AfterCreatedAt())
+	}
+}
+
+// TestNewPageQuery_Defaults verifies limit clamping and default directions.
+func TestNewPageQuery_Defaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    PageParams
+		sortBy    string
+		wantDesc  bool
+		wantLimit int32
+	}{
+		{"name defaults to asc", PageParams{}, SortByName, false, DefaultPageLimit},
+		{"created_at defaults to desc", PageParams{}, SortByCreatedAt, true, DefaultPageLimit},
+		{"explicit order wins", PageParams{Order: "asc"}, SortByCreatedAt, false, DefaultPageLimit},
+		{"limit clamped", PageParams{Limit: 1000}, SortByRole, false, MaxPageLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := newPageQuery(tt.params, tt.sortBy)
+			if err != nil {
+				t.Fatalf("newPageQuery: %v", err)
+			}
+			if q.Desc != tt.wantDesc || q.Limit != tt.wantLimit {
+				t.Errorf("got desc=%v limit=%d, want desc=%v limit=%d", q.Desc, q.Limit, tt.wantDesc, tt.wantLimit)
+			}
+			if q.FetchLimit() != q.Limit+1 {
+				t.Errorf("FetchLimit should request one look-ahead row")
+			}
+			if q.After.ID != 0 || q.AfterCreatedAt().Valid {
+				t.Errorf("first page should have no cursor position")
+			}
+		})
+	}
+}
+
+// TestNewPageQuery_RejectsBadCursor verifies malformed and mismatched cursors are 400s.
+func TestNewPageQuery_RejectsBadCursor(t *testing.T) {
+	byName := pageQuery{SortBy: SortByName}.Cursor(1, "a", "", time.Time{})
+
+	tests := []struct {
+		name   string
+		params PageParams
+		sortBy string
+	}{
+		{"not base64", PageParams{Cursor: "!!!"}, SortByName},
+		{"not json", PageParams{Cursor: "bm90LWpzb24"}, SortByName},
+		{"no position", PageParams{Cursor: encodeCursor(pageCursor{Sort: SortByName})}, SortByName},
+		{"different sort", PageParams{Cursor: byName}, SortByCreatedAt},
+		{"different order", PageParams{Cursor: byName, Order: "desc"}, SortByName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPageQuery(tt.params, tt.sortBy)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			statusErr, ok := err.(huma.StatusError)
+			if !ok || statusErr.GetStatus() != http.StatusBadRequest {
+				t.Errorf("expected 400 error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestTrimPage verifies the look-ahead row is dropped and reported.
+func TestTrimPage(t *testing.T) {
+	q := pageQuery{Limit: 2}
+
+	rows, hasMore := trimPage([]int{1, 2, 3}, q)
+	if !hasMore || len(rows) != 2 {
+		t.Errorf("expected 2 rows and more, got %v %v", rows, hasMore)
+	}
+
+	rows, hasMore = trimPage([]int{1, 2}, q)
+	if hasMore || len(rows) != 2 {
+		t.Errorf("expected 2 rows and no more, got %v %v", rows, hasMore)
+	}
+}
+
+// TestNextPageLink verifies the Link header carries filters and paging state.
+func TestNextPageLink(t *testing.T) {
+	q := pageQuery{SortBy: SortByName, Limit: 25}
+	link := nextPageLink("/api/v1/groups/7/memberships", url.Values{"status": {"active"}}, q, "abc")
+
+	want := `</api/v1/groups/7/memberships?cursor=abc&limit=25&order=asc&sort=name&status=active>; rel="next"`
+	if link != want {
+		t.Errorf("got %s, want %s", link, want)
+	}
+}
+
+// TestLikePattern verifies search input is escaped for ILIKE.
+func TestLikePattern(t *testing.T) {
+	tests := map[string]string{
+		"":         "",
+		"  ":       "",
+		"ann":      "%ann%",
+		"50%_off":  `%50\%\_off%`,
+		`back\sl`:  `%back\\sl%`,
+		" padded ": "%padded%",
+	}
+	for in, want := range tests {
+		if got := likePattern(in); got != want {
+			t.Errorf("likePattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
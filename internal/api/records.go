@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"maps"
+	"mime"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Records format: the response shape of Loomio's v1 API, which the Loomio Vue
+// client expects. Instead of the {group: GroupDTO} wrappers, every record
+// type gets a top-level array keyed by its Loomio root (groups, memberships,
+// users, notifications), related records are sideloaded into their own
+// arrays and referenced by <name>_id, and each record appears once:
+//
+//	{"groups": [...], "memberships": [...], "users": [...], "meta": {"root": "memberships"}}
+//
+// meta.root names the primary records; other body fields, e.g. next_cursor,
+// are moved into meta. Clients opt in per request with ?format=records or
+// Accept: application/vnd.loomio+json. Error responses and bodies without
+// records are left unchanged.
+const (
+	RecordsFormatParam = "format"
+	RecordsFormatValue = "records"
+	RecordsMediaType   = "application/vnd.loomio+json"
+)
+
+// recordRoots maps each DTO that serializes to Loomio records to the root
+// key of its primary record.
+var recordRoots = map[reflect.Type]string{
+	reflect.TypeFor[GroupDTO]():         "groups",
+	reflect.TypeFor[GroupDetailDTO]():   "groups",
+	reflect.TypeFor[GroupTreeNodeDTO](): "groups",
+	reflect.TypeFor[MembershipDTO]():    "memberships",
+	reflect.TypeFor[InvitationDTO]():    "memberships",
+	reflect.TypeFor[UserDTO]():          "users",
+	reflect.TypeFor[UserSummaryDTO]():   "users",
+	reflect.TypeFor[NotificationDTO]():  "notifications",
+}
+
+// UseRecordsFormat enables the records format on an API created from config:
+// it registers the Loomio media type and installs RecordsTransformer ahead of
+// the default transformers. Call it before passing config to humago.New.
+func UseRecordsFormat(config *huma.Config) {
+	formats := maps.Clone(config.Formats)
+	formats[RecordsMediaType] = huma.DefaultJSONFormat
+	config.Formats = formats
+	config.Transformers = append([]huma.Transformer{RecordsTransformer}, config.Transformers...)
+}
+
+// responseFormatKey is the context key of the format a request selected.
+type responseFormatKey struct{}
+
+// RecordsFormatMiddleware stores the format the request selected in its
+// context, for handlers whose entity tags must differ per format, and adds
+// Vary: Accept since the format can be chosen by header. Install it with
+// huma.API.UseMiddleware on APIs using UseRecordsFormat.
+func RecordsFormatMiddleware(ctx huma.Context, next func(huma.Context)) {
+	ctx.AppendHeader("Vary", "Accept")
+	format := "json"
+	if wantsRecords(ctx) {
+		format = RecordsFormatValue
+	}
+	next(huma.WithValue(ctx, responseFormatKey{}, format))
+}
+
+// responseFormat returns the format stored by RecordsFormatMiddleware, or
+// "json" on an API without it.
+func responseFormat(ctx context.Context) string {
+	if format, ok := ctx.Value(responseFormatKey{}).(string); ok {
+		return format
+	}
+	return "json"
+}
+
+// RecordsTransformer rewrites successful response bodies into the records
+// format when the request asks for it.
+func RecordsTransformer(ctx huma.Context, status string, v any) (any, error) {
+	if !strings.HasPrefix(status, "2") || !wantsRecords(ctx) {
+		return v, nil
+	}
+	var userID int64
+	if principal, ok := PrincipalFromContext(ctx.Context()); ok {
+		userID = principal.UserID
+	}
+	if records, ok := toRecords(v, userID); ok {
+		return records, nil
+	}
+	return v, nil
+}
+
+// wantsRecords reports whether the request selected the records format.
+func wantsRecords(ctx huma.Context) bool {
+	if ctx.Query(RecordsFormatParam) == RecordsFormatValue {
+		return true
+	}
+	for _, accepted := range strings.Split(ctx.Header("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && mediaType == RecordsMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// toRecords converts a response body to the records format. userID is the
+// requesting user, who pending invitations belong to. It returns false when
+// the body holds no records.
+func toRecords(body any, userID int64) (map[string]any, bool) {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	set := newRecordSet(userID)
+	meta := map[string]any{}
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, omitEmpty, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		value := v.FieldByIndex(field.Index)
+
+		root, isRecord := recordRoots[recordElemType(field.Type)]
+		if !isRecord {
+			if !(omitEmpty && value.IsZero()) {
+				meta[name] = value.Interface()
+			}
+			continue
+		}
+		set.ensureRoot(root)
+		if _, ok := meta["root"]; !ok {
+			meta["root"] = root
+		}
+		set.addValue(value)
+	}
+	if _, ok := meta["root"]; !ok {
+		return nil, false
+	}
+
+	out := set.roots()
+	out["meta"] = meta
+	return out, true
+}
+
+// recordElemType strips pointers and slices from a field type.
+func recordElemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonFieldName returns the JSON name of a struct field and whether it is
+// omitted when empty; ok is false for fields excluded from JSON.
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,"), true
+}
+
+// recordSet collects records by root key, deduplicated by ID.
+type recordSet struct {
+	userID int64
+	order  []string
+	byRoot map[string][]map[string]any
+	byID   map[string]map[int64]map[string]any
+}
+
+func newRecordSet(userID int64) *recordSet {
+	return &recordSet{
+		userID: userID,
+		byRoot: map[string][]map[string]any{},
+		byID:   map[string]map[int64]map[string]any{},
+	}
+}
+
+// ensureRoot makes root appear in the output even if it ends up empty, so
+// an empty list still has its array.
+func (s *recordSet) ensureRoot(root string) {
+	if _, ok := s.byID[root]; ok {
+		return
+	}
+	s.order = append(s.order, root)
+	s.byRoot[root] = []map[string]any{}
+	s.byID[root] = map[int64]map[string]any{}
+}
+
+// put adds a record under root. A record seen before keeps its values and
+// gains only the attributes it lacked, so a group sideloaded from a
+// membership does not overwrite the detailed group of the same response.
+func (s *recordSet) put(root string, id int64, record map[string]any) {
+	s.ensureRoot(root)
+	if existing, ok := s.byID[root][id]; ok {
+		for k, v := range record {
+			if _, ok := existing[k]; !ok {
+				existing[k] = v
+			}
+		}
+		return
+	}
+	record["id"] = id
+	s.byID[root][id] = record
+	s.byRoot[root] = append(s.byRoot[root], record)
+}
+
+// roots returns the collected records keyed by root.
+func (s *recordSet) roots() map[string]any {
+	out := make(map[string]any, len(s.order)+1)
+	for _, root := range s.order {
+		out[root] = s.byRoot[root]
+	}
+	return out
+}
+
+// addValue adds the records in v, which may be a DTO, a pointer to one or a
+// slice of either.
+func (s *recordSet) addValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			s.addValue(v.Elem())
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			s.addValue(v.Index(i))
+		}
+	default:
+		s.add(v.Interface())
+	}
+}
+
+// add adds a single DTO and the records it embeds.
+func (s *recordSet) add(dto any) {
+	switch d := dto.(type) {
+	case GroupDTO:
+		s.put("groups", d.ID, groupRecord(d))
+	case GroupDetailDTO:
+		s.put("groups", d.ID, groupDetailRecord(d))
+	case GroupTreeNodeDTO:
+		s.put("groups", d.ID, groupRecord(d.GroupDTO))
+		for _, child := range d.Children {
+			if child != nil {
+				s.add(*child)
+			}
+		}
+	case MembershipDTO:
+		record := map[string]any{
+			"group_id":    d.GroupID,
+			"user_id":     d.UserID,
+			"admin":       d.Role == "admin",
+			"accepted_at": d.AcceptedAt,
+			"created_at":  d.CreatedAt,
+		}
+		if d.User != nil {
+			s.add(*d.User)
+		}
+		if d.Inviter != nil {
+			record["inviter_id"] = d.Inviter.ID
+			s.add(*d.Inviter)
+		}
+		s.put("memberships", d.ID, record)
+	case InvitationDTO:
+		// An invitation is a pending membership of the requesting user
+		record := map[string]any{
+			"group_id":    d.Group.ID,
+			"inviter_id":  d.Inviter.ID,
+			"admin":       d.Role == "admin",
+			"accepted_at": nil,
+			"created_at":  d.CreatedAt,
+		}
+		if s.userID != 0 {
+			record["user_id"] = s.userID
+		}
+		s.put("memberships", d.ID, record)
+		// The invitation's group carries the membership's created_at
+		group := groupRecord(d.Group)
+		delete(group, "created_at")
+		s.put("groups", d.Group.ID, group)
+		s.add(d.Inviter)
+	case UserSummaryDTO:
+		s.put("users", d.ID, userSummaryRecord(d))
+	case UserDTO:
+		record := userSummaryRecord(UserSummaryDTO{ID: d.ID, Name: d.Name, Username: d.Username})
+		record["email"] = d.Email
+		record["email_verified"] = d.EmailVerified
+		record["created_at"] = d.CreatedAt
+		s.put("users", d.ID, record)
+	case NotificationDTO:
+		s.put("notifications", d.ID, map[string]any{
+			"kind":       d.Kind,
+			"viewed":     d.ReadAt != nil,
+			"created_at": d.CreatedAt,
+		})
+	}
+}
+
+// groupRecord maps a group to Loomio's group attributes.
+func groupRecord(g GroupDTO) map[string]any {
+	return map[string]any{
+		"name":        g.Name,
+		"handle":      g.Handle,
+		"description": g.Description,
+		"parent_id":   g.ParentID,
+		"archived_at": g.ArchivedAt,
+		"created_at":  g.CreatedAt,
+	}
+}
+
+// groupDetailRecord adds the permission flags and counts of a detailed
+// group. The caller's role has no group attribute in Loomio, which conveys
+// it through the caller's membership record instead.
+func groupDetailRecord(g GroupDetailDTO) map[string]any {
+	record := groupRecord(g.GroupDTO)
+	record["members_can_add_members"] = g.MembersCanAddMembers
+	record["members_can_add_guests"] = g.MembersCanAddGuests
+	record["members_can_start_discussions"] = g.MembersCanStartDiscussions
+	record["members_can_raise_motions"] = g.MembersCanRaiseMotions
+	record["members_can_edit_discussions"] = g.MembersCanEditDiscussions
+	record["members_can_edit_comments"] = g.MembersCanEditComments
+	record["members_can_delete_comments"] = g.MembersCanDeleteComments
+	record["members_can_announce"] = g.MembersCanAnnounce
+	record["members_can_create_subgroups"] = g.MembersCanCreateSubgroups
+	record["admins_can_edit_user_content"] = g.AdminsCanEditUserContent
+	record["parent_members_can_see_discussions"] = g.ParentMembersCanSeeDiscussions
+	record["memberships_count"] = g.MemberCount
+	record["admin_memberships_count"] = g.AdminCount
+	return record
+}
+
+// userSummaryRecord maps a user to Loomio's author attributes. Users have no
+// uploaded avatars, so the client draws their initials.
+func userSummaryRecord(u UserSummaryDTO) map[string]any {
+	return map[string]any{
+		"name":            u.Name,
+		"username":        u.Username,
+		"avatar_initials": avatarInitials(u.Name),
+		"avatar_kind":     "initials",
+	}
+}
+
+// avatarInitials returns the upper-cased first letters of up to three words
+// of name, as Loomio does.
+func avatarInitials(name string) string {
+	var b strings.Builder
+	for i, word := range strings.Fields(name) {
+		if i == 3 {
+			break
+		}
+		r, _ := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,443 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+)
+
+// loomioSchemaDir holds the serializer schemas extracted from Loomio.
+const loomioSchemaDir = "../../discovery/schemas/response_schemas"
+
+// loomioAttribute is an attribute in a discovery serializer schema.
+type loomioAttribute struct {
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// loomioAttributes returns the attributes a root may carry according to the
+// discovery schemas: the serializers' attributes, including inherited ones,
+// plus a <name>_id key for each has_one relationship.
+func loomioAttributes(t *testing.T) map[string]map[string]loomioAttribute {
+	t.Helper()
+
+	load := func(file string) map[string]loomioAttribute {
+		data, err := os.ReadFile(filepath.Join(loomioSchemaDir, file))
+		if err != nil {
+			t.Fatalf("read schema: %v", err)
+		}
+		var schema struct {
+			Attributes    map[string]loomioAttribute `json:"attributes"`
+			Relationships map[string]struct {
+				Type string `json:"type"`
+				Key  string `json:"key"`
+			} `json:"relationships"`
+		}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("parse %s: %v", file, err)
+		}
+		attrs := schema.Attributes
+		for name, rel := range schema.Relationships {
+			if rel.Type != "has_one" {
+				continue
+			}
+			key := rel.Key
+			if key == "" {
+				key = name + "_id"
+			}
+			if _, ok := attrs[key]; !ok {
+				attrs[key] = loomioAttribute{Type: "integer", Nullable: true}
+			}
+		}
+		return attrs
+	}
+
+	users := load("author.json")
+	for name, attr := range load("user.json") {
+		users[name] = attr
+	}
+	return map[string]map[string]loomioAttribute{
+		"groups":        load("group.json"),
+		"memberships":   load("membership.json"),
+		"users":         users,
+		"notifications": load("notification.json"),
+	}
+}
+
+// checkLoomioRecords validates a records response against the discovery
+// schemas: every record has an ID, and every attribute is one the Loomio
+// serializer emits, with a matching type.
+func checkLoomioRecords(t *testing.T, body []byte) map[string][]map[string]any {
+	t.Helper()
+	schemas := loomioAttributes(t)
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatalf("parse response: %v", err)
+	}
+	var meta struct {
+		Root string `json:"root"`
+	}
+	if err := json.Unmarshal(raw["meta"], &meta); err != nil || meta.Root == "" {
+		t.Fatalf("expected meta.root in %s", body)
+	}
+
+	out := map[string][]map[string]any{}
+	for root, data := range raw {
+		if root == "meta" {
+			continue
+		}
+		attrs, ok := schemas[root]
+		if !ok {
+			t.Errorf("unexpected root %q", root)
+			continue
+		}
+		var records []map[string]any
+		if err := json.Unmarshal(data, &records); err != nil {
+			t.Fatalf("root %q is not a record array: %v", root, err)
+		}
+		seen := map[float64]bool{}
+		for _, record := range records {
+			id, ok := record["id"].(float64)
+			if !ok {
+				t.Errorf("%s record without id: %v", root, record)
+			}
+			if seen[id] {
+				t.Errorf("%s record %v appears twice", root, id)
+			}
+			seen[id] = true
+
+			for key, value := range record {
+				attr, ok := attrs[key]
+				if !ok {
+					t.Errorf("%s.%s is not a Loomio attribute", root, key)
+					continue
+				}
+				if value == nil {
+					if !attr.Nullable {
+						t.Errorf("%s.%s is null but not nullable", root, key)
+					}
+					continue
+				}
+				if !matchesLoomioType(attr.Type, value) {
+					t.Errorf("%s.%s = %#v is not of type %s", root, key, value, attr.Type)
+				}
+			}
+		}
+		out[root] = records
+	}
+	if _, ok := out[meta.Root]; !ok {
+		t.Errorf("meta.root %q has no records array", meta.Root)
+	}
+	return out
+}
+
+func matchesLoomioType(typ string, value any) bool {
+	switch typ {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "datetime":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	}
+	return false
+}
+
+// TestRecordsFormat serves existing DTOs through an API with the records
+// format enabled and validates the output against the Loomio schemas.
+func TestRecordsFormat(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	description := "About the group"
+	parentID := int64(1)
+	parent := GroupDTO{ID: 1, Name: "Parent", Handle: "parent", CreatedAt: created}
+	child := GroupDTO{ID: 2, Name: "Child", Handle: "child", Description: &description, ParentID: &parentID, CreatedAt: created}
+	alice := UserSummaryDTO{ID: 10, Name: "Alice van Dyke Smith", Username: "alice"}
+	bob := UserSummaryDTO{ID: 11, Name: "Bob", Username: "bob"}
+
+	config := huma.DefaultConfig("Test API", "1.0.0")
+	UseRecordsFormat(&config)
+	mux := http.NewServeMux()
+	api := humago.New(mux, config)
+
+	huma.Register(api, huma.Operation{OperationID: "getGroup", Method: http.MethodGet, Path: "/group"},
+		func(ctx context.Context, input *struct{}) (*GetGroupOutput, error) {
+			resp := &GetGroupOutput{}
+			resp.Body.Group = GroupDetailDTO{GroupDTO: child, MembersCanAddMembers: true, MemberCount: 2, AdminCount: 1, CurrentUserRole: "admin"}
+			return resp, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "listMemberships", Method: http.MethodGet, Path: "/memberships"},
+		func(ctx context.Context, input *struct{}) (*ListMembershipsOutput, error) {
+			resp := &ListMembershipsOutput{}
+			resp.Body.Memberships = []MembershipDTO{
+				{ID: 100, GroupID: 2, UserID: 10, Role: "admin", AcceptedAt: &created, CreatedAt: created, User: &alice},
+				{ID: 101, GroupID: 2, UserID: 11, Role: "member", CreatedAt: created, User: &bob, Inviter: &alice},
+			}
+			resp.Body.NextCursor = "abc"
+			return resp, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "listEmptyMemberships", Method: http.MethodGet, Path: "/empty"},
+		func(ctx context.Context, input *struct{}) (*ListMembershipsOutput, error) {
+			return &ListMembershipsOutput{}, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "getTree", Method: http.MethodGet, Path: "/tree"},
+		func(ctx context.Context, input *struct{}) (*GetGroupTreeOutput, error) {
+			resp := &GetGroupTreeOutput{}
+			resp.Body.Group = GroupTreeNodeDTO{GroupDTO: child, Children: []*GroupTreeNodeDTO{
+				{GroupDTO: GroupDTO{ID: 3, Name: "Grandchild", Handle: "grandchild", ParentID: &child.ID, CreatedAt: created}, Depth: 1},
+			}}
+			resp.Body.Ancestors = []GroupDTO{parent}
+			return resp, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "listNotifications", Method: http.MethodGet, Path: "/notifications"},
+		func(ctx context.Context, input *struct{}) (*ListMyNotificationsOutput, error) {
+			resp := &ListMyNotificationsOutput{}
+			resp.Body.Notifications = []NotificationDTO{
+				{ID: 1, Kind: "membership_created", Data: map[string]any{}, ReadAt: &created, CreatedAt: created},
+				{ID: 2, Kind: "membership_expiring", Data: map[string]any{}, CreatedAt: created},
+			}
+			return resp, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "getMe", Method: http.MethodGet, Path: "/me"},
+		func(ctx context.Context, input *struct{}) (*UserResponse, error) {
+			resp := &UserResponse{}
+			resp.Body.User = UserDTO{ID: 10, Email: "alice@example.com", Name: alice.Name, Username: "alice", EmailVerified: true, Key: "k", CreatedAt: created}
+			return resp, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "getStats", Method: http.MethodGet, Path: "/stats"},
+		func(ctx context.Context, input *struct{}) (*GetInvitationStatsOutput, error) {
+			return &GetInvitationStatsOutput{}, nil
+		})
+	huma.Register(api, huma.Operation{OperationID: "getMissing", Method: http.MethodGet, Path: "/missing"},
+		func(ctx context.Context, input *struct{}) (*GetGroupOutput, error) {
+			return nil, huma.Error404NotFound("Group not found")
+		})
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("default format is unchanged", func(t *testing.T) {
+		w := get("/group", "")
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("parse response: %v", err)
+		}
+		if _, ok := body["group"]; !ok {
+			t.Errorf("expected the group wrapper, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("group detail", func(t *testing.T) {
+		w := get("/group?format=records", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		records := checkLoomioRecords(t, w.Body.Bytes())
+		group := records["groups"][0]
+		if group["memberships_count"] != float64(2) || group["admin_memberships_count"] != float64(1) {
+			t.Errorf("expected member counts, got %v", group)
+		}
+		if group["parent_id"] != float64(1) || group["members_can_add_members"] != true {
+			t.Errorf("expected parent_id and flags, got %v", group)
+		}
+	})
+
+	t.Run("memberships sideload users", func(t *testing.T) {
+		w := get("/memberships", RecordsMediaType)
+		if ct := w.Header().Get("Content-Type"); ct != RecordsMediaType {
+			t.Errorf("expected Content-Type %s, got %q", RecordsMediaType, ct)
+		}
+		records := checkLoomioRecords(t, w.Body.Bytes())
+		if len(records["memberships"]) != 2 {
+			t.Errorf("expected 2 memberships, got %s", w.Body.String())
+		}
+		// Alice is both a member and Bob's inviter, but listed once
+		if len(records["users"]) != 2 {
+			t.Errorf("expected 2 users, got %v", records["users"])
+		}
+		if m := records["memberships"][1]; m["inviter_id"] != float64(10) || m["admin"] != false || m["accepted_at"] != nil {
+			t.Errorf("unexpected pending membership %v", m)
+		}
+		if u := records["users"][0]; u["avatar_initials"] != "AVD" {
+			t.Errorf("expected initials AVD, got %v", u["avatar_initials"])
+		}
+
+		var body struct {
+			Meta map[string]any `json:"meta"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Meta["root"] != "memberships" || body.Meta["next_cursor"] != "abc" {
+			t.Errorf("expected root and cursor in meta, got %v", body.Meta)
+		}
+	})
+
+	t.Run("tree and ancestors are flattened", func(t *testing.T) {
+		records := checkLoomioRecords(t, get("/tree?format=records", "").Body.Bytes())
+		if len(records["groups"]) != 3 {
+			t.Errorf("expected 3 groups, got %v", records["groups"])
+		}
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		records := checkLoomioRecords(t, get("/notifications?format=records", "").Body.Bytes())
+		if n := records["notifications"]; len(n) != 2 || n[0]["viewed"] != true || n[1]["viewed"] != false {
+			t.Errorf("unexpected notifications %v", n)
+		}
+	})
+
+	t.Run("current user", func(t *testing.T) {
+		records := checkLoomioRecords(t, get("/me?format=records", "").Body.Bytes())
+		if u := records["users"][0]; u["email"] != "alice@example.com" || u["email_verified"] != true {
+			t.Errorf("unexpected user %v", u)
+		}
+	})
+
+	t.Run("empty list keeps its root", func(t *testing.T) {
+		w := get("/empty?format=records", "")
+		if w.Body.String() != `{"memberships":[],"meta":{"root":"memberships"}}`+"\n" {
+			t.Errorf("unexpected body %s", w.Body.String())
+		}
+	})
+
+	t.Run("bodies without records are unchanged", func(t *testing.T) {
+		var body map[string]any
+		if err := json.Unmarshal(get("/stats?format=records", "").Body.Bytes(), &body); err != nil {
+			t.Fatalf("parse response: %v", err)
+		}
+		if _, ok := body["stats"]; !ok {
+			t.Errorf("expected the stats body, got %v", body)
+		}
+	})
+
+	t.Run("errors are unchanged", func(t *testing.T) {
+		w := get("/missing?format=records", "")
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", w.Code)
+		}
+		var body map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if body["detail"] != "Group not found" {
+			t.Errorf("expected the error model, got %s", w.Body.String())
+		}
+	})
+}
+
+// TestRecordsFormat_Invitations verifies pending invitations become
+// memberships of the requesting user.
+func TestRecordsFormat_Invitations(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := struct {
+		Invitations []InvitationDTO `json:"invitations"`
+	}{
+		Invitations: []InvitationDTO{{
+			ID:        5,
+			Group:     GroupDTO{ID: 2, Name: "Child", Handle: "child", CreatedAt: created},
+			Inviter:   UserSummaryDTO{ID: 10, Name: "Alice", Username: "alice"},
+			Role:      "member",
+			CreatedAt: created,
+		}},
+	}
+
+	out, ok := toRecords(body, 42)
+	if !ok {
+		t.Fatal("expected records")
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	records := checkLoomioRecords(t, data)
+	m := records["memberships"][0]
+	if m["user_id"] != float64(42) || m["group_id"] != float64(2) || m["inviter_id"] != float64(10) {
+		t.Errorf("unexpected invitation membership %v", m)
+	}
+	if _, ok := records["groups"][0]["created_at"]; ok {
+		t.Error("the invitation's group should not carry the membership's created_at")
+	}
+}
+
+// TestRecordsFormatMiddleware verifies the selected format reaches handlers
+// and responses vary on Accept.
+func TestRecordsFormatMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	api.UseMiddleware(RecordsFormatMiddleware)
+
+	type formatOutput struct {
+		Body struct {
+			Format string `json:"format"`
+		}
+	}
+	huma.Register(api, huma.Operation{OperationID: "getFormat", Method: http.MethodGet, Path: "/format"},
+		func(ctx context.Context, input *struct{}) (*formatOutput, error) {
+			resp := &formatOutput{}
+			resp.Body.Format = responseFormat(ctx)
+			return resp, nil
+		})
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   string
+	}{
+		{"default", "/format", "", "json"},
+		{"query", "/format?format=records", "", RecordsFormatValue},
+		{"accept", "/format", RecordsMediaType, RecordsFormatValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if vary := w.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", vary)
+			}
+			var body struct {
+				Format string `json:"format"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("parse response: %v", err)
+			}
+			if body.Format != tt.want {
+				t.Errorf("expected format %q, got %q", tt.want, body.Format)
+			}
+		})
+	}
+}
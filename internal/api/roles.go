@@ -0,0 +1,511 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// RoleHandler handles custom group role HTTP requests.
+type RoleHandler struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewRoleHandler creates a new role handler.
+func NewRoleHandler(pool *pgxpool.Pool, queries *db.Queries) *RoleHandler {
+	return &RoleHandler{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// RegisterRoutes registers all custom role routes.
+func (h *RoleHandler) RegisterRoutes(api huma.API) {
+	// List custom roles in a group
+	huma.Register(api, huma.Operation{
+		OperationID: "listGroupRoles",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/groups/{groupId}/roles",
+		Summary:     "List custom roles",
+		Description: "Returns the custom roles defined in a group. Requires membership in the group.",
+		Tags:        []string{"Roles"},
+		Security:    SessionSecurity,
+	}, h.handleListGroupRoles)
+
+	// Create a custom role
+	huma.Register(api, huma.Operation{
+		OperationID:   "createGroupRole",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/groups/{groupId}/roles",
+		Summary:       "Create custom role",
+		Description:   "Defines a named set of permissions that can be assigned to members. Requires admin role.",
+		Tags:          []string{"Roles"},
+		DefaultStatus: http.StatusCreated,
+		Security:      SessionSecurity,
+	}, h.handleCreateGroupRole)
+
+	// Update a custom role
+	huma.Register(api, huma.Operation{
+		OperationID: "updateGroupRole",
+		Method:      http.MethodPatch,
+		Path:        "/api/v1/groups/{groupId}/roles/{roleId}",
+		Summary:     "Update custom role",
+		Description: "Renames a custom role or replaces its permissions. Requires admin role.",
+		Tags:        []string{"Roles"},
+		Security:    SessionSecurity,
+	}, h.handleUpdateGroupRole)
+
+	// Delete a custom role
+	huma.Register(api, huma.Operation{
+		OperationID:   "deleteGroupRole",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/groups/{groupId}/roles/{roleId}",
+		Summary:       "Delete custom role",
+		Description:   "Deletes a custom role. Members holding it keep the plain member role. Requires admin role.",
+		Tags:          []string{"Roles"},
+		DefaultStatus: http.StatusNoContent,
+		Security:      SessionSecurity,
+	}, h.handleDeleteGroupRole)
+
+	// Assign or clear a membership's custom role
+	huma.Register(api, huma.Operation{
+		OperationID: "assignGroupRole",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/memberships/{id}/group-role",
+		Summary:     "Assign custom role",
+		Description: "Assigns a custom role to a member, or clears it when group_role_id is null. Only member-role memberships can hold a custom role. Requires admin role.",
+		Tags:        []string{"Roles"},
+		Security:    SessionSecurity,
+	}, h.handleAssignGroupRole)
+}
+
+// validatePermissions checks every permission name against AllPermissions.
+// Duplicates are dropped so the stored array stays canonical.
+func validatePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool, len(permissions))
+	result := make([]string, 0, len(permissions))
+	for i, p := range permissions {
+		if !Permission(p).Valid() {
+			return nil, huma.Error422UnprocessableEntity("Unknown permission",
+				&huma.ErrorDetail{
+					Location: fmt.Sprintf("body.permissions[%d]", i),
+					Message:  "Unknown permission",
+					Value:    p,
+				})
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// validateRoleName trims the name and rejects the built-in role names.
+func validateRoleName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", huma.Error422UnprocessableEntity("Name is required",
+			&huma.ErrorDetail{
+				Location: "body.name",
+				Message:  "Name is required",
+			})
+	}
+	if Role(strings.ToLower(name)).Valid() {
+		return "", huma.Error422UnprocessableEntity("Name is reserved for a built-in role",
+			&huma.ErrorDetail{
+				Location: "body.name",
+				Message:  "Name is reserved for a built-in role",
+				Value:    name,
+			})
+	}
+	return name, nil
+}
+
+// authorizeRoleManagement authenticates the request and checks the user can
+// manage roles in a non-archived group.
+func (h *RoleHandler) authorizeRoleManagement(ctx context.Context, groupID int64) (*AuthorizationContext, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, groupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanManageRoles() {
+		return nil, huma.Error403Forbidden("Only admins can manage roles")
+	}
+
+	if authCtx.Group.ArchivedAt.Valid {
+		return nil, huma.Error409Conflict("Cannot manage roles in an archived group")
+	}
+
+	return authCtx, nil
+}
+
+// getGroupRole loads a role and checks it belongs to the group in the path.
+func (h *RoleHandler) getGroupRole(ctx context.Context, groupID, roleID int64) (*db.GroupRole, error) {
+	role, err := h.queries.GetGroupRoleByID(ctx, roleID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Role not found")
+		}
+		LogDBError(ctx, "GetGroupRoleByID", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+	if role.GroupID != groupID {
+		return nil, huma.Error404NotFound("Role not found")
+	}
+	return role, nil
+}
+
+// ============================================================
+// GET /api/v1/groups/{groupId}/roles - List custom roles
+// ============================================================
+
+// ListGroupRolesInput is the request for listing custom roles.
+type ListGroupRolesInput struct {
+	GroupID int64 `path:"groupId" doc:"Group ID"`
+}
+
+// ListGroupRolesOutput is the response for listing custom roles.
+type ListGroupRolesOutput struct {
+	Body struct {
+		Roles []GroupRoleDTO `json:"roles"`
+	}
+}
+
+func (h *RoleHandler) handleListGroupRoles(ctx context.Context, input *ListGroupRolesInput) (*ListGroupRolesOutput, error) {
+	// Authenticate
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	// Authorize: user must be able to view the group
+	authCtx, err := NewAuthorizationContext(ctx, h.queries, principal.UserID, input.GroupID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Group not found")
+		}
+		LogDBError(ctx, "NewAuthorizationContext", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	if !authCtx.CanViewGroup() {
+		return nil, huma.Error403Forbidden("Not a member of this group")
+	}
+
+	roles, err := h.queries.ListGroupRolesByGroup(ctx, input.GroupID)
+	if err != nil {
+		LogDBError(ctx, "ListGroupRolesByGroup", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &ListGroupRolesOutput{}
+	output.Body.Roles = make([]GroupRoleDTO, len(roles))
+	for i, r := range roles {
+		output.Body.Roles[i] = GroupRoleDTOFromGroupRole(r)
+	}
+	return output, nil
+}
+
+// ============================================================
+// POST /api/v1/groups/{groupId}/roles - Create custom role
+// ============================================================
+
+// CreateGroupRoleInput is the request for creating a custom role.
+type CreateGroupRoleInput struct {
+	GroupID int64 `path:"groupId" doc:"Group ID"`
+	Body    struct {
+		Name        string   `json:"name" required:"true" minLength:"1" maxLength:"50" doc:"Role name, unique within the group (e.g. moderator)"`
+		Permissions []string `json:"permissions" required:"true" doc:"Permissions granted on top of the member role: update_group, invite_members, add_guests, create_subgroups, manage_comments, manage_polls"`
+	}
+}
+
+// CreateGroupRoleOutput is the response for creating a custom role.
+type CreateGroupRoleOutput struct {
+	Body struct {
+		Role GroupRoleDTO `json:"role"`
+	}
+}
+
+func (h *RoleHandler) handleCreateGroupRole(ctx context.Context, input *CreateGroupRoleInput) (*CreateGroupRoleOutput, error) {
+	authCtx, err := h.authorizeRoleManagement(ctx, input.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateRoleName(input.Body.Name)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := validatePermissions(input.Body.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	var role *db.GroupRole
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, authCtx.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		var createErr error
+		role, createErr = h.queries.WithTx(tx).CreateGroupRole(ctx, db.CreateGroupRoleParams{
+			GroupID:     input.GroupID,
+			Name:        name,
+			Permissions: permissions,
+			CreatedByID: pgtype.Int8{Int64: authCtx.UserID, Valid: true},
+		})
+		if createErr != nil {
+			if isUniqueViolation(createErr, "group_roles_unique_name") {
+				return huma.Error409Conflict("A role with this name already exists")
+			}
+			return fmt.Errorf("CreateGroupRole: %w", createErr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "CreateGroupRole", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &CreateGroupRoleOutput{}
+	output.Body.Role = GroupRoleDTOFromGroupRole(role)
+	return output, nil
+}
+
+// ============================================================
+// PATCH /api/v1/groups/{groupId}/roles/{roleId} - Update custom role
+// ============================================================
+
+// UpdateGroupRoleInput is the request for updating a custom role.
+type UpdateGroupRoleInput struct {
+	GroupID int64 `path:"groupId" doc:"Group ID"`
+	RoleID  int64 `path:"roleId" doc:"Role ID"`
+	Body    struct {
+		Name        *string  `json:"name,omitempty" minLength:"1" maxLength:"50" doc:"New role name"`
+		Permissions []string `json:"permissions,omitempty" doc:"Replacement permission list"`
+	}
+}
+
+// UpdateGroupRoleOutput is the response for updating a custom role.
+type UpdateGroupRoleOutput struct {
+	Body struct {
+		Role GroupRoleDTO `json:"role"`
+	}
+}
+
+func (h *RoleHandler) handleUpdateGroupRole(ctx context.Context, input *UpdateGroupRoleInput) (*UpdateGroupRoleOutput, error) {
+	authCtx, err := h.authorizeRoleManagement(ctx, input.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.getGroupRole(ctx, input.GroupID, input.RoleID); err != nil {
+		return nil, err
+	}
+
+	updateParams := db.UpdateGroupRoleParams{ID: input.RoleID}
+	if input.Body.Name != nil {
+		name, err := validateRoleName(*input.Body.Name)
+		if err != nil {
+			return nil, err
+		}
+		updateParams.Name = pgtype.Text{String: name, Valid: true}
+	}
+	if input.Body.Permissions != nil {
+		updateParams.Permissions, err = validatePermissions(input.Body.Permissions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var role *db.GroupRole
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, authCtx.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		var updateErr error
+		role, updateErr = h.queries.WithTx(tx).UpdateGroupRole(ctx, updateParams)
+		if updateErr != nil {
+			if isUniqueViolation(updateErr, "group_roles_unique_name") {
+				return huma.Error409Conflict("A role with this name already exists")
+			}
+			return fmt.Errorf("UpdateGroupRole: %w", updateErr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "UpdateGroupRole", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &UpdateGroupRoleOutput{}
+	output.Body.Role = GroupRoleDTOFromGroupRole(role)
+	return output, nil
+}
+
+// ============================================================
+// DELETE /api/v1/groups/{groupId}/roles/{roleId} - Delete custom role
+// ============================================================
+
+// DeleteGroupRoleInput is the request for deleting a custom role.
+type DeleteGroupRoleInput struct {
+	GroupID int64 `path:"groupId" doc:"Group ID"`
+	RoleID  int64 `path:"roleId" doc:"Role ID"`
+}
+
+// DeleteGroupRoleOutput is an empty response for role deletion.
+type DeleteGroupRoleOutput struct{}
+
+func (h *RoleHandler) handleDeleteGroupRole(ctx context.Context, input *DeleteGroupRoleInput) (*DeleteGroupRoleOutput, error) {
+	authCtx, err := h.authorizeRoleManagement(ctx, input.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.getGroupRole(ctx, input.GroupID, input.RoleID); err != nil {
+		return nil, err
+	}
+
+	// Memberships holding the role are unassigned by ON DELETE SET NULL
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, authCtx.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		if deleteErr := h.queries.WithTx(tx).DeleteGroupRole(ctx, input.RoleID); deleteErr != nil {
+			return fmt.Errorf("DeleteGroupRole: %w", deleteErr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		LogDBError(ctx, "DeleteGroupRole", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	return &DeleteGroupRoleOutput{}, nil
+}
+
+// ============================================================
+// PUT /api/v1/memberships/{id}/group-role - Assign custom role
+// ============================================================
+
+// AssignGroupRoleInput is the request for assigning a custom role to a membership.
+type AssignGroupRoleInput struct {
+	conditional.Params
+	MembershipID int64 `path:"id" doc:"Membership ID"`
+	Body         struct {
+		GroupRoleID *int64 `json:"group_role_id" required:"false" nullable:"true" doc:"Custom role to assign, or null to clear"`
+	}
+}
+
+// AssignGroupRoleOutput is the response for assigning a custom role.
+type AssignGroupRoleOutput struct {
+	ETag string `header:"ETag" doc:"Entity tag of the updated membership"`
+	Body struct {
+		Membership MembershipDTO `json:"membership"`
+	}
+}
+
+func (h *RoleHandler) handleAssignGroupRole(ctx context.Context, input *AssignGroupRoleInput) (*AssignGroupRoleOutput, error) {
+	// Authenticate before revealing whether the membership exists
+	if _, ok := PrincipalFromContext(ctx); !ok {
+		return nil, huma.Error401Unauthorized("Not authenticated")
+	}
+
+	membership, err := h.queries.GetMembershipByID(ctx, input.MembershipID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, huma.Error404NotFound("Membership not found")
+		}
+		LogDBError(ctx, "GetMembershipByID", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	authCtx, err := h.authorizeRoleManagement(ctx, membership.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupRoleID pgtype.Int8
+	if input.Body.GroupRoleID != nil {
+		// Admins already hold every permission and guests are scoped by grants
+		if Role(membership.Role) != RoleMember {
+			return nil, huma.Error409Conflict("Custom roles can only be assigned to members")
+		}
+		if _, err := h.getGroupRole(ctx, membership.GroupID, *input.Body.GroupRoleID); err != nil {
+			return nil, err
+		}
+		groupRoleID = pgtype.Int8{Int64: *input.Body.GroupRoleID, Valid: true}
+	}
+
+	var updated *db.Membership
+	var versions membershipVersions
+	err = pgx.BeginTxFunc(ctx, h.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if auditErr := db.SetAuditContext(ctx, tx, authCtx.UserID); auditErr != nil {
+			return fmt.Errorf("SetAuditContext: %w", auditErr)
+		}
+
+		txQueries := h.queries.WithTx(tx)
+
+		// If-Match: refuse to overwrite changes the client has not seen
+		if condErr := checkMembershipPrecondition(ctx, txQueries, &input.Params, input.MembershipID, authCtx.Group); condErr != nil {
+			return condErr
+		}
+
+		var assignErr error
+		updated, assignErr = txQueries.AssignGroupRole(ctx, db.AssignGroupRoleParams{
+			ID:          input.MembershipID,
+			GroupRoleID: groupRoleID,
+		})
+		if assignErr != nil {
+			return fmt.Errorf("AssignGroupRole: %w", assignErr)
+		}
+		var versionsErr error
+		versions, versionsErr = loadMembershipVersions(ctx, txQueries, updated)
+		return versionsErr
+	})
+
+	if err != nil {
+		if humaErr, ok := err.(huma.StatusError); ok {
+			return nil, humaErr
+		}
+		LogDBError(ctx, "AssignGroupRole", err)
+		return nil, huma.Error500InternalServerError("Database error")
+	}
+
+	output := &AssignGroupRoleOutput{ETag: quoteETag(membershipETag(updated.ID, versions, authCtx.Group, responseFormat(ctx)))}
+	output.Body.Membership = MembershipDTOFromMembership(updated)
+	return output, nil
+}
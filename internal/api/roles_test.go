@@ -0,0 +1,267 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// sendJSON sends a JSON request with the session cookie and returns the recorder.
+func (s *testMembershipsSetup) sendJSON(t *testing.T, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Buffer
+	if body != nil {
+		bodyBytes, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(bodyBytes)
+	} else {
+		reader = &bytes.Buffer{}
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "loomio_session", Value: token})
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	return w
+}
+
+// createRole creates a custom role via API and returns its ID.
+func (s *testMembershipsSetup) createRole(t *testing.T, token string, groupID int64, name string, permissions []string) int64 {
+	t.Helper()
+
+	w := s.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/groups/%d/roles", groupID), token,
+		map[string]any{"name": name, "permissions": permissions})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create role failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return int64(resp["role"].(map[string]any)["id"].(float64))
+}
+
+// TestGroupRoles_CRUD tests defining, listing, updating, and deleting custom roles.
+func TestGroupRoles_CRUD(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+	rolesPath := fmt.Sprintf("/api/v1/groups/%d/roles", groupID)
+
+	roleID := setup.createRole(t, adminToken, groupID, "moderator", []string{"manage_comments"})
+
+	tests := []struct {
+		name       string
+		token      string
+		body       map[string]any
+		wantStatus int
+	}{
+		{"duplicate name", adminToken, map[string]any{"name": "moderator", "permissions": []string{}}, http.StatusConflict},
+		{"built-in name", adminToken, map[string]any{"name": "Admin", "permissions": []string{}}, http.StatusUnprocessableEntity},
+		{"unknown permission", adminToken, map[string]any{"name": "boss", "permissions": []string{"manage_members"}}, http.StatusUnprocessableEntity},
+		{"member cannot create", memberToken, map[string]any{"name": "facilitator", "permissions": []string{"manage_polls"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setup.sendJSON(t, http.MethodPost, rolesPath, tt.token, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Members can list roles
+	w := setup.sendJSON(t, http.MethodGet, rolesPath, memberToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list roles failed: %d: %s", w.Code, w.Body.String())
+	}
+	var listResp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &listResp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if roles := listResp["roles"].([]any); len(roles) != 1 {
+		t.Errorf("expected 1 role, got %d", len(roles))
+	}
+
+	// Replace permissions; duplicates collapse
+	w = setup.sendJSON(t, http.MethodPatch, fmt.Sprintf("%s/%d", rolesPath, roleID), adminToken,
+		map[string]any{"permissions": []string{"manage_comments", "manage_polls", "manage_polls"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("update role failed: %d: %s", w.Code, w.Body.String())
+	}
+	role, err := setup.queries.GetGroupRoleByID(context.Background(), roleID)
+	if err != nil {
+		t.Fatalf("GetGroupRoleByID: %v", err)
+	}
+	if len(role.Permissions) != 2 || role.Name != "moderator" {
+		t.Errorf("unexpected role after update: %+v", role)
+	}
+
+	// Roles are scoped to their group
+	otherGroupID := setup.createTestGroup(t, adminToken, "Other Group")
+	w = setup.sendJSON(t, http.MethodDelete, fmt.Sprintf("/api/v1/groups/%d/roles/%d", otherGroupID, roleID), adminToken, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for role of another group, got %d", w.Code)
+	}
+
+	w = setup.sendJSON(t, http.MethodDelete, fmt.Sprintf("%s/%d", rolesPath, roleID), adminToken, nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestAssignGroupRole tests that an assigned custom role grants its permissions
+// and is dropped on promotion or role deletion.
+func TestAssignGroupRole(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+	ctx := context.Background()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+	roleID := setup.createRole(t, adminToken, groupID, "editor", []string{"update_group"})
+	assignPath := fmt.Sprintf("/api/v1/memberships/%d/group-role", membershipID)
+	groupPath := fmt.Sprintf("/api/v1/groups/%d", groupID)
+
+	// Plain member cannot update settings
+	w := setup.sendJSON(t, http.MethodPatch, groupPath, memberToken, map[string]any{"description": "x"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 before assignment, got %d", w.Code)
+	}
+
+	// Member cannot assign roles to themselves
+	w = setup.sendJSON(t, http.MethodPut, assignPath, memberToken, map[string]any{"group_role_id": roleID})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for self-assignment, got %d", w.Code)
+	}
+
+	w = setup.sendJSON(t, http.MethodPut, assignPath, adminToken, map[string]any{"group_role_id": roleID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("assign failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Custom role grants update_group, but not admin-only settings
+	w = setup.sendJSON(t, http.MethodPatch, groupPath, memberToken, map[string]any{"description": "edited"})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with update_group, got %d: %s", w.Code, w.Body.String())
+	}
+	w = setup.sendJSON(t, http.MethodPatch, groupPath, memberToken, map[string]any{"parent_admins_can_manage": false})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 changing parent admin rights, got %d", w.Code)
+	}
+
+	// Admin memberships cannot hold custom roles
+	adminMembership, err := setup.queries.GetMembershipByGroupAndUser(ctx, db.GetMembershipByGroupAndUserParams{
+		GroupID: groupID,
+		UserID:  adminUser.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to get admin membership: %v", err)
+	}
+	w = setup.sendJSON(t, http.MethodPut, fmt.Sprintf("/api/v1/memberships/%d/group-role", adminMembership.ID), adminToken,
+		map[string]any{"group_role_id": roleID})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 assigning to admin, got %d", w.Code)
+	}
+
+	// Promotion clears the custom role
+	w = setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/promote", membershipID), adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("promote failed: %d: %s", w.Code, w.Body.String())
+	}
+	membership, err := setup.queries.GetMembershipByID(ctx, membershipID)
+	if err != nil {
+		t.Fatalf("GetMembershipByID: %v", err)
+	}
+	if membership.GroupRoleID.Valid {
+		t.Error("promotion should clear the custom role")
+	}
+
+	// Deleting a role unassigns it but keeps the membership
+	w = setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/demote", membershipID), adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("demote failed: %d: %s", w.Code, w.Body.String())
+	}
+	setup.sendJSON(t, http.MethodPut, assignPath, adminToken, map[string]any{"group_role_id": roleID})
+	w = setup.sendJSON(t, http.MethodDelete, fmt.Sprintf("/api/v1/groups/%d/roles/%d", groupID, roleID), adminToken, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete role failed: %d: %s", w.Code, w.Body.String())
+	}
+	membership, err = setup.queries.GetMembershipByID(ctx, membershipID)
+	if err != nil {
+		t.Fatalf("membership should survive role deletion: %v", err)
+	}
+	if membership.GroupRoleID.Valid || Role(membership.Role) != RoleMember {
+		t.Errorf("expected plain member after role deletion, got %+v", membership)
+	}
+}
+
+// TestGroupRoles_LastAdminProtection verifies custom roles do not weaken
+// last-admin protection or grant member management.
+func TestGroupRoles_LastAdminProtection(t *testing.T) {
+	setup := setupMembershipsTest(t)
+	defer setup.cleanup()
+
+	adminUser := setup.createTestUser(t, "admin@example.com", "Admin User")
+	adminToken := setup.createTestSession(t, adminUser.ID)
+	memberUser := setup.createTestUser(t, "member@example.com", "Member User")
+	memberToken := setup.createTestSession(t, memberUser.ID)
+
+	groupID := setup.createTestGroup(t, adminToken, "Test Group")
+	membershipID := setup.inviteAndAccept(t, adminToken, memberToken, groupID, memberUser.ID)
+
+	permissions := make([]string, len(AllPermissions))
+	for i, p := range AllPermissions {
+		permissions[i] = p.String()
+	}
+	roleID := setup.createRole(t, adminToken, groupID, "deputy", permissions)
+	w := setup.sendJSON(t, http.MethodPut, fmt.Sprintf("/api/v1/memberships/%d/group-role", membershipID), adminToken,
+		map[string]any{"group_role_id": roleID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("assign failed: %d: %s", w.Code, w.Body.String())
+	}
+
+	adminMembership, err := setup.queries.GetMembershipByGroupAndUser(context.Background(), db.GetMembershipByGroupAndUserParams{
+		GroupID: groupID,
+		UserID:  adminUser.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to get admin membership: %v", err)
+	}
+
+	// A fully-permissioned custom role still cannot touch the admin
+	w = setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/demote", adminMembership.ID), memberToken, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for custom role demoting admin, got %d", w.Code)
+	}
+
+	// The only admin still cannot be demoted or removed
+	w = setup.sendJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/memberships/%d/demote", adminMembership.ID), adminToken, nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 demoting last admin, got %d: %s", w.Code, w.Body.String())
+	}
+	w = setup.sendJSON(t, http.MethodDelete, fmt.Sprintf("/api/v1/memberships/%d", adminMembership.ID), adminToken, nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 removing last admin, got %d: %s", w.Code, w.Body.String())
+	}
+}
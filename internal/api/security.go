@@ -0,0 +1,242 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/zacaytion/llmio/internal/config"
+)
+
+const (
+	// CSRFCookieName is the cookie holding the CSRF token. It is readable by
+	// scripts so a same-origin app can copy it into CSRFHeader.
+	CSRFCookieName = "loomio_csrf"
+	// CSRFHeader carries the CSRF token: the server sends it with every
+	// response, and cookie-authenticated writes must send it back.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// docsPath is where Huma serves the API reference page by default.
+const docsPath = "/docs"
+
+// docsContentSecurityPolicy lets the API reference page load its viewer from
+// unpkg.com and fetch the spec.
+const docsContentSecurityPolicy = "default-src 'none'; script-src https://unpkg.com; " +
+	"style-src 'unsafe-inline' https://unpkg.com; font-src data: https:; img-src data: https:; " +
+	"connect-src 'self'; frame-ancestors 'none'"
+
+// corsAllowedHeaders are the request headers clients of the API send.
+var corsAllowedHeaders = strings.Join([]string{
+	"Accept", "Authorization", "Content-Type", CSRFHeader, IdempotencyKeyHeader,
+	"If-Match", "If-None-Match", RequestIDHeader,
+}, ", ")
+
+// corsExposedHeaders are the response headers cross-origin scripts may read.
+var corsExposedHeaders = strings.Join([]string{
+	"Content-Location", CSRFHeader, "ETag", IdempotentReplayedHeader, "Link", "Location", RequestIDHeader,
+}, ", ")
+
+// CORSMiddleware answers preflight requests and adds CORS headers to
+// responses for the configured origins. Requests from other origins are
+// served without them, so browsers withhold the response from the page.
+// With the "*" origin, any page may read responses, but not with the
+// session cookie.
+func CORSMiddleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" || len(cfg.AllowedOrigins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			listed := slices.Contains(cfg.AllowedOrigins, origin)
+			switch {
+			case listed:
+				h.Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			case anyOrigin:
+				h.Set("Access-Control-Allow-Origin", "*")
+			}
+			allowed := listed || anyOrigin
+
+			if !preflight {
+				if allowed {
+					h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Preflights never reach the API: it has no OPTIONS operations
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if allowed {
+				h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+				h.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// SecurityHeadersMiddleware sets the configured security headers, and
+// X-Content-Type-Options, on every response. The API reference page at
+// /docs gets a content security policy that lets it load.
+func SecurityHeadersMiddleware(cfg config.SecurityHeadersConfig) func(http.Handler) http.Handler {
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			if cfg.FrameOptions != "" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.ContentSecurityPolicy != "" {
+				csp := cfg.ContentSecurityPolicy
+				if r.URL.Path == docsPath {
+					csp = docsContentSecurityPolicy
+				}
+				h.Set("Content-Security-Policy", csp)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFMiddleware protects cookie-authenticated writes with a double-submit
+// token. Each client gets a random token in the CSRFCookieName cookie, also
+// sent in the CSRFHeader response header for apps on another origin, which
+// cannot read the cookie. A POST, PUT, PATCH or DELETE carrying the session
+// cookie must send the token back in CSRFHeader: a forged cross-site request
+// can send the cookies but not read the token. Requests with an
+// Authorization header are exempt, since browsers never add one on their
+// own, as are requests without a session, such as logging in.
+func CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(CSRFCookieName); err == nil {
+			token = cookie.Value
+		}
+		if token == "" {
+			fresh := newCSRFToken()
+			http.SetCookie(w, &http.Cookie{
+				Name:     CSRFCookieName,
+				Value:    fresh,
+				Path:     "/",
+				Secure:   true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			w.Header().Set(CSRFHeader, fresh)
+		} else {
+			w.Header().Set(CSRFHeader, token)
+		}
+
+		if csrfProtected(r) {
+			sent := r.Header.Get(CSRFHeader)
+			if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				writeCSRFError(w)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// csrfProtected reports whether r is a write authenticated by the session
+// cookie alone.
+func csrfProtected(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	_, err := r.Cookie(SessionCookieName)
+	return err == nil
+}
+
+// writeCSRFError writes a 403 problem document like the API's own errors.
+func writeCSRFError(w http.ResponseWriter) {
+	body, _ := json.Marshal(&huma.ErrorModel{
+		Title:  http.StatusText(http.StatusForbidden),
+		Status: http.StatusForbidden,
+		Detail: "Missing or invalid " + CSRFHeader + " header; send the value of the " + CSRFCookieName + " cookie",
+	})
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write(body)
+}
+
+// probePaths are the health endpoints an HTTP port that redirects to HTTPS
+// keeps serving, so orchestrator probes work without following redirects or
+// presenting client certificates.
+var probePaths = []string{"/livez", "/readyz", "/health"}
+
+// HTTPSRedirectHandler redirects every request to the same URL over HTTPS on
+// tlsPort, for an HTTP port that serves nothing else but the health probes,
+// which are passed to probes. The redirect is permanent and keeps the method,
+// so browsers and clients switch for good.
+func HTTPSRedirectHandler(tlsPort int, probes http.Handler) http.Handler {
+	port := strconv.Itoa(tlsPort)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && slices.Contains(probePaths, r.URL.Path) {
+			probes.ServeHTTP(w, r)
+			return
+		}
+
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if host == "" {
+			http.Error(w, "missing Host header", http.StatusBadRequest)
+			return
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
+
+// newCSRFToken generates a random 256-bit token.
+func newCSRFToken() string {
+	bytes := make([]byte, 32)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(bytes)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
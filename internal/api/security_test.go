@@ -0,0 +1,290 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zacaytion/llmio/internal/config"
+)
+
+// okHandler answers every request it is passed with 200.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestCORSMiddleware(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		cfg             config.CORSConfig
+		method          string
+		origin          string
+		preflight       bool
+		wantStatus      int
+		wantAllowOrigin string
+		wantCredentials string
+		wantMaxAge      string
+	}{
+		{
+			name: "allowed origin", cfg: cfg, method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example.com", wantCredentials: "true",
+		},
+		{
+			name: "other origin", cfg: cfg, method: http.MethodGet, origin: "https://evil.example",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "same-origin request", cfg: cfg, method: http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "preflight from allowed origin", cfg: cfg, method: http.MethodOptions, origin: "https://app.example.com", preflight: true,
+			wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example.com", wantCredentials: "true", wantMaxAge: "600",
+		},
+		{
+			name: "preflight from other origin", cfg: cfg, method: http.MethodOptions, origin: "https://evil.example", preflight: true,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "wildcard never allows credentials", method: http.MethodGet, origin: "https://evil.example",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			wantStatus: http.StatusOK, wantAllowOrigin: "*",
+		},
+		{
+			name: "disabled without origins", method: http.MethodOptions, origin: "https://app.example.com", preflight: true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/groups", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			CORSMiddleware(tt.cfg)(okHandler).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			h := w.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+			if got := h.Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+			if tt.wantAllowOrigin != "" && tt.preflight {
+				if got := h.Get("Access-Control-Allow-Headers"); !strings.Contains(got, CSRFHeader) {
+					t.Errorf("Access-Control-Allow-Headers = %q, want it to include %s", got, CSRFHeader)
+				}
+			}
+			if tt.wantAllowOrigin != "" && !tt.preflight {
+				if got := h.Get("Access-Control-Expose-Headers"); !strings.Contains(got, CSRFHeader) {
+					t.Errorf("Access-Control-Expose-Headers = %q, want it to include %s", got, CSRFHeader)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	cfg := config.SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		FrameOptions:          "DENY",
+		ContentSecurityPolicy: config.DefaultContentSecurityPolicy,
+	}
+
+	serve := func(cfg config.SecurityHeadersConfig, path string) http.Header {
+		w := httptest.NewRecorder()
+		SecurityHeadersMiddleware(cfg)(okHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Header()
+	}
+
+	h := serve(cfg, "/api/v1/groups")
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Content-Security-Policy":   config.DefaultContentSecurityPolicy,
+	}
+	for name, value := range want {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	if got := serve(cfg, "/docs").Get("Content-Security-Policy"); !strings.Contains(got, "https://unpkg.com") {
+		t.Errorf("docs Content-Security-Policy = %q, want it to allow unpkg.com", got)
+	}
+
+	h = serve(config.SecurityHeadersConfig{}, "/api/v1/groups")
+	for _, name := range []string{"Strict-Transport-Security", "X-Frame-Options", "Content-Security-Policy"} {
+		if got := h.Get(name); got != "" {
+			t.Errorf("%s = %q with an empty config, want it omitted", name, got)
+		}
+	}
+	if h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("X-Content-Type-Options should always be sent")
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	const token = "csrf-token"
+
+	tests := []struct {
+		name       string
+		method     string
+		session    bool
+		csrfCookie string
+		csrfHeader string
+		auth       string
+		wantStatus int
+	}{
+		{"safe method needs no token", http.MethodGet, true, "", "", "", http.StatusOK},
+		{"write with matching token", http.MethodPost, true, token, token, "", http.StatusOK},
+		{"write without header", http.MethodPost, true, token, "", "", http.StatusForbidden},
+		{"write with wrong header", http.MethodDelete, true, token, "other", "", http.StatusForbidden},
+		{"write without cookie", http.MethodPatch, true, "", token, "", http.StatusForbidden},
+		{"write without session", http.MethodPost, false, "", "", "", http.StatusOK},
+		{"bearer-authenticated write", http.MethodPost, true, "", "", "Bearer abc", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/groups", nil)
+			if tt.session {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session"})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFHeader, tt.csrfHeader)
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			CSRFMiddleware(okHandler).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusForbidden && w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get(CSRFHeader) == "" {
+				t.Errorf("response has no %s header", CSRFHeader)
+			}
+		})
+	}
+}
+
+func TestCSRFMiddleware_IssuesToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	CSRFMiddleware(okHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/me", nil))
+
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == CSRFCookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("no CSRF cookie set")
+	}
+	if cookie.HttpOnly {
+		t.Error("CSRF cookie must be readable by scripts")
+	}
+	if got := w.Header().Get(CSRFHeader); got != cookie.Value {
+		t.Errorf("%s = %q, want the cookie value %q", CSRFHeader, got, cookie.Value)
+	}
+
+	// The issued token then authorizes a cookie-authenticated write
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/groups", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session"})
+	req.AddCookie(cookie)
+	req.Header.Set(CSRFHeader, cookie.Value)
+	w = httptest.NewRecorder()
+	CSRFMiddleware(okHandler).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("a client with a token should not be issued another")
+	}
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		tlsPort int
+		method  string
+		target  string
+		host    string
+		want    string
+	}{
+		{"keeps path and query", 8443, http.MethodGet, "/api/v1/groups?limit=5", "example.com:8080", "https://example.com:8443/api/v1/groups?limit=5"},
+		{"default port is omitted", 443, http.MethodGet, "/docs", "example.com", "https://example.com/docs"},
+		{"writes keep their method", 443, http.MethodPost, "/api/v1/sessions", "example.com:80", "https://example.com/api/v1/sessions"},
+		{"IPv6 host", 8443, http.MethodGet, "/docs", "[::1]:8080", "https://[::1]:8443/docs"},
+		{"IPv6 host on the default port", 443, http.MethodGet, "/docs", "[::1]", "https://[::1]/docs"},
+		{"probe paths only pass through for reads", 443, http.MethodPost, "/readyz", "example.com", "https://example.com/readyz"},
+		{"probe subpaths are redirected", 443, http.MethodGet, "/livez/x", "example.com", "https://example.com/livez/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			HTTPSRedirectHandler(tt.tlsPort, http.NotFoundHandler()).ServeHTTP(w, req)
+
+			if w.Code != http.StatusPermanentRedirect {
+				t.Errorf("status = %d, want 308", w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = ""
+	w := httptest.NewRecorder()
+	HTTPSRedirectHandler(443, http.NotFoundHandler()).ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status without Host = %d, want 400", w.Code)
+	}
+
+	// Health probes are served over HTTP
+	probes := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	for _, path := range []string{"/livez", "/readyz", "/health"} {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			req := httptest.NewRequest(method, path, nil)
+			req.Host = "example.com"
+			w := httptest.NewRecorder()
+			HTTPSRedirectHandler(443, probes).ServeHTTP(w, req)
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("%s %s: status = %d, want the probe's 503", method, path, w.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// DefaultArchiveBatchSize limits how many audit rows are read per export query.
+const DefaultArchiveBatchSize = 5000
+
+// ArchiveRecord is one line of an archive file: an audit.record_version row
+// with its hashes in hex, so the archived part of the chain can be checked
+// against the next archive or the rows still in the database.
+type ArchiveRecord struct {
+	ID          int64           `json:"id"`
+	ChainSeq    int64           `json:"chain_seq"`
+	RecordID    *string         `json:"record_id"`
+	OldRecordID *string         `json:"old_record_id"`
+	Op          string          `json:"op"`
+	Ts          time.Time       `json:"ts"`
+	XactID      int64           `json:"xact_id"`
+	TableOid    uint32          `json:"table_oid"`
+	TableSchema string          `json:"table_schema"`
+	TableName   string          `json:"table_name"`
+	Record      json.RawMessage `json:"record"`
+	OldRecord   json.RawMessage `json:"old_record"`
+	ActorID     *int64          `json:"actor_id"`
+	RequestID   *string         `json:"request_id"`
+	ClientIP    *string         `json:"client_ip"`
+	UserAgent   *string         `json:"user_agent"`
+	PrevHash    string          `json:"prev_hash"`
+	RowHash     string          `json:"row_hash"`
+}
+
+// archiveRecordFromRow converts an audit row for export.
+func archiveRecordFromRow(r *db.AuditRecordVersion) ArchiveRecord {
+	rec := ArchiveRecord{
+		ID:          r.ID,
+		ChainSeq:    r.ChainSeq,
+		Op:          string(r.Op),
+		Ts:          r.Ts.Time.UTC(),
+		XactID:      r.XactID,
+		TableOid:    r.TableOid.Uint32,
+		TableSchema: r.TableSchema,
+		TableName:   r.TableName,
+		Record:      r.Record,
+		OldRecord:   r.OldRecord,
+		PrevHash:    hex.EncodeToString(r.PrevHash),
+		RowHash:     hex.EncodeToString(r.RowHash),
+	}
+	if r.RecordID.Valid {
+		rec.RecordID = &r.RecordID.String
+	}
+	if r.OldRecordID.Valid {
+		rec.OldRecordID = &r.OldRecordID.String
+	}
+	if r.ActorID.Valid {
+		rec.ActorID = &r.ActorID.Int64
+	}
+	if r.RequestID.Valid {
+		rec.RequestID = &r.RequestID.String
+	}
+	if r.ClientIp.Valid {
+		rec.ClientIP = &r.ClientIp.String
+	}
+	if r.UserAgent.Valid {
+		rec.UserAgent = &r.UserAgent.String
+	}
+	return rec
+}
+
+// RetentionCutoff returns the start of the oldest month kept when keeping
+// months full calendar months (UTC) before the month of now. Partitions
+// ending on or before the cutoff are archived.
+func RetentionCutoff(now time.Time, months int) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Archiver maintains the monthly partitions of audit.record_version: it
+// creates partitions ahead of time, and exports expired partitions to
+// gzip-compressed JSONL files before dropping them.
+type Archiver struct {
+	pool      *pgxpool.Pool
+	queries   *db.Queries
+	dir       string
+	batchSize int32
+}
+
+// NewArchiver creates an archiver that writes export files to dir.
+func NewArchiver(pool *pgxpool.Pool, queries *db.Queries, dir string) *Archiver {
+	return &Archiver{
+		pool:      pool,
+		queries:   queries,
+		dir:       dir,
+		batchSize: DefaultArchiveBatchSize,
+	}
+}
+
+// EnsurePartitions creates any missing partitions for the month of now and
+// the monthsAhead months after it, and returns the names of those created.
+func (a *Archiver) EnsurePartitions(ctx context.Context, now time.Time, monthsAhead int) ([]string, error) {
+	created, err := a.queries.EnsureAuditPartitions(ctx, db.EnsureAuditPartitionsParams{
+		FromMonth:   pgtype.Date{Time: now.UTC(), Valid: true},
+		MonthsAhead: int32(monthsAhead), // #nosec G115 -- validated config value
+	})
+	if err != nil {
+		return nil, fmt.Errorf("EnsureAuditPartitions: %w", err)
+	}
+	return created, nil
+}
+
+// ArchiveBefore exports and drops every monthly partition that ends on or
+// before cutoff, oldest first, and returns what was archived. It stops at the
+// first failure so the chain is always archived from the start without gaps.
+func (a *Archiver) ArchiveBefore(ctx context.Context, cutoff time.Time) ([]*db.AuditChainArchive, error) {
+	partitions, err := a.queries.ListAuditPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListAuditPartitions: %w", err)
+	}
+
+	var archived []*db.AuditChainArchive
+	for _, p := range partitions {
+		if p.RangeEnd.Time.After(cutoff) {
+			break
+		}
+		archive, err := a.archivePartition(ctx, p)
+		if err != nil {
+			return archived, fmt.Errorf("archive %s: %w", p.PartitionName, err)
+		}
+		archived = append(archived, archive)
+	}
+	return archived, nil
+}
+
+// exportResult summarises one exported partition.
+type exportResult struct {
+	path     string
+	sha256   []byte
+	rows     int64
+	firstSeq int64
+	lastSeq  int64
+	lastHash []byte
+}
+
+// archivePartition exports a partition, then drops it and records the
+// archive in one transaction. A partition only holds rows for a past month,
+// so nothing is added to it between the export and the drop; the row count
+// is re-checked anyway before dropping.
+func (a *Archiver) archivePartition(ctx context.Context, p *db.ListAuditPartitionsRow) (*db.AuditChainArchive, error) {
+	exported, err := a.export(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive *db.AuditChainArchive
+	err = pgx.BeginTxFunc(ctx, a.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		txQueries := a.queries.WithTx(tx)
+
+		count, err := txQueries.CountAuditRecordsInRange(ctx, db.CountAuditRecordsInRangeParams{
+			RangeStart: p.RangeStart,
+			RangeEnd:   p.RangeEnd,
+		})
+		if err != nil {
+			return fmt.Errorf("CountAuditRecordsInRange: %w", err)
+		}
+		if count != exported.rows {
+			return fmt.Errorf("partition changed during export: exported %d rows, now has %d", exported.rows, count)
+		}
+
+		if err := txQueries.DropAuditPartition(ctx, p.PartitionName); err != nil {
+			return fmt.Errorf("DropAuditPartition: %w", err)
+		}
+
+		params := db.CreateAuditArchiveParams{
+			PartitionName: p.PartitionName,
+			RangeStart:    p.RangeStart,
+			RangeEnd:      p.RangeEnd,
+			RowCount:      exported.rows,
+		}
+		if exported.rows > 0 {
+			params.FirstSeq = pgtype.Int8{Int64: exported.firstSeq, Valid: true}
+			params.LastSeq = pgtype.Int8{Int64: exported.lastSeq, Valid: true}
+			params.LastHash = exported.lastHash
+			params.FilePath = pgtype.Text{String: exported.path, Valid: true}
+			params.FileSha256 = exported.sha256
+		}
+		archive, err = txQueries.CreateAuditArchive(ctx, params)
+		if err != nil {
+			return fmt.Errorf("CreateAuditArchive: %w", err)
+		}
+		return nil
+	})
+	return archive, err
+}
+
+// export writes a partition's rows in chain order to
+// <dir>/<partition>.jsonl.gz. The file is written under a temporary name and
+// renamed once complete; nothing is written for an empty partition.
+func (a *Archiver) export(ctx context.Context, p *db.ListAuditPartitionsRow) (*exportResult, error) {
+	if err := os.MkdirAll(a.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(a.dir, p.PartitionName+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create archive file: %w", err)
+	}
+	// Removes the temporary file on failure; a no-op after the rename
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	defer func() { _ = tmp.Close() }()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	enc := json.NewEncoder(gz)
+
+	result := &exportResult{path: filepath.Join(a.dir, p.PartitionName+".jsonl.gz")}
+	for {
+		rows, err := a.queries.ListAuditRecordsInRange(ctx, db.ListAuditRecordsInRangeParams{
+			RangeStart: p.RangeStart,
+			RangeEnd:   p.RangeEnd,
+			AfterSeq:   result.lastSeq,
+			BatchSize:  a.batchSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ListAuditRecordsInRange: %w", err)
+		}
+		for _, row := range rows {
+			if err := enc.Encode(archiveRecordFromRow(row)); err != nil {
+				return nil, fmt.Errorf("write archive record: %w", err)
+			}
+			if result.rows == 0 {
+				result.firstSeq = row.ChainSeq
+			}
+			result.rows++
+			result.lastSeq = row.ChainSeq
+			result.lastHash = row.RowHash
+		}
+		if len(rows) < int(a.batchSize) {
+			break
+		}
+	}
+	if result.rows == 0 {
+		return result, nil
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("write archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, fmt.Errorf("sync archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("close archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), result.path); err != nil {
+		return nil, fmt.Errorf("rename archive file: %w", err)
+	}
+	result.sha256 = hash.Sum(nil)
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestRetentionCutoff verifies cutoffs fall on UTC month boundaries.
+func TestRetentionCutoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		now    time.Time
+		months int
+		want   time.Time
+	}{
+		{"mid month", time.Date(2026, 5, 17, 12, 0, 0, 0, time.UTC), 3, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"across a year", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), 12, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"local time is converted", time.Date(2026, 6, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*3600)), 1, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetentionCutoff(tt.now, tt.months); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestArchiveRecordFromRow verifies the archived line format.
+func TestArchiveRecordFromRow(t *testing.T) {
+	row := &db.AuditRecordVersion{
+		ID:          10,
+		RecordID:    pgtype.Text{String: "4", Valid: true},
+		Op:          "INSERT",
+		Ts:          pgtype.Timestamptz{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true},
+		XactID:      99,
+		TableOid:    pgtype.Uint32{Uint32: 16384, Valid: true},
+		TableSchema: "public",
+		TableName:   "groups",
+		Record:      []byte(`{"id": 4}`),
+		ChainSeq:    7,
+		RowHash:     []byte{0xab, 0xcd},
+	}
+
+	data, err := json.Marshal(archiveRecordFromRow(row))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"id":10,"chain_seq":7,"record_id":"4","old_record_id":null,"op":"INSERT",` +
+		`"ts":"2026-01-02T03:04:05Z","xact_id":99,"table_oid":16384,"table_schema":"public","table_name":"groups",` +
+		`"record":{"id":4},"old_record":null,"actor_id":null,"request_id":null,"client_ip":null,"user_agent":null,"prev_hash":"","row_hash":"abcd"}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+// TestArchiver exports and drops a partition, and the remaining chain still
+// verifies from the archived anchor.
+func TestArchiver(t *testing.T) {
+	ctx := context.Background()
+	pool, queries := setupChainTest(t)
+	key := newTestKey(t)
+	pub := key.Public().(ed25519.PublicKey)
+
+	if _, _, err := NewCheckpointer(queries, key).Checkpoint(ctx, time.Now()); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	archiver := NewArchiver(pool, queries, t.TempDir())
+	archiver.batchSize = 2
+
+	created, err := archiver.EnsurePartitions(ctx, time.Now(), 6)
+	if err != nil {
+		t.Fatalf("EnsurePartitions: %v", err)
+	}
+	if len(created) != 3 {
+		t.Errorf("expected 3 partitions beyond the migration's, got %v", created)
+	}
+
+	// A cutoff at the start of next month expires the current partition
+	archived, err := archiver.ArchiveBefore(ctx, RetentionCutoff(time.Now(), -1))
+	if err != nil {
+		t.Fatalf("ArchiveBefore: %v", err)
+	}
+	if len(archived) != 1 {
+		t.Fatalf("expected the current month to be archived, got %d archives", len(archived))
+	}
+	a := archived[0]
+	if a.RowCount != 4 || a.FirstSeq.Int64 != 1 || a.LastSeq.Int64 != 4 {
+		t.Errorf("expected rows 1-4 archived, got %d rows %d-%d", a.RowCount, a.FirstSeq.Int64, a.LastSeq.Int64)
+	}
+
+	data, err := os.ReadFile(a.FilePath.String)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if sum := sha256.Sum256(data); string(sum[:]) != string(a.FileSha256) {
+		t.Error("archive file does not match the recorded sha256")
+	}
+	f, err := os.Open(a.FilePath.String)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var seqs []int64
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var rec ArchiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("decode archive line: %v", err)
+		}
+		seqs = append(seqs, rec.ChainSeq)
+	}
+	if len(seqs) != 4 || seqs[0] != 1 || seqs[3] != 4 {
+		t.Errorf("expected chain_seq 1-4 in order, got %v", seqs)
+	}
+
+	// New writes link to the archived head
+	if _, err := pool.Exec(ctx, `UPDATE groups SET description = 'after archive'`); err != nil {
+		t.Fatalf("update group: %v", err)
+	}
+	result, err := VerifyChain(ctx, queries, 0)
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if !result.OK() || result.ArchivedSeq != 4 || result.Checked != 1 {
+		t.Errorf("expected 1 row verified after archived seq 4, got %+v", result)
+	}
+
+	// The checkpoint of an archived row is still signature-checked
+	checked, problems, err := VerifyCheckpoints(ctx, queries, pub)
+	if err != nil {
+		t.Fatalf("VerifyCheckpoints: %v", err)
+	}
+	if checked != 1 || len(problems) != 0 {
+		t.Errorf("expected the archived checkpoint to verify, got %d with problems %+v", checked, problems)
+	}
+}
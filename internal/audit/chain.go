@@ -0,0 +1,155 @@
+// Package audit verifies and checkpoints the tamper-evident hash chain over
+// audit.record_version.
+//
+// Every audit row stores row_hash = sha256(prev_hash || row contents), computed
+// by the record_version_chain trigger (see migrations/015_audit_hash_chain.sql).
+// VerifyChain recomputes each hash in Go from the stored contents (see RowHash)
+// and checks the links; Checkpointer signs the chain head with Ed25519 so it can be published
+// outside the database.
+//
+// The table is partitioned by month (see migrations/016_partition_audit_log.sql).
+// Archiver exports expired partitions to compressed JSONL files and drops them;
+// audit.chain_archive records the last archived position and hash, which
+// anchors verification of the rows that remain.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// DefaultVerifyBatchSize limits how many chain rows are read per query.
+const DefaultVerifyBatchSize = 1000
+
+// ChainBreak describes the first broken link found in the chain.
+type ChainBreak struct {
+	Seq      int64  // Chain position where the break was detected
+	RecordID int64  // audit.record_version id at that position, 0 if the row is missing
+	Reason   string // What did not match
+}
+
+func (b ChainBreak) String() string {
+	if b.RecordID == 0 {
+		return fmt.Sprintf("chain_seq %d: %s", b.Seq, b.Reason)
+	}
+	return fmt.Sprintf("chain_seq %d (record_version id %d): %s", b.Seq, b.RecordID, b.Reason)
+}
+
+// VerifyResult summarises one chain verification.
+// Break is nil when every checked row is intact. ArchivedSeq is the last
+// chain position exported to an archive file; rows up to it are not checked.
+type VerifyResult struct {
+	Checked     int64
+	ArchivedSeq int64
+	HeadSeq     int64
+	HeadHash    []byte
+	Break       *ChainBreak
+}
+
+// OK reports whether the chain verified without a break.
+func (r VerifyResult) OK() bool {
+	return r.Break == nil
+}
+
+// VerifyChain walks the audit chain from the first unarchived row to the recorded head
+// and reports the first broken link: a row whose contents no longer match its
+// hash, a row whose prev_hash does not match its predecessor, a missing
+// position, or a head that points past the last row.
+//
+// Rows appended after verification starts are not checked.
+func VerifyChain(ctx context.Context, queries *db.Queries, batchSize int32) (VerifyResult, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultVerifyBatchSize
+	}
+
+	// Read the head first so concurrent appends are not mistaken for breaks
+	head, err := queries.GetAuditChainHead(ctx)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("GetAuditChainHead: %w", err)
+	}
+	result := VerifyResult{HeadSeq: head.LastSeq, HeadHash: head.LastHash}
+
+	// Archived rows are gone; the first remaining row links to the archive anchor
+	var prevHash []byte
+	var lastSeq int64
+	anchor, err := queries.GetAuditChainAnchor(ctx)
+	if err != nil && !db.IsNotFound(err) {
+		return result, fmt.Errorf("GetAuditChainAnchor: %w", err)
+	}
+	if err == nil {
+		lastSeq, prevHash = anchor.LastSeq, anchor.LastHash
+		result.ArchivedSeq = anchor.LastSeq
+	}
+	for lastSeq < head.LastSeq {
+		links, err := queries.ListAuditChainLinks(ctx, db.ListAuditChainLinksParams{
+			AfterSeq:  lastSeq,
+			BatchSize: batchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("ListAuditChainLinks: %w", err)
+		}
+		if len(links) == 0 {
+			break
+		}
+
+		for _, link := range links {
+			if link.ChainSeq > head.LastSeq {
+				break
+			}
+			if brk := checkLink(link, lastSeq, prevHash); brk != nil {
+				result.Break = brk
+				return result, nil
+			}
+			result.Checked++
+			lastSeq = link.ChainSeq
+			prevHash = link.RowHash
+		}
+
+		if len(links) < int(batchSize) {
+			break
+		}
+	}
+
+	if lastSeq != head.LastSeq {
+		result.Break = &ChainBreak{
+			Seq:    lastSeq + 1,
+			Reason: fmt.Sprintf("chain ends at %d but the head records %d", lastSeq, head.LastSeq),
+		}
+		return result, nil
+	}
+	if !bytes.Equal(prevHash, head.LastHash) {
+		result.Break = &ChainBreak{
+			Seq:    lastSeq,
+			Reason: "last row_hash does not match the recorded head",
+		}
+	}
+	return result, nil
+}
+
+// checkLink validates one row against the row before it.
+func checkLink(link *db.AuditRecordVersion, prevSeq int64, prevHash []byte) *ChainBreak {
+	if link.ChainSeq != prevSeq+1 {
+		return &ChainBreak{
+			Seq:    prevSeq + 1,
+			Reason: fmt.Sprintf("row missing (next row is at %d)", link.ChainSeq),
+		}
+	}
+	if !bytes.Equal(link.PrevHash, prevHash) {
+		return &ChainBreak{
+			Seq:      link.ChainSeq,
+			RecordID: link.ID,
+			Reason:   "prev_hash does not match the previous row",
+		}
+	}
+	if !bytes.Equal(RowHash(link), link.RowHash) {
+		return &ChainBreak{
+			Seq:      link.ChainSeq,
+			RecordID: link.ID,
+			Reason:   "row contents do not match row_hash",
+		}
+	}
+	return nil
+}
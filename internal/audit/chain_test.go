@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/testutil"
+)
+
+// setupChainTest starts a migrated database with four audited writes.
+func setupChainTest(t *testing.T) (*pgxpool.Pool, *db.Queries) {
+	t.Helper()
+	ctx := context.Background()
+
+	connStr, cleanup := testutil.SetupTestDB(ctx, t)
+	t.Cleanup(cleanup)
+
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID, groupID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO users (email, name, username, password_hash, key)
+		 VALUES ('chain@example.com', 'Chain', 'chain', 'hash', 'chain') RETURNING id`,
+	).Scan(&userID); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO groups (name, handle, created_by_id) VALUES ('Chain', 'chain', $1) RETURNING id`, userID,
+	).Scan(&groupID); err != nil {
+		t.Fatalf("insert group: %v", err)
+	}
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at) VALUES ($1, $2, 'admin', $2, NOW())`,
+		groupID, userID,
+	); err != nil {
+		t.Fatalf("insert membership: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE groups SET description = 'changed' WHERE id = $1`, groupID); err != nil {
+		t.Fatalf("update group: %v", err)
+	}
+
+	return pool, db.New(pool)
+}
+
+// TestVerifyChain detects edits, deletions, and truncation.
+func TestVerifyChain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("intact chain verifies", func(t *testing.T) {
+		_, queries := setupChainTest(t)
+
+		result, err := VerifyChain(ctx, queries, 2)
+		if err != nil {
+			t.Fatalf("VerifyChain: %v", err)
+		}
+		if !result.OK() {
+			t.Fatalf("expected intact chain, got break: %s", result.Break)
+		}
+		if result.Checked != 4 || result.HeadSeq != 4 {
+			t.Errorf("expected 4 rows checked up to seq 4, got %d up to %d", result.Checked, result.HeadSeq)
+		}
+	})
+
+	t.Run("edited record is detected", func(t *testing.T) {
+		pool, queries := setupChainTest(t)
+		if _, err := pool.Exec(ctx,
+			`UPDATE audit.record_version SET record = jsonb_set(record, '{name}', '"Forged"') WHERE chain_seq = 1`,
+		); err != nil {
+			t.Fatalf("tamper: %v", err)
+		}
+
+		result, err := VerifyChain(ctx, queries, 0)
+		if err != nil {
+			t.Fatalf("VerifyChain: %v", err)
+		}
+		if result.OK() || result.Break.Seq != 1 || !strings.Contains(result.Break.Reason, "contents") {
+			t.Errorf("expected contents mismatch at seq 1, got %+v", result.Break)
+		}
+	})
+
+	t.Run("deleted row is detected", func(t *testing.T) {
+		pool, queries := setupChainTest(t)
+		if _, err := pool.Exec(ctx, `DELETE FROM audit.record_version WHERE chain_seq = 2`); err != nil {
+			t.Fatalf("tamper: %v", err)
+		}
+
+		result, err := VerifyChain(ctx, queries, 0)
+		if err != nil {
+			t.Fatalf("VerifyChain: %v", err)
+		}
+		if result.OK() || result.Break.Seq != 2 || result.Checked != 1 {
+			t.Errorf("expected missing row at seq 2 after 1 row, got %+v (checked %d)", result.Break, result.Checked)
+		}
+	})
+
+	t.Run("truncated tail is detected", func(t *testing.T) {
+		pool, queries := setupChainTest(t)
+		if _, err := pool.Exec(ctx, `DELETE FROM audit.record_version WHERE chain_seq = 4`); err != nil {
+			t.Fatalf("tamper: %v", err)
+		}
+
+		result, err := VerifyChain(ctx, queries, 0)
+		if err != nil {
+			t.Fatalf("VerifyChain: %v", err)
+		}
+		if result.OK() || !strings.Contains(result.Break.Reason, "head records 4") {
+			t.Errorf("expected truncation to be reported, got %+v", result.Break)
+		}
+	})
+}
+
+// TestChain_TimestampOrder tests that concurrent writers get ts in chain_seq
+// order, so each month's partition holds a contiguous run of the chain.
+func TestChain_TimestampOrder(t *testing.T) {
+	ctx := context.Background()
+	pool, queries := setupChainTest(t)
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := range 10 {
+				if _, err := pool.Exec(ctx,
+					`UPDATE groups SET description = $1 WHERE handle = 'chain'`, fmt.Sprintf("w%d-%d", w, i),
+				); err != nil {
+					t.Errorf("update group: %v", err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var outOfOrder int
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT ts, LAG(ts) OVER (ORDER BY chain_seq) AS prev_ts FROM audit.record_version
+		) s
+		WHERE ts < prev_ts`,
+	).Scan(&outOfOrder); err != nil {
+		t.Fatalf("count out-of-order rows: %v", err)
+	}
+	if outOfOrder != 0 {
+		t.Errorf("expected ts to follow chain_seq order, got %d rows out of order", outOfOrder)
+	}
+
+	result, err := VerifyChain(ctx, queries, 0)
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if !result.OK() || result.HeadSeq != 84 {
+		t.Errorf("expected intact chain up to seq 84, got break %+v at head %d", result.Break, result.HeadSeq)
+	}
+}
+
+// TestCheckpointer stores signed heads and detects a rewritten chain.
+func TestCheckpointer(t *testing.T) {
+	ctx := context.Background()
+	pool, queries := setupChainTest(t)
+	key := newTestKey(t)
+	pub := key.Public().(ed25519.PublicKey)
+	checkpointer := NewCheckpointer(queries, key)
+
+	cp, created, err := checkpointer.Checkpoint(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if !created || cp.ChainSeq != 4 {
+		t.Fatalf("expected new checkpoint at seq 4, got %+v (created=%v)", cp, created)
+	}
+
+	// Unchanged head returns the stored checkpoint, which still verifies
+	again, created, err := checkpointer.Checkpoint(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if created || again.ChainSeq != cp.ChainSeq || again.Verify(pub) != nil {
+		t.Errorf("expected existing checkpoint to be reused, got %+v (created=%v)", again, created)
+	}
+
+	checked, problems, err := VerifyCheckpoints(ctx, queries, pub)
+	if err != nil {
+		t.Fatalf("VerifyCheckpoints: %v", err)
+	}
+	if checked != 1 || len(problems) != 0 {
+		t.Fatalf("expected 1 valid checkpoint, got %d with problems %+v", checked, problems)
+	}
+
+	// Rewriting the checkpointed row's hash is caught even if the chain is recomputed
+	if _, err := pool.Exec(ctx,
+		`UPDATE audit.record_version SET row_hash = sha256('forged') WHERE chain_seq = 4`,
+	); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	_, problems, err = VerifyCheckpoints(ctx, queries, pub)
+	if err != nil {
+		t.Fatalf("VerifyCheckpoints: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Reason, "no longer matches") {
+		t.Errorf("expected checkpoint mismatch, got %+v", problems)
+	}
+}
@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// checkpointDomain prefixes signed messages so a checkpoint signature cannot
+// be replayed as a signature over anything else.
+const checkpointDomain = "llmio-audit-checkpoint/v1"
+
+// ErrEmptyChain is returned when there is nothing to checkpoint yet.
+var ErrEmptyChain = errors.New("audit chain is empty")
+
+// Checkpoint is a signed statement of the chain head at a point in time.
+// Publishing checkpoints outside the database makes a rewritten chain
+// detectable even by someone who can recompute every hash.
+type Checkpoint struct {
+	ChainSeq  int64
+	RowHash   []byte
+	KeyID     string
+	Signature []byte
+	CreatedAt time.Time
+}
+
+// Message returns the bytes that are signed.
+func (c Checkpoint) Message() []byte {
+	return fmt.Appendf(nil, "%s\nchain_seq=%d\nrow_hash=%x\ncreated_at=%s\n",
+		checkpointDomain, c.ChainSeq, c.RowHash, c.CreatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// Verify checks the signature against a public key.
+func (c Checkpoint) Verify(pub ed25519.PublicKey) error {
+	if c.KeyID != KeyID(pub) {
+		return fmt.Errorf("checkpoint signed by key %s, not %s", c.KeyID, KeyID(pub))
+	}
+	if !ed25519.Verify(pub, c.Message(), c.Signature) {
+		return errors.New("invalid checkpoint signature")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the checkpoint for publication, with the hash in hex
+// and the signature in base64.
+func (c Checkpoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ChainSeq  int64     `json:"chain_seq"`
+		RowHash   string    `json:"row_hash"`
+		KeyID     string    `json:"key_id"`
+		Signature []byte    `json:"signature"`
+		CreatedAt time.Time `json:"created_at"`
+	}{c.ChainSeq, hex.EncodeToString(c.RowHash), c.KeyID, c.Signature, c.CreatedAt.UTC()})
+}
+
+// SignCheckpoint signs the chain head at seq. createdAt is truncated to
+// microseconds to match what PostgreSQL stores.
+func SignCheckpoint(key ed25519.PrivateKey, seq int64, rowHash []byte, createdAt time.Time) Checkpoint {
+	c := Checkpoint{
+		ChainSeq:  seq,
+		RowHash:   rowHash,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		CreatedAt: createdAt.UTC().Truncate(time.Microsecond),
+	}
+	c.Signature = ed25519.Sign(key, c.Message())
+	return c
+}
+
+// KeyID is a short fingerprint of a public key: the first 8 bytes of its
+// SHA-256, in hex.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadPrivateKey reads an Ed25519 private key from a PKCS#8 PEM file, as
+// produced by `openssl genpkey -algorithm ed25519`.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key %s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is %T, not Ed25519", path, parsed)
+	}
+	return key, nil
+}
+
+// LoadPublicKey reads an Ed25519 public key from a PKIX PEM file, as produced
+// by `openssl pkey -pubout`. A private key file is accepted too.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "PRIVATE KEY" {
+		key, err := LoadPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public().(ed25519.PublicKey), nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %w", path, err)
+	}
+	pub, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is %T, not Ed25519", path, parsed)
+	}
+	return pub, nil
+}
+
+// readPEM reads the first PEM block of a file.
+func readPEM(path string) (*pem.Block, error) {
+	// #nosec G304 -- Key paths come from operator configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// Checkpointer signs and stores the current chain head.
+type Checkpointer struct {
+	queries *db.Queries
+	key     ed25519.PrivateKey
+}
+
+// NewCheckpointer creates a checkpointer that signs with key.
+func NewCheckpointer(queries *db.Queries, key ed25519.PrivateKey) *Checkpointer {
+	return &Checkpointer{queries: queries, key: key}
+}
+
+// Checkpoint signs and stores the current chain head. It returns false
+// without storing anything if the head has not moved since the latest
+// checkpoint, and ErrEmptyChain if there are no audit rows yet.
+func (c *Checkpointer) Checkpoint(ctx context.Context, now time.Time) (Checkpoint, bool, error) {
+	head, err := c.queries.GetAuditChainHead(ctx)
+	if err != nil {
+		return Checkpoint{}, false, fmt.Errorf("GetAuditChainHead: %w", err)
+	}
+	if head.LastSeq == 0 {
+		return Checkpoint{}, false, ErrEmptyChain
+	}
+
+	latest, err := c.queries.GetLatestAuditCheckpoint(ctx)
+	if err != nil && !db.IsNotFound(err) {
+		return Checkpoint{}, false, fmt.Errorf("GetLatestAuditCheckpoint: %w", err)
+	}
+	if err == nil && latest.ChainSeq == head.LastSeq && bytes.Equal(latest.RowHash, head.LastHash) {
+		return checkpointFromRow(latest), false, nil
+	}
+
+	cp := SignCheckpoint(c.key, head.LastSeq, head.LastHash, now)
+	if _, err := c.queries.CreateAuditCheckpoint(ctx, db.CreateAuditCheckpointParams{
+		ChainSeq:  cp.ChainSeq,
+		RowHash:   cp.RowHash,
+		KeyID:     cp.KeyID,
+		Signature: cp.Signature,
+		CreatedAt: pgtype.Timestamptz{Time: cp.CreatedAt, Valid: true},
+	}); err != nil {
+		return Checkpoint{}, false, fmt.Errorf("CreateAuditCheckpoint: %w", err)
+	}
+	return cp, true, nil
+}
+
+// CheckpointProblem describes a stored checkpoint that failed verification.
+type CheckpointProblem struct {
+	ID       int64
+	ChainSeq int64
+	Reason   string
+}
+
+// VerifyCheckpoints checks every stored checkpoint's signature and that the
+// chain still holds the hash it vouched for. Checkpoints signed by other keys
+// are reported as problems rather than skipped. Checkpoints of rows that have
+// since been archived only have their signature checked.
+func VerifyCheckpoints(ctx context.Context, queries *db.Queries, pub ed25519.PublicKey) (int, []CheckpointProblem, error) {
+	rows, err := queries.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("ListAuditCheckpoints: %w", err)
+	}
+
+	var archivedSeq int64
+	anchor, err := queries.GetAuditChainAnchor(ctx)
+	if err != nil && !db.IsNotFound(err) {
+		return 0, nil, fmt.Errorf("GetAuditChainAnchor: %w", err)
+	}
+	if err == nil {
+		archivedSeq = anchor.LastSeq
+	}
+
+	var problems []CheckpointProblem
+	for _, row := range rows {
+		cp := checkpointFromRow(row)
+		if err := cp.Verify(pub); err != nil {
+			problems = append(problems, CheckpointProblem{ID: row.ID, ChainSeq: row.ChainSeq, Reason: err.Error()})
+			continue
+		}
+
+		rowHash, err := queries.GetAuditChainLink(ctx, row.ChainSeq)
+		if err != nil {
+			if db.IsNotFound(err) {
+				if row.ChainSeq <= archivedSeq {
+					continue
+				}
+				problems = append(problems, CheckpointProblem{ID: row.ID, ChainSeq: row.ChainSeq, Reason: "checkpointed row is missing"})
+				continue
+			}
+			return len(rows), problems, fmt.Errorf("GetAuditChainLink: %w", err)
+		}
+		if !bytes.Equal(rowHash, row.RowHash) {
+			problems = append(problems, CheckpointProblem{ID: row.ID, ChainSeq: row.ChainSeq, Reason: "chain no longer matches the checkpointed hash"})
+		}
+	}
+	return len(rows), problems, nil
+}
+
+// checkpointFromRow converts a stored checkpoint.
+func checkpointFromRow(row *db.AuditChainCheckpoint) Checkpoint {
+	return Checkpoint{
+		ChainSeq:  row.ChainSeq,
+		RowHash:   row.RowHash,
+		KeyID:     row.KeyID,
+		Signature: row.Signature,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestKey generates an Ed25519 key pair.
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// writePEM writes a PEM block to a file in a temp directory.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// TestCheckpoint_SignVerify verifies signatures and that tampering is detected.
+func TestCheckpoint_SignVerify(t *testing.T) {
+	key := newTestKey(t)
+	pub := key.Public().(ed25519.PublicKey)
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 123456789, time.UTC)
+
+	cp := SignCheckpoint(key, 42, []byte{0xde, 0xad, 0xbe, 0xef}, createdAt)
+	if err := cp.Verify(pub); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if cp.CreatedAt.Nanosecond() != 123456000 {
+		t.Errorf("expected created_at truncated to microseconds, got %v", cp.CreatedAt)
+	}
+
+	tampered := cp
+	tampered.ChainSeq = 43
+	if err := tampered.Verify(pub); err == nil {
+		t.Error("expected tampered chain_seq to fail verification")
+	}
+
+	tampered = cp
+	tampered.RowHash = []byte{0xde, 0xad, 0xbe, 0xee}
+	if err := tampered.Verify(pub); err == nil {
+		t.Error("expected tampered row_hash to fail verification")
+	}
+
+	other := newTestKey(t).Public().(ed25519.PublicKey)
+	if err := cp.Verify(other); err == nil || !strings.Contains(err.Error(), "signed by key") {
+		t.Errorf("expected key mismatch error, got %v", err)
+	}
+}
+
+// TestCheckpoint_MarshalJSON verifies the published encoding.
+func TestCheckpoint_MarshalJSON(t *testing.T) {
+	cp := SignCheckpoint(newTestKey(t), 7, []byte{0x01, 0xab}, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded["row_hash"] != "01ab" || decoded["chain_seq"] != float64(7) || decoded["key_id"] != cp.KeyID {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+	if decoded["created_at"] != "2025-01-02T03:04:05Z" {
+		t.Errorf("unexpected created_at: %v", decoded["created_at"])
+	}
+}
+
+// TestLoadKeys verifies PEM key loading for both key types.
+func TestLoadKeys(t *testing.T) {
+	key := newTestKey(t)
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	privPath := writePEM(t, "audit.key", "PRIVATE KEY", privDER)
+	pubPath := writePEM(t, "audit.pub", "PUBLIC KEY", pubDER)
+
+	loaded, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+	if !loaded.Equal(key) {
+		t.Error("loaded private key does not match")
+	}
+
+	for _, path := range []string{pubPath, privPath} {
+		pub, err := LoadPublicKey(path)
+		if err != nil {
+			t.Fatalf("LoadPublicKey(%s): %v", path, err)
+		}
+		if !pub.Equal(key.Public()) {
+			t.Errorf("public key from %s does not match", path)
+		}
+	}
+
+	if _, err := LoadPrivateKey(filepath.Join(t.TempDir(), "missing.key")); err == nil {
+		t.Error("expected error for missing key file")
+	}
+	notPEM := filepath.Join(t.TempDir(), "plain.key")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadPrivateKey(notPEM); err == nil {
+		t.Error("expected error for non-PEM key file")
+	}
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// nullField stands in for NULL columns in the hashed row, as in SQL.
+const nullField = `\N`
+
+// RowHash computes a row's chain hash from its stored contents. It matches
+// audit.record_version_hash (migrations 015 and 017): sha256 of prev_hash
+// followed by the columns joined with \x1f, with the request metadata
+// appended only when the row has some. VerifyChain uses it so a replaced
+// SQL function cannot vouch for edited rows.
+func RowHash(rv *db.AuditRecordVersion) []byte {
+	fields := []string{
+		strconv.FormatInt(rv.ChainSeq, 10),
+		strconv.FormatInt(rv.ID, 10),
+		textField(rv.RecordID),
+		textField(rv.OldRecordID),
+		string(rv.Op),
+		strconv.FormatInt(rv.Ts.Time.UnixMicro(), 10),
+		strconv.FormatInt(rv.XactID, 10),
+		rv.TableSchema,
+		rv.TableName,
+		jsonField(rv.Record),
+		jsonField(rv.OldRecord),
+		int8Field(rv.ActorID),
+	}
+	if rv.RequestID.Valid || rv.ClientIp.Valid || rv.UserAgent.Valid {
+		fields = append(fields, textField(rv.RequestID), textField(rv.ClientIp), textField(rv.UserAgent))
+	}
+
+	h := sha256.New()
+	h.Write(rv.PrevHash)
+	h.Write([]byte(strings.Join(fields, "\x1f")))
+	return h.Sum(nil)
+}
+
+func textField(v pgtype.Text) string {
+	if !v.Valid {
+		return nullField
+	}
+	return v.String
+}
+
+func int8Field(v pgtype.Int8) string {
+	if !v.Valid {
+		return nullField
+	}
+	return strconv.FormatInt(v.Int64, 10)
+}
+
+// jsonField relies on pgx returning jsonb in its canonical text form, the
+// same as jsonb::text.
+func jsonField(v []byte) string {
+	if v == nil {
+		return nullField
+	}
+	return string(v)
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// TestRowHash_MatchesSQL pins RowHash to audit.record_version_hash, for rows
+// with and without request metadata.
+func TestRowHash_MatchesSQL(t *testing.T) {
+	ctx := context.Background()
+	pool, queries := setupChainTest(t)
+
+	// Partial metadata, and JSON whose text form needs escaping
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`SELECT set_config('app.client_ip', '203.0.113.7', true), set_config('app.user_agent', 'hash test', true)`,
+	); err != nil {
+		t.Fatalf("set metadata: %v", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE groups SET description = $1 WHERE handle = 'chain'`, "Zürich ☃ \"quoted\"\n\ttabbed",
+	); err != nil {
+		t.Fatalf("update group: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	rows, err := queries.ListAuditChainLinks(ctx, db.ListAuditChainLinksParams{BatchSize: 100})
+	if err != nil {
+		t.Fatalf("ListAuditChainLinks: %v", err)
+	}
+	if len(rows) != 5 || !rows[4].ClientIp.Valid || rows[4].RequestID.Valid {
+		t.Fatalf("expected 5 rows, the last with partial metadata, got %d", len(rows))
+	}
+
+	for _, rv := range rows {
+		var sqlHash []byte
+		if err := pool.QueryRow(ctx, `
+			SELECT audit.record_version_hash(
+				r.prev_hash, r.chain_seq, r.id, r.record_id, r.old_record_id, r.op, r.ts,
+				r.xact_id, r.table_schema, r.table_name, r.record, r.old_record, r.actor_id,
+				r.request_id, r.client_ip, r.user_agent)
+			FROM audit.record_version r WHERE r.chain_seq = $1`, rv.ChainSeq,
+		).Scan(&sqlHash); err != nil {
+			t.Fatalf("record_version_hash: %v", err)
+		}
+
+		got := RowHash(rv)
+		if !bytes.Equal(got, sqlHash) {
+			t.Errorf("chain_seq %d: Go hash %x, SQL hash %x", rv.ChainSeq, got, sqlHash)
+		}
+		if !bytes.Equal(got, rv.RowHash) {
+			t.Errorf("chain_seq %d: Go hash %x, stored row_hash %x", rv.ChainSeq, got, rv.RowHash)
+		}
+	}
+}
@@ -138,6 +138,17 @@ func (s *SessionStore) DeleteByUserID(userID int64) {
 	}
 }
 
+// Len returns the number of sessions in the store, including expired
+// sessions not yet removed by CleanupExpired.
+func (s *SessionStore) Len() int {
+	var n int
+	s.sessions.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
 // CleanupExpired removes all expired sessions.
 // This should be called periodically (e.g., every 10 minutes).
 func (s *SessionStore) CleanupExpired() int {
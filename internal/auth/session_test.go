@@ -88,6 +88,24 @@ func TestSessionStore_Delete(t *testing.T) {
 	}
 }
 
+func TestSessionStore_Len(t *testing.T) {
+	store := NewSessionStore()
+	if store.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 for a new store", store.Len())
+	}
+
+	first, _ := store.Create(123, "Mozilla/5.0", "192.168.1.1")
+	_, _ = store.Create(456, "Mozilla/5.0", "192.168.1.2")
+	if store.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", store.Len())
+	}
+
+	store.Delete(first.Token)
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 after Delete", store.Len())
+	}
+}
+
 func TestSessionStore_Expiry(t *testing.T) {
 	store := NewSessionStore()
 
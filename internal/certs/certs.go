@@ -0,0 +1,173 @@
+// Package certs loads the server's TLS certificate and client CA bundle and
+// reloads them without a restart, so renewed certificates are picked up on
+// SIGHUP or when the files change.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Reloader holds the current certificate and client CAs. A failed reload
+// keeps the previous ones, so a half-written renewal never takes the server
+// down. It is safe for concurrent use.
+type Reloader struct {
+	certFile, keyFile, clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	// loaded identifies the file versions in use, for ReloadIfChanged
+	loaded string
+}
+
+// Load reads the certificate, its key and, unless clientCAFile is empty, a
+// PEM bundle of CAs that client certificates must chain to.
+func Load(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again. New TLS connections use the result; open
+// ones are unaffected.
+func (r *Reloader) Reload() error {
+	// Fingerprint first: a file replaced while reading is then seen as
+	// changed again on the next ReloadIfChanged
+	fingerprint, err := r.fingerprint()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		if clientCAs, err = loadCertPool(r.clientCAFile); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.loaded = &cert, clientCAs, fingerprint
+	r.mu.Unlock()
+
+	slog.Info("TLS certificate loaded",
+		"subject", cert.Leaf.Subject.String(), "not_after", cert.Leaf.NotAfter, "client_ca", r.clientCAFile != "")
+	return nil
+}
+
+// ReloadIfChanged reloads when any of the files has changed since the last
+// load, e.g. from jobs.RunPeriodically.
+func (r *Reloader) ReloadIfChanged(ctx context.Context) error {
+	fingerprint, err := r.fingerprint()
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := fingerprint != r.loaded
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return r.Reload()
+}
+
+// TLSConfig returns a server configuration that uses the current
+// certificate and client CAs for each new connection. With client CAs,
+// clients must present a certificate that chains to one of them.
+func (r *Reloader) TLSConfig(minVersion uint16) *tls.Config {
+	config := &tls.Config{
+		MinVersion: minVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.clientCAFile != "" {
+		// Verified here rather than with ClientCAs, which would fix the
+		// pool for the life of the config
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = r.verifyClient
+	}
+	return config
+}
+
+// verifyClient checks the client certificate against the current client CAs.
+// It also runs for resumed sessions, so a removed CA takes effect at once.
+func (r *Reloader) verifyClient(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("client certificate required")
+	}
+	r.mu.RLock()
+	roots := r.clientCAs
+	r.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("verify client certificate: %w", err)
+	}
+	return nil
+}
+
+// MinVersion returns the TLS version for a configured minimum version,
+// "1.2" or "1.3". Empty means TLS 1.2.
+func MinVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q (want 1.2 or 1.3)", version)
+	}
+}
+
+// fingerprint identifies the current version of each file by size and
+// modification time. Files are stat'ed through symlinks, so a Kubernetes
+// secret update, which swaps a symlink, counts as a change.
+func (r *Reloader) fingerprint() (string, error) {
+	var fingerprint string
+	for _, path := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("stat %s: %w", path, err)
+		}
+		fingerprint += fmt.Sprintf("%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return fingerprint, nil
+}
+
+// loadCertPool reads a PEM bundle of CA certificates.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("client CA file %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
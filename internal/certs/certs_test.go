@@ -0,0 +1,293 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for name, for server or client use.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("issue certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data and moves the modification time forward, so a
+// rewrite within the file system's timestamp resolution is still seen.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var mtime time.Time
+	if info, err := os.Stat(path); err == nil {
+		mtime = info.ModTime().Add(time.Second)
+	} else {
+		mtime = time.Now()
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes %s: %v", path, err)
+	}
+}
+
+// handshake connects to a TLS listener using config and returns the common
+// name of the server certificate.
+func handshake(t *testing.T, config *tls.Config, client *tls.Config) (string, error) {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.(*tls.Conn).Handshake()
+		// Wait for the client to finish reading any alert
+		_, _ = conn.Read(make([]byte, 1))
+	}()
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", ln.Addr().String(), client)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+	// TLS 1.3 reports client certificate rejection on the first read
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			return "", err
+		}
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func setupFiles(t *testing.T) (dir string, ca *testCA) {
+	t.Helper()
+	dir = t.TempDir()
+	ca = newTestCA(t, "Test CA")
+	certPEM, keyPEM := ca.issue(t, "one.example", x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "tls.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "tls.key"), keyPEM)
+	return dir, ca
+}
+
+func clientConfig(ca *testCA, serverName string) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &tls.Config{RootCAs: roots, ServerName: serverName}
+}
+
+func TestReloader_ReloadIfChanged(t *testing.T) {
+	dir, ca := setupFiles(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	r, err := Load(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	config := r.TLSConfig(tls.VersionTLS12)
+
+	if name, err := handshake(t, config, clientConfig(ca, "one.example")); err != nil || name != "one.example" {
+		t.Fatalf("handshake = %q, %v; want one.example", name, err)
+	}
+
+	// Unchanged files are not reloaded
+	if err := r.ReloadIfChanged(context.Background()); err != nil {
+		t.Fatalf("ReloadIfChanged: %v", err)
+	}
+
+	// A renewed certificate is served to new connections
+	certPEM, keyPEM := ca.issue(t, "two.example", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	if err := r.ReloadIfChanged(context.Background()); err != nil {
+		t.Fatalf("ReloadIfChanged: %v", err)
+	}
+	if name, err := handshake(t, config, clientConfig(ca, "two.example")); err != nil || name != "two.example" {
+		t.Errorf("handshake after reload = %q, %v; want two.example", name, err)
+	}
+}
+
+func TestReloader_KeepsCertificateOnFailedReload(t *testing.T) {
+	dir, ca := setupFiles(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	r, err := Load(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// A certificate whose key has not been written yet
+	certPEM, _ := ca.issue(t, "two.example", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	if err := r.ReloadIfChanged(context.Background()); err == nil {
+		t.Fatal("ReloadIfChanged with a mismatched key succeeded, want error")
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload with a mismatched key succeeded, want error")
+	}
+
+	name, err := handshake(t, r.TLSConfig(tls.VersionTLS12), clientConfig(ca, "one.example"))
+	if err != nil || name != "one.example" {
+		t.Errorf("handshake = %q, %v; want the previous certificate one.example", name, err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir, _ := setupFiles(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	notPEM := filepath.Join(dir, "ca.txt")
+	writeFile(t, notPEM, []byte("not a certificate"))
+
+	tests := []struct {
+		name                            string
+		certFile, keyFile, clientCAFile string
+	}{
+		{"missing certificate", filepath.Join(dir, "missing.crt"), keyFile, ""},
+		{"key is not a key", certFile, certFile, ""},
+		{"missing client CA file", certFile, keyFile, filepath.Join(dir, "missing-ca.crt")},
+		{"client CA file without certificates", certFile, keyFile, notPEM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.certFile, tt.keyFile, tt.clientCAFile); err == nil {
+				t.Error("Load succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestReloader_ClientCertificates(t *testing.T) {
+	dir, ca := setupFiles(t)
+	clientCA := newTestCA(t, "Client CA")
+	otherCA := newTestCA(t, "Other CA")
+	caFile := filepath.Join(dir, "client-ca.crt")
+	writeFile(t, caFile, clientCA.pem)
+
+	r, err := Load(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), caFile)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	config := r.TLSConfig(tls.VersionTLS12)
+
+	withClientCert := func(issuer *testCA) *tls.Config {
+		c := clientConfig(ca, "one.example")
+		certPEM, keyPEM := issuer.issue(t, "client", x509.ExtKeyUsageClientAuth)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatalf("client key pair: %v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+		return c
+	}
+
+	if _, err := handshake(t, config, clientConfig(ca, "one.example")); err == nil {
+		t.Error("handshake without a client certificate succeeded, want error")
+	}
+	if _, err := handshake(t, config, withClientCert(otherCA)); err == nil {
+		t.Error("handshake with a certificate from another CA succeeded, want error")
+	}
+	if _, err := handshake(t, config, withClientCert(clientCA)); err != nil {
+		t.Errorf("handshake with a trusted client certificate: %v", err)
+	}
+
+	// Rotating the client CA bundle changes which clients are trusted
+	writeFile(t, caFile, otherCA.pem)
+	if err := r.ReloadIfChanged(context.Background()); err != nil {
+		t.Fatalf("ReloadIfChanged: %v", err)
+	}
+	if _, err := handshake(t, config, withClientCert(otherCA)); err != nil {
+		t.Errorf("handshake with a certificate from the new CA: %v", err)
+	}
+	if _, err := handshake(t, config, withClientCert(clientCA)); err == nil {
+		t.Error("handshake with a certificate from the removed CA succeeded, want error")
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{"", tls.VersionTLS12, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.1", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := MinVersion(tt.version)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("MinVersion(%q) = %v, %v; want %v, error %v", tt.version, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -13,10 +13,15 @@ import (
 
 // Config holds all application configuration.
 type Config struct {
-	Database DatabaseConfig `mapstructure:"database"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Session  SessionConfig  `mapstructure:"session"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Session     SessionConfig     `mapstructure:"session"`
+	Logging     LoggingConfig     `mapstructure:"logging"`
+	Retention   RetentionConfig   `mapstructure:"retention"`
+	Memberships MembershipConfig  `mapstructure:"memberships"`
+	Audit       AuditConfig       `mapstructure:"audit"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
 }
 
 // Validate checks if all configuration sections have valid values.
@@ -94,19 +99,116 @@ func escapePassword(password string) string {
 }
 
 // ServerConfig holds HTTP server settings.
+// MetricsPort is the admin port serving /metrics; 0 disables it.
+// ShutdownDelay is how long /readyz reports not-ready, while requests are
+// still served, before the listeners close on shutdown.
+// TrustedProxies are the IPs or CIDRs of reverse proxies whose
+// X-Forwarded-For and X-Real-IP headers identify the client.
 type ServerConfig struct {
-	Port         int           `mapstructure:"port" validate:"required,min=1,max=65535"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout" validate:"required,gt=0"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout" validate:"required,gt=0"`
-	IdleTimeout  time.Duration `mapstructure:"idle_timeout" validate:"required,gt=0"`
+	Port            int                   `mapstructure:"port" validate:"required,min=1,max=65535"`
+	ReadTimeout     time.Duration         `mapstructure:"read_timeout" validate:"required,gt=0"`
+	WriteTimeout    time.Duration         `mapstructure:"write_timeout" validate:"required,gt=0"`
+	IdleTimeout     time.Duration         `mapstructure:"idle_timeout" validate:"required,gt=0"`
+	MetricsPort     int                   `mapstructure:"metrics_port" validate:"omitempty,min=1,max=65535,nefield=Port"`
+	ShutdownDelay   time.Duration         `mapstructure:"shutdown_delay" validate:"gte=0"`
+	TrustedProxies  []string              `mapstructure:"trusted_proxies" validate:"dive,cidr|ip"`
+	CORS            CORSConfig            `mapstructure:"cors"`
+	SecurityHeaders SecurityHeadersConfig `mapstructure:"security_headers"`
+	TLS             TLSConfig             `mapstructure:"tls"`
 }
 
+// TLSConfig holds settings for serving HTTPS on Port, alongside HTTP on the
+// server port. TLS is enabled by setting CertFile and KeyFile.
+// ClientCAFile, a PEM bundle, makes clients authenticate with certificates
+// signed by one of its CAs (mTLS). RedirectHTTP makes the HTTP port only
+// redirect to HTTPS, except for the health probes (/livez, /readyz,
+// /health), which the HTTP port always serves; point orchestrator probes
+// at it. The files are reloaded on SIGHUP and, unless
+// WatchInterval is 0, when a check every WatchInterval sees them change.
+type TLSConfig struct {
+	CertFile      string        `mapstructure:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile       string        `mapstructure:"key_file" validate:"required_with=CertFile"`
+	Port          int           `mapstructure:"port" validate:"required_with=CertFile,omitempty,min=1,max=65535"`
+	MinVersion    string        `mapstructure:"min_version" validate:"omitempty,oneof=1.2 1.3"`
+	ClientCAFile  string        `mapstructure:"client_ca_file" validate:"excluded_without=CertFile"`
+	RedirectHTTP  bool          `mapstructure:"redirect_http" validate:"excluded_without=CertFile"`
+	WatchInterval time.Duration `mapstructure:"watch_interval" validate:"gte=0"`
+}
+
+// Enabled reports whether HTTPS is served.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// CORSConfig holds settings for cross-origin requests, e.g. from the SPA.
+// AllowedOrigins are origins such as "https://app.example.com"; empty
+// disables CORS, and "*" allows any origin but never with credentials.
+// AllowCredentials lets allowed origins send the session cookie.
+// MaxAge is how long browsers may cache a preflight response.
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" validate:"dive,corsorigin"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age" validate:"gte=0"`
+}
+
+// SecurityHeadersConfig holds the security headers sent with every response.
+// HSTSMaxAge of 0 omits Strict-Transport-Security; an empty FrameOptions
+// or ContentSecurityPolicy omits that header.
+type SecurityHeadersConfig struct {
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age" validate:"gte=0"`
+	FrameOptions          string        `mapstructure:"frame_options" validate:"omitempty,oneof=DENY SAMEORIGIN"`
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+}
+
+// DefaultContentSecurityPolicy suits an API that serves only JSON: it
+// allows no content to load and the responses to be framed nowhere.
+const DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
 // SessionConfig holds session management settings.
 type SessionConfig struct {
 	Duration        time.Duration `mapstructure:"duration" validate:"required,gt=0"`
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval" validate:"required,gt=0"`
 }
 
+// RetentionConfig holds data retention settings.
+// ArchivedGroupDays of 0 disables hard deletion of archived groups.
+type RetentionConfig struct {
+	ArchivedGroupDays int           `mapstructure:"archived_group_days" validate:"min=0"`
+	PurgeInterval     time.Duration `mapstructure:"purge_interval" validate:"required,gt=0"`
+}
+
+// ArchivedGroupRetention returns how long archived groups are kept, or 0 if
+// they are kept forever.
+func (c RetentionConfig) ArchivedGroupRetention() time.Duration {
+	return time.Duration(c.ArchivedGroupDays) * 24 * time.Hour
+}
+
+// MembershipConfig holds settings for time-bound memberships.
+// ExpiryNotice of 0 disables the expiring-soon notification.
+type MembershipConfig struct {
+	ExpiryInterval time.Duration `mapstructure:"expiry_interval" validate:"required,gt=0"`
+	ExpiryNotice   time.Duration `mapstructure:"expiry_notice" validate:"gte=0"`
+}
+
+// AuditConfig holds settings for the audit log hash chain and its partitions.
+// An empty SigningKeyFile disables periodic signed checkpoints.
+// RetentionMonths of 0 keeps every partition in the database.
+type AuditConfig struct {
+	SigningKeyFile       string        `mapstructure:"signing_key_file"`
+	CheckpointInterval   time.Duration `mapstructure:"checkpoint_interval" validate:"required,gt=0"`
+	PartitionMonthsAhead int           `mapstructure:"partition_months_ahead" validate:"min=1"`
+	RetentionMonths      int           `mapstructure:"retention_months" validate:"min=0"`
+	ArchiveDir           string        `mapstructure:"archive_dir" validate:"required"`
+	MaintenanceInterval  time.Duration `mapstructure:"maintenance_interval" validate:"required,gt=0"`
+}
+
+// IdempotencyConfig holds settings for Idempotency-Key support on POST
+// endpoints. TTL is how long a stored response is replayed for retries.
+type IdempotencyConfig struct {
+	TTL             time.Duration `mapstructure:"ttl" validate:"required,gt=0"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval" validate:"required,gt=0"`
+}
+
 // LogLevel represents valid log levels.
 // Note: This type is defined for documentation and type-safe usage in code,
 // but LoggingConfig uses string for Level to simplify Viper unmarshaling.
@@ -170,6 +272,44 @@ type LoggingConfig struct {
 	Output string `mapstructure:"output" validate:"required"`
 }
 
+// TracingConfig holds OpenTelemetry tracing settings.
+// An empty OTLPEndpoint leaves the endpoint to the OTEL_EXPORTER_OTLP_*
+// environment variables (default http://localhost:4318).
+type TracingConfig struct {
+	Exporter     string  `mapstructure:"exporter" validate:"required,traceexporter"`
+	OTLPEndpoint string  `mapstructure:"otlp_endpoint"`
+	ServiceName  string  `mapstructure:"service_name" validate:"required"`
+	SampleRatio  float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`
+}
+
+// TraceExporter represents valid trace exporters.
+// Note: This type is defined for documentation and type-safe usage in code,
+// but TracingConfig uses string for Exporter to simplify Viper unmarshaling.
+// Validation is handled by the "traceexporter" custom validator in internal/validation.
+type TraceExporter string
+
+// Valid trace exporters.
+const (
+	TraceExporterNone   TraceExporter = "none"
+	TraceExporterStdout TraceExporter = "stdout"
+	TraceExporterOTLP   TraceExporter = "otlp"
+)
+
+// Valid returns true if the TraceExporter is a recognized exporter.
+func (e TraceExporter) Valid() bool {
+	switch e {
+	case TraceExporterNone, TraceExporterStdout, TraceExporterOTLP:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the TraceExporter.
+func (e TraceExporter) String() string {
+	return string(e)
+}
+
 // NewViper creates a new Viper instance with defaults set.
 // Use this when you need to bind CLI flags before loading config.
 func NewViper() *viper.Viper {
@@ -243,6 +383,28 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.read_timeout", 15*time.Second)
 	v.SetDefault("server.write_timeout", 15*time.Second)
 	v.SetDefault("server.idle_timeout", 60*time.Second)
+	v.SetDefault("server.metrics_port", 9090)
+	v.SetDefault("server.shutdown_delay", 5*time.Second)
+	v.SetDefault("server.trusted_proxies", []string{})
+
+	// CORS defaults (disabled until origins are configured)
+	v.SetDefault("server.cors.allowed_origins", []string{})
+	v.SetDefault("server.cors.allow_credentials", false)
+	v.SetDefault("server.cors.max_age", 10*time.Minute)
+
+	// Security header defaults (HSTS for a year; no framing, no content)
+	v.SetDefault("server.security_headers.hsts_max_age", 365*24*time.Hour)
+	v.SetDefault("server.security_headers.frame_options", "DENY")
+	v.SetDefault("server.security_headers.content_security_policy", DefaultContentSecurityPolicy)
+
+	// TLS defaults (disabled until a certificate is configured)
+	v.SetDefault("server.tls.cert_file", "")
+	v.SetDefault("server.tls.key_file", "")
+	v.SetDefault("server.tls.port", 8443)
+	v.SetDefault("server.tls.min_version", "1.2")
+	v.SetDefault("server.tls.client_ca_file", "")
+	v.SetDefault("server.tls.redirect_http", false)
+	v.SetDefault("server.tls.watch_interval", time.Minute)
 
 	// Session defaults
 	v.SetDefault("session.duration", 168*time.Hour)
@@ -252,4 +414,32 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
+
+	// Retention defaults (hard deletion disabled)
+	v.SetDefault("retention.archived_group_days", 0)
+	v.SetDefault("retention.purge_interval", time.Hour)
+
+	// Membership expiry defaults (notify three days ahead)
+	v.SetDefault("memberships.expiry_interval", 5*time.Minute)
+	v.SetDefault("memberships.expiry_notice", 72*time.Hour)
+
+	// Audit checkpoint defaults (disabled until a signing key is configured)
+	v.SetDefault("audit.signing_key_file", "")
+	v.SetDefault("audit.checkpoint_interval", 24*time.Hour)
+
+	// Audit partition defaults (archiving disabled)
+	v.SetDefault("audit.partition_months_ahead", 3)
+	v.SetDefault("audit.retention_months", 0)
+	v.SetDefault("audit.archive_dir", "./audit-archive")
+	v.SetDefault("audit.maintenance_interval", 24*time.Hour)
+
+	// Tracing defaults (disabled)
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.otlp_endpoint", "")
+	v.SetDefault("tracing.service_name", "llmio")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	// Idempotency key defaults (responses replayed for a day)
+	v.SetDefault("idempotency.ttl", 24*time.Hour)
+	v.SetDefault("idempotency.cleanup_interval", time.Hour)
 }
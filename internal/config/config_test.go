@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -111,6 +112,9 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Server.IdleTimeout != 60*time.Second {
 		t.Errorf("expected 60s, got %v", cfg.Server.IdleTimeout)
 	}
+	if cfg.Server.MetricsPort != 9090 {
+		t.Errorf("expected 9090, got %d", cfg.Server.MetricsPort)
+	}
 
 	// Session defaults
 	if cfg.Session.Duration != 168*time.Hour {
@@ -130,6 +134,105 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Logging.Output != "stdout" {
 		t.Errorf("expected stdout, got %s", cfg.Logging.Output)
 	}
+
+	// Retention defaults
+	if cfg.Retention.ArchivedGroupDays != 0 {
+		t.Errorf("expected 0 (disabled), got %d", cfg.Retention.ArchivedGroupDays)
+	}
+	if cfg.Retention.PurgeInterval != time.Hour {
+		t.Errorf("expected 1h, got %v", cfg.Retention.PurgeInterval)
+	}
+
+	// Membership expiry defaults
+	if cfg.Memberships.ExpiryInterval != 5*time.Minute {
+		t.Errorf("expected 5m, got %v", cfg.Memberships.ExpiryInterval)
+	}
+	if cfg.Memberships.ExpiryNotice != 72*time.Hour {
+		t.Errorf("expected 72h, got %v", cfg.Memberships.ExpiryNotice)
+	}
+
+	// Audit checkpoint defaults
+	if cfg.Audit.SigningKeyFile != "" {
+		t.Errorf("expected no signing key, got %q", cfg.Audit.SigningKeyFile)
+	}
+	if cfg.Audit.CheckpointInterval != 24*time.Hour {
+		t.Errorf("expected 24h, got %v", cfg.Audit.CheckpointInterval)
+	}
+
+	// Audit partition defaults
+	if cfg.Audit.PartitionMonthsAhead != 3 {
+		t.Errorf("expected 3 months ahead, got %d", cfg.Audit.PartitionMonthsAhead)
+	}
+	if cfg.Audit.RetentionMonths != 0 {
+		t.Errorf("expected archiving disabled, got %d months", cfg.Audit.RetentionMonths)
+	}
+	if cfg.Audit.ArchiveDir != "./audit-archive" {
+		t.Errorf("expected ./audit-archive, got %q", cfg.Audit.ArchiveDir)
+	}
+	if cfg.Audit.MaintenanceInterval != 24*time.Hour {
+		t.Errorf("expected 24h, got %v", cfg.Audit.MaintenanceInterval)
+	}
+
+	// Tracing defaults
+	if cfg.Tracing.Exporter != "none" {
+		t.Errorf("expected tracing disabled, got exporter %q", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.ServiceName != "llmio" {
+		t.Errorf("expected service name llmio, got %q", cfg.Tracing.ServiceName)
+	}
+	if cfg.Tracing.SampleRatio != 1.0 {
+		t.Errorf("expected sample ratio 1.0, got %v", cfg.Tracing.SampleRatio)
+	}
+
+	// Idempotency defaults
+	if cfg.Idempotency.TTL != 24*time.Hour {
+		t.Errorf("expected 24h, got %v", cfg.Idempotency.TTL)
+	}
+	if cfg.Idempotency.CleanupInterval != time.Hour {
+		t.Errorf("expected 1h, got %v", cfg.Idempotency.CleanupInterval)
+	}
+
+	if cfg.Server.ShutdownDelay != 5*time.Second {
+		t.Errorf("expected 5s shutdown delay, got %v", cfg.Server.ShutdownDelay)
+	}
+
+	// No trusted proxies: forwarding headers are ignored
+	if len(cfg.Server.TrustedProxies) != 0 {
+		t.Errorf("expected no trusted proxies, got %v", cfg.Server.TrustedProxies)
+	}
+
+	// CORS defaults (disabled)
+	if len(cfg.Server.CORS.AllowedOrigins) != 0 {
+		t.Errorf("expected no CORS origins, got %v", cfg.Server.CORS.AllowedOrigins)
+	}
+	if cfg.Server.CORS.MaxAge != 10*time.Minute {
+		t.Errorf("expected 10m, got %v", cfg.Server.CORS.MaxAge)
+	}
+
+	// Security header defaults
+	if cfg.Server.SecurityHeaders.HSTSMaxAge != 365*24*time.Hour {
+		t.Errorf("expected 8760h, got %v", cfg.Server.SecurityHeaders.HSTSMaxAge)
+	}
+	if cfg.Server.SecurityHeaders.FrameOptions != "DENY" {
+		t.Errorf("expected DENY, got %q", cfg.Server.SecurityHeaders.FrameOptions)
+	}
+	if cfg.Server.SecurityHeaders.ContentSecurityPolicy != DefaultContentSecurityPolicy {
+		t.Errorf("expected default CSP, got %q", cfg.Server.SecurityHeaders.ContentSecurityPolicy)
+	}
+
+	// TLS defaults (disabled)
+	if cfg.Server.TLS.Enabled() {
+		t.Errorf("expected TLS disabled, got cert file %q", cfg.Server.TLS.CertFile)
+	}
+	if cfg.Server.TLS.Port != 8443 {
+		t.Errorf("expected 8443, got %d", cfg.Server.TLS.Port)
+	}
+	if cfg.Server.TLS.MinVersion != "1.2" {
+		t.Errorf("expected 1.2, got %q", cfg.Server.TLS.MinVersion)
+	}
+	if cfg.Server.TLS.WatchInterval != time.Minute {
+		t.Errorf("expected 1m, got %v", cfg.Server.TLS.WatchInterval)
+	}
 }
 
 // T033: Test for environment variable override (LOOMIO_*).
@@ -595,6 +698,21 @@ func TestServerConfig_Validate(t *testing.T) {
 		t.Errorf("valid config should pass validation, got: %v", err)
 	}
 
+	withProxies := validConfig
+	withProxies.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.10", "2001:db8::/32"}
+	if err := validation.Validate(withProxies); err != nil {
+		t.Errorf("trusted proxy IPs and CIDRs should pass validation, got: %v", err)
+	}
+
+	withTLS := validConfig
+	withTLS.TLS = TLSConfig{
+		CertFile: "tls.crt", KeyFile: "tls.key", Port: 8443, MinVersion: "1.3",
+		ClientCAFile: "ca.crt", RedirectHTTP: true,
+	}
+	if err := validation.Validate(withTLS); err != nil {
+		t.Errorf("valid TLS config should pass validation, got: %v", err)
+	}
+
 	tests := []struct {
 		name      string
 		modify    func(*ServerConfig)
@@ -620,6 +738,58 @@ func TestServerConfig_Validate(t *testing.T) {
 			modify:    func(c *ServerConfig) { c.IdleTimeout = 0 },
 			wantField: "IdleTimeout",
 		},
+		{
+			name:      "shutdown_delay negative",
+			modify:    func(c *ServerConfig) { c.ShutdownDelay = -time.Second },
+			wantField: "ShutdownDelay",
+		},
+		{
+			name:      "trusted proxy not an IP or CIDR",
+			modify:    func(c *ServerConfig) { c.TrustedProxies = []string{"10.0.0.0/8", "proxy.internal"} },
+			wantField: "TrustedProxies",
+		},
+		{
+			name:      "cors origin with path",
+			modify:    func(c *ServerConfig) { c.CORS.AllowedOrigins = []string{"https://app.example.com/"} },
+			wantField: "AllowedOrigins",
+		},
+		{
+			name:      "cors max_age negative",
+			modify:    func(c *ServerConfig) { c.CORS.MaxAge = -time.Second },
+			wantField: "MaxAge",
+		},
+		{
+			name:      "tls cert without key",
+			modify:    func(c *ServerConfig) { c.TLS.CertFile = "tls.crt" },
+			wantField: "KeyFile",
+		},
+		{
+			name: "tls without port",
+			modify: func(c *ServerConfig) {
+				c.TLS.CertFile, c.TLS.KeyFile = "tls.crt", "tls.key"
+			},
+			wantField: "Port",
+		},
+		{
+			name:      "tls min_version unsupported",
+			modify:    func(c *ServerConfig) { c.TLS.MinVersion = "1.1" },
+			wantField: "MinVersion",
+		},
+		{
+			name:      "redirect_http without tls",
+			modify:    func(c *ServerConfig) { c.TLS.RedirectHTTP = true },
+			wantField: "RedirectHTTP",
+		},
+		{
+			name:      "client_ca_file without tls",
+			modify:    func(c *ServerConfig) { c.TLS.ClientCAFile = "ca.crt" },
+			wantField: "ClientCAFile",
+		},
+		{
+			name:      "unknown frame_options",
+			modify:    func(c *ServerConfig) { c.SecurityHeaders.FrameOptions = "ALLOW-FROM https://example.com" },
+			wantField: "FrameOptions",
+		},
 	}
 
 	for _, tt := range tests {
@@ -680,6 +850,102 @@ func TestSessionConfig_Validate(t *testing.T) {
 	}
 }
 
+// Test RetentionConfig validation catches invalid values.
+func TestRetentionConfig_Validate(t *testing.T) {
+	validConfig := RetentionConfig{
+		ArchivedGroupDays: 90,
+		PurgeInterval:     time.Hour,
+	}
+
+	if err := validation.Validate(validConfig); err != nil {
+		t.Errorf("valid config should pass validation, got: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*RetentionConfig)
+		wantField string
+	}{
+		{
+			name:      "archived_group_days negative",
+			modify:    func(c *RetentionConfig) { c.ArchivedGroupDays = -1 },
+			wantField: "ArchivedGroupDays",
+		},
+		{
+			name:      "purge_interval zero",
+			modify:    func(c *RetentionConfig) { c.PurgeInterval = 0 },
+			wantField: "PurgeInterval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig
+			tt.modify(&cfg)
+			err := validation.Validate(cfg)
+			if err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("error should reference field %q, got: %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+// Test ArchivedGroupRetention converts days to a duration.
+func TestRetentionConfig_ArchivedGroupRetention(t *testing.T) {
+	if got := (RetentionConfig{ArchivedGroupDays: 0}).ArchivedGroupRetention(); got != 0 {
+		t.Errorf("expected 0 for disabled retention, got %v", got)
+	}
+	if got := (RetentionConfig{ArchivedGroupDays: 30}).ArchivedGroupRetention(); got != 30*24*time.Hour {
+		t.Errorf("expected 720h, got %v", got)
+	}
+}
+
+// Test MembershipConfig validation catches invalid values.
+func TestMembershipConfig_Validate(t *testing.T) {
+	validConfig := MembershipConfig{
+		ExpiryInterval: 5 * time.Minute,
+		ExpiryNotice:   0,
+	}
+
+	if err := validation.Validate(validConfig); err != nil {
+		t.Errorf("valid config should pass validation, got: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*MembershipConfig)
+		wantField string
+	}{
+		{
+			name:      "expiry_interval zero",
+			modify:    func(c *MembershipConfig) { c.ExpiryInterval = 0 },
+			wantField: "ExpiryInterval",
+		},
+		{
+			name:      "expiry_notice negative",
+			modify:    func(c *MembershipConfig) { c.ExpiryNotice = -time.Hour },
+			wantField: "ExpiryNotice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig
+			tt.modify(&cfg)
+			err := validation.Validate(cfg)
+			if err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantField) {
+				t.Errorf("error should reference field %q, got: %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
 // T102: Test LoggingConfig validation catches invalid values.
 func TestLoggingConfig_Validate(t *testing.T) {
 	validConfig := LoggingConfig{
@@ -798,6 +1064,28 @@ func TestLogFormat_Valid(t *testing.T) {
 	}
 }
 
+// Test TraceExporter.Valid() for all known exporters.
+func TestTraceExporter_Valid(t *testing.T) {
+	validExporters := []TraceExporter{
+		TraceExporterNone,
+		TraceExporterStdout,
+		TraceExporterOTLP,
+	}
+
+	for _, exporter := range validExporters {
+		if !exporter.Valid() {
+			t.Errorf("TraceExporter %q should be valid", exporter)
+		}
+	}
+
+	invalidExporters := []TraceExporter{"jaeger", "OTLP", ""}
+	for _, exporter := range invalidExporters {
+		if exporter.Valid() {
+			t.Errorf("TraceExporter %q should be invalid", exporter)
+		}
+	}
+}
+
 // T103/T104: Test that Load() fails with invalid config values.
 func TestLoad_ValidationFailure(t *testing.T) {
 	// Create a config file with invalid values
@@ -832,6 +1120,44 @@ server:
 	}
 }
 
+// Test that the metrics port may be disabled but must not clash with the API port.
+func TestLoad_CORSOriginsFromEnv(t *testing.T) {
+	t.Setenv("LOOMIO_SERVER_CORS_ALLOWED_ORIGINS", "https://app.example.com,http://localhost:5173")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []string{"https://app.example.com", "http://localhost:5173"}
+	if fmt.Sprint(cfg.Server.CORS.AllowedOrigins) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, cfg.Server.CORS.AllowedOrigins)
+	}
+}
+
+func TestLoad_MetricsPort(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	load := func(metricsPort int) error {
+		configPath := tmpDir + "/metrics_config.yaml"
+		yamlContent := fmt.Sprintf("server:\n  port: 8080\n  metrics_port: %d\n", metricsPort)
+		if err := os.WriteFile(configPath, []byte(yamlContent), 0600); err != nil {
+			t.Fatalf("Failed to write temp config: %v", err)
+		}
+		_, err := Load(configPath)
+		return err
+	}
+
+	if err := load(0); err != nil {
+		t.Errorf("metrics_port 0 should disable the admin server, got: %v", err)
+	}
+	if err := load(9100); err != nil {
+		t.Errorf("metrics_port 9100 should be valid, got: %v", err)
+	}
+	if err := load(8080); err == nil || !strings.Contains(err.Error(), "MetricsPort") {
+		t.Errorf("expected MetricsPort error when it equals the API port, got: %v", err)
+	}
+}
+
 // T138: Test that type coercion failures in YAML cause unmarshal errors.
 // For example, setting port to a non-numeric string should fail.
 func TestLoad_UnmarshalError(t *testing.T) {
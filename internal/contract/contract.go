@@ -0,0 +1,421 @@
+// Package contract compares OpenAPI documents and reports the changes that
+// would break a client written against the older one: removed operations,
+// responses and fields, changed types, and new required parameters or
+// request fields. Additions that clients can ignore are not reported.
+package contract
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Kind classifies a breaking change.
+type Kind string
+
+const (
+	RemovedOperation     Kind = "removed operation"
+	RemovedResponse      Kind = "removed response"
+	RemovedField         Kind = "removed field"
+	ChangedType          Kind = "changed type"
+	NewRequiredParameter Kind = "new required parameter"
+	NewRequiredField     Kind = "new required field"
+)
+
+// Change is a breaking change to one operation.
+type Change struct {
+	// Operation is the method and full path, e.g. "GET /api/v1/groups/{}",
+	// with path parameter names elided.
+	Operation string
+	Kind      Kind
+	// Location is where in the operation the change is, e.g.
+	// "response 200 body.group.name" or "query limit".
+	Location string
+	Detail   string
+}
+
+func (c Change) String() string {
+	s := c.Operation + ": " + string(c.Kind)
+	if c.Location != "" {
+		s += " " + c.Location
+	}
+	if c.Detail != "" {
+		s += " (" + c.Detail + ")"
+	}
+	return s
+}
+
+// Document is a parsed OpenAPI 3.x document.
+type Document struct {
+	root map[string]any
+	// prefix is the path of the first server URL, which operation paths are
+	// relative to.
+	prefix string
+}
+
+// Load reads an OpenAPI document in YAML or JSON from path.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Parse parses an OpenAPI document in YAML or JSON.
+func Parse(data []byte) (*Document, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if _, ok := root["openapi"]; !ok {
+		return nil, fmt.Errorf("not an OpenAPI document: missing openapi version")
+	}
+	doc := &Document{root: root}
+	if servers := list(root["servers"]); len(servers) > 0 {
+		if u, err := url.Parse(str(object(servers[0])["url"])); err == nil {
+			doc.prefix = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	return doc, nil
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// pathParam matches a templated path segment.
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// operation is an operation of a document with its path-level parameters
+// merged in.
+type operation struct {
+	key    string
+	op     map[string]any
+	params []any
+}
+
+// operations indexes the document's operations by method and full path.
+func (d *Document) operations() map[string]operation {
+	ops := map[string]operation{}
+	for path, item := range object(d.root["paths"]) {
+		item := object(item)
+		full := pathParam.ReplaceAllString(d.prefix+path, "{}")
+		for _, method := range httpMethods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			key := strings.ToUpper(method) + " " + full
+			params := append(slices.Clone(list(item["parameters"])), list(op["parameters"])...)
+			ops[key] = operation{key: key, op: op, params: params}
+		}
+	}
+	return ops
+}
+
+// Breaking returns the changes from base to current that break clients of
+// base, sorted by operation. Only operations in base are checked, and only
+// the JSON bodies of successful responses: error responses are compared by
+// status code alone.
+func Breaking(base, current *Document) []Change {
+	c := &comparer{base: base, current: current}
+	baseOps := base.operations()
+	currentOps := current.operations()
+
+	for _, key := range slices.Sorted(maps.Keys(baseOps)) {
+		cur, ok := currentOps[key]
+		if !ok {
+			c.add(key, RemovedOperation, "", "")
+			continue
+		}
+		c.operation(baseOps[key], cur)
+	}
+	return c.changes
+}
+
+type comparer struct {
+	base, current *Document
+	changes       []Change
+}
+
+func (c *comparer) add(op string, kind Kind, location, detail string) {
+	c.changes = append(c.changes, Change{Operation: op, Kind: kind, Location: location, Detail: detail})
+}
+
+func (c *comparer) operation(base, cur operation) {
+	c.parameters(base, cur)
+	c.requestBody(base, cur)
+	c.responses(base, cur)
+}
+
+// parameters reports required parameters of current that base clients do
+// not send, and parameters whose type changed. Path parameters are matched
+// by the path itself.
+func (c *comparer) parameters(base, cur operation) {
+	baseParams := map[string]map[string]any{}
+	for _, p := range base.params {
+		p := c.base.resolve(p)
+		baseParams[str(p["in"])+" "+str(p["name"])] = p
+	}
+	for _, p := range cur.params {
+		p := c.current.resolve(p)
+		in, name := str(p["in"]), str(p["name"])
+		if in == "path" {
+			continue
+		}
+		location := in + " parameter " + name
+		bp, ok := baseParams[in+" "+name]
+		if p["required"] == true && (!ok || bp["required"] != true) {
+			c.add(cur.key, NewRequiredParameter, in+" "+name, "")
+		}
+		if ok {
+			c.schema(cur.key, location, object(bp["schema"]), object(p["schema"]), true, nil)
+		}
+	}
+}
+
+// requestBody compares the JSON request bodies.
+func (c *comparer) requestBody(base, cur operation) {
+	baseBody := c.base.resolve(base.op["requestBody"])
+	curBody := c.current.resolve(cur.op["requestBody"])
+	if curBody == nil {
+		return
+	}
+	if baseBody == nil {
+		if curBody["required"] == true {
+			c.add(cur.key, NewRequiredField, "request body", "")
+		}
+		return
+	}
+	c.schema(cur.key, "request body", jsonSchema(baseBody), jsonSchema(curBody), true, nil)
+}
+
+// responses checks that every response status of base is still declared,
+// and compares the JSON bodies of successful ones.
+func (c *comparer) responses(base, cur operation) {
+	baseResponses := object(base.op["responses"])
+	curResponses := object(cur.op["responses"])
+
+	for _, status := range slices.Sorted(maps.Keys(baseResponses)) {
+		curResponse, ok := curResponses[status]
+		if !ok {
+			if !strings.HasPrefix(status, "2") {
+				// Undeclared errors fall back to the default response
+				if _, ok := curResponses["default"]; ok {
+					continue
+				}
+			}
+			c.add(cur.key, RemovedResponse, status, "")
+			continue
+		}
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		baseSchema := jsonSchema(c.base.resolve(baseResponses[status]))
+		curSchema := jsonSchema(c.current.resolve(curResponse))
+		c.schema(cur.key, "response "+status+" body", baseSchema, curSchema, false, nil)
+	}
+}
+
+// schema compares two schemas at location. For requests, current must
+// accept everything base clients send: no new required fields and no
+// narrower types. For responses, current must return everything base
+// clients read: no removed fields and no wider types. seen guards against
+// recursive schemas.
+func (c *comparer) schema(op, location string, base, cur map[string]any, request bool, seen map[string]bool) {
+	if ref := str(base["$ref"]) + "|" + str(cur["$ref"]); ref != "|" {
+		if seen[ref] {
+			return
+		}
+		seen = maps.Clone(seen)
+		if seen == nil {
+			seen = map[string]bool{}
+		}
+		seen[ref] = true
+	}
+	base = c.base.flatten(base)
+	cur = c.current.flatten(cur)
+	if base == nil || cur == nil {
+		return
+	}
+
+	baseTypes, curTypes := types(base), types(cur)
+	if len(baseTypes) > 0 && len(curTypes) > 0 {
+		from, to := baseTypes, curTypes
+		if request {
+			// Everything base clients send must still be accepted
+			from, to = curTypes, baseTypes
+		}
+		for _, t := range to {
+			if !acceptsType(from, t) {
+				c.add(op, ChangedType, location, strings.Join(baseTypes, "|")+" -> "+strings.Join(curTypes, "|"))
+				return
+			}
+		}
+	}
+
+	if baseItems, ok := base["items"].(map[string]any); ok {
+		if curItems, ok := cur["items"].(map[string]any); ok {
+			c.schema(op, location+"[]", baseItems, curItems, request, seen)
+		}
+	}
+
+	baseProps, curProps := object(base["properties"]), object(cur["properties"])
+	if request {
+		baseRequired := stringSet(base["required"])
+		curRequired := stringSet(cur["required"])
+		for _, name := range slices.Sorted(maps.Keys(curProps)) {
+			// A required field with a default may be omitted: the server
+			// fills it in before validating
+			_, hasDefault := c.current.resolve(curProps[name])["default"]
+			if curRequired[name] && !baseRequired[name] && !hasDefault {
+				c.add(op, NewRequiredField, location+"."+name, "")
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(baseProps)) {
+		curProp, ok := curProps[name]
+		if !ok {
+			// Response readers lose it; request senders only break if
+			// current rejects unknown fields
+			if !request || cur["additionalProperties"] == false {
+				c.add(op, RemovedField, location+"."+name, "")
+			}
+			continue
+		}
+		c.schema(op, location+"."+name, object(baseProps[name]), object(curProp), request, seen)
+	}
+}
+
+// resolve follows a local $ref, returning v itself if it is not one.
+func (d *Document) resolve(v any) map[string]any {
+	m := object(v)
+	for range 10 {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		m = d.lookup(ref)
+	}
+	return m
+}
+
+// lookup returns the object at a local JSON pointer such as
+// "#/components/schemas/Group".
+func (d *Document) lookup(ref string) map[string]any {
+	ptr, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var cur any = d.root
+	for _, part := range strings.Split(ptr, "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		cur = object(cur)[part]
+	}
+	return object(cur)
+}
+
+// flatten resolves a schema and merges its allOf members into it.
+func (d *Document) flatten(schema map[string]any) map[string]any {
+	schema = d.resolve(schema)
+	all := list(schema["allOf"])
+	if len(all) == 0 {
+		return schema
+	}
+	merged := map[string]any{}
+	props := map[string]any{}
+	var required []any
+	parts := []map[string]any{schema}
+	for _, part := range all {
+		parts = append(parts, d.flatten(object(part)))
+	}
+	for _, part := range parts {
+		for k, v := range part {
+			switch k {
+			case "allOf":
+			case "properties":
+				for name, p := range object(v) {
+					props[name] = p
+				}
+			case "required":
+				required = append(required, list(v)...)
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	merged["properties"] = props
+	merged["required"] = required
+	return merged
+}
+
+// jsonSchema returns the JSON schema of a request body or response.
+func jsonSchema(v map[string]any) map[string]any {
+	content := object(v["content"])
+	for _, ct := range slices.Sorted(maps.Keys(content)) {
+		if ct == "application/json" || strings.HasSuffix(ct, "+json") {
+			return object(object(content[ct])["schema"])
+		}
+	}
+	return nil
+}
+
+// types returns the non-null types a schema allows, sorted. A schema with
+// properties but no type is an object.
+func types(schema map[string]any) []string {
+	var out []string
+	switch t := schema["type"].(type) {
+	case string:
+		out = []string{t}
+	case []any:
+		for _, v := range t {
+			if s := str(v); s != "null" {
+				out = append(out, s)
+			}
+		}
+	default:
+		if _, ok := schema["properties"]; ok {
+			out = []string{"object"}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// acceptsType reports whether a value of type t is valid for one of types.
+func acceptsType(types []string, t string) bool {
+	return slices.Contains(types, t) || (t == "integer" && slices.Contains(types, "number"))
+}
+
+func object(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func list(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func stringSet(v any) map[string]bool {
+	set := map[string]bool{}
+	for _, s := range list(v) {
+		set[str(s)] = true
+	}
+	return set
+}
@@ -0,0 +1,205 @@
+package contract
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// baseSpec is the contract the test cases change.
+const baseSpec = `
+openapi: 3.1.0
+servers:
+  - url: https://example.com/api/v1
+paths:
+  /groups/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+        - {name: include, in: query, schema: {type: string}}
+      responses:
+        '200':
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/GroupResponse'}
+        '404':
+          description: Not found
+    patch:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+                description: {type: string}
+      responses:
+        '200': {description: Updated}
+components:
+  schemas:
+    GroupResponse:
+      type: object
+      properties:
+        group: {$ref: '#/components/schemas/Group'}
+    Group:
+      allOf:
+        - {$ref: '#/components/schemas/Base'}
+        - type: object
+          properties:
+            name: {type: string}
+            member_count: {type: integer}
+            tags: {type: array, items: {type: string}}
+    Base:
+      type: object
+      properties:
+        id: {type: integer}
+`
+
+// currentSpec is an implementation of baseSpec with different names for
+// the path parameter and schemas, and no server prefix.
+const currentSpec = `{
+  "openapi": "3.1.0",
+  "paths": {
+    "/api/v1/groups/{groupId}": {
+      "get": {
+        "parameters": [
+          {"name": "groupId", "in": "path", "required": true, "schema": {"type": "integer"}},
+          {"name": "include", "in": "query", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}}
+        ],
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Out"}}}},
+          "default": {"description": "Error"}
+        }
+      },
+      "patch": {
+        "requestBody": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["role"],
+          "properties": {
+            "name": {"type": "string"},
+            "description": {"type": "string"},
+            "role": {"type": "string", "default": "member"},
+            "extra": {"type": "string"}
+          }
+        }}}},
+        "responses": {"200": {"description": "Updated"}}
+      }
+    }
+  },
+  "components": {"schemas": {
+    "Out": {"type": "object", "properties": {"group": {"$ref": "#/components/schemas/G"}, "extra": {"type": "string"}}},
+    "G": {"type": "object", "properties": {
+      "id": {"type": "integer"},
+      "name": {"type": ["string", "null"]},
+      "member_count": {"type": "integer"},
+      "tags": {"type": "array", "items": {"type": "string"}}
+    }}
+  }}
+}`
+
+func mustParse(t *testing.T, spec string) *Document {
+	t.Helper()
+	doc, err := Parse([]byte(spec))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return doc
+}
+
+// TestBreaking_Compatible verifies renamed parameters and schemas, added
+// optional parameters, fields and responses, and a default error response
+// are not reported.
+func TestBreaking_Compatible(t *testing.T) {
+	if changes := Breaking(mustParse(t, baseSpec), mustParse(t, currentSpec)); len(changes) != 0 {
+		t.Errorf("expected no breaking changes, got %v", changes)
+	}
+}
+
+// TestBreaking_Changes verifies each kind of breaking change is reported.
+func TestBreaking_Changes(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "removed operation",
+			old:  `"patch": {`,
+			new:  `"put": {`,
+			want: "PATCH /api/v1/groups/{}: removed operation",
+		},
+		{
+			name: "removed response field",
+			old:  `"member_count": {"type": "integer"},`,
+			new:  ``,
+			want: "GET /api/v1/groups/{}: removed field response 200 body.group.member_count",
+		},
+		{
+			name: "changed array item type",
+			old:  `"items": {"type": "string"}`,
+			new:  `"items": {"type": "integer"}`,
+			want: "GET /api/v1/groups/{}: changed type response 200 body.group.tags[] (string -> integer)",
+		},
+		{
+			name: "widened response type",
+			old:  `"member_count": {"type": "integer"}`,
+			new:  `"member_count": {"type": "number"}`,
+			want: "GET /api/v1/groups/{}: changed type response 200 body.group.member_count (integer -> number)",
+		},
+		{
+			name: "removed success response",
+			old:  `"200": {"content"`,
+			new:  `"201": {"content"`,
+			want: "GET /api/v1/groups/{}: removed response 200",
+		},
+		{
+			name: "new required parameter",
+			old:  `{"name": "limit", "in": "query", "schema"`,
+			new:  `{"name": "limit", "in": "query", "required": true, "schema"`,
+			want: "GET /api/v1/groups/{}: new required parameter query limit",
+		},
+		{
+			name: "narrowed parameter type",
+			old:  `{"name": "include", "in": "query", "schema": {"type": "string"}}`,
+			new:  `{"name": "include", "in": "query", "schema": {"type": "boolean"}}`,
+			want: "GET /api/v1/groups/{}: changed type query parameter include (string -> boolean)",
+		},
+		{
+			name: "new required request field",
+			old:  `"required": ["role"]`,
+			new:  `"required": ["role", "extra"]`,
+			want: "PATCH /api/v1/groups/{}: new required field request body.extra",
+		},
+		{
+			name: "rejected request field",
+			old:  `"description": {"type": "string"},`,
+			new:  ``,
+			want: "PATCH /api/v1/groups/{}: removed field request body.description",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(currentSpec, tt.old) {
+				t.Fatalf("current spec does not contain %q", tt.old)
+			}
+			current := mustParse(t, strings.Replace(currentSpec, tt.old, tt.new, 1))
+			var got []string
+			for _, c := range Breaking(mustParse(t, baseSpec), current) {
+				got = append(got, c.String())
+			}
+			if !slices.Contains(got, tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestParse_RejectsNonOpenAPI verifies other documents are not compared.
+func TestParse_RejectsNonOpenAPI(t *testing.T) {
+	if _, err := Parse([]byte("title: not a spec\n")); err == nil {
+		t.Error("expected an error for a document without an openapi version")
+	}
+}
@@ -2,15 +2,41 @@ package db
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// SetAuditContext sets the app.current_user_id session variable for audit logging.
-// This must be called at the start of a transaction before any mutations.
-// The actor_id will be captured by audit triggers on groups and memberships tables.
+// RequestMetadata identifies the HTTP request behind an audited write.
+// Empty fields are stored as NULL.
+type RequestMetadata struct {
+	RequestID string
+	ClientIP  string
+	UserAgent string
+}
+
+// requestMetadataKey is the context key for RequestMetadata.
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a context carrying request metadata for
+// SetAuditContext to record.
+func WithRequestMetadata(ctx context.Context, md RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, md)
+}
+
+// RequestMetadataFromContext returns the request metadata stored in ctx, or
+// the zero value outside a request.
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	md, _ := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return md
+}
+
+// SetAuditContext sets the app.current_user_id session variable for audit logging,
+// along with app.request_id, app.client_ip, and app.user_agent from the request
+// metadata in ctx. This must be called at the start of a transaction before any mutations.
+// The actor_id and request columns will be captured by audit triggers on groups and memberships tables.
 //
 // Usage:
 //
@@ -23,11 +49,25 @@ import (
 //	})
 func SetAuditContext(ctx context.Context, tx pgx.Tx, userID int64) error {
 	// T135: Use SELECT set_config() instead of SET LOCAL per CLAUDE.md
-	// The third parameter (true) means "is_local" - setting is transaction-scoped
-	_, err := tx.Exec(ctx, fmt.Sprintf("SELECT set_config('app.current_user_id', '%d', true)", userID))
+	// The third parameter (true) means "is_local" - setting is transaction-scoped.
+	// Values are bound as parameters because the user agent is client-controlled,
+	// and made valid text because Postgres rejects invalid UTF-8 and NUL bytes.
+	md := RequestMetadataFromContext(ctx)
+	_, err := tx.Exec(ctx,
+		`SELECT set_config('app.current_user_id', $1, true),
+		        set_config('app.request_id', $2, true),
+		        set_config('app.client_ip', $3, true),
+		        set_config('app.user_agent', $4, true)`,
+		strconv.FormatInt(userID, 10), validText(md.RequestID), validText(md.ClientIP), validText(md.UserAgent))
 	return err
 }
 
+// validText makes s storable as Postgres text: invalid UTF-8 becomes U+FFFD
+// and NUL bytes are dropped.
+func validText(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+}
+
 // WithAuditContext executes a function within a transaction with audit context set.
 // This is a convenience wrapper that combines transaction creation, audit context setup,
 // and automatic commit/rollback.
@@ -2,8 +2,10 @@ package db
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // IsNotFound returns true if the error indicates no rows were found.
@@ -11,3 +13,13 @@ import (
 func IsNotFound(err error) bool {
 	return errors.Is(err, pgx.ErrNoRows)
 }
+
+// IsLastAdminViolation returns true if the error was raised by the
+// prevent_last_admin_removal trigger (P0001 "... last administrator ...").
+func IsLastAdminViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "P0001" && strings.Contains(pgErr.Message, "last administrator")
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: group_roles.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const assignGroupRole = `-- name: AssignGroupRole :one
+UPDATE memberships SET group_role_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+`
+
+type AssignGroupRoleParams struct {
+	ID          int64       `json:"id"`
+	GroupRoleID pgtype.Int8 `json:"group_role_id"`
+}
+
+// Assigns (or clears, with NULL) the custom role of a membership
+func (q *Queries) AssignGroupRole(ctx context.Context, arg AssignGroupRoleParams) (*Membership, error) {
+	row := q.db.QueryRow(ctx, assignGroupRole, arg.ID, arg.GroupRoleID)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
+const createGroupRole = `-- name: CreateGroupRole :one
+
+INSERT INTO group_roles (group_id, name, permissions, created_by_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, group_id, name, permissions, created_by_id, created_at, updated_at
+`
+
+type CreateGroupRoleParams struct {
+	GroupID     int64       `json:"group_id"`
+	Name        string      `json:"name"`
+	Permissions []string    `json:"permissions"`
+	CreatedByID pgtype.Int8 `json:"created_by_id"`
+}
+
+// sqlc queries for group_roles table
+// Custom roles are assigned to member-role memberships via memberships.group_role_id
+// Creates a custom role in a group
+func (q *Queries) CreateGroupRole(ctx context.Context, arg CreateGroupRoleParams) (*GroupRole, error) {
+	row := q.db.QueryRow(ctx, createGroupRole,
+		arg.GroupID,
+		arg.Name,
+		arg.Permissions,
+		arg.CreatedByID,
+	)
+	var i GroupRole
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Name,
+		&i.Permissions,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const deleteGroupRole = `-- name: DeleteGroupRole :exec
+DELETE FROM group_roles WHERE id = $1
+`
+
+// Deletes a custom role; memberships holding it fall back to the plain member role
+func (q *Queries) DeleteGroupRole(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteGroupRole, id)
+	return err
+}
+
+const getGroupRoleByID = `-- name: GetGroupRoleByID :one
+SELECT id, group_id, name, permissions, created_by_id, created_at, updated_at FROM group_roles WHERE id = $1
+`
+
+// Retrieves a custom role by its ID
+func (q *Queries) GetGroupRoleByID(ctx context.Context, id int64) (*GroupRole, error) {
+	row := q.db.QueryRow(ctx, getGroupRoleByID, id)
+	var i GroupRole
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Name,
+		&i.Permissions,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const listGroupRolesByGroup = `-- name: ListGroupRolesByGroup :many
+SELECT id, group_id, name, permissions, created_by_id, created_at, updated_at FROM group_roles
+WHERE group_id = $1
+ORDER BY name
+`
+
+// Lists the custom roles defined in a group
+func (q *Queries) ListGroupRolesByGroup(ctx context.Context, groupID int64) ([]*GroupRole, error) {
+	rows, err := q.db.Query(ctx, listGroupRolesByGroup, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GroupRole{}
+	for rows.Next() {
+		var i GroupRole
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.Name,
+			&i.Permissions,
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateGroupRole = `-- name: UpdateGroupRole :one
+UPDATE group_roles SET
+    name = COALESCE($1, name),
+    permissions = COALESCE($2::text[], permissions),
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, group_id, name, permissions, created_by_id, created_at, updated_at
+`
+
+type UpdateGroupRoleParams struct {
+	Name        pgtype.Text `json:"name"`
+	Permissions []string    `json:"permissions"`
+	ID          int64       `json:"id"`
+}
+
+// Updates a custom role (partial update using COALESCE)
+func (q *Queries) UpdateGroupRole(ctx context.Context, arg UpdateGroupRoleParams) (*GroupRole, error) {
+	row := q.db.QueryRow(ctx, updateGroupRole, arg.Name, arg.Permissions, arg.ID)
+	var i GroupRole
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Name,
+		&i.Permissions,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
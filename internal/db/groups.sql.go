@@ -14,7 +14,7 @@ import (
 const archiveGroup = `-- name: ArchiveGroup :one
 UPDATE groups SET archived_at = NOW(), updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
 `
 
 // Soft-deletes a group by setting archived_at
@@ -42,10 +42,72 @@ func (q *Queries) ArchiveGroup(ctx context.Context, id int64) (*Group, error) {
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
 
+const archiveGroupTree = `-- name: ArchiveGroupTree :many
+WITH RECURSIVE subtree AS (
+    SELECT r.id FROM groups r WHERE r.id = $1
+    UNION ALL
+    SELECT g.id FROM groups g
+    JOIN subtree s ON g.parent_id = s.id
+)
+UPDATE groups g SET archived_at = NOW(), updated_at = NOW()
+FROM subtree s
+WHERE g.id = s.id
+  AND g.archived_at IS NULL
+RETURNING g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage
+`
+
+// Archives a group and every descendant that is not already archived
+// NOW() is fixed for the transaction, so the whole cascade shares one
+// archived_at value; UnarchiveGroupTree relies on this to find it again.
+// Groups already archived, the root included, keep their archived_at, so
+// the result lacks the root if it was archived already
+func (q *Queries) ArchiveGroupTree(ctx context.Context, groupID int64) ([]*Group, error) {
+	rows, err := q.db.Query(ctx, archiveGroupTree, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Group{}
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Handle,
+			&i.Description,
+			&i.ParentID,
+			&i.CreatedByID,
+			&i.ArchivedAt,
+			&i.MembersCanAddMembers,
+			&i.MembersCanAddGuests,
+			&i.MembersCanStartDiscussions,
+			&i.MembersCanRaiseMotions,
+			&i.MembersCanEditDiscussions,
+			&i.MembersCanEditComments,
+			&i.MembersCanDeleteComments,
+			&i.MembersCanAnnounce,
+			&i.MembersCanCreateSubgroups,
+			&i.AdminsCanEditUserContent,
+			&i.ParentMembersCanSeeDiscussions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentAdminsCanManage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countGroupAdmins = `-- name: CountGroupAdmins :one
 SELECT COUNT(*) AS admin_count FROM memberships
 WHERE group_id = $1 AND role = 'admin' AND accepted_at IS NOT NULL
@@ -61,10 +123,10 @@ func (q *Queries) CountGroupAdmins(ctx context.Context, groupID int64) (int64, e
 
 const countGroupMembers = `-- name: CountGroupMembers :one
 SELECT COUNT(*) AS member_count FROM memberships
-WHERE group_id = $1 AND accepted_at IS NOT NULL
+WHERE group_id = $1 AND accepted_at IS NOT NULL AND role != 'guest'
 `
 
-// Counts active members in a group
+// Counts active members in a group (guests are not counted as members)
 func (q *Queries) CountGroupMembers(ctx context.Context, groupID int64) (int64, error) {
 	row := q.db.QueryRow(ctx, countGroupMembers, groupID)
 	var member_count int64
@@ -77,7 +139,7 @@ SELECT
     COUNT(*) AS member_count,
     COUNT(*) FILTER (WHERE role = 'admin') AS admin_count
 FROM memberships
-WHERE group_id = $1 AND accepted_at IS NOT NULL
+WHERE group_id = $1 AND accepted_at IS NOT NULL AND role != 'guest'
 `
 
 type CountGroupMembershipStatsRow struct {
@@ -87,6 +149,7 @@ type CountGroupMembershipStatsRow struct {
 
 // T169: Combined query to get both member and admin counts in a single query
 // More efficient than two separate queries for handleGetGroup
+// Guests are excluded from member_count
 func (q *Queries) CountGroupMembershipStats(ctx context.Context, groupID int64) (*CountGroupMembershipStatsRow, error) {
 	row := q.db.QueryRow(ctx, countGroupMembershipStats, groupID)
 	var i CountGroupMembershipStatsRow
@@ -101,7 +164,8 @@ INSERT INTO groups (
     members_can_add_members, members_can_add_guests, members_can_start_discussions,
     members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments,
     members_can_delete_comments, members_can_announce, members_can_create_subgroups,
-    admins_can_edit_user_content, parent_members_can_see_discussions
+    admins_can_edit_user_content, parent_members_can_see_discussions,
+    parent_admins_can_manage
 ) VALUES (
     $1, $2, $3, $4, $5,
     COALESCE($6::boolean, TRUE),
@@ -114,9 +178,10 @@ INSERT INTO groups (
     COALESCE($13::boolean, FALSE),
     COALESCE($14::boolean, FALSE),
     COALESCE($15::boolean, FALSE),
-    COALESCE($16::boolean, FALSE)
+    COALESCE($16::boolean, FALSE),
+    COALESCE($17::boolean, TRUE)
 )
-RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
 `
 
 type CreateGroupParams struct {
@@ -136,6 +201,7 @@ type CreateGroupParams struct {
 	MembersCanCreateSubgroups      pgtype.Bool `json:"members_can_create_subgroups"`
 	AdminsCanEditUserContent       pgtype.Bool `json:"admins_can_edit_user_content"`
 	ParentMembersCanSeeDiscussions pgtype.Bool `json:"parent_members_can_see_discussions"`
+	ParentAdminsCanManage          pgtype.Bool `json:"parent_admins_can_manage"`
 }
 
 // sqlc queries for groups table
@@ -160,6 +226,7 @@ func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (*Grou
 		arg.MembersCanCreateSubgroups,
 		arg.AdminsCanEditUserContent,
 		arg.ParentMembersCanSeeDiscussions,
+		arg.ParentAdminsCanManage,
 	)
 	var i Group
 	err := row.Scan(
@@ -183,12 +250,23 @@ func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (*Grou
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
 
+const deleteGroup = `-- name: DeleteGroup :exec
+DELETE FROM groups WHERE id = $1
+`
+
+// Permanently deletes a group; memberships and guest grants cascade
+func (q *Queries) DeleteGroup(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteGroup, id)
+	return err
+}
+
 const getGroupByHandle = `-- name: GetGroupByHandle :one
-SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at FROM groups WHERE handle = $1
+SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage FROM groups WHERE handle = $1
 `
 
 // Retrieves a group by its URL-safe handle (case-insensitive via CITEXT)
@@ -216,12 +294,13 @@ func (q *Queries) GetGroupByHandle(ctx context.Context, handle string) (*Group,
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
 
 const getGroupByID = `-- name: GetGroupByID :one
-SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at FROM groups WHERE id = $1
+SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage FROM groups WHERE id = $1
 `
 
 // Retrieves a group by its ID
@@ -249,15 +328,23 @@ func (q *Queries) GetGroupByID(ctx context.Context, id int64) (*Group, error) {
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
 
 const handleExists = `-- name: HandleExists :one
-SELECT EXISTS(SELECT 1 FROM groups WHERE handle = $1) AS exists
+SELECT (
+    EXISTS(SELECT 1 FROM groups g WHERE g.handle = $1::citext)
+    OR EXISTS(
+        SELECT 1 FROM group_handle_history h
+        WHERE h.handle = $1::citext
+          AND h.reserved_until > NOW()
+    )
+)::boolean AS exists
 `
 
-// Checks if a handle is already taken
+// Checks if a handle is already taken by a group or reserved after a rename
 func (q *Queries) HandleExists(ctx context.Context, handle string) (bool, error) {
 	row := q.db.QueryRow(ctx, handleExists, handle)
 	var exists bool
@@ -265,24 +352,242 @@ func (q *Queries) HandleExists(ctx context.Context, handle string) (bool, error)
 	return exists, err
 }
 
+const isInheritedAdmin = `-- name: IsInheritedAdmin :one
+WITH RECURSIVE managing_ancestors AS (
+    SELECT g.parent_id AS id
+    FROM groups g
+    WHERE g.id = $2
+      AND g.parent_id IS NOT NULL
+      AND g.parent_admins_can_manage
+    UNION
+    SELECT p.parent_id
+    FROM groups p
+    JOIN managing_ancestors a ON p.id = a.id
+    WHERE p.parent_id IS NOT NULL
+      AND p.parent_admins_can_manage
+)
+SELECT EXISTS(
+    SELECT 1 FROM managing_ancestors a
+    JOIN memberships m ON m.group_id = a.id
+    WHERE m.user_id = $1
+      AND m.role = 'admin'
+      AND m.accepted_at IS NOT NULL
+      AND (m.expires_at IS NULL OR m.expires_at > NOW() OR m.expiry_blocked_at IS NOT NULL)
+) AS is_inherited_admin
+`
+
+type IsInheritedAdminParams struct {
+	UserID  int64 `json:"user_id"`
+	GroupID int64 `json:"group_id"`
+}
+
+// Checks whether a user administers an ancestor that has admin rights over the group
+// Walks up one level at a time while each group on the path has
+// parent_admins_can_manage enabled. Expired admin memberships only count
+// while their expiry is blocked by last-admin protection
+func (q *Queries) IsInheritedAdmin(ctx context.Context, arg IsInheritedAdminParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isInheritedAdmin, arg.UserID, arg.GroupID)
+	var is_inherited_admin bool
+	err := row.Scan(&is_inherited_admin)
+	return is_inherited_admin, err
+}
+
+const listGroupAncestors = `-- name: ListGroupAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT p.id, 1 AS depth
+    FROM groups c
+    JOIN groups p ON p.id = c.parent_id
+    WHERE c.id = $1
+    UNION ALL
+    SELECT p.id, a.depth + 1
+    FROM ancestors a
+    JOIN groups c ON c.id = a.id
+    JOIN groups p ON p.id = c.parent_id
+)
+SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage, a.depth::integer AS depth
+FROM groups g
+JOIN ancestors a ON a.id = g.id
+ORDER BY a.depth
+`
+
+type ListGroupAncestorsRow struct {
+	Group Group `json:"group"`
+	Depth int32 `json:"depth"`
+}
+
+// Lists every ancestor of a group, nearest parent first (depth 1)
+// Termination relies on groups_prevent_hierarchy_cycle keeping the hierarchy acyclic
+func (q *Queries) ListGroupAncestors(ctx context.Context, groupID int64) ([]*ListGroupAncestorsRow, error) {
+	rows, err := q.db.Query(ctx, listGroupAncestors, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*ListGroupAncestorsRow{}
+	for rows.Next() {
+		var i ListGroupAncestorsRow
+		if err := rows.Scan(
+			&i.Group.ID,
+			&i.Group.Name,
+			&i.Group.Handle,
+			&i.Group.Description,
+			&i.Group.ParentID,
+			&i.Group.CreatedByID,
+			&i.Group.ArchivedAt,
+			&i.Group.MembersCanAddMembers,
+			&i.Group.MembersCanAddGuests,
+			&i.Group.MembersCanStartDiscussions,
+			&i.Group.MembersCanRaiseMotions,
+			&i.Group.MembersCanEditDiscussions,
+			&i.Group.MembersCanEditComments,
+			&i.Group.MembersCanDeleteComments,
+			&i.Group.MembersCanAnnounce,
+			&i.Group.MembersCanCreateSubgroups,
+			&i.Group.AdminsCanEditUserContent,
+			&i.Group.ParentMembersCanSeeDiscussions,
+			&i.Group.CreatedAt,
+			&i.Group.UpdatedAt,
+			&i.Group.ParentAdminsCanManage,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupDescendants = `-- name: ListGroupDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT g.id, 1 AS depth
+    FROM groups g
+    WHERE g.parent_id = $1::bigint
+      AND ($2::boolean = TRUE OR g.archived_at IS NULL)
+    UNION ALL
+    SELECT g.id, d.depth + 1
+    FROM groups g
+    JOIN descendants d ON g.parent_id = d.id
+    WHERE $2::boolean = TRUE OR g.archived_at IS NULL
+)
+SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage, d.depth::integer AS depth
+FROM groups g
+JOIN descendants d ON d.id = g.id
+ORDER BY d.depth, g.name
+`
+
+type ListGroupDescendantsParams struct {
+	GroupID         int64 `json:"group_id"`
+	IncludeArchived bool  `json:"include_archived"`
+}
+
+type ListGroupDescendantsRow struct {
+	Group Group `json:"group"`
+	Depth int32 `json:"depth"`
+}
+
+// Lists every descendant of a group with its depth below the root (children are depth 1)
+// Archived groups and everything beneath them are skipped unless include_archived is true
+func (q *Queries) ListGroupDescendants(ctx context.Context, arg ListGroupDescendantsParams) ([]*ListGroupDescendantsRow, error) {
+	rows, err := q.db.Query(ctx, listGroupDescendants, arg.GroupID, arg.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*ListGroupDescendantsRow{}
+	for rows.Next() {
+		var i ListGroupDescendantsRow
+		if err := rows.Scan(
+			&i.Group.ID,
+			&i.Group.Name,
+			&i.Group.Handle,
+			&i.Group.Description,
+			&i.Group.ParentID,
+			&i.Group.CreatedByID,
+			&i.Group.ArchivedAt,
+			&i.Group.MembersCanAddMembers,
+			&i.Group.MembersCanAddGuests,
+			&i.Group.MembersCanStartDiscussions,
+			&i.Group.MembersCanRaiseMotions,
+			&i.Group.MembersCanEditDiscussions,
+			&i.Group.MembersCanEditComments,
+			&i.Group.MembersCanDeleteComments,
+			&i.Group.MembersCanAnnounce,
+			&i.Group.MembersCanCreateSubgroups,
+			&i.Group.AdminsCanEditUserContent,
+			&i.Group.ParentMembersCanSeeDiscussions,
+			&i.Group.CreatedAt,
+			&i.Group.UpdatedAt,
+			&i.Group.ParentAdminsCanManage,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listGroupsByUser = `-- name: ListGroupsByUser :many
-SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at FROM groups g
+SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage FROM groups g
 JOIN memberships m ON m.group_id = g.id
 WHERE m.user_id = $1
   AND m.accepted_at IS NOT NULL
+  AND m.role != 'guest'
   AND ($2::boolean = TRUE OR g.archived_at IS NULL)
-ORDER BY g.name
+  AND (
+    $3::bigint = 0
+    OR ($4::text = 'name' AND NOT $5::boolean
+        AND (g.name, g.id) > ($6::text, $3::bigint))
+    OR ($4::text = 'name' AND $5::boolean
+        AND (g.name, g.id) < ($6::text, $3::bigint))
+    OR ($4::text = 'created_at' AND NOT $5::boolean
+        AND (g.created_at, g.id) > ($7::timestamptz, $3::bigint))
+    OR ($4::text = 'created_at' AND $5::boolean
+        AND (g.created_at, g.id) < ($7::timestamptz, $3::bigint))
+  )
+ORDER BY
+    CASE WHEN $4::text = 'name' AND NOT $5::boolean THEN g.name END ASC,
+    CASE WHEN $4::text = 'name' AND $5::boolean THEN g.name END DESC,
+    CASE WHEN $4::text = 'created_at' AND NOT $5::boolean THEN g.created_at END ASC,
+    CASE WHEN $4::text = 'created_at' AND $5::boolean THEN g.created_at END DESC,
+    CASE WHEN NOT $5::boolean THEN g.id END ASC,
+    CASE WHEN $5::boolean THEN g.id END DESC
+LIMIT $8
 `
 
 type ListGroupsByUserParams struct {
-	UserID          int64 `json:"user_id"`
-	IncludeArchived bool  `json:"include_archived"`
+	UserID          int64              `json:"user_id"`
+	IncludeArchived bool               `json:"include_archived"`
+	AfterID         int64              `json:"after_id"`
+	SortBy          string             `json:"sort_by"`
+	SortDesc        bool               `json:"sort_desc"`
+	AfterName       string             `json:"after_name"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
-// Lists all groups a user is an active member of
+// Lists one page of the groups a user is an active member of
 // Excludes archived groups by default unless include_archived is true
+// Guest memberships are excluded: guests only see the resources they were granted
+// Keyset pagination: sort_by is 'name' or 'created_at', ties broken by id;
+// after_id = 0 starts from the first page (see api/pagination.go)
 func (q *Queries) ListGroupsByUser(ctx context.Context, arg ListGroupsByUserParams) ([]*Group, error) {
-	rows, err := q.db.Query(ctx, listGroupsByUser, arg.UserID, arg.IncludeArchived)
+	rows, err := q.db.Query(ctx, listGroupsByUser,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.AfterName,
+		arg.AfterCreatedAt,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -311,6 +616,7 @@ func (q *Queries) ListGroupsByUser(ctx context.Context, arg ListGroupsByUserPara
 			&i.ParentMembersCanSeeDiscussions,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentAdminsCanManage,
 		); err != nil {
 			return nil, err
 		}
@@ -324,14 +630,15 @@ func (q *Queries) ListGroupsByUser(ctx context.Context, arg ListGroupsByUserPara
 
 const listGroupsByUserWithCounts = `-- name: ListGroupsByUserWithCounts :many
 SELECT
-    g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at,
+    g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage,
     m.role AS current_user_role,
-    (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.accepted_at IS NOT NULL) AS member_count,
+    (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.accepted_at IS NOT NULL AND sm.role != 'guest') AS member_count,
     (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.role = 'admin' AND sm.accepted_at IS NOT NULL) AS admin_count
 FROM groups g
 JOIN memberships m ON m.group_id = g.id
 WHERE m.user_id = $1
   AND m.accepted_at IS NOT NULL
+  AND m.role != 'guest'
   AND ($2::boolean = TRUE OR g.archived_at IS NULL)
 ORDER BY g.name
 `
@@ -362,6 +669,7 @@ type ListGroupsByUserWithCountsRow struct {
 	ParentMembersCanSeeDiscussions bool               `json:"parent_members_can_see_discussions"`
 	CreatedAt                      pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt                      pgtype.Timestamptz `json:"updated_at"`
+	ParentAdminsCanManage          bool               `json:"parent_admins_can_manage"`
 	CurrentUserRole                string             `json:"current_user_role"`
 	MemberCount                    int64              `json:"member_count"`
 	AdminCount                     int64              `json:"admin_count"`
@@ -400,6 +708,7 @@ func (q *Queries) ListGroupsByUserWithCounts(ctx context.Context, arg ListGroups
 			&i.ParentMembersCanSeeDiscussions,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentAdminsCanManage,
 			&i.CurrentUserRole,
 			&i.MemberCount,
 			&i.AdminCount,
@@ -414,21 +723,99 @@ func (q *Queries) ListGroupsByUserWithCounts(ctx context.Context, arg ListGroups
 	return items, nil
 }
 
+const listGroupsForPurge = `-- name: ListGroupsForPurge :many
+SELECT g.id, g.handle, g.archived_at FROM groups g
+WHERE g.archived_at IS NOT NULL
+  AND g.archived_at < $1
+  AND NOT EXISTS (SELECT 1 FROM groups c WHERE c.parent_id = g.id)
+ORDER BY g.archived_at
+LIMIT $2
+`
+
+type ListGroupsForPurgeParams struct {
+	Cutoff    pgtype.Timestamptz `json:"cutoff"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+type ListGroupsForPurgeRow struct {
+	ID         int64              `json:"id"`
+	Handle     string             `json:"handle"`
+	ArchivedAt pgtype.Timestamptz `json:"archived_at"`
+}
+
+// Lists groups archived before the retention cutoff, oldest first
+// Groups with subgroups are left out: deleting them would re-root the
+// subgroups through parent_id ON DELETE SET NULL. They become eligible
+// once their subgroups have been purged
+func (q *Queries) ListGroupsForPurge(ctx context.Context, arg ListGroupsForPurgeParams) ([]*ListGroupsForPurgeRow, error) {
+	rows, err := q.db.Query(ctx, listGroupsForPurge, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*ListGroupsForPurgeRow{}
+	for rows.Next() {
+		var i ListGroupsForPurgeRow
+		if err := rows.Scan(&i.ID, &i.Handle, &i.ArchivedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSubgroupsByParent = `-- name: ListSubgroupsByParent :many
-SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at FROM groups
-WHERE parent_id = $1
-  AND ($2::boolean = TRUE OR archived_at IS NULL)
-ORDER BY name
+SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage FROM groups g
+WHERE g.parent_id = $1
+  AND ($2::boolean = TRUE OR g.archived_at IS NULL)
+  AND (
+    $3::bigint = 0
+    OR ($4::text = 'name' AND NOT $5::boolean
+        AND (g.name, g.id) > ($6::text, $3::bigint))
+    OR ($4::text = 'name' AND $5::boolean
+        AND (g.name, g.id) < ($6::text, $3::bigint))
+    OR ($4::text = 'created_at' AND NOT $5::boolean
+        AND (g.created_at, g.id) > ($7::timestamptz, $3::bigint))
+    OR ($4::text = 'created_at' AND $5::boolean
+        AND (g.created_at, g.id) < ($7::timestamptz, $3::bigint))
+  )
+ORDER BY
+    CASE WHEN $4::text = 'name' AND NOT $5::boolean THEN g.name END ASC,
+    CASE WHEN $4::text = 'name' AND $5::boolean THEN g.name END DESC,
+    CASE WHEN $4::text = 'created_at' AND NOT $5::boolean THEN g.created_at END ASC,
+    CASE WHEN $4::text = 'created_at' AND $5::boolean THEN g.created_at END DESC,
+    CASE WHEN NOT $5::boolean THEN g.id END ASC,
+    CASE WHEN $5::boolean THEN g.id END DESC
+LIMIT $8
 `
 
 type ListSubgroupsByParentParams struct {
-	ParentID        pgtype.Int8 `json:"parent_id"`
-	IncludeArchived bool        `json:"include_archived"`
+	ParentID        pgtype.Int8        `json:"parent_id"`
+	IncludeArchived bool               `json:"include_archived"`
+	AfterID         int64              `json:"after_id"`
+	SortBy          string             `json:"sort_by"`
+	SortDesc        bool               `json:"sort_desc"`
+	AfterName       string             `json:"after_name"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
-// Lists all subgroups under a parent group
+// Lists one page of the subgroups under a parent group
+// Keyset pagination as in ListGroupsByUser
 func (q *Queries) ListSubgroupsByParent(ctx context.Context, arg ListSubgroupsByParentParams) ([]*Group, error) {
-	rows, err := q.db.Query(ctx, listSubgroupsByParent, arg.ParentID, arg.IncludeArchived)
+	rows, err := q.db.Query(ctx, listSubgroupsByParent,
+		arg.ParentID,
+		arg.IncludeArchived,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.AfterName,
+		arg.AfterCreatedAt,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -457,6 +844,7 @@ func (q *Queries) ListSubgroupsByParent(ctx context.Context, arg ListSubgroupsBy
 			&i.ParentMembersCanSeeDiscussions,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentAdminsCanManage,
 		); err != nil {
 			return nil, err
 		}
@@ -468,10 +856,242 @@ func (q *Queries) ListSubgroupsByParent(ctx context.Context, arg ListSubgroupsBy
 	return items, nil
 }
 
+const lockGroupByID = `-- name: LockGroupByID :one
+SELECT id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage FROM groups WHERE id = $1 FOR UPDATE
+`
+
+// Locks a group for a conditional update so its ETag cannot change between
+// the If-Match check and the write
+func (q *Queries) LockGroupByID(ctx context.Context, id int64) (*Group, error) {
+	row := q.db.QueryRow(ctx, lockGroupByID, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Handle,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedByID,
+		&i.ArchivedAt,
+		&i.MembersCanAddMembers,
+		&i.MembersCanAddGuests,
+		&i.MembersCanStartDiscussions,
+		&i.MembersCanRaiseMotions,
+		&i.MembersCanEditDiscussions,
+		&i.MembersCanEditComments,
+		&i.MembersCanDeleteComments,
+		&i.MembersCanAnnounce,
+		&i.MembersCanCreateSubgroups,
+		&i.AdminsCanEditUserContent,
+		&i.ParentMembersCanSeeDiscussions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
+	)
+	return &i, err
+}
+
+const lockGroupForPurge = `-- name: LockGroupForPurge :one
+SELECT g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage FROM groups g
+WHERE g.id = $1
+  AND g.archived_at IS NOT NULL
+  AND g.archived_at < $2
+  AND NOT EXISTS (SELECT 1 FROM groups c WHERE c.parent_id = g.id)
+FOR UPDATE
+`
+
+type LockGroupForPurgeParams struct {
+	ID     int64              `json:"id"`
+	Cutoff pgtype.Timestamptz `json:"cutoff"`
+}
+
+// Locks a group for deletion, re-checking the cutoff and subgroups so a
+// group unarchived or given a subgroup since ListGroupsForPurge is skipped
+func (q *Queries) LockGroupForPurge(ctx context.Context, arg LockGroupForPurgeParams) (*Group, error) {
+	row := q.db.QueryRow(ctx, lockGroupForPurge, arg.ID, arg.Cutoff)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Handle,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedByID,
+		&i.ArchivedAt,
+		&i.MembersCanAddMembers,
+		&i.MembersCanAddGuests,
+		&i.MembersCanStartDiscussions,
+		&i.MembersCanRaiseMotions,
+		&i.MembersCanEditDiscussions,
+		&i.MembersCanEditComments,
+		&i.MembersCanDeleteComments,
+		&i.MembersCanAnnounce,
+		&i.MembersCanCreateSubgroups,
+		&i.AdminsCanEditUserContent,
+		&i.ParentMembersCanSeeDiscussions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
+	)
+	return &i, err
+}
+
+const moveGroup = `-- name: MoveGroup :one
+UPDATE groups SET parent_id = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
+`
+
+type MoveGroupParams struct {
+	ParentID pgtype.Int8 `json:"parent_id"`
+	ID       int64       `json:"id"`
+}
+
+// Moves a group under a new parent, or to the top level when parent_id is NULL
+// Cycles are rejected by the groups_prevent_hierarchy_cycle trigger
+func (q *Queries) MoveGroup(ctx context.Context, arg MoveGroupParams) (*Group, error) {
+	row := q.db.QueryRow(ctx, moveGroup, arg.ParentID, arg.ID)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Handle,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedByID,
+		&i.ArchivedAt,
+		&i.MembersCanAddMembers,
+		&i.MembersCanAddGuests,
+		&i.MembersCanStartDiscussions,
+		&i.MembersCanRaiseMotions,
+		&i.MembersCanEditDiscussions,
+		&i.MembersCanEditComments,
+		&i.MembersCanDeleteComments,
+		&i.MembersCanAnnounce,
+		&i.MembersCanCreateSubgroups,
+		&i.AdminsCanEditUserContent,
+		&i.ParentMembersCanSeeDiscussions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
+	)
+	return &i, err
+}
+
+const restoreGroupSettings = `-- name: RestoreGroupSettings :one
+UPDATE groups SET
+    name = $1,
+    description = $2,
+    members_can_add_members = $3,
+    members_can_add_guests = $4,
+    members_can_start_discussions = $5,
+    members_can_raise_motions = $6,
+    members_can_edit_discussions = $7,
+    members_can_edit_comments = $8,
+    members_can_delete_comments = $9,
+    members_can_announce = $10,
+    members_can_create_subgroups = $11,
+    admins_can_edit_user_content = $12,
+    parent_members_can_see_discussions = $13,
+    parent_admins_can_manage = $14,
+    updated_at = NOW()
+WHERE id = $15
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
+`
+
+type RestoreGroupSettingsParams struct {
+	Name                           string      `json:"name"`
+	Description                    pgtype.Text `json:"description"`
+	MembersCanAddMembers           bool        `json:"members_can_add_members"`
+	MembersCanAddGuests            bool        `json:"members_can_add_guests"`
+	MembersCanStartDiscussions     bool        `json:"members_can_start_discussions"`
+	MembersCanRaiseMotions         bool        `json:"members_can_raise_motions"`
+	MembersCanEditDiscussions      bool        `json:"members_can_edit_discussions"`
+	MembersCanEditComments         bool        `json:"members_can_edit_comments"`
+	MembersCanDeleteComments       bool        `json:"members_can_delete_comments"`
+	MembersCanAnnounce             bool        `json:"members_can_announce"`
+	MembersCanCreateSubgroups      bool        `json:"members_can_create_subgroups"`
+	AdminsCanEditUserContent       bool        `json:"admins_can_edit_user_content"`
+	ParentMembersCanSeeDiscussions bool        `json:"parent_members_can_see_discussions"`
+	ParentAdminsCanManage          bool        `json:"parent_admins_can_manage"`
+	ID                             int64       `json:"id"`
+}
+
+// Sets every group setting back to earlier values (point-in-time restore);
+// handle, parent, and archive state have their own operations
+func (q *Queries) RestoreGroupSettings(ctx context.Context, arg RestoreGroupSettingsParams) (*Group, error) {
+	row := q.db.QueryRow(ctx, restoreGroupSettings,
+		arg.Name,
+		arg.Description,
+		arg.MembersCanAddMembers,
+		arg.MembersCanAddGuests,
+		arg.MembersCanStartDiscussions,
+		arg.MembersCanRaiseMotions,
+		arg.MembersCanEditDiscussions,
+		arg.MembersCanEditComments,
+		arg.MembersCanDeleteComments,
+		arg.MembersCanAnnounce,
+		arg.MembersCanCreateSubgroups,
+		arg.AdminsCanEditUserContent,
+		arg.ParentMembersCanSeeDiscussions,
+		arg.ParentAdminsCanManage,
+		arg.ID,
+	)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Handle,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedByID,
+		&i.ArchivedAt,
+		&i.MembersCanAddMembers,
+		&i.MembersCanAddGuests,
+		&i.MembersCanStartDiscussions,
+		&i.MembersCanRaiseMotions,
+		&i.MembersCanEditDiscussions,
+		&i.MembersCanEditComments,
+		&i.MembersCanDeleteComments,
+		&i.MembersCanAnnounce,
+		&i.MembersCanCreateSubgroups,
+		&i.AdminsCanEditUserContent,
+		&i.ParentMembersCanSeeDiscussions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
+	)
+	return &i, err
+}
+
+const snapshotGroupForPurge = `-- name: SnapshotGroupForPurge :execrows
+INSERT INTO audit.record_version (record_id, op, table_oid, table_schema, table_name, record, actor_id)
+SELECT g.id::text, 'SNAPSHOT'::audit.operation, 'public.groups'::regclass::oid, 'public', 'groups', to_jsonb(g), NULL::bigint
+FROM groups g WHERE g.id = $1
+UNION ALL
+SELECT m.id::text, 'SNAPSHOT'::audit.operation, 'public.memberships'::regclass::oid, 'public', 'memberships', to_jsonb(m), NULL::bigint
+FROM memberships m WHERE m.group_id = $1
+UNION ALL
+SELECT gg.id::text, 'SNAPSHOT'::audit.operation, 'public.guest_grants'::regclass::oid, 'public', 'guest_grants', to_jsonb(gg), NULL::bigint
+FROM guest_grants gg
+JOIN memberships m ON m.id = gg.membership_id
+WHERE m.group_id = $1
+`
+
+// Writes SNAPSHOT rows to audit.record_version for a group, its memberships,
+// and their guest grants so the final state survives hard deletion
+func (q *Queries) SnapshotGroupForPurge(ctx context.Context, groupID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, snapshotGroupForPurge, groupID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const unarchiveGroup = `-- name: UnarchiveGroup :one
 UPDATE groups SET archived_at = NULL, updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
 `
 
 // Restores an archived group
@@ -499,33 +1119,96 @@ func (q *Queries) UnarchiveGroup(ctx context.Context, id int64) (*Group, error)
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
 
+const unarchiveGroupTree = `-- name: UnarchiveGroupTree :many
+WITH RECURSIVE subtree AS (
+    SELECT r.id, r.archived_at FROM groups r WHERE r.id = $1
+    UNION ALL
+    SELECT g.id, s.archived_at FROM groups g
+    JOIN subtree s ON g.parent_id = s.id
+    WHERE g.archived_at = s.archived_at
+)
+UPDATE groups g SET archived_at = NULL, updated_at = NOW()
+FROM subtree s
+WHERE g.id = s.id
+RETURNING g.id, g.name, g.handle, g.description, g.parent_id, g.created_by_id, g.archived_at, g.members_can_add_members, g.members_can_add_guests, g.members_can_start_discussions, g.members_can_raise_motions, g.members_can_edit_discussions, g.members_can_edit_comments, g.members_can_delete_comments, g.members_can_announce, g.members_can_create_subgroups, g.admins_can_edit_user_content, g.parent_members_can_see_discussions, g.created_at, g.updated_at, g.parent_admins_can_manage
+`
+
+// Restores a group and the descendants archived together with it
+// The recursion stops at descendants archived separately (different
+// archived_at), so they and everything below them stay archived
+func (q *Queries) UnarchiveGroupTree(ctx context.Context, groupID int64) ([]*Group, error) {
+	rows, err := q.db.Query(ctx, unarchiveGroupTree, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Group{}
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Handle,
+			&i.Description,
+			&i.ParentID,
+			&i.CreatedByID,
+			&i.ArchivedAt,
+			&i.MembersCanAddMembers,
+			&i.MembersCanAddGuests,
+			&i.MembersCanStartDiscussions,
+			&i.MembersCanRaiseMotions,
+			&i.MembersCanEditDiscussions,
+			&i.MembersCanEditComments,
+			&i.MembersCanDeleteComments,
+			&i.MembersCanAnnounce,
+			&i.MembersCanCreateSubgroups,
+			&i.AdminsCanEditUserContent,
+			&i.ParentMembersCanSeeDiscussions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentAdminsCanManage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateGroup = `-- name: UpdateGroup :one
 UPDATE groups SET
     name = COALESCE($2, name),
-    description = COALESCE($3, description),
-    members_can_add_members = COALESCE($4, members_can_add_members),
-    members_can_add_guests = COALESCE($5, members_can_add_guests),
-    members_can_start_discussions = COALESCE($6, members_can_start_discussions),
-    members_can_raise_motions = COALESCE($7, members_can_raise_motions),
-    members_can_edit_discussions = COALESCE($8, members_can_edit_discussions),
-    members_can_edit_comments = COALESCE($9, members_can_edit_comments),
-    members_can_delete_comments = COALESCE($10, members_can_delete_comments),
-    members_can_announce = COALESCE($11, members_can_announce),
-    members_can_create_subgroups = COALESCE($12, members_can_create_subgroups),
-    admins_can_edit_user_content = COALESCE($13, admins_can_edit_user_content),
-    parent_members_can_see_discussions = COALESCE($14, parent_members_can_see_discussions),
+    handle = COALESCE($3, handle),
+    description = COALESCE($4, description),
+    members_can_add_members = COALESCE($5, members_can_add_members),
+    members_can_add_guests = COALESCE($6, members_can_add_guests),
+    members_can_start_discussions = COALESCE($7, members_can_start_discussions),
+    members_can_raise_motions = COALESCE($8, members_can_raise_motions),
+    members_can_edit_discussions = COALESCE($9, members_can_edit_discussions),
+    members_can_edit_comments = COALESCE($10, members_can_edit_comments),
+    members_can_delete_comments = COALESCE($11, members_can_delete_comments),
+    members_can_announce = COALESCE($12, members_can_announce),
+    members_can_create_subgroups = COALESCE($13, members_can_create_subgroups),
+    admins_can_edit_user_content = COALESCE($14, admins_can_edit_user_content),
+    parent_members_can_see_discussions = COALESCE($15, parent_members_can_see_discussions),
+    parent_admins_can_manage = COALESCE($16, parent_admins_can_manage),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at
+RETURNING id, name, handle, description, parent_id, created_by_id, archived_at, members_can_add_members, members_can_add_guests, members_can_start_discussions, members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments, members_can_delete_comments, members_can_announce, members_can_create_subgroups, admins_can_edit_user_content, parent_members_can_see_discussions, created_at, updated_at, parent_admins_can_manage
 `
 
 type UpdateGroupParams struct {
 	ID                             int64       `json:"id"`
 	Name                           pgtype.Text `json:"name"`
+	Handle                         pgtype.Text `json:"handle"`
 	Description                    pgtype.Text `json:"description"`
 	MembersCanAddMembers           pgtype.Bool `json:"members_can_add_members"`
 	MembersCanAddGuests            pgtype.Bool `json:"members_can_add_guests"`
@@ -538,6 +1221,7 @@ type UpdateGroupParams struct {
 	MembersCanCreateSubgroups      pgtype.Bool `json:"members_can_create_subgroups"`
 	AdminsCanEditUserContent       pgtype.Bool `json:"admins_can_edit_user_content"`
 	ParentMembersCanSeeDiscussions pgtype.Bool `json:"parent_members_can_see_discussions"`
+	ParentAdminsCanManage          pgtype.Bool `json:"parent_admins_can_manage"`
 }
 
 // Updates group fields (partial update pattern)
@@ -545,6 +1229,7 @@ func (q *Queries) UpdateGroup(ctx context.Context, arg UpdateGroupParams) (*Grou
 	row := q.db.QueryRow(ctx, updateGroup,
 		arg.ID,
 		arg.Name,
+		arg.Handle,
 		arg.Description,
 		arg.MembersCanAddMembers,
 		arg.MembersCanAddGuests,
@@ -557,6 +1242,7 @@ func (q *Queries) UpdateGroup(ctx context.Context, arg UpdateGroupParams) (*Grou
 		arg.MembersCanCreateSubgroups,
 		arg.AdminsCanEditUserContent,
 		arg.ParentMembersCanSeeDiscussions,
+		arg.ParentAdminsCanManage,
 	)
 	var i Group
 	err := row.Scan(
@@ -580,6 +1266,7 @@ func (q *Queries) UpdateGroup(ctx context.Context, arg UpdateGroupParams) (*Grou
 		&i.ParentMembersCanSeeDiscussions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentAdminsCanManage,
 	)
 	return &i, err
 }
@@ -14,7 +14,7 @@ import (
 const acceptMembership = `-- name: AcceptMembership :one
 UPDATE memberships SET accepted_at = NOW(), updated_at = NOW()
 WHERE id = $1
-RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
 `
 
 // Accepts a pending invitation
@@ -30,6 +30,10 @@ func (q *Queries) AcceptMembership(ctx context.Context, id int64) (*Membership,
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 	)
 	return &i, err
 }
@@ -49,9 +53,9 @@ func (q *Queries) CountAdminsByGroup(ctx context.Context, groupID int64) (int64,
 
 const createMembership = `-- name: CreateMembership :one
 
-INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at
+INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
 `
 
 type CreateMembershipParams struct {
@@ -60,6 +64,7 @@ type CreateMembershipParams struct {
 	Role       string             `json:"role"`
 	InviterID  int64              `json:"inviter_id"`
 	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
 }
 
 // sqlc queries for memberships table
@@ -72,6 +77,7 @@ func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipPara
 		arg.Role,
 		arg.InviterID,
 		arg.AcceptedAt,
+		arg.ExpiresAt,
 	)
 	var i Membership
 	err := row.Scan(
@@ -83,6 +89,10 @@ func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipPara
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 	)
 	return &i, err
 }
@@ -97,8 +107,59 @@ func (q *Queries) DeleteMembership(ctx context.Context, id int64) error {
 	return err
 }
 
+const deletePendingMembership = `-- name: DeletePendingMembership :execrows
+DELETE FROM memberships WHERE id = $1 AND accepted_at IS NULL
+`
+
+// Removes an invitation that has not been accepted (decline/revoke);
+// returns 0 if it was accepted or removed concurrently
+func (q *Queries) DeletePendingMembership(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePendingMembership, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const extendMembership = `-- name: ExtendMembership :one
+UPDATE memberships SET
+    expires_at = $1,
+    expiry_notified_at = NULL,
+    expiry_blocked_at = NULL,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+`
+
+type ExtendMembershipParams struct {
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	ID        int64              `json:"id"`
+}
+
+// Sets a new expiry (NULL makes the membership permanent) and resets the
+// expiry notification and blocked markers
+func (q *Queries) ExtendMembership(ctx context.Context, arg ExtendMembershipParams) (*Membership, error) {
+	row := q.db.QueryRow(ctx, extendMembership, arg.ExpiresAt, arg.ID)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
 const getMembershipByGroupAndUser = `-- name: GetMembershipByGroupAndUser :one
-SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at FROM memberships WHERE group_id = $1 AND user_id = $2
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships WHERE group_id = $1 AND user_id = $2
 `
 
 type GetMembershipByGroupAndUserParams struct {
@@ -119,12 +180,16 @@ func (q *Queries) GetMembershipByGroupAndUser(ctx context.Context, arg GetMember
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 	)
 	return &i, err
 }
 
 const getMembershipByID = `-- name: GetMembershipByID :one
-SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at FROM memberships WHERE id = $1
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships WHERE id = $1
 `
 
 // Retrieves a membership by its ID
@@ -140,17 +205,45 @@ func (q *Queries) GetMembershipByID(ctx context.Context, id int64) (*Membership,
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 	)
 	return &i, err
 }
 
+const getMembershipUsersUpdatedAt = `-- name: GetMembershipUsersUpdatedAt :one
+SELECT u.updated_at AS user_updated_at, i.updated_at AS inviter_updated_at
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+JOIN users i ON i.id = m.inviter_id
+WHERE m.id = $1
+`
+
+type GetMembershipUsersUpdatedAtRow struct {
+	UserUpdatedAt    pgtype.Timestamptz `json:"user_updated_at"`
+	InviterUpdatedAt pgtype.Timestamptz `json:"inviter_updated_at"`
+}
+
+// Returns the updated_at of a membership's user and inviter, which
+// GET /memberships/{id} embeds and its entity tag covers
+func (q *Queries) GetMembershipUsersUpdatedAt(ctx context.Context, id int64) (*GetMembershipUsersUpdatedAtRow, error) {
+	row := q.db.QueryRow(ctx, getMembershipUsersUpdatedAt, id)
+	var i GetMembershipUsersUpdatedAtRow
+	err := row.Scan(&i.UserUpdatedAt, &i.InviterUpdatedAt)
+	return &i, err
+}
+
 const getMembershipWithUser = `-- name: GetMembershipWithUser :one
 SELECT
-    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at,
+    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at, m.group_role_id, m.expires_at, m.expiry_notified_at, m.expiry_blocked_at,
     u.name AS user_name,
     u.username AS user_username,
+    u.updated_at AS user_updated_at,
     i.name AS inviter_name,
-    i.username AS inviter_username
+    i.username AS inviter_username,
+    i.updated_at AS inviter_updated_at
 FROM memberships m
 JOIN users u ON u.id = m.user_id
 JOIN users i ON i.id = m.inviter_id
@@ -158,18 +251,24 @@ WHERE m.id = $1
 `
 
 type GetMembershipWithUserRow struct {
-	ID              int64              `json:"id"`
-	GroupID         int64              `json:"group_id"`
-	UserID          int64              `json:"user_id"`
-	Role            string             `json:"role"`
-	InviterID       int64              `json:"inviter_id"`
-	AcceptedAt      pgtype.Timestamptz `json:"accepted_at"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
-	UserName        string             `json:"user_name"`
-	UserUsername    string             `json:"user_username"`
-	InviterName     string             `json:"inviter_name"`
-	InviterUsername string             `json:"inviter_username"`
+	ID               int64              `json:"id"`
+	GroupID          int64              `json:"group_id"`
+	UserID           int64              `json:"user_id"`
+	Role             string             `json:"role"`
+	InviterID        int64              `json:"inviter_id"`
+	AcceptedAt       pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	GroupRoleID      pgtype.Int8        `json:"group_role_id"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	ExpiryBlockedAt  pgtype.Timestamptz `json:"expiry_blocked_at"`
+	UserName         string             `json:"user_name"`
+	UserUsername     string             `json:"user_username"`
+	UserUpdatedAt    pgtype.Timestamptz `json:"user_updated_at"`
+	InviterName      string             `json:"inviter_name"`
+	InviterUsername  string             `json:"inviter_username"`
+	InviterUpdatedAt pgtype.Timestamptz `json:"inviter_updated_at"`
 }
 
 // Gets membership with embedded user info
@@ -185,10 +284,16 @@ func (q *Queries) GetMembershipWithUser(ctx context.Context, id int64) (*GetMemb
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 		&i.UserName,
 		&i.UserUsername,
+		&i.UserUpdatedAt,
 		&i.InviterName,
 		&i.InviterUsername,
+		&i.InviterUpdatedAt,
 	)
 	return &i, err
 }
@@ -216,7 +321,7 @@ func (q *Queries) IsAdmin(ctx context.Context, arg IsAdminParams) (bool, error)
 const isMember = `-- name: IsMember :one
 SELECT EXISTS(
     SELECT 1 FROM memberships
-    WHERE group_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL
+    WHERE group_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL AND role != 'guest'
 ) AS is_member
 `
 
@@ -225,7 +330,7 @@ type IsMemberParams struct {
 	UserID  int64 `json:"user_id"`
 }
 
-// Checks if a user is an active member of a group
+// Checks if a user is an active member of a group (guests are not members)
 func (q *Queries) IsMember(ctx context.Context, arg IsMemberParams) (bool, error) {
 	row := q.db.QueryRow(ctx, isMember, arg.GroupID, arg.UserID)
 	var is_member bool
@@ -233,9 +338,85 @@ func (q *Queries) IsMember(ctx context.Context, arg IsMemberParams) (bool, error
 	return is_member, err
 }
 
+const listAdminUserIDsByGroup = `-- name: ListAdminUserIDsByGroup :many
+SELECT user_id FROM memberships
+WHERE group_id = $1 AND role = 'admin' AND accepted_at IS NOT NULL
+ORDER BY user_id
+`
+
+// Lists the user IDs of the active admins of a group
+func (q *Queries) ListAdminUserIDsByGroup(ctx context.Context, groupID int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listAdminUserIDsByGroup, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var user_id int64
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredMemberships = `-- name: ListExpiredMemberships :many
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
+WHERE expires_at <= $1
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListExpiredMembershipsParams struct {
+	Now       pgtype.Timestamptz `json:"now"`
+	AfterID   int64              `json:"after_id"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Lists memberships past their expiry, keyset-paginated by id so memberships
+// blocked by last-admin protection do not stall the scan
+func (q *Queries) ListExpiredMemberships(ctx context.Context, arg ListExpiredMembershipsParams) ([]*Membership, error) {
+	rows, err := q.db.Query(ctx, listExpiredMemberships, arg.Now, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Membership{}
+	for rows.Next() {
+		var i Membership
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.UserID,
+			&i.Role,
+			&i.InviterID,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listInvitationsWithGroups = `-- name: ListInvitationsWithGroups :many
 SELECT
-    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at,
+    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at, m.group_role_id, m.expires_at, m.expiry_notified_at, m.expiry_blocked_at,
     g.name AS group_name,
     g.handle AS group_handle,
     g.description AS group_description,
@@ -245,9 +426,37 @@ FROM memberships m
 JOIN groups g ON g.id = m.group_id
 JOIN users i ON i.id = m.inviter_id
 WHERE m.user_id = $1 AND m.accepted_at IS NULL
-ORDER BY m.created_at DESC
+  AND (
+    $2::bigint = 0
+    OR ($3::text = 'name' AND NOT $4::boolean
+        AND (g.name, m.id) > ($5::text, $2::bigint))
+    OR ($3::text = 'name' AND $4::boolean
+        AND (g.name, m.id) < ($5::text, $2::bigint))
+    OR ($3::text = 'created_at' AND NOT $4::boolean
+        AND (m.created_at, m.id) > ($6::timestamptz, $2::bigint))
+    OR ($3::text = 'created_at' AND $4::boolean
+        AND (m.created_at, m.id) < ($6::timestamptz, $2::bigint))
+  )
+ORDER BY
+    CASE WHEN $3::text = 'name' AND NOT $4::boolean THEN g.name END ASC,
+    CASE WHEN $3::text = 'name' AND $4::boolean THEN g.name END DESC,
+    CASE WHEN $3::text = 'created_at' AND NOT $4::boolean THEN m.created_at END ASC,
+    CASE WHEN $3::text = 'created_at' AND $4::boolean THEN m.created_at END DESC,
+    CASE WHEN NOT $4::boolean THEN m.id END ASC,
+    CASE WHEN $4::boolean THEN m.id END DESC
+LIMIT $7
 `
 
+type ListInvitationsWithGroupsParams struct {
+	UserID         int64              `json:"user_id"`
+	AfterID        int64              `json:"after_id"`
+	SortBy         string             `json:"sort_by"`
+	SortDesc       bool               `json:"sort_desc"`
+	AfterName      string             `json:"after_name"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	PageLimit      int32              `json:"page_limit"`
+}
+
 type ListInvitationsWithGroupsRow struct {
 	ID               int64              `json:"id"`
 	GroupID          int64              `json:"group_id"`
@@ -257,6 +466,10 @@ type ListInvitationsWithGroupsRow struct {
 	AcceptedAt       pgtype.Timestamptz `json:"accepted_at"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	GroupRoleID      pgtype.Int8        `json:"group_role_id"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	ExpiryBlockedAt  pgtype.Timestamptz `json:"expiry_blocked_at"`
 	GroupName        string             `json:"group_name"`
 	GroupHandle      string             `json:"group_handle"`
 	GroupDescription pgtype.Text        `json:"group_description"`
@@ -264,9 +477,19 @@ type ListInvitationsWithGroupsRow struct {
 	InviterUsername  string             `json:"inviter_username"`
 }
 
-// Lists pending invitations with group and inviter info
-func (q *Queries) ListInvitationsWithGroups(ctx context.Context, userID int64) ([]*ListInvitationsWithGroupsRow, error) {
-	rows, err := q.db.Query(ctx, listInvitationsWithGroups, userID)
+// Lists one page of pending invitations with group and inviter info
+// Keyset pagination: sort_by is 'name' (group name) or 'created_at', ties
+// broken by membership id; after_id = 0 starts from the first page
+func (q *Queries) ListInvitationsWithGroups(ctx context.Context, arg ListInvitationsWithGroupsParams) ([]*ListInvitationsWithGroupsRow, error) {
+	rows, err := q.db.Query(ctx, listInvitationsWithGroups,
+		arg.UserID,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.AfterName,
+		arg.AfterCreatedAt,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +506,10 @@ func (q *Queries) ListInvitationsWithGroups(ctx context.Context, userID int64) (
 			&i.AcceptedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
 			&i.GroupName,
 			&i.GroupHandle,
 			&i.GroupDescription,
@@ -300,7 +527,7 @@ func (q *Queries) ListInvitationsWithGroups(ctx context.Context, userID int64) (
 }
 
 const listMembershipsByGroup = `-- name: ListMembershipsByGroup :many
-SELECT m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at FROM memberships m
+SELECT m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at, m.group_role_id, m.expires_at, m.expiry_notified_at, m.expiry_blocked_at FROM memberships m
 WHERE m.group_id = $1
   AND (
     $2::text = 'all'
@@ -335,6 +562,50 @@ func (q *Queries) ListMembershipsByGroup(ctx context.Context, arg ListMembership
 			&i.AcceptedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMembershipsByIDs = `-- name: ListMembershipsByIDs :many
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+`
+
+// Lists the memberships that still exist out of a set of IDs
+func (q *Queries) ListMembershipsByIDs(ctx context.Context, ids []int64) ([]*Membership, error) {
+	rows, err := q.db.Query(ctx, listMembershipsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Membership{}
+	for rows.Next() {
+		var i Membership
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.UserID,
+			&i.Role,
+			&i.InviterID,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -347,7 +618,7 @@ func (q *Queries) ListMembershipsByGroup(ctx context.Context, arg ListMembership
 }
 
 const listMembershipsByUser = `-- name: ListMembershipsByUser :many
-SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at FROM memberships
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -371,6 +642,62 @@ func (q *Queries) ListMembershipsByUser(ctx context.Context, userID int64) ([]*M
 			&i.AcceptedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMembershipsExpiringSoon = `-- name: ListMembershipsExpiringSoon :many
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
+WHERE expires_at IS NOT NULL
+  AND expires_at > $1
+  AND expires_at <= $2
+  AND expiry_notified_at IS NULL
+  AND accepted_at IS NOT NULL
+ORDER BY id
+LIMIT $3
+`
+
+type ListMembershipsExpiringSoonParams struct {
+	Now          pgtype.Timestamptz `json:"now"`
+	NoticeCutoff pgtype.Timestamptz `json:"notice_cutoff"`
+	BatchSize    int32              `json:"batch_size"`
+}
+
+// Lists accepted memberships expiring before the notice cutoff that have not
+// been notified yet
+func (q *Queries) ListMembershipsExpiringSoon(ctx context.Context, arg ListMembershipsExpiringSoonParams) ([]*Membership, error) {
+	rows, err := q.db.Query(ctx, listMembershipsExpiringSoon, arg.Now, arg.NoticeCutoff, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Membership{}
+	for rows.Next() {
+		var i Membership
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.UserID,
+			&i.Role,
+			&i.InviterID,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -384,7 +711,7 @@ func (q *Queries) ListMembershipsByUser(ctx context.Context, userID int64) ([]*M
 
 const listMembershipsWithUsers = `-- name: ListMembershipsWithUsers :many
 SELECT
-    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at,
+    m.id, m.group_id, m.user_id, m.role, m.inviter_id, m.accepted_at, m.created_at, m.updated_at, m.group_role_id, m.expires_at, m.expiry_notified_at, m.expiry_blocked_at,
     u.name AS user_name,
     u.username AS user_username,
     i.name AS inviter_name,
@@ -398,32 +725,97 @@ WHERE m.group_id = $1
     OR ($2::text = 'active' AND m.accepted_at IS NOT NULL)
     OR ($2::text = 'pending' AND m.accepted_at IS NULL)
   )
-ORDER BY m.role DESC, u.name
+  AND (
+    $3::text = ''
+    OR u.name ILIKE $3::text
+    OR u.username ILIKE $3::text
+  )
+  AND (
+    $4::bigint = 0
+    OR ($5::text = 'name' AND NOT $6::boolean
+        AND (u.name, m.id) > ($7::text, $4::bigint))
+    OR ($5::text = 'name' AND $6::boolean
+        AND (u.name, m.id) < ($7::text, $4::bigint))
+    OR ($5::text = 'created_at' AND NOT $6::boolean
+        AND (m.created_at, m.id) > ($8::timestamptz, $4::bigint))
+    OR ($5::text = 'created_at' AND $6::boolean
+        AND (m.created_at, m.id) < ($8::timestamptz, $4::bigint))
+    OR ($5::text = 'role' AND NOT $6::boolean
+        AND (CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, u.name, m.id)
+          > (CASE $9::text WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END,
+             $7::text, $4::bigint))
+    OR ($5::text = 'role' AND $6::boolean
+        AND (CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, u.name, m.id)
+          < (CASE $9::text WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END,
+             $7::text, $4::bigint))
+  )
+ORDER BY
+    CASE WHEN $5::text = 'name' AND NOT $6::boolean THEN u.name END ASC,
+    CASE WHEN $5::text = 'name' AND $6::boolean THEN u.name END DESC,
+    CASE WHEN $5::text = 'created_at' AND NOT $6::boolean THEN m.created_at END ASC,
+    CASE WHEN $5::text = 'created_at' AND $6::boolean THEN m.created_at END DESC,
+    CASE WHEN $5::text = 'role' AND NOT $6::boolean
+        THEN CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END END ASC,
+    CASE WHEN $5::text = 'role' AND $6::boolean
+        THEN CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END END DESC,
+    CASE WHEN $5::text = 'role' AND NOT $6::boolean THEN u.name END ASC,
+    CASE WHEN $5::text = 'role' AND $6::boolean THEN u.name END DESC,
+    CASE WHEN NOT $6::boolean THEN m.id END ASC,
+    CASE WHEN $6::boolean THEN m.id END DESC
+LIMIT $10
 `
 
 type ListMembershipsWithUsersParams struct {
-	GroupID int64  `json:"group_id"`
-	Status  string `json:"status"`
+	GroupID        int64              `json:"group_id"`
+	Status         string             `json:"status"`
+	Search         string             `json:"search"`
+	AfterID        int64              `json:"after_id"`
+	SortBy         string             `json:"sort_by"`
+	SortDesc       bool               `json:"sort_desc"`
+	AfterName      string             `json:"after_name"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterRole      string             `json:"after_role"`
+	PageLimit      int32              `json:"page_limit"`
 }
 
 type ListMembershipsWithUsersRow struct {
-	ID              int64              `json:"id"`
-	GroupID         int64              `json:"group_id"`
-	UserID          int64              `json:"user_id"`
-	Role            string             `json:"role"`
-	InviterID       int64              `json:"inviter_id"`
-	AcceptedAt      pgtype.Timestamptz `json:"accepted_at"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
-	UserName        string             `json:"user_name"`
-	UserUsername    string             `json:"user_username"`
-	InviterName     string             `json:"inviter_name"`
-	InviterUsername string             `json:"inviter_username"`
-}
-
-// Lists memberships with embedded user info
+	ID               int64              `json:"id"`
+	GroupID          int64              `json:"group_id"`
+	UserID           int64              `json:"user_id"`
+	Role             string             `json:"role"`
+	InviterID        int64              `json:"inviter_id"`
+	AcceptedAt       pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	GroupRoleID      pgtype.Int8        `json:"group_role_id"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	ExpiryBlockedAt  pgtype.Timestamptz `json:"expiry_blocked_at"`
+	UserName         string             `json:"user_name"`
+	UserUsername     string             `json:"user_username"`
+	InviterName      string             `json:"inviter_name"`
+	InviterUsername  string             `json:"inviter_username"`
+}
+
+// Lists one page of memberships with embedded user info
+// search matches the member's name or username (ILIKE pattern, ” = no filter)
+// Keyset pagination: sort_by is 'name' (user name), 'created_at', or 'role',
+// ties broken by membership id; after_id = 0 starts from the first page.
+// 'role' ranks admins, then members, then guests, with ties broken by user
+// name before id
 func (q *Queries) ListMembershipsWithUsers(ctx context.Context, arg ListMembershipsWithUsersParams) ([]*ListMembershipsWithUsersRow, error) {
-	rows, err := q.db.Query(ctx, listMembershipsWithUsers, arg.GroupID, arg.Status)
+	rows, err := q.db.Query(ctx, listMembershipsWithUsers,
+		arg.GroupID,
+		arg.Status,
+		arg.Search,
+		arg.AfterID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.AfterName,
+		arg.AfterCreatedAt,
+		arg.AfterRole,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -440,6 +832,10 @@ func (q *Queries) ListMembershipsWithUsers(ctx context.Context, arg ListMembersh
 			&i.AcceptedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
 			&i.UserName,
 			&i.UserUsername,
 			&i.InviterName,
@@ -456,7 +852,7 @@ func (q *Queries) ListMembershipsWithUsers(ctx context.Context, arg ListMembersh
 }
 
 const listPendingInvitationsByUser = `-- name: ListPendingInvitationsByUser :many
-SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at FROM memberships
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
 WHERE user_id = $1 AND accepted_at IS NULL
 ORDER BY created_at DESC
 `
@@ -480,6 +876,10 @@ func (q *Queries) ListPendingInvitationsByUser(ctx context.Context, userID int64
 			&i.AcceptedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.GroupRoleID,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+			&i.ExpiryBlockedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -491,20 +891,224 @@ func (q *Queries) ListPendingInvitationsByUser(ctx context.Context, userID int64
 	return items, nil
 }
 
+const lockExpiredMembership = `-- name: LockExpiredMembership :one
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships
+WHERE id = $1 AND expires_at <= $2
+FOR UPDATE
+`
+
+type LockExpiredMembershipParams struct {
+	ID  int64              `json:"id"`
+	Now pgtype.Timestamptz `json:"now"`
+}
+
+// Locks a membership for expiry, re-checking it was not extended meanwhile
+func (q *Queries) LockExpiredMembership(ctx context.Context, arg LockExpiredMembershipParams) (*Membership, error) {
+	row := q.db.QueryRow(ctx, lockExpiredMembership, arg.ID, arg.Now)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
+const lockMembershipByID = `-- name: LockMembershipByID :one
+SELECT id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at FROM memberships WHERE id = $1 FOR UPDATE
+`
+
+// Locks a membership for a conditional update so its ETag cannot change
+// between the If-Match check and the write
+func (q *Queries) LockMembershipByID(ctx context.Context, id int64) (*Membership, error) {
+	row := q.db.QueryRow(ctx, lockMembershipByID, id)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
+const markMembershipExpiryBlocked = `-- name: MarkMembershipExpiryBlocked :execrows
+UPDATE memberships SET expiry_blocked_at = NOW()
+WHERE id = $1 AND expiry_blocked_at IS NULL
+`
+
+// Records that expiry was refused by last-admin protection; returns 0 rows
+// if it was already recorded so callers report each block once
+func (q *Queries) MarkMembershipExpiryBlocked(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, markMembershipExpiryBlocked, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markMembershipExpiryNotified = `-- name: MarkMembershipExpiryNotified :execrows
+UPDATE memberships SET expiry_notified_at = NOW()
+WHERE id = $1 AND expiry_notified_at IS NULL
+`
+
+// Records that the expiring-soon notification was sent (no-op if already sent)
+func (q *Queries) MarkMembershipExpiryNotified(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, markMembershipExpiryNotified, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recreateMembership = `-- name: RecreateMembership :one
+INSERT INTO memberships (
+    id, group_id, user_id, role, inviter_id, accepted_at, created_at,
+    group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+) VALUES (
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11
+)
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+`
+
+type RecreateMembershipParams struct {
+	ID               int64              `json:"id"`
+	GroupID          int64              `json:"group_id"`
+	UserID           int64              `json:"user_id"`
+	Role             string             `json:"role"`
+	InviterID        int64              `json:"inviter_id"`
+	AcceptedAt       pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	GroupRoleID      pgtype.Int8        `json:"group_role_id"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	ExpiryBlockedAt  pgtype.Timestamptz `json:"expiry_blocked_at"`
+}
+
+// Re-inserts a deleted membership with its original ID and creation time
+// (point-in-time restore)
+func (q *Queries) RecreateMembership(ctx context.Context, arg RecreateMembershipParams) (*Membership, error) {
+	row := q.db.QueryRow(ctx, recreateMembership,
+		arg.ID,
+		arg.GroupID,
+		arg.UserID,
+		arg.Role,
+		arg.InviterID,
+		arg.AcceptedAt,
+		arg.CreatedAt,
+		arg.GroupRoleID,
+		arg.ExpiresAt,
+		arg.ExpiryNotifiedAt,
+		arg.ExpiryBlockedAt,
+	)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
+const restoreMembership = `-- name: RestoreMembership :one
+UPDATE memberships SET
+    role = $1,
+    group_role_id = $2,
+    accepted_at = $3,
+    expires_at = $4,
+    expiry_notified_at = $5,
+    expiry_blocked_at = $6,
+    updated_at = NOW()
+WHERE id = $7
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+`
+
+type RestoreMembershipParams struct {
+	Role             string             `json:"role"`
+	GroupRoleID      pgtype.Int8        `json:"group_role_id"`
+	AcceptedAt       pgtype.Timestamptz `json:"accepted_at"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	ExpiryBlockedAt  pgtype.Timestamptz `json:"expiry_blocked_at"`
+	ID               int64              `json:"id"`
+}
+
+// Sets a membership's role, acceptance, and expiry back to earlier values
+// (point-in-time restore)
+func (q *Queries) RestoreMembership(ctx context.Context, arg RestoreMembershipParams) (*Membership, error) {
+	row := q.db.QueryRow(ctx, restoreMembership,
+		arg.Role,
+		arg.GroupRoleID,
+		arg.AcceptedAt,
+		arg.ExpiresAt,
+		arg.ExpiryNotifiedAt,
+		arg.ExpiryBlockedAt,
+		arg.ID,
+	)
+	var i Membership
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.UserID,
+		&i.Role,
+		&i.InviterID,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
+	)
+	return &i, err
+}
+
 const updateMembershipRole = `-- name: UpdateMembershipRole :one
-UPDATE memberships SET role = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at
+UPDATE memberships SET
+    role = $1,
+    group_role_id = CASE WHEN $1::text = 'member' THEN group_role_id END,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, group_id, user_id, role, inviter_id, accepted_at, created_at, updated_at, group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
 `
 
 type UpdateMembershipRoleParams struct {
-	ID   int64  `json:"id"`
 	Role string `json:"role"`
+	ID   int64  `json:"id"`
 }
 
 // Changes the role of a membership (promote/demote)
+// Custom roles only apply to members, so they are cleared on promotion
 func (q *Queries) UpdateMembershipRole(ctx context.Context, arg UpdateMembershipRoleParams) (*Membership, error) {
-	row := q.db.QueryRow(ctx, updateMembershipRole, arg.ID, arg.Role)
+	row := q.db.QueryRow(ctx, updateMembershipRole, arg.Role, arg.ID)
 	var i Membership
 	err := row.Scan(
 		&i.ID,
@@ -515,6 +1119,10 @@ func (q *Queries) UpdateMembershipRole(ctx context.Context, arg UpdateMembership
 		&i.AcceptedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GroupRoleID,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+		&i.ExpiryBlockedAt,
 	)
 	return &i, err
 }
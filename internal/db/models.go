@@ -56,7 +56,41 @@ func (ns NullAuditOperation) Value() (driver.Value, error) {
 	return string(ns.AuditOperation), nil
 }
 
-// Immutable audit log storing JSONB snapshots of record changes
+// Audit partitions exported to JSONL and dropped after the retention period
+type AuditChainArchive struct {
+	ID            int64              `json:"id"`
+	PartitionName string             `json:"partition_name"`
+	RangeStart    pgtype.Timestamptz `json:"range_start"`
+	RangeEnd      pgtype.Timestamptz `json:"range_end"`
+	RowCount      int64              `json:"row_count"`
+	FirstSeq      pgtype.Int8        `json:"first_seq"`
+	LastSeq       pgtype.Int8        `json:"last_seq"`
+	// row_hash at last_seq; verification of the remaining chain starts from it
+	LastHash []byte      `json:"last_hash"`
+	FilePath pgtype.Text `json:"file_path"`
+	// sha256 of the compressed export file
+	FileSha256 []byte             `json:"file_sha256"`
+	ArchivedAt pgtype.Timestamptz `json:"archived_at"`
+}
+
+// Ed25519-signed audit chain heads for external publication
+type AuditChainCheckpoint struct {
+	ID        int64              `json:"id"`
+	ChainSeq  int64              `json:"chain_seq"`
+	RowHash   []byte             `json:"row_hash"`
+	KeyID     string             `json:"key_id"`
+	Signature []byte             `json:"signature"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// Last position and hash of the audit chain; locked to append
+type AuditChainHead struct {
+	Singleton bool   `json:"singleton"`
+	LastSeq   int64  `json:"last_seq"`
+	LastHash  []byte `json:"last_hash"`
+}
+
+// Immutable audit log storing JSONB snapshots of record changes, partitioned by month
 type AuditRecordVersion struct {
 	ID          int64              `json:"id"`
 	RecordID    pgtype.Text        `json:"record_id"`
@@ -72,6 +106,26 @@ type AuditRecordVersion struct {
 	OldRecord   []byte        `json:"old_record"`
 	// User ID from app.current_user_id session variable
 	ActorID pgtype.Int8 `json:"actor_id"`
+	// Gapless position in the audit hash chain
+	ChainSeq int64 `json:"chain_seq"`
+	// row_hash of the previous row in the chain (NULL for the first row)
+	PrevHash []byte `json:"prev_hash"`
+	// sha256 of prev_hash and this row, see audit.record_version_hash
+	RowHash []byte `json:"row_hash"`
+	// X-Request-ID of the HTTP request, from app.request_id
+	RequestID pgtype.Text `json:"request_id"`
+	// Client IP of the HTTP request, from app.client_ip
+	ClientIp pgtype.Text `json:"client_ip"`
+	// User-Agent of the HTTP request, from app.user_agent
+	UserAgent pgtype.Text `json:"user_agent"`
+}
+
+// Columns redacted or hashed in audit snapshots, per table
+type AuditRedactedColumn struct {
+	TableSchema string `json:"table_schema"`
+	TableName   string `json:"table_name"`
+	ColumnName  string `json:"column_name"`
+	Strategy    string `json:"strategy"`
 }
 
 // Organizational containers with permission-based membership
@@ -99,6 +153,71 @@ type Group struct {
 	ParentMembersCanSeeDiscussions bool               `json:"parent_members_can_see_discussions"`
 	CreatedAt                      pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt                      pgtype.Timestamptz `json:"updated_at"`
+	// Parent group admins have admin rights over this group
+	ParentAdminsCanManage bool `json:"parent_admins_can_manage"`
+}
+
+// Retired group handles kept for redirects and reservation
+type GroupHandleHistory struct {
+	ID      int64 `json:"id"`
+	GroupID int64 `json:"group_id"`
+	// Previous handle of the group, case-insensitive unique
+	Handle      string             `json:"handle"`
+	ChangedByID pgtype.Int8        `json:"changed_by_id"`
+	RetiredAt   pgtype.Timestamptz `json:"retired_at"`
+	// Other groups cannot claim the handle before this time
+	ReservedUntil pgtype.Timestamptz `json:"reserved_until"`
+}
+
+// Custom per-group roles made of named permissions
+type GroupRole struct {
+	ID      int64  `json:"id"`
+	GroupID int64  `json:"group_id"`
+	Name    string `json:"name"`
+	// Permission names granted on top of the member role
+	Permissions []string           `json:"permissions"`
+	CreatedByID pgtype.Int8        `json:"created_by_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Discussions and polls a guest membership can access
+type GuestGrant struct {
+	ID           int64 `json:"id"`
+	MembershipID int64 `json:"membership_id"`
+	// Either discussion or poll
+	ResourceType string `json:"resource_type"`
+	// ID of the discussion or poll (FK added with feature 005)
+	ResourceID  int64              `json:"resource_id"`
+	GrantedByID int64              `json:"granted_by_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+// Stored first responses of POST requests sent with an Idempotency-Key
+type IdempotencyKey struct {
+	UserID int64  `json:"user_id"`
+	Key    string `json:"key"`
+	// SHA-256 of method, path, and body of the first request
+	RequestHash []byte `json:"request_hash"`
+	// HTTP status of the stored response; NULL while the first request is in flight
+	Status          pgtype.Int4        `json:"status"`
+	ResponseHeaders []byte             `json:"response_headers"`
+	ResponseBody    []byte             `json:"response_body"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt       pgtype.Timestamptz `json:"expires_at"`
+}
+
+// Append-only log of invitation outcomes
+type InvitationEvent struct {
+	ID      int64 `json:"id"`
+	GroupID int64 `json:"group_id"`
+	UserID  int64 `json:"user_id"`
+	// Membership the event concerns; may since have been deleted
+	MembershipID int64 `json:"membership_id"`
+	// User who performed the action (invitee for accept/decline)
+	ActorID   pgtype.Int8        `json:"actor_id"`
+	Kind      string             `json:"kind"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 // User-group relationships with role and invitation status
@@ -106,7 +225,7 @@ type Membership struct {
 	ID      int64 `json:"id"`
 	GroupID int64 `json:"group_id"`
 	UserID  int64 `json:"user_id"`
-	// Either admin or member
+	// One of admin, member, or guest
 	Role string `json:"role"`
 	// User who created this membership/invitation
 	InviterID int64 `json:"inviter_id"`
@@ -114,6 +233,27 @@ type Membership struct {
 	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	// Optional custom role; only valid for member-role memberships
+	GroupRoleID pgtype.Int8 `json:"group_role_id"`
+	// Membership is removed by the expiry job after this time; NULL = permanent
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// When the expiring-soon notification was sent
+	ExpiryNotifiedAt pgtype.Timestamptz `json:"expiry_notified_at"`
+	// When expiry was first refused by last-admin protection
+	ExpiryBlockedAt pgtype.Timestamptz `json:"expiry_blocked_at"`
+}
+
+// Per-user notifications emitted by background jobs
+type Notification struct {
+	ID           int64       `json:"id"`
+	UserID       int64       `json:"user_id"`
+	Kind         string      `json:"kind"`
+	GroupID      pgtype.Int8 `json:"group_id"`
+	MembershipID pgtype.Int8 `json:"membership_id"`
+	// Kind-specific details, e.g. expires_at
+	Data      []byte             `json:"data"`
+	ReadAt    pgtype.Timestamptz `json:"read_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type User struct {
@@ -95,6 +95,9 @@ func NewPoolFromConfig(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool
 	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
 	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
 
+	// Trace every query as a child of the request span
+	poolConfig.ConnConfig.Tracer = NewQueryTracer()
+
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("create pool: %w", err)
@@ -108,3 +111,14 @@ func NewPoolFromConfig(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool
 
 	return pool, nil
 }
+
+// SchemaVersion returns the newest migration version goose has applied, or 0
+// if none has been.
+func SchemaVersion(ctx context.Context, conn DBTX) (int64, error) {
+	var version int64
+	err := conn.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("query goose_db_version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,191 @@
+-- sqlc queries for audit.record_version (read-only; rows are written by triggers)
+
+-- name: ListGroupHistory :many
+-- Lists one page of audit records scoped to a group, with the acting user
+-- Filters are optional: an empty string or NULL disables each one
+-- Keyset pagination on (ts, id); after_id = 0 starts from the first page
+SELECT
+    rv.id,
+    rv.op,
+    rv.ts,
+    rv.xact_id,
+    rv.table_name::text AS table_name,
+    COALESCE(rv.record_id, rv.old_record_id)::text AS record_id,
+    rv.record,
+    rv.old_record,
+    rv.actor_id,
+    rv.request_id,
+    u.name AS actor_name,
+    u.username AS actor_username
+FROM audit.record_version rv
+LEFT JOIN users u ON u.id = rv.actor_id
+WHERE audit.group_scope_id(rv.table_name, rv.record_id, rv.old_record_id, rv.record, rv.old_record)
+        = sqlc.arg(group_id)::text
+  AND (sqlc.arg(table_filter)::text = '' OR rv.table_name = sqlc.arg(table_filter)::text)
+  AND (sqlc.arg(op_filter)::text = '' OR rv.op::text = sqlc.arg(op_filter)::text)
+  AND (sqlc.narg(actor_id)::bigint IS NULL OR rv.actor_id = sqlc.narg(actor_id)::bigint)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR rv.ts >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR rv.ts < sqlc.narg(until)::timestamptz)
+  AND (
+    sqlc.arg(after_id)::bigint = 0
+    OR (NOT sqlc.arg(sort_desc)::boolean
+        AND (rv.ts, rv.id) > (sqlc.arg(after_ts)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_desc)::boolean
+        AND (rv.ts, rv.id) < (sqlc.arg(after_ts)::timestamptz, sqlc.arg(after_id)::bigint))
+  )
+ORDER BY
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN rv.ts END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN rv.ts END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN rv.id END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN rv.id END DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListAuditChainLinks :many
+-- Lists one batch of the audit hash chain in chain order. The verifier
+-- recomputes each row's hash itself, so it does not depend on the database's
+-- audit.record_version_hash
+SELECT rv.*
+FROM audit.record_version rv
+WHERE rv.chain_seq > sqlc.arg(after_seq)::bigint
+ORDER BY rv.chain_seq
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetAuditChainHead :one
+-- Returns the recorded end of the audit hash chain
+SELECT last_seq, last_hash FROM audit.chain_head;
+
+-- name: GetAuditChainLink :one
+-- Returns the hash stored at a chain position (used to check checkpoints)
+SELECT row_hash FROM audit.record_version WHERE chain_seq = $1;
+
+-- name: CreateAuditCheckpoint :one
+-- Stores a signed chain head
+INSERT INTO audit.chain_checkpoint (chain_seq, row_hash, key_id, signature, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLatestAuditCheckpoint :one
+-- Returns the most recent signed chain head
+SELECT * FROM audit.chain_checkpoint
+ORDER BY chain_seq DESC, id DESC
+LIMIT 1;
+
+-- name: ListAuditCheckpoints :many
+-- Lists signed chain heads in chain order
+SELECT * FROM audit.chain_checkpoint
+ORDER BY chain_seq, id;
+
+-- name: GetAuditChainSeqAt :one
+-- Returns the last chain position written at or before a point in time
+-- (0 if the chain starts later); used as a restore cutoff
+SELECT COALESCE((
+    SELECT rv.chain_seq FROM audit.record_version rv
+    WHERE rv.ts <= sqlc.arg(at)::timestamptz
+    ORDER BY rv.chain_seq DESC
+    LIMIT 1
+), 0)::bigint AS chain_seq;
+
+-- name: GetAuditChainSeqForXact :one
+-- Returns the last chain position written by a transaction; used as a
+-- restore cutoff
+SELECT rv.chain_seq FROM audit.record_version rv
+WHERE rv.xact_id = $1
+ORDER BY rv.chain_seq DESC
+LIMIT 1;
+
+-- name: GetOldestAuditXact :one
+-- Returns the lowest transaction ID still in the audit log (0 if it is
+-- empty); older transactions may have been archived
+SELECT COALESCE(MIN(rv.xact_id), 0)::bigint AS xact_id FROM audit.record_version rv;
+
+-- name: GetGroupLineageChangedAt :one
+-- Returns when a group, its ancestors, or any of their memberships last
+-- changed, deletions included (NULL if nothing is in the retained log)
+WITH RECURSIVE lineage AS (
+    SELECT g.id, g.parent_id FROM groups g WHERE g.id = @group_id
+    UNION ALL
+    SELECT p.id, p.parent_id FROM groups p
+    JOIN lineage l ON p.id = l.parent_id
+)
+SELECT MAX(rv.ts)::timestamptz AS changed_at
+FROM audit.record_version rv
+WHERE audit.group_scope_id(rv.table_name, rv.record_id, rv.old_record_id, rv.record, rv.old_record)
+        IN (SELECT l.id::text FROM lineage l);
+
+-- name: ListGroupChangesAfter :many
+-- Returns, for each group or membership row changed after a chain position,
+-- the first change made after it. Its old_record is the row as it was at the
+-- cutoff (NULL when the row was created after it). With membership_id set,
+-- only that membership is returned.
+SELECT DISTINCT ON (rv.table_name, COALESCE(rv.record_id, rv.old_record_id))
+    rv.table_name::text AS table_name,
+    COALESCE(rv.record_id, rv.old_record_id)::text AS record_id,
+    rv.op,
+    rv.old_record
+FROM audit.record_version rv
+WHERE audit.group_scope_id(rv.table_name, rv.record_id, rv.old_record_id, rv.record, rv.old_record)
+        = sqlc.arg(group_id)::text
+  AND rv.table_name IN ('groups', 'memberships')
+  AND rv.op IN ('INSERT', 'UPDATE', 'DELETE')
+  AND rv.chain_seq > sqlc.arg(after_seq)::bigint
+  AND (
+    sqlc.narg(membership_id)::bigint IS NULL
+    OR (rv.table_name = 'memberships'
+        AND COALESCE(rv.record_id, rv.old_record_id) = sqlc.narg(membership_id)::bigint::text)
+  )
+ORDER BY rv.table_name, COALESCE(rv.record_id, rv.old_record_id), rv.chain_seq;
+
+-- name: EnsureAuditPartitions :many
+-- Creates any missing monthly partitions from from_month through months_ahead
+-- months later and returns the names of the partitions it created
+SELECT audit.ensure_record_version_partitions(
+    sqlc.arg(from_month)::date, sqlc.arg(months_ahead)::integer
+)::text AS partition_name;
+
+-- name: ListAuditPartitions :many
+-- Lists the monthly partitions of audit.record_version, oldest first
+-- (the default partition is not included)
+SELECT
+    c.relname::text AS partition_name,
+    (to_date(substring(c.relname FROM 17), 'YYYYMM')::timestamp AT TIME ZONE 'UTC')::timestamptz AS range_start,
+    ((to_date(substring(c.relname FROM 17), 'YYYYMM')::timestamp + INTERVAL '1 month') AT TIME ZONE 'UTC')::timestamptz AS range_end
+FROM pg_catalog.pg_inherits i
+JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = 'audit.record_version'::regclass
+  AND c.relname ~ '^record_version_p[0-9]{6}$'
+ORDER BY c.relname;
+
+-- name: ListAuditRecordsInRange :many
+-- Lists one batch of audit rows in a time range in chain order (for export)
+SELECT * FROM audit.record_version
+WHERE ts >= sqlc.arg(range_start)::timestamptz
+  AND ts < sqlc.arg(range_end)::timestamptz
+  AND chain_seq > sqlc.arg(after_seq)::bigint
+ORDER BY chain_seq
+LIMIT sqlc.arg(batch_size);
+
+-- name: CountAuditRecordsInRange :one
+-- Counts the audit rows in a time range
+SELECT COUNT(*) FROM audit.record_version
+WHERE ts >= sqlc.arg(range_start)::timestamptz
+  AND ts < sqlc.arg(range_end)::timestamptz;
+
+-- name: DropAuditPartition :exec
+-- Drops a monthly partition; its rows must have been exported first
+SELECT audit.drop_record_version_partition(sqlc.arg(partition_name)::text);
+
+-- name: CreateAuditArchive :one
+-- Records an exported and dropped partition
+INSERT INTO audit.chain_archive (
+    partition_name, range_start, range_end, row_count,
+    first_seq, last_seq, last_hash, file_path, file_sha256
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetAuditChainAnchor :one
+-- Returns the last archived chain position and its hash; verification of
+-- the rows still in the database starts after it
+SELECT last_seq::bigint AS last_seq, last_hash FROM audit.chain_archive
+WHERE last_seq IS NOT NULL
+ORDER BY last_seq DESC
+LIMIT 1;
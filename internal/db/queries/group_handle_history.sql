@@ -0,0 +1,36 @@
+-- sqlc queries for group_handle_history table
+-- Retired handles redirect to their group and are reserved for a cooling-off period
+
+-- name: RecordHandleChange :one
+-- Records a retired handle; a previous row for the same handle is taken over
+INSERT INTO group_handle_history (group_id, handle, changed_by_id, reserved_until)
+VALUES (@group_id, @handle, @changed_by_id, @reserved_until)
+ON CONFLICT (handle) DO UPDATE SET
+    group_id = EXCLUDED.group_id,
+    changed_by_id = EXCLUDED.changed_by_id,
+    retired_at = NOW(),
+    reserved_until = EXCLUDED.reserved_until
+RETURNING *;
+
+-- name: ReleaseHandle :exec
+-- Removes the history row for a handle that is being claimed again
+DELETE FROM group_handle_history WHERE handle = $1;
+
+-- name: GetGroupByHistoricalHandle :one
+-- Resolves a retired handle to the group that last used it
+SELECT g.* FROM group_handle_history h
+JOIN groups g ON g.id = h.group_id
+WHERE h.handle = $1;
+
+-- name: HandleAvailableForGroup :one
+-- Checks if a group may take a handle: not used by another group and not
+-- reserved by another group. Pass group_id 0 for groups not yet created.
+SELECT (
+    NOT EXISTS(SELECT 1 FROM groups g WHERE g.handle = @handle AND g.id != @group_id)
+    AND NOT EXISTS(
+        SELECT 1 FROM group_handle_history h
+        WHERE h.handle = @handle
+          AND h.group_id != @group_id
+          AND h.reserved_until > NOW()
+    )
+)::boolean AS available;
@@ -0,0 +1,37 @@
+-- sqlc queries for group_roles table
+-- Custom roles are assigned to member-role memberships via memberships.group_role_id
+
+-- name: CreateGroupRole :one
+-- Creates a custom role in a group
+INSERT INTO group_roles (group_id, name, permissions, created_by_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetGroupRoleByID :one
+-- Retrieves a custom role by its ID
+SELECT * FROM group_roles WHERE id = $1;
+
+-- name: ListGroupRolesByGroup :many
+-- Lists the custom roles defined in a group
+SELECT * FROM group_roles
+WHERE group_id = $1
+ORDER BY name;
+
+-- name: UpdateGroupRole :one
+-- Updates a custom role (partial update using COALESCE)
+UPDATE group_roles SET
+    name = COALESCE(sqlc.narg(name), name),
+    permissions = COALESCE(sqlc.narg(permissions)::text[], permissions),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteGroupRole :exec
+-- Deletes a custom role; memberships holding it fall back to the plain member role
+DELETE FROM group_roles WHERE id = $1;
+
+-- name: AssignGroupRole :one
+-- Assigns (or clears, with NULL) the custom role of a membership
+UPDATE memberships SET group_role_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -9,7 +9,8 @@ INSERT INTO groups (
     members_can_add_members, members_can_add_guests, members_can_start_discussions,
     members_can_raise_motions, members_can_edit_discussions, members_can_edit_comments,
     members_can_delete_comments, members_can_announce, members_can_create_subgroups,
-    admins_can_edit_user_content, parent_members_can_see_discussions
+    admins_can_edit_user_content, parent_members_can_see_discussions,
+    parent_admins_can_manage
 ) VALUES (
     @name, @handle, @description, @parent_id, @created_by_id,
     COALESCE(sqlc.narg(members_can_add_members)::boolean, TRUE),
@@ -22,7 +23,8 @@ INSERT INTO groups (
     COALESCE(sqlc.narg(members_can_announce)::boolean, FALSE),
     COALESCE(sqlc.narg(members_can_create_subgroups)::boolean, FALSE),
     COALESCE(sqlc.narg(admins_can_edit_user_content)::boolean, FALSE),
-    COALESCE(sqlc.narg(parent_members_can_see_discussions)::boolean, FALSE)
+    COALESCE(sqlc.narg(parent_members_can_see_discussions)::boolean, FALSE),
+    COALESCE(sqlc.narg(parent_admins_can_manage)::boolean, TRUE)
 )
 RETURNING *;
 
@@ -35,26 +37,140 @@ SELECT * FROM groups WHERE id = $1;
 SELECT * FROM groups WHERE handle = $1;
 
 -- name: ListGroupsByUser :many
--- Lists all groups a user is an active member of
+-- Lists one page of the groups a user is an active member of
 -- Excludes archived groups by default unless include_archived is true
+-- Guest memberships are excluded: guests only see the resources they were granted
+-- Keyset pagination: sort_by is 'name' or 'created_at', ties broken by id;
+-- after_id = 0 starts from the first page (see api/pagination.go)
 SELECT g.* FROM groups g
 JOIN memberships m ON m.group_id = g.id
-WHERE m.user_id = $1
+WHERE m.user_id = sqlc.arg(user_id)
   AND m.accepted_at IS NOT NULL
+  AND m.role != 'guest'
   AND (sqlc.arg(include_archived)::boolean = TRUE OR g.archived_at IS NULL)
-ORDER BY g.name;
+  AND (
+    sqlc.arg(after_id)::bigint = 0
+    OR (sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (g.name, g.id) > (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean
+        AND (g.name, g.id) < (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (g.created_at, g.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean
+        AND (g.created_at, g.id) < (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean THEN g.name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean THEN g.name END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean THEN g.created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean THEN g.created_at END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN g.id END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN g.id END DESC
+LIMIT sqlc.arg(page_limit);
 
 -- name: ListSubgroupsByParent :many
--- Lists all subgroups under a parent group
-SELECT * FROM groups
-WHERE parent_id = $1
-  AND (sqlc.arg(include_archived)::boolean = TRUE OR archived_at IS NULL)
-ORDER BY name;
+-- Lists one page of the subgroups under a parent group
+-- Keyset pagination as in ListGroupsByUser
+SELECT g.* FROM groups g
+WHERE g.parent_id = sqlc.arg(parent_id)
+  AND (sqlc.arg(include_archived)::boolean = TRUE OR g.archived_at IS NULL)
+  AND (
+    sqlc.arg(after_id)::bigint = 0
+    OR (sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (g.name, g.id) > (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean
+        AND (g.name, g.id) < (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (g.created_at, g.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean
+        AND (g.created_at, g.id) < (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean THEN g.name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean THEN g.name END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean THEN g.created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean THEN g.created_at END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN g.id END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN g.id END DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListGroupAncestors :many
+-- Lists every ancestor of a group, nearest parent first (depth 1)
+-- Termination relies on groups_prevent_hierarchy_cycle keeping the hierarchy acyclic
+WITH RECURSIVE ancestors AS (
+    SELECT p.id, 1 AS depth
+    FROM groups c
+    JOIN groups p ON p.id = c.parent_id
+    WHERE c.id = @group_id
+    UNION ALL
+    SELECT p.id, a.depth + 1
+    FROM ancestors a
+    JOIN groups c ON c.id = a.id
+    JOIN groups p ON p.id = c.parent_id
+)
+SELECT sqlc.embed(g), a.depth::integer AS depth
+FROM groups g
+JOIN ancestors a ON a.id = g.id
+ORDER BY a.depth;
+
+-- name: ListGroupDescendants :many
+-- Lists every descendant of a group with its depth below the root (children are depth 1)
+-- Archived groups and everything beneath them are skipped unless include_archived is true
+WITH RECURSIVE descendants AS (
+    SELECT g.id, 1 AS depth
+    FROM groups g
+    WHERE g.parent_id = sqlc.arg(group_id)::bigint
+      AND (sqlc.arg(include_archived)::boolean = TRUE OR g.archived_at IS NULL)
+    UNION ALL
+    SELECT g.id, d.depth + 1
+    FROM groups g
+    JOIN descendants d ON g.parent_id = d.id
+    WHERE sqlc.arg(include_archived)::boolean = TRUE OR g.archived_at IS NULL
+)
+SELECT sqlc.embed(g), d.depth::integer AS depth
+FROM groups g
+JOIN descendants d ON d.id = g.id
+ORDER BY d.depth, g.name;
+
+-- name: IsInheritedAdmin :one
+-- Checks whether a user administers an ancestor that has admin rights over the group
+-- Walks up one level at a time while each group on the path has
+-- parent_admins_can_manage enabled. Expired admin memberships only count
+-- while their expiry is blocked by last-admin protection
+WITH RECURSIVE managing_ancestors AS (
+    SELECT g.parent_id AS id
+    FROM groups g
+    WHERE g.id = @group_id
+      AND g.parent_id IS NOT NULL
+      AND g.parent_admins_can_manage
+    UNION
+    SELECT p.parent_id
+    FROM groups p
+    JOIN managing_ancestors a ON p.id = a.id
+    WHERE p.parent_id IS NOT NULL
+      AND p.parent_admins_can_manage
+)
+SELECT EXISTS(
+    SELECT 1 FROM managing_ancestors a
+    JOIN memberships m ON m.group_id = a.id
+    WHERE m.user_id = @user_id
+      AND m.role = 'admin'
+      AND m.accepted_at IS NOT NULL
+      AND (m.expires_at IS NULL OR m.expires_at > NOW() OR m.expiry_blocked_at IS NOT NULL)
+) AS is_inherited_admin;
+
+-- name: MoveGroup :one
+-- Moves a group under a new parent, or to the top level when parent_id is NULL
+-- Cycles are rejected by the groups_prevent_hierarchy_cycle trigger
+UPDATE groups SET parent_id = sqlc.narg(parent_id), updated_at = NOW()
+WHERE id = @id
+RETURNING *;
 
 -- name: UpdateGroup :one
 -- Updates group fields (partial update pattern)
 UPDATE groups SET
     name = COALESCE(sqlc.narg(name), name),
+    handle = COALESCE(sqlc.narg(handle), handle),
     description = COALESCE(sqlc.narg(description), description),
     members_can_add_members = COALESCE(sqlc.narg(members_can_add_members), members_can_add_members),
     members_can_add_guests = COALESCE(sqlc.narg(members_can_add_guests), members_can_add_guests),
@@ -67,10 +183,33 @@ UPDATE groups SET
     members_can_create_subgroups = COALESCE(sqlc.narg(members_can_create_subgroups), members_can_create_subgroups),
     admins_can_edit_user_content = COALESCE(sqlc.narg(admins_can_edit_user_content), admins_can_edit_user_content),
     parent_members_can_see_discussions = COALESCE(sqlc.narg(parent_members_can_see_discussions), parent_members_can_see_discussions),
+    parent_admins_can_manage = COALESCE(sqlc.narg(parent_admins_can_manage), parent_admins_can_manage),
     updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: RestoreGroupSettings :one
+-- Sets every group setting back to earlier values (point-in-time restore);
+-- handle, parent, and archive state have their own operations
+UPDATE groups SET
+    name = sqlc.arg(name),
+    description = sqlc.narg(description),
+    members_can_add_members = sqlc.arg(members_can_add_members),
+    members_can_add_guests = sqlc.arg(members_can_add_guests),
+    members_can_start_discussions = sqlc.arg(members_can_start_discussions),
+    members_can_raise_motions = sqlc.arg(members_can_raise_motions),
+    members_can_edit_discussions = sqlc.arg(members_can_edit_discussions),
+    members_can_edit_comments = sqlc.arg(members_can_edit_comments),
+    members_can_delete_comments = sqlc.arg(members_can_delete_comments),
+    members_can_announce = sqlc.arg(members_can_announce),
+    members_can_create_subgroups = sqlc.arg(members_can_create_subgroups),
+    admins_can_edit_user_content = sqlc.arg(admins_can_edit_user_content),
+    parent_members_can_see_discussions = sqlc.arg(parent_members_can_see_discussions),
+    parent_admins_can_manage = sqlc.arg(parent_admins_can_manage),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: ArchiveGroup :one
 -- Soft-deletes a group by setting archived_at
 UPDATE groups SET archived_at = NOW(), updated_at = NOW()
@@ -83,10 +222,90 @@ UPDATE groups SET archived_at = NULL, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: ArchiveGroupTree :many
+-- Archives a group and every descendant that is not already archived
+-- NOW() is fixed for the transaction, so the whole cascade shares one
+-- archived_at value; UnarchiveGroupTree relies on this to find it again.
+-- Groups already archived, the root included, keep their archived_at, so
+-- the result lacks the root if it was archived already
+WITH RECURSIVE subtree AS (
+    SELECT r.id FROM groups r WHERE r.id = @group_id
+    UNION ALL
+    SELECT g.id FROM groups g
+    JOIN subtree s ON g.parent_id = s.id
+)
+UPDATE groups g SET archived_at = NOW(), updated_at = NOW()
+FROM subtree s
+WHERE g.id = s.id
+  AND g.archived_at IS NULL
+RETURNING g.*;
+
+-- name: UnarchiveGroupTree :many
+-- Restores a group and the descendants archived together with it
+-- The recursion stops at descendants archived separately (different
+-- archived_at), so they and everything below them stay archived
+WITH RECURSIVE subtree AS (
+    SELECT r.id, r.archived_at FROM groups r WHERE r.id = @group_id
+    UNION ALL
+    SELECT g.id, s.archived_at FROM groups g
+    JOIN subtree s ON g.parent_id = s.id
+    WHERE g.archived_at = s.archived_at
+)
+UPDATE groups g SET archived_at = NULL, updated_at = NOW()
+FROM subtree s
+WHERE g.id = s.id
+RETURNING g.*;
+
+-- name: ListGroupsForPurge :many
+-- Lists groups archived before the retention cutoff, oldest first
+-- Groups with subgroups are left out: deleting them would re-root the
+-- subgroups through parent_id ON DELETE SET NULL. They become eligible
+-- once their subgroups have been purged
+SELECT g.id, g.handle, g.archived_at FROM groups g
+WHERE g.archived_at IS NOT NULL
+  AND g.archived_at < @cutoff
+  AND NOT EXISTS (SELECT 1 FROM groups c WHERE c.parent_id = g.id)
+ORDER BY g.archived_at
+LIMIT @batch_size;
+
+-- name: LockGroupForPurge :one
+-- Locks a group for deletion, re-checking the cutoff and subgroups so a
+-- group unarchived or given a subgroup since ListGroupsForPurge is skipped
+SELECT g.* FROM groups g
+WHERE g.id = @id
+  AND g.archived_at IS NOT NULL
+  AND g.archived_at < @cutoff
+  AND NOT EXISTS (SELECT 1 FROM groups c WHERE c.parent_id = g.id)
+FOR UPDATE;
+
+-- name: SnapshotGroupForPurge :execrows
+-- Writes SNAPSHOT rows to audit.record_version for a group, its memberships,
+-- and their guest grants so the final state survives hard deletion
+INSERT INTO audit.record_version (record_id, op, table_oid, table_schema, table_name, record, actor_id)
+SELECT g.id::text, 'SNAPSHOT'::audit.operation, 'public.groups'::regclass::oid, 'public', 'groups', to_jsonb(g), NULL::bigint
+FROM groups g WHERE g.id = @group_id
+UNION ALL
+SELECT m.id::text, 'SNAPSHOT'::audit.operation, 'public.memberships'::regclass::oid, 'public', 'memberships', to_jsonb(m), NULL::bigint
+FROM memberships m WHERE m.group_id = @group_id
+UNION ALL
+SELECT gg.id::text, 'SNAPSHOT'::audit.operation, 'public.guest_grants'::regclass::oid, 'public', 'guest_grants', to_jsonb(gg), NULL::bigint
+FROM guest_grants gg
+JOIN memberships m ON m.id = gg.membership_id
+WHERE m.group_id = @group_id;
+
+-- name: DeleteGroup :exec
+-- Permanently deletes a group; memberships and guest grants cascade
+DELETE FROM groups WHERE id = $1;
+
+-- name: LockGroupByID :one
+-- Locks a group for a conditional update so its ETag cannot change between
+-- the If-Match check and the write
+SELECT * FROM groups WHERE id = $1 FOR UPDATE;
+
 -- name: CountGroupMembers :one
--- Counts active members in a group
+-- Counts active members in a group (guests are not counted as members)
 SELECT COUNT(*) AS member_count FROM memberships
-WHERE group_id = $1 AND accepted_at IS NOT NULL;
+WHERE group_id = $1 AND accepted_at IS NOT NULL AND role != 'guest';
 
 -- name: CountGroupAdmins :one
 -- Counts active admins in a group
@@ -96,15 +315,23 @@ WHERE group_id = $1 AND role = 'admin' AND accepted_at IS NOT NULL;
 -- name: CountGroupMembershipStats :one
 -- T169: Combined query to get both member and admin counts in a single query
 -- More efficient than two separate queries for handleGetGroup
+-- Guests are excluded from member_count
 SELECT
     COUNT(*) AS member_count,
     COUNT(*) FILTER (WHERE role = 'admin') AS admin_count
 FROM memberships
-WHERE group_id = $1 AND accepted_at IS NOT NULL;
+WHERE group_id = $1 AND accepted_at IS NOT NULL AND role != 'guest';
 
 -- name: HandleExists :one
--- Checks if a handle is already taken
-SELECT EXISTS(SELECT 1 FROM groups WHERE handle = $1) AS exists;
+-- Checks if a handle is already taken by a group or reserved after a rename
+SELECT (
+    EXISTS(SELECT 1 FROM groups g WHERE g.handle = sqlc.arg(handle)::citext)
+    OR EXISTS(
+        SELECT 1 FROM group_handle_history h
+        WHERE h.handle = sqlc.arg(handle)::citext
+          AND h.reserved_until > NOW()
+    )
+)::boolean AS exists;
 
 -- name: ListGroupsByUserWithCounts :many
 -- T190-T191: Lists all groups a user is an active member of, with member counts
@@ -113,11 +340,12 @@ SELECT EXISTS(SELECT 1 FROM groups WHERE handle = $1) AS exists;
 SELECT
     g.*,
     m.role AS current_user_role,
-    (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.accepted_at IS NOT NULL) AS member_count,
+    (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.accepted_at IS NOT NULL AND sm.role != 'guest') AS member_count,
     (SELECT COUNT(*) FROM memberships sm WHERE sm.group_id = g.id AND sm.role = 'admin' AND sm.accepted_at IS NOT NULL) AS admin_count
 FROM groups g
 JOIN memberships m ON m.group_id = g.id
 WHERE m.user_id = $1
   AND m.accepted_at IS NOT NULL
+  AND m.role != 'guest'
   AND (sqlc.arg(include_archived)::boolean = TRUE OR g.archived_at IS NULL)
 ORDER BY g.name;
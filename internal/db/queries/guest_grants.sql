@@ -0,0 +1,15 @@
+-- sqlc queries for guest_grants table
+-- Guests are memberships with role 'guest' scoped to specific discussions/polls
+
+-- name: CreateGuestGrant :one
+-- Grants a guest membership access to a discussion or poll
+INSERT INTO guest_grants (membership_id, resource_type, resource_id, granted_by_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListGuestGrantsByMembership :many
+-- Lists all resources a guest membership can access
+SELECT * FROM guest_grants
+WHERE membership_id = $1
+ORDER BY resource_type, resource_id;
+
@@ -0,0 +1,39 @@
+-- sqlc queries for idempotency_keys table
+-- First responses of POST requests, replayed for retries with the same Idempotency-Key
+
+-- name: ClaimIdempotencyKey :one
+-- Claims a key for a request about to run. Returns no row if the key is held:
+-- by a stored response that has not expired, or by a request still in flight
+-- that started after stale_before. Expired and abandoned claims are taken over.
+INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+VALUES (@user_id, @key, @request_hash, @expires_at)
+ON CONFLICT (user_id, key) DO UPDATE SET
+    request_hash = EXCLUDED.request_hash,
+    status = NULL,
+    response_headers = NULL,
+    response_body = NULL,
+    created_at = NOW(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
+   OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at < @stale_before)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+-- Gets the claim or stored response for a key
+SELECT * FROM idempotency_keys WHERE user_id = @user_id AND key = @key;
+
+-- name: CompleteIdempotencyKey :exec
+-- Stores the response of the request holding a claim
+UPDATE idempotency_keys SET
+    status = @status,
+    response_headers = @response_headers,
+    response_body = @response_body
+WHERE user_id = @user_id AND key = @key AND status IS NULL;
+
+-- name: ReleaseIdempotencyKey :exec
+-- Drops an unfinished claim so a retry runs the request again
+DELETE FROM idempotency_keys WHERE user_id = @user_id AND key = @key AND status IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+-- Removes stored responses past their expiry
+DELETE FROM idempotency_keys WHERE expires_at <= @now;
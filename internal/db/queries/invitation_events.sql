@@ -0,0 +1,29 @@
+-- sqlc queries for invitation_events table
+
+-- name: RecordInvitationEvent :one
+-- Appends an invitation outcome
+INSERT INTO invitation_events (group_id, user_id, membership_id, actor_id, kind)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLatestInvitationEvent :one
+-- Returns the most recent invitation event for a user in a group
+SELECT * FROM invitation_events
+WHERE group_id = $1 AND user_id = $2
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: GetInvitationStats :one
+-- Counts invitation events by kind for a group, optionally since a time,
+-- plus the invitations currently pending
+SELECT
+    COUNT(*) FILTER (WHERE e.kind = 'invited')::bigint AS invited,
+    COUNT(*) FILTER (WHERE e.kind = 'accepted')::bigint AS accepted,
+    COUNT(*) FILTER (WHERE e.kind = 'declined')::bigint AS declined,
+    COUNT(*) FILTER (WHERE e.kind = 'revoked')::bigint AS revoked,
+    COUNT(*) FILTER (WHERE e.kind = 'resent')::bigint AS resent,
+    (SELECT COUNT(*) FROM memberships m
+     WHERE m.group_id = sqlc.arg(group_id) AND m.accepted_at IS NULL)::bigint AS pending
+FROM invitation_events e
+WHERE e.group_id = sqlc.arg(group_id)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR e.created_at >= sqlc.narg(since));
@@ -3,14 +3,19 @@
 
 -- name: CreateMembership :one
 -- Creates a new membership (invitation if accepted_at is NULL)
-INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetMembershipByID :one
 -- Retrieves a membership by its ID
 SELECT * FROM memberships WHERE id = $1;
 
+-- name: LockMembershipByID :one
+-- Locks a membership for a conditional update so its ETag cannot change
+-- between the If-Match check and the write
+SELECT * FROM memberships WHERE id = $1 FOR UPDATE;
+
 -- name: GetMembershipByGroupAndUser :one
 -- Retrieves a specific user's membership in a group
 SELECT * FROM memberships WHERE group_id = $1 AND user_id = $2;
@@ -47,8 +52,12 @@ RETURNING *;
 
 -- name: UpdateMembershipRole :one
 -- Changes the role of a membership (promote/demote)
-UPDATE memberships SET role = $2, updated_at = NOW()
-WHERE id = $1
+-- Custom roles only apply to members, so they are cleared on promotion
+UPDATE memberships SET
+    role = sqlc.arg(role),
+    group_role_id = CASE WHEN sqlc.arg(role)::text = 'member' THEN group_role_id END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteMembership :exec
@@ -61,10 +70,10 @@ SELECT COUNT(*) AS admin_count FROM memberships
 WHERE group_id = $1 AND role = 'admin' AND accepted_at IS NOT NULL;
 
 -- name: IsMember :one
--- Checks if a user is an active member of a group
+-- Checks if a user is an active member of a group (guests are not members)
 SELECT EXISTS(
     SELECT 1 FROM memberships
-    WHERE group_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL
+    WHERE group_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL AND role != 'guest'
 ) AS is_member;
 
 -- name: IsAdmin :one
@@ -80,15 +89,31 @@ SELECT
     m.*,
     u.name AS user_name,
     u.username AS user_username,
+    u.updated_at AS user_updated_at,
     i.name AS inviter_name,
-    i.username AS inviter_username
+    i.username AS inviter_username,
+    i.updated_at AS inviter_updated_at
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+JOIN users i ON i.id = m.inviter_id
+WHERE m.id = $1;
+
+-- name: GetMembershipUsersUpdatedAt :one
+-- Returns the updated_at of a membership's user and inviter, which
+-- GET /memberships/{id} embeds and its entity tag covers
+SELECT u.updated_at AS user_updated_at, i.updated_at AS inviter_updated_at
 FROM memberships m
 JOIN users u ON u.id = m.user_id
 JOIN users i ON i.id = m.inviter_id
 WHERE m.id = $1;
 
 -- name: ListMembershipsWithUsers :many
--- Lists memberships with embedded user info
+-- Lists one page of memberships with embedded user info
+-- search matches the member's name or username (ILIKE pattern, '' = no filter)
+-- Keyset pagination: sort_by is 'name' (user name), 'created_at', or 'role',
+-- ties broken by membership id; after_id = 0 starts from the first page.
+-- 'role' ranks admins, then members, then guests, with ties broken by user
+-- name before id
 SELECT
     m.*,
     u.name AS user_name,
@@ -98,16 +123,55 @@ SELECT
 FROM memberships m
 JOIN users u ON u.id = m.user_id
 JOIN users i ON i.id = m.inviter_id
-WHERE m.group_id = $1
+WHERE m.group_id = sqlc.arg(group_id)
   AND (
     sqlc.arg(status)::text = 'all'
     OR (sqlc.arg(status)::text = 'active' AND m.accepted_at IS NOT NULL)
     OR (sqlc.arg(status)::text = 'pending' AND m.accepted_at IS NULL)
   )
-ORDER BY m.role DESC, u.name;
+  AND (
+    sqlc.arg(search)::text = ''
+    OR u.name ILIKE sqlc.arg(search)::text
+    OR u.username ILIKE sqlc.arg(search)::text
+  )
+  AND (
+    sqlc.arg(after_id)::bigint = 0
+    OR (sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (u.name, m.id) > (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean
+        AND (u.name, m.id) < (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (m.created_at, m.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean
+        AND (m.created_at, m.id) < (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'role' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, u.name, m.id)
+          > (CASE sqlc.arg(after_role)::text WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END,
+             sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'role' AND sqlc.arg(sort_desc)::boolean
+        AND (CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, u.name, m.id)
+          < (CASE sqlc.arg(after_role)::text WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END,
+             sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean THEN u.name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean THEN u.name END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean THEN m.created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean THEN m.created_at END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'role' AND NOT sqlc.arg(sort_desc)::boolean
+        THEN CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'role' AND sqlc.arg(sort_desc)::boolean
+        THEN CASE m.role WHEN 'admin' THEN 0 WHEN 'member' THEN 1 ELSE 2 END END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'role' AND NOT sqlc.arg(sort_desc)::boolean THEN u.name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'role' AND sqlc.arg(sort_desc)::boolean THEN u.name END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN m.id END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN m.id END DESC
+LIMIT sqlc.arg(page_limit);
 
 -- name: ListInvitationsWithGroups :many
--- Lists pending invitations with group and inviter info
+-- Lists one page of pending invitations with group and inviter info
+-- Keyset pagination: sort_by is 'name' (group name) or 'created_at', ties
+-- broken by membership id; after_id = 0 starts from the first page
 SELECT
     m.*,
     g.name AS group_name,
@@ -118,5 +182,116 @@ SELECT
 FROM memberships m
 JOIN groups g ON g.id = m.group_id
 JOIN users i ON i.id = m.inviter_id
-WHERE m.user_id = $1 AND m.accepted_at IS NULL
-ORDER BY m.created_at DESC;
+WHERE m.user_id = sqlc.arg(user_id) AND m.accepted_at IS NULL
+  AND (
+    sqlc.arg(after_id)::bigint = 0
+    OR (sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (g.name, m.id) > (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean
+        AND (g.name, m.id) < (sqlc.arg(after_name)::text, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean
+        AND (m.created_at, m.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean
+        AND (m.created_at, m.id) < (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean THEN g.name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean THEN g.name END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::boolean THEN m.created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::boolean THEN m.created_at END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN m.id END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN m.id END DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ExtendMembership :one
+-- Sets a new expiry (NULL makes the membership permanent) and resets the
+-- expiry notification and blocked markers
+UPDATE memberships SET
+    expires_at = sqlc.narg(expires_at),
+    expiry_notified_at = NULL,
+    expiry_blocked_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListMembershipsExpiringSoon :many
+-- Lists accepted memberships expiring before the notice cutoff that have not
+-- been notified yet
+SELECT * FROM memberships
+WHERE expires_at IS NOT NULL
+  AND expires_at > sqlc.arg(now)
+  AND expires_at <= sqlc.arg(notice_cutoff)
+  AND expiry_notified_at IS NULL
+  AND accepted_at IS NOT NULL
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: MarkMembershipExpiryNotified :execrows
+-- Records that the expiring-soon notification was sent (no-op if already sent)
+UPDATE memberships SET expiry_notified_at = NOW()
+WHERE id = $1 AND expiry_notified_at IS NULL;
+
+-- name: ListExpiredMemberships :many
+-- Lists memberships past their expiry, keyset-paginated by id so memberships
+-- blocked by last-admin protection do not stall the scan
+SELECT * FROM memberships
+WHERE expires_at <= sqlc.arg(now)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: LockExpiredMembership :one
+-- Locks a membership for expiry, re-checking it was not extended meanwhile
+SELECT * FROM memberships
+WHERE id = sqlc.arg(id) AND expires_at <= sqlc.arg(now)
+FOR UPDATE;
+
+-- name: MarkMembershipExpiryBlocked :execrows
+-- Records that expiry was refused by last-admin protection; returns 0 rows
+-- if it was already recorded so callers report each block once
+UPDATE memberships SET expiry_blocked_at = NOW()
+WHERE id = $1 AND expiry_blocked_at IS NULL;
+
+-- name: ListAdminUserIDsByGroup :many
+-- Lists the user IDs of the active admins of a group
+SELECT user_id FROM memberships
+WHERE group_id = $1 AND role = 'admin' AND accepted_at IS NOT NULL
+ORDER BY user_id;
+
+-- name: DeletePendingMembership :execrows
+-- Removes an invitation that has not been accepted (decline/revoke);
+-- returns 0 if it was accepted or removed concurrently
+DELETE FROM memberships WHERE id = $1 AND accepted_at IS NULL;
+
+-- name: ListMembershipsByIDs :many
+-- Lists the memberships that still exist out of a set of IDs
+SELECT * FROM memberships
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id;
+
+-- name: RestoreMembership :one
+-- Sets a membership's role, acceptance, and expiry back to earlier values
+-- (point-in-time restore)
+UPDATE memberships SET
+    role = sqlc.arg(role),
+    group_role_id = sqlc.narg(group_role_id),
+    accepted_at = sqlc.narg(accepted_at),
+    expires_at = sqlc.narg(expires_at),
+    expiry_notified_at = sqlc.narg(expiry_notified_at),
+    expiry_blocked_at = sqlc.narg(expiry_blocked_at),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RecreateMembership :one
+-- Re-inserts a deleted membership with its original ID and creation time
+-- (point-in-time restore)
+INSERT INTO memberships (
+    id, group_id, user_id, role, inviter_id, accepted_at, created_at,
+    group_role_id, expires_at, expiry_notified_at, expiry_blocked_at
+) VALUES (
+    sqlc.arg(id), sqlc.arg(group_id), sqlc.arg(user_id), sqlc.arg(role), sqlc.arg(inviter_id),
+    sqlc.narg(accepted_at), sqlc.arg(created_at), sqlc.narg(group_role_id), sqlc.narg(expires_at),
+    sqlc.narg(expiry_notified_at), sqlc.narg(expiry_blocked_at)
+)
+RETURNING *;
@@ -0,0 +1,21 @@
+-- sqlc queries for notifications table
+
+-- name: CreateNotification :one
+-- Adds a notification to a user's inbox
+INSERT INTO notifications (user_id, kind, group_id, membership_id, data)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListNotificationsByUser :many
+-- Lists a user's notifications, newest first, optionally only unread ones
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: MarkNotificationRead :one
+-- Marks one of the user's notifications as read
+UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2
+RETURNING *;
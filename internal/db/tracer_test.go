@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"-- name: GetGroupByID :one\nSELECT * FROM groups WHERE id = $1", "GetGroupByID"},
+		{"\n  -- name: ListAuditPartitions :many\nSELECT 1", "ListAuditPartitions"},
+		{"SELECT set_config('app.current_user_id', $1, true)", "SELECT"},
+		{"-- a comment\n\nupdate users set name = $1", "UPDATE"},
+		{"", "query"},
+	}
+	for _, tt := range tests {
+		if got := queryName(tt.sql); got != tt.want {
+			t.Errorf("queryName(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+// TestQueryTracer verifies each query becomes a span named after the sqlc
+// query, and only real failures are recorded as errors.
+func TestQueryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewQueryTracerWithProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ctx := context.Background()
+
+	run := func(sql string, err error) {
+		qctx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql, Args: []any{"secret"}})
+		tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 2"), Err: err})
+	}
+	run("-- name: UpdateGroup :one\nUPDATE groups SET name = $1", nil)
+	run("-- name: GetGroupByID :one\nSELECT 1", pgx.ErrNoRows)
+	run("SELECT broken", errors.New("syntax error"))
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "UpdateGroup" || spans[0].Status().Code == codes.Error {
+		t.Errorf("unexpected first span %q with status %v", spans[0].Name(), spans[0].Status())
+	}
+	for _, attr := range spans[0].Attributes() {
+		if attr.Value.AsString() == "secret" {
+			t.Error("query arguments must not be recorded")
+		}
+		if attr.Key == "db.response.rows_affected" && attr.Value.AsInt64() != 2 {
+			t.Errorf("expected 2 rows affected, got %d", attr.Value.AsInt64())
+		}
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Error("pgx.ErrNoRows should not mark the span as failed")
+	}
+	if spans[2].Name() != "SELECT" || spans[2].Status().Code != codes.Error {
+		t.Errorf("expected failed SELECT span, got %q with status %v", spans[2].Name(), spans[2].Status())
+	}
+}
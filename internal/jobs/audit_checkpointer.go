@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/zacaytion/llmio/internal/audit"
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// AuditCheckpointer periodically signs the head of the audit hash chain so it
+// can be published externally. Runs where the head has not moved are skipped.
+type AuditCheckpointer struct {
+	checkpointer *audit.Checkpointer
+}
+
+// NewAuditCheckpointer creates a checkpoint job that signs with key.
+func NewAuditCheckpointer(queries *db.Queries, key ed25519.PrivateKey) *AuditCheckpointer {
+	return &AuditCheckpointer{checkpointer: audit.NewCheckpointer(queries, key)}
+}
+
+// Run signs the chain head every interval until ctx is cancelled.
+func (c *AuditCheckpointer) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, "audit_checkpointer", interval, func(ctx context.Context) error {
+		cp, created, err := c.checkpointer.Checkpoint(ctx, time.Now())
+		if errors.Is(err, audit.ErrEmptyChain) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if created {
+			slog.InfoContext(ctx, "audit chain checkpoint signed",
+				"chain_seq", cp.ChainSeq,
+				"row_hash", hex.EncodeToString(cp.RowHash),
+				"key_id", cp.KeyID,
+			)
+		}
+		return nil
+	})
+}
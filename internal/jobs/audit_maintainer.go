@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/audit"
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// AuditMaintainer keeps the monthly partitions of audit.record_version in
+// shape: it creates partitions ahead of time and, when retention is enabled,
+// exports partitions older than the retention period to archive files and
+// drops them.
+type AuditMaintainer struct {
+	archiver        *audit.Archiver
+	monthsAhead     int
+	retentionMonths int
+}
+
+// NewAuditMaintainer creates a maintenance job that keeps monthsAhead future
+// partitions and archives to archiveDir after retentionMonths (0 keeps
+// everything).
+func NewAuditMaintainer(pool *pgxpool.Pool, queries *db.Queries, archiveDir string, monthsAhead, retentionMonths int) *AuditMaintainer {
+	return &AuditMaintainer{
+		archiver:        audit.NewArchiver(pool, queries, archiveDir),
+		monthsAhead:     monthsAhead,
+		retentionMonths: retentionMonths,
+	}
+}
+
+// Run maintains the audit partitions every interval until ctx is cancelled.
+func (m *AuditMaintainer) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, "audit_maintainer", interval, func(ctx context.Context) error {
+		return m.Maintain(ctx, time.Now())
+	})
+}
+
+// Maintain creates missing partitions and archives expired ones as of now.
+func (m *AuditMaintainer) Maintain(ctx context.Context, now time.Time) error {
+	created, err := m.archiver.EnsurePartitions(ctx, now, m.monthsAhead)
+	if err != nil {
+		return err
+	}
+	for _, name := range created {
+		slog.InfoContext(ctx, "created audit partition", "partition", name)
+	}
+
+	if m.retentionMonths <= 0 {
+		return nil
+	}
+	archived, err := m.archiver.ArchiveBefore(ctx, audit.RetentionCutoff(now, m.retentionMonths))
+	for _, a := range archived {
+		slog.InfoContext(ctx, "archived audit partition",
+			"partition", a.PartitionName,
+			"rows", a.RowCount,
+			"last_seq", a.LastSeq.Int64,
+			"file", a.FilePath.String,
+		)
+	}
+	return err
+}
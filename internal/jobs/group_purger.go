@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// DefaultPurgeBatchSize limits how many groups are listed per purge query.
+const DefaultPurgeBatchSize = 100
+
+// GroupPurger permanently deletes groups that have been archived for longer
+// than the retention period. Each group is deleted in its own transaction,
+// after SNAPSHOT rows for the group, its memberships, and their guest grants
+// are written to audit.record_version. Subgroups are purged before their
+// parent; a group with a subgroup that is live or not yet expired is kept.
+type GroupPurger struct {
+	pool      *pgxpool.Pool
+	queries   *db.Queries
+	retention time.Duration
+	batchSize int32
+}
+
+// NewGroupPurger creates a purger for groups archived longer than retention.
+func NewGroupPurger(pool *pgxpool.Pool, queries *db.Queries, retention time.Duration) *GroupPurger {
+	return &GroupPurger{
+		pool:      pool,
+		queries:   queries,
+		retention: retention,
+		batchSize: DefaultPurgeBatchSize,
+	}
+}
+
+// Run purges expired groups every interval until ctx is cancelled.
+func (p *GroupPurger) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, "group_purger", interval, func(ctx context.Context) error {
+		_, err := p.PurgeExpired(ctx, time.Now())
+		return err
+	})
+}
+
+// PurgeExpired deletes every group archived before now minus the retention
+// period and returns how many were deleted.
+func (p *GroupPurger) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	cutoff := pgtype.Timestamptz{Time: now.Add(-p.retention), Valid: true}
+	purged := 0
+
+	for {
+		candidates, err := p.queries.ListGroupsForPurge(ctx, db.ListGroupsForPurgeParams{
+			Cutoff:    cutoff,
+			BatchSize: p.batchSize,
+		})
+		if err != nil {
+			return purged, fmt.Errorf("ListGroupsForPurge: %w", err)
+		}
+
+		deletedInBatch := 0
+		for _, c := range candidates {
+			deleted, err := p.purgeGroup(ctx, c.ID, cutoff)
+			if err != nil {
+				return purged, fmt.Errorf("purge group %d: %w", c.ID, err)
+			}
+			if deleted {
+				purged++
+				deletedInBatch++
+				slog.InfoContext(ctx, "purged archived group",
+					"group_id", c.ID,
+					"handle", c.Handle,
+					"archived_at", c.ArchivedAt.Time,
+				)
+			}
+		}
+
+		// Keep listing while groups are deleted: purging the last subgroup
+		// of an expired parent makes the parent eligible
+		if deletedInBatch == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// purgeGroup snapshots and deletes a single group. It returns false without
+// error if the group was unarchived, deleted, or given a subgroup since it
+// was listed.
+func (p *GroupPurger) purgeGroup(ctx context.Context, groupID int64, cutoff pgtype.Timestamptz) (bool, error) {
+	deleted := false
+	err := pgx.BeginTxFunc(ctx, p.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		txQueries := p.queries.WithTx(tx)
+
+		if _, err := txQueries.LockGroupForPurge(ctx, db.LockGroupForPurgeParams{
+			ID:     groupID,
+			Cutoff: cutoff,
+		}); err != nil {
+			if db.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("LockGroupForPurge: %w", err)
+		}
+
+		if _, err := txQueries.SnapshotGroupForPurge(ctx, groupID); err != nil {
+			return fmt.Errorf("SnapshotGroupForPurge: %w", err)
+		}
+
+		if err := txQueries.DeleteGroup(ctx, groupID); err != nil {
+			return fmt.Errorf("DeleteGroup: %w", err)
+		}
+
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
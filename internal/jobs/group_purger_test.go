@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+	"github.com/zacaytion/llmio/internal/testutil"
+)
+
+// setupPurgerTest creates a migrated database and returns a pool and queries.
+func setupPurgerTest(t *testing.T) (*pgxpool.Pool, *db.Queries) {
+	t.Helper()
+	ctx := context.Background()
+
+	connStr, cleanup := testutil.SetupTestDB(ctx, t)
+	t.Cleanup(cleanup)
+
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool, db.New(pool)
+}
+
+// createArchivedGroup creates a group with an admin membership archived at archivedAt.
+func createArchivedGroup(t *testing.T, pool *pgxpool.Pool, userID int64, handle string, archivedAt time.Time) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	var groupID int64
+	err := pool.QueryRow(ctx,
+		`INSERT INTO groups (name, handle, created_by_id, archived_at) VALUES ($1, $1, $2, $3) RETURNING id`,
+		handle, userID, archivedAt,
+	).Scan(&groupID)
+	if err != nil {
+		t.Fatalf("insert group: %v", err)
+	}
+
+	_, err = pool.Exec(ctx,
+		`INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at) VALUES ($1, $2, 'admin', $2, NOW())`,
+		groupID, userID,
+	)
+	if err != nil {
+		t.Fatalf("insert membership: %v", err)
+	}
+	return groupID
+}
+
+// TestGroupPurger_PurgeExpired tests that only groups past retention are deleted
+// and that a SNAPSHOT is written for the group and its memberships first.
+func TestGroupPurger_PurgeExpired(t *testing.T) {
+	pool, queries := setupPurgerTest(t)
+	ctx := context.Background()
+
+	var userID int64
+	err := pool.QueryRow(ctx,
+		`INSERT INTO users (email, name, username, password_hash, key) VALUES ('admin@example.com', 'Admin', 'admin', 'hash', 'key1') RETURNING id`,
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	now := time.Now()
+	expiredID := createArchivedGroup(t, pool, userID, "expired-group", now.Add(-40*24*time.Hour))
+	recentID := createArchivedGroup(t, pool, userID, "recent-group", now.Add(-5*24*time.Hour))
+
+	purger := NewGroupPurger(pool, queries, 30*24*time.Hour)
+	purged, err := purger.PurgeExpired(ctx, now)
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 purged group, got %d", purged)
+	}
+
+	if _, err := queries.GetGroupByID(ctx, expiredID); !db.IsNotFound(err) {
+		t.Errorf("expected expired group to be deleted, got err=%v", err)
+	}
+	if _, err := queries.GetGroupByID(ctx, recentID); err != nil {
+		t.Errorf("expected recent group to remain, got err=%v", err)
+	}
+
+	var snapshots int
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM audit.record_version
+		WHERE op = 'SNAPSHOT'
+		  AND ((table_name = 'groups' AND record_id = $1::text)
+		    OR (table_name = 'memberships' AND record->>'group_id' = $1::text))`,
+		expiredID,
+	).Scan(&snapshots)
+	if err != nil {
+		t.Fatalf("count snapshots: %v", err)
+	}
+	if snapshots != 2 {
+		t.Errorf("expected 2 SNAPSHOT rows (group + membership), got %d", snapshots)
+	}
+
+	// Running again is a no-op
+	purged, err = purger.PurgeExpired(ctx, now)
+	if err != nil {
+		t.Fatalf("second PurgeExpired: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("expected 0 purged on second run, got %d", purged)
+	}
+}
+
+// TestGroupPurger_Subgroups tests that a parent is purged after its expired
+// subgroups in the same run, and kept while it has a live subgroup.
+func TestGroupPurger_Subgroups(t *testing.T) {
+	pool, queries := setupPurgerTest(t)
+	ctx := context.Background()
+
+	var userID int64
+	err := pool.QueryRow(ctx,
+		`INSERT INTO users (email, name, username, password_hash, key) VALUES ('admin@example.com', 'Admin', 'admin', 'hash', 'key1') RETURNING id`,
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	setParent := func(childID, parentID int64) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE groups SET parent_id = $2 WHERE id = $1`, childID, parentID); err != nil {
+			t.Fatalf("set parent: %v", err)
+		}
+	}
+
+	now := time.Now()
+	expired := now.Add(-40 * 24 * time.Hour)
+	parentID := createArchivedGroup(t, pool, userID, "parent", expired)
+	childID := createArchivedGroup(t, pool, userID, "child", expired)
+	setParent(childID, parentID)
+
+	blockedID := createArchivedGroup(t, pool, userID, "blocked", expired)
+	liveID := createArchivedGroup(t, pool, userID, "live", expired)
+	setParent(liveID, blockedID)
+	if _, err := pool.Exec(ctx, `UPDATE groups SET archived_at = NULL WHERE id = $1`, liveID); err != nil {
+		t.Fatalf("unarchive: %v", err)
+	}
+
+	purger := NewGroupPurger(pool, queries, 30*24*time.Hour)
+	purged, err := purger.PurgeExpired(ctx, now)
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("expected 2 purged groups, got %d", purged)
+	}
+
+	for _, id := range []int64{parentID, childID} {
+		if _, err := queries.GetGroupByID(ctx, id); !db.IsNotFound(err) {
+			t.Errorf("expected group %d to be deleted, got err=%v", id, err)
+		}
+	}
+
+	// The parent of a live subgroup is kept, and the subgroup keeps its parent
+	if _, err := queries.GetGroupByID(ctx, blockedID); err != nil {
+		t.Errorf("expected parent of a live subgroup to remain, got err=%v", err)
+	}
+	live, err := queries.GetGroupByID(ctx, liveID)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if !live.ParentID.Valid || live.ParentID.Int64 != blockedID {
+		t.Errorf("expected live subgroup to keep parent %d, got %v", blockedID, live.ParentID)
+	}
+}
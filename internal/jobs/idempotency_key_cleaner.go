@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// IdempotencyKeyCleaner deletes stored Idempotency-Key responses once they
+// expire. Expired keys are already ignored when claimed again; this only
+// keeps the table small.
+type IdempotencyKeyCleaner struct {
+	queries *db.Queries
+}
+
+// NewIdempotencyKeyCleaner creates a cleanup job.
+func NewIdempotencyKeyCleaner(queries *db.Queries) *IdempotencyKeyCleaner {
+	return &IdempotencyKeyCleaner{queries: queries}
+}
+
+// Run deletes expired keys every interval until ctx is cancelled.
+func (c *IdempotencyKeyCleaner) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, "idempotency_key_cleaner", interval, func(ctx context.Context) error {
+		deleted, err := c.queries.DeleteExpiredIdempotencyKeys(ctx, pgtype.Timestamptz{Time: time.Now(), Valid: true})
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "expired idempotency keys deleted", "count", deleted)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,259 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// Notification kinds accepted by the notifications_kind_valid constraint.
+const (
+	NotificationMembershipExpiring      = "membership_expiring"
+	NotificationMembershipExpired       = "membership_expired"
+	NotificationMembershipExpiryBlocked = "membership_expiry_blocked"
+)
+
+// DefaultExpiryBatchSize limits how many memberships are listed per expiry query.
+const DefaultExpiryBatchSize = 100
+
+// errExpiryBlocked signals that the last-admin trigger refused the deletion.
+var errExpiryBlocked = errors.New("membership expiry blocked by last-admin protection")
+
+// ExpiryResult summarises one expiry run.
+// Blocked counts memberships past expiry that could not be removed because
+// they hold the last admin seat of their group; they are retried every run.
+type ExpiryResult struct {
+	Notified int
+	Expired  int
+	Blocked  int
+}
+
+// MembershipExpirer removes memberships past their expires_at and notifies
+// members ahead of time. Removal goes through the same DELETE as a manual
+// removal, so the prevent_last_admin_removal trigger still applies: a blocked
+// expiry is logged, counted, and reported once to the group's admins.
+type MembershipExpirer struct {
+	pool      *pgxpool.Pool
+	queries   *db.Queries
+	notice    time.Duration
+	batchSize int32
+}
+
+// NewMembershipExpirer creates an expirer that notifies members notice ahead
+// of expiry (0 disables the advance notice).
+func NewMembershipExpirer(pool *pgxpool.Pool, queries *db.Queries, notice time.Duration) *MembershipExpirer {
+	return &MembershipExpirer{
+		pool:      pool,
+		queries:   queries,
+		notice:    notice,
+		batchSize: DefaultExpiryBatchSize,
+	}
+}
+
+// Run notifies and expires memberships every interval until ctx is cancelled.
+func (e *MembershipExpirer) Run(ctx context.Context, interval time.Duration) {
+	RunPeriodically(ctx, "membership_expirer", interval, func(ctx context.Context) error {
+		_, err := e.ExpireDue(ctx, time.Now())
+		return err
+	})
+}
+
+// ExpireDue sends expiring-soon notifications and removes every membership
+// whose expires_at is at or before now.
+func (e *MembershipExpirer) ExpireDue(ctx context.Context, now time.Time) (ExpiryResult, error) {
+	var result ExpiryResult
+
+	notified, err := e.notifyExpiringSoon(ctx, now)
+	result.Notified = notified
+	if err != nil {
+		return result, err
+	}
+
+	nowTS := pgtype.Timestamptz{Time: now, Valid: true}
+	var afterID int64
+	for {
+		candidates, err := e.queries.ListExpiredMemberships(ctx, db.ListExpiredMembershipsParams{
+			Now:       nowTS,
+			AfterID:   afterID,
+			BatchSize: e.batchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("ListExpiredMemberships: %w", err)
+		}
+
+		for _, m := range candidates {
+			afterID = m.ID
+			expired, err := e.expireMembership(ctx, m.ID, nowTS)
+			switch {
+			case errors.Is(err, errExpiryBlocked):
+				result.Blocked++
+				if err := e.reportBlocked(ctx, m); err != nil {
+					return result, fmt.Errorf("report blocked membership %d: %w", m.ID, err)
+				}
+			case err != nil:
+				return result, fmt.Errorf("expire membership %d: %w", m.ID, err)
+			case expired:
+				result.Expired++
+				slog.InfoContext(ctx, "membership expired",
+					"membership_id", m.ID,
+					"group_id", m.GroupID,
+					"user_id", m.UserID,
+					"expires_at", m.ExpiresAt.Time,
+				)
+			}
+		}
+
+		if len(candidates) < int(e.batchSize) {
+			return result, nil
+		}
+	}
+}
+
+// notifyExpiringSoon notifies members whose membership expires within the
+// notice period. Each membership is notified once until it is extended.
+func (e *MembershipExpirer) notifyExpiringSoon(ctx context.Context, now time.Time) (int, error) {
+	if e.notice <= 0 {
+		return 0, nil
+	}
+
+	notified := 0
+	for {
+		candidates, err := e.queries.ListMembershipsExpiringSoon(ctx, db.ListMembershipsExpiringSoonParams{
+			Now:          pgtype.Timestamptz{Time: now, Valid: true},
+			NoticeCutoff: pgtype.Timestamptz{Time: now.Add(e.notice), Valid: true},
+			BatchSize:    e.batchSize,
+		})
+		if err != nil {
+			return notified, fmt.Errorf("ListMembershipsExpiringSoon: %w", err)
+		}
+
+		for _, m := range candidates {
+			sent := false
+			err := pgx.BeginTxFunc(ctx, e.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+				txQueries := e.queries.WithTx(tx)
+
+				rows, err := txQueries.MarkMembershipExpiryNotified(ctx, m.ID)
+				if err != nil {
+					return fmt.Errorf("MarkMembershipExpiryNotified: %w", err)
+				}
+				if rows == 0 {
+					return nil
+				}
+				sent = true
+				return createNotification(ctx, txQueries, m.UserID, NotificationMembershipExpiring, m,
+					map[string]any{"expires_at": m.ExpiresAt.Time})
+			})
+			if err != nil {
+				return notified, fmt.Errorf("notify membership %d: %w", m.ID, err)
+			}
+			if sent {
+				notified++
+			}
+		}
+
+		// Notified rows drop out of the query, so a short batch means done
+		if len(candidates) < int(e.batchSize) {
+			return notified, nil
+		}
+	}
+}
+
+// expireMembership deletes a single expired membership and notifies its user.
+// It returns false without error if the membership was extended or removed
+// since it was listed, and errExpiryBlocked if it holds the last admin seat.
+func (e *MembershipExpirer) expireMembership(ctx context.Context, membershipID int64, now pgtype.Timestamptz) (bool, error) {
+	expired := false
+	err := pgx.BeginTxFunc(ctx, e.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		txQueries := e.queries.WithTx(tx)
+
+		m, err := txQueries.LockExpiredMembership(ctx, db.LockExpiredMembershipParams{
+			ID:  membershipID,
+			Now: now,
+		})
+		if err != nil {
+			if db.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("LockExpiredMembership: %w", err)
+		}
+
+		if err := txQueries.DeleteMembership(ctx, membershipID); err != nil {
+			if db.IsLastAdminViolation(err) {
+				return errExpiryBlocked
+			}
+			return fmt.Errorf("DeleteMembership: %w", err)
+		}
+
+		if err := createNotification(ctx, txQueries, m.UserID, NotificationMembershipExpired, m,
+			map[string]any{"expires_at": m.ExpiresAt.Time}); err != nil {
+			return err
+		}
+
+		expired = true
+		return nil
+	})
+	return expired, err
+}
+
+// reportBlocked logs a blocked expiry and, the first time it is blocked,
+// notifies every admin of the group so someone can extend it or add an admin.
+func (e *MembershipExpirer) reportBlocked(ctx context.Context, m *db.Membership) error {
+	slog.WarnContext(ctx, "membership expiry blocked by last-admin protection",
+		"membership_id", m.ID,
+		"group_id", m.GroupID,
+		"user_id", m.UserID,
+		"expires_at", m.ExpiresAt.Time,
+	)
+
+	return pgx.BeginTxFunc(ctx, e.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		txQueries := e.queries.WithTx(tx)
+
+		rows, err := txQueries.MarkMembershipExpiryBlocked(ctx, m.ID)
+		if err != nil {
+			return fmt.Errorf("MarkMembershipExpiryBlocked: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+
+		adminIDs, err := txQueries.ListAdminUserIDsByGroup(ctx, m.GroupID)
+		if err != nil {
+			return fmt.Errorf("ListAdminUserIDsByGroup: %w", err)
+		}
+		for _, adminID := range adminIDs {
+			if err := createNotification(ctx, txQueries, adminID, NotificationMembershipExpiryBlocked, m,
+				map[string]any{"expires_at": m.ExpiresAt.Time, "user_id": m.UserID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createNotification writes a notification about a membership.
+func createNotification(ctx context.Context, queries *db.Queries, userID int64, kind string, m *db.Membership, data map[string]any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal notification data: %w", err)
+	}
+
+	if _, err := queries.CreateNotification(ctx, db.CreateNotificationParams{
+		UserID:       userID,
+		Kind:         kind,
+		GroupID:      pgtype.Int8{Int64: m.GroupID, Valid: true},
+		MembershipID: pgtype.Int8{Int64: m.ID, Valid: true},
+		Data:         payload,
+	}); err != nil {
+		return fmt.Errorf("CreateNotification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zacaytion/llmio/internal/db"
+)
+
+// insertUser creates a user and returns its ID.
+func insertUser(t *testing.T, pool *pgxpool.Pool, username string) int64 {
+	t.Helper()
+
+	var userID int64
+	err := pool.QueryRow(context.Background(),
+		`INSERT INTO users (email, name, username, password_hash, key) VALUES ($1 || '@example.com', $1, $1, 'hash', $1) RETURNING id`,
+		username,
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return userID
+}
+
+// insertMembership creates an accepted membership with an optional expiry.
+func insertMembership(t *testing.T, pool *pgxpool.Pool, groupID, userID int64, role string, expiresAt *time.Time) int64 {
+	t.Helper()
+
+	var membershipID int64
+	err := pool.QueryRow(context.Background(),
+		`INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at, expires_at)
+		 VALUES ($1, $2, $3, $2, NOW(), $4) RETURNING id`,
+		groupID, userID, role, expiresAt,
+	).Scan(&membershipID)
+	if err != nil {
+		t.Fatalf("insert membership: %v", err)
+	}
+	return membershipID
+}
+
+// countNotifications counts notifications of a kind for a user.
+func countNotifications(t *testing.T, pool *pgxpool.Pool, userID int64, kind string) int {
+	t.Helper()
+
+	var count int
+	err := pool.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND kind = $2`, userID, kind,
+	).Scan(&count)
+	if err != nil {
+		t.Fatalf("count notifications: %v", err)
+	}
+	return count
+}
+
+// TestMembershipExpirer_ExpireDue tests notification ahead of expiry, removal
+// after expiry, and reporting of expiries blocked by last-admin protection.
+func TestMembershipExpirer_ExpireDue(t *testing.T) {
+	pool, queries := setupPurgerTest(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	soon := now.Add(24 * time.Hour)
+	later := now.Add(30 * 24 * time.Hour)
+
+	adminID := insertUser(t, pool, "admin")
+	contractorID := insertUser(t, pool, "contractor")
+	seatID := insertUser(t, pool, "seat")
+	soloID := insertUser(t, pool, "solo")
+
+	var groupID, soloGroupID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO groups (name, handle, created_by_id) VALUES ('Group', 'group', $1) RETURNING id`, adminID,
+	).Scan(&groupID); err != nil {
+		t.Fatalf("insert group: %v", err)
+	}
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO groups (name, handle, created_by_id) VALUES ('Solo', 'solo', $1) RETURNING id`, soloID,
+	).Scan(&soloGroupID); err != nil {
+		t.Fatalf("insert group: %v", err)
+	}
+
+	insertMembership(t, pool, groupID, adminID, "admin", nil)
+	expiredID := insertMembership(t, pool, groupID, contractorID, "member", &past)
+	soonID := insertMembership(t, pool, groupID, seatID, "member", &soon)
+	insertMembership(t, pool, groupID, soloID, "member", &later)
+	blockedID := insertMembership(t, pool, soloGroupID, soloID, "admin", &past)
+
+	expirer := NewMembershipExpirer(pool, queries, 72*time.Hour)
+	result, err := expirer.ExpireDue(ctx, now)
+	if err != nil {
+		t.Fatalf("ExpireDue: %v", err)
+	}
+	if result != (ExpiryResult{Notified: 1, Expired: 1, Blocked: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, err := queries.GetMembershipByID(ctx, expiredID); !db.IsNotFound(err) {
+		t.Errorf("expired membership should be deleted, got err=%v", err)
+	}
+	if countNotifications(t, pool, contractorID, NotificationMembershipExpired) != 1 {
+		t.Error("expired member should be notified")
+	}
+
+	soonMembership, err := queries.GetMembershipByID(ctx, soonID)
+	if err != nil {
+		t.Fatalf("membership expiring soon should remain: %v", err)
+	}
+	if !soonMembership.ExpiryNotifiedAt.Valid {
+		t.Error("membership expiring soon should be marked notified")
+	}
+
+	blocked, err := queries.GetMembershipByID(ctx, blockedID)
+	if err != nil {
+		t.Fatalf("last admin membership should not be deleted: %v", err)
+	}
+	if !blocked.ExpiryBlockedAt.Valid {
+		t.Error("blocked expiry should be recorded")
+	}
+	if countNotifications(t, pool, soloID, NotificationMembershipExpiryBlocked) != 1 {
+		t.Error("admins should be notified of the blocked expiry")
+	}
+
+	// A second run retries the blocked expiry but does not notify again
+	result, err = expirer.ExpireDue(ctx, now)
+	if err != nil {
+		t.Fatalf("ExpireDue (second run): %v", err)
+	}
+	if result != (ExpiryResult{Blocked: 1}) {
+		t.Errorf("unexpected second result: %+v", result)
+	}
+	if countNotifications(t, pool, seatID, NotificationMembershipExpiring) != 1 {
+		t.Error("expiring-soon notification should be sent once")
+	}
+	if countNotifications(t, pool, soloID, NotificationMembershipExpiryBlocked) != 1 {
+		t.Error("blocked expiry should be reported once")
+	}
+}
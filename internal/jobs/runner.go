@@ -0,0 +1,33 @@
+// Package jobs provides background maintenance jobs run by the server.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunPeriodically calls fn every interval until ctx is cancelled.
+// Errors are logged and do not stop the loop; the next tick retries.
+// The outcome of each run is reported by Statuses.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	setRunning(name, true)
+	defer setRunning(name, false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.DebugContext(ctx, "background job stopped", "job", name)
+			return
+		case <-ticker.C:
+			err := fn(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "background job failed", "job", name, "error", err)
+			}
+			recordRun(name, time.Now(), err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunPeriodically_Statuses verifies each run's outcome is reported and
+// the job is marked stopped when its context is cancelled.
+func TestRunPeriodically_Statuses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := errors.New("boom")
+
+	runs := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		calls := 0
+		RunPeriodically(ctx, "test_job", time.Millisecond, func(context.Context) error {
+			calls++
+			if calls == 2 {
+				close(runs)
+				<-ctx.Done()
+			}
+			return failure
+		})
+	}()
+
+	<-runs
+	status := findStatus(t, "test_job")
+	if !status.Running || status.LastRun.IsZero() || !errors.Is(status.LastError, failure) {
+		t.Errorf("expected a running job with a failed last run, got %+v", status)
+	}
+
+	cancel()
+	<-done
+	if status := findStatus(t, "test_job"); status.Running {
+		t.Errorf("expected the job to be stopped, got %+v", status)
+	}
+}
+
+func findStatus(t *testing.T, name string) Status {
+	t.Helper()
+	for _, s := range Statuses() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no status for %q", name)
+	return Status{}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the state of a job started with RunPeriodically.
+type Status struct {
+	Name      string
+	Running   bool      // false once the job's context is cancelled
+	LastRun   time.Time // zero until the first run finishes
+	LastError error     // error of the last run, nil if it succeeded
+}
+
+// statuses holds the status of every job started in this process.
+var statuses = struct {
+	sync.Mutex
+	byName map[string]*Status
+}{byName: make(map[string]*Status)}
+
+// Statuses returns the status of every job started in this process, sorted
+// by name.
+func Statuses() []Status {
+	statuses.Lock()
+	defer statuses.Unlock()
+
+	list := make([]Status, 0, len(statuses.byName))
+	for _, s := range statuses.byName {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// setRunning marks a job as started or stopped.
+func setRunning(name string, running bool) {
+	statuses.Lock()
+	defer statuses.Unlock()
+
+	s, ok := statuses.byName[name]
+	if !ok {
+		s = &Status{Name: name}
+		statuses.byName[name] = s
+	}
+	s.Running = running
+}
+
+// recordRun stores the outcome of a job run.
+func recordRun(name string, at time.Time, err error) {
+	statuses.Lock()
+	defer statuses.Unlock()
+
+	if s, ok := statuses.byName[name]; ok {
+		s.LastRun = at
+		s.LastError = err
+	}
+}
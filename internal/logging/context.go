@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestAttrsKey is the context key of the request attributes.
+type requestAttrsKey struct{}
+
+// requestAttrs collects attributes added while a request is handled.
+type requestAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithRequestAttrs returns a context that collects attributes from AddAttrs.
+// Records logged with it, or with any context derived from it, carry those
+// attributes, including ones added after the context was derived. HTTP
+// middleware calls it once per request so that inner middleware can
+// annotate the request's log lines, the access log among them.
+func WithRequestAttrs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestAttrsKey{}, &requestAttrs{})
+}
+
+// AddAttrs adds attributes to the records logged with ctx for the rest of
+// the request. It does nothing if ctx does not derive from WithRequestAttrs.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	ra, ok := ctx.Value(requestAttrsKey{}).(*requestAttrs)
+	if !ok {
+		return
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	ra.attrs = append(ra.attrs, attrs...)
+}
+
+// contextHandler adds context values to records: the attributes from
+// AddAttrs, and trace_id and span_id when the context carries a valid span so
+// log lines can be joined to traces.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the context attributes before passing the record on.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ra, ok := ctx.Value(requestAttrsKey{}).(*requestAttrs); ok {
+		ra.mu.Lock()
+		r.AddAttrs(ra.attrs...)
+		ra.mu.Unlock()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context handler around the returned handler.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context handler around the returned handler.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
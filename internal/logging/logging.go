@@ -181,10 +181,17 @@ func getWriterWithCleanupStrict(output string, overrideWriter io.Writer) (io.Wri
 	}
 }
 
-// createHandler creates the appropriate slog.Handler based on format.
+// createHandler creates the appropriate slog.Handler based on format,
+// wrapped to add request attributes and trace and span IDs from the
+// record's context.
 // Empty string silently defaults to JSON. Non-empty invalid formats
 // default to JSON with a warning logged.
 func createHandler(format string, writer io.Writer, level slog.Level) slog.Handler {
+	return contextHandler{createFormatHandler(format, writer, level)}
+}
+
+// createFormatHandler creates the JSON or text handler for format.
+func createFormatHandler(format string, writer io.Writer, level slog.Level) slog.Handler {
 	opts := &slog.HandlerOptions{
 		Level: level,
 	}
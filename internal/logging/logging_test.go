@@ -2,12 +2,15 @@ package logging
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"os"
 	"strings"
 	"testing"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/zacaytion/llmio/internal/config"
 )
 
@@ -438,3 +441,61 @@ func TestSetupWithCleanup_FileHandleClosedAfterCleanup(t *testing.T) {
 		t.Error("expected log content in file")
 	}
 }
+
+// TestTraceHandler verifies records logged with a span context carry its IDs.
+func TestTraceHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(createHandler("json", &buf, slog.LevelInfo)).With("component", "test")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.InfoContext(ctx, "traced")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %s", buf.String())
+	}
+	if entry["trace_id"] != traceID.String() || entry["span_id"] != spanID.String() {
+		t.Errorf("expected trace and span IDs, got %v", entry)
+	}
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "untraced")
+	if bytes.Contains(buf.Bytes(), []byte("trace_id")) {
+		t.Errorf("expected no trace_id without a span, got %s", buf.String())
+	}
+}
+
+// TestAddAttrs verifies attributes added after a context is derived still
+// reach records logged with it.
+func TestAddAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(createHandler("json", &buf, slog.LevelInfo))
+
+	ctx := WithRequestAttrs(context.Background())
+	derived, cancel := context.WithCancel(ctx)
+	defer cancel()
+	AddAttrs(ctx, slog.Int64("user_id", 42))
+
+	logger.InfoContext(derived, "annotated")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %s", buf.String())
+	}
+	if entry["user_id"] != float64(42) {
+		t.Errorf("expected user_id 42, got %v", entry)
+	}
+
+	// Without WithRequestAttrs, AddAttrs is a no-op
+	buf.Reset()
+	AddAttrs(context.Background(), slog.Int64("user_id", 7))
+	logger.InfoContext(context.Background(), "plain")
+	if strings.Contains(buf.String(), "user_id") {
+		t.Errorf("expected no user_id, got %s", buf.String())
+	}
+}
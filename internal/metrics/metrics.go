@@ -0,0 +1,124 @@
+// Package metrics exposes Prometheus metrics for the server.
+//
+// All collectors live in Registry, which Handler serves on the admin port.
+// HTTP requests are counted by HumaMiddleware per operation ID and status;
+// the database pool and session store are sampled at scrape time once
+// registered with RegisterPool and RegisterSessionStore; and handlers count
+// domain events with the Record functions.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name.
+const namespace = "llmio"
+
+// LoginSuccess is the login result recorded for a successful login. Failed
+// logins are recorded with their api.AuthFailureReason.
+const LoginSuccess = "success"
+
+// Invitation kinds recorded by RecordInvitationSent.
+const (
+	InvitationMember = "member"
+	InvitationGuest  = "guest"
+	InvitationResend = "resend"
+)
+
+// Registry holds all server metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by Huma operation ID, method, and status code.",
+	}, []string{"operation", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by Huma operation ID, method, and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "method", "status"})
+
+	registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "User registrations.",
+	})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result: success or the authentication failure reason.",
+	}, []string{"result"})
+
+	invitationsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invitations_sent_total",
+		Help:      "Invitations sent by kind: member, guest, or resend.",
+	}, []string{"kind"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		registrations,
+		logins,
+		invitationsSent,
+	)
+}
+
+// Handler serves the metrics in Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// HumaMiddleware counts requests and observes their latency by operation ID
+// and response status. Register it with huma.API.UseMiddleware; requests
+// that match no operation are not counted.
+func HumaMiddleware(ctx huma.Context, next func(huma.Context)) {
+	op := ctx.Operation()
+	if op == nil {
+		next(ctx)
+		return
+	}
+
+	start := time.Now()
+	next(ctx)
+
+	labels := prometheus.Labels{
+		"operation": op.OperationID,
+		"method":    op.Method,
+		"status":    strconv.Itoa(ctx.Status()),
+	}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(time.Since(start).Seconds())
+}
+
+// RecordRegistration counts a successful user registration.
+func RecordRegistration() {
+	registrations.Inc()
+}
+
+// RecordLogin counts a login attempt with its result: LoginSuccess or the
+// failure reason.
+func RecordLogin(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// RecordInvitationSent counts an invitation of the given kind.
+func RecordInvitationSent(kind string) {
+	invitationsSent.WithLabelValues(kind).Inc()
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/zacaytion/llmio/internal/auth"
+)
+
+// TestHumaMiddleware verifies requests are counted by operation and status.
+func TestHumaMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	api.UseMiddleware(HumaMiddleware)
+	huma.Register(api, huma.Operation{
+		OperationID: "getWidget",
+		Method:      http.MethodGet,
+		Path:        "/widgets/{id}",
+	}, func(ctx context.Context, input *struct {
+		ID int64 `path:"id"`
+	}) (*struct{}, error) {
+		if input.ID == 0 {
+			return nil, huma.Error404NotFound("Widget not found")
+		}
+		return &struct{}{}, nil
+	})
+
+	for _, path := range []string{"/widgets/1", "/widgets/2", "/widgets/0"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("getWidget", "GET", "204")); got != 2 {
+		t.Errorf("expected 2 successful requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("getWidget", "GET", "404")); got != 1 {
+		t.Errorf("expected 1 not found request, got %v", got)
+	}
+	if n := testutil.CollectAndCount(httpDuration, "llmio_http_request_duration_seconds"); n != 2 {
+		t.Errorf("expected latency series for both statuses, got %d", n)
+	}
+}
+
+func TestRecordDomainEvents(t *testing.T) {
+	before := testutil.ToFloat64(registrations)
+	RecordRegistration()
+	if got := testutil.ToFloat64(registrations); got != before+1 {
+		t.Errorf("expected registrations to increase by 1, got %v -> %v", before, got)
+	}
+
+	RecordLogin(LoginSuccess)
+	RecordLogin("invalid_password")
+	RecordLogin("invalid_password")
+	if got := testutil.ToFloat64(logins.WithLabelValues("invalid_password")); got != 2 {
+		t.Errorf("expected 2 invalid_password logins, got %v", got)
+	}
+
+	RecordInvitationSent(InvitationGuest)
+	if got := testutil.ToFloat64(invitationsSent.WithLabelValues(InvitationGuest)); got != 1 {
+		t.Errorf("expected 1 guest invitation, got %v", got)
+	}
+}
+
+// TestHandler verifies the exposition includes registered gauges.
+func TestHandler(t *testing.T) {
+	store := auth.NewSessionStore()
+	if _, err := store.Create(1, "", ""); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := RegisterSessionStore(store); err != nil {
+		t.Fatalf("RegisterSessionStore: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	for _, want := range []string{"llmio_sessions 1", "go_goroutines", "llmio_registrations_total"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
+}
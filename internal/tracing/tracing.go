@@ -0,0 +1,111 @@
+// Package tracing configures OpenTelemetry tracing for the server.
+//
+// Setup installs a global tracer provider for the configured exporter. HTTP
+// requests get a server span from Middleware, which HumaMiddleware renames
+// after the matched route; database queries get child spans from the pgx
+// tracer installed by db.NewPoolFromConfig; and log records written with a
+// context carry the trace and span IDs (see internal/logging).
+//
+// With the "none" exporter the global provider stays a no-op, so the
+// instrumentation costs almost nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/danielgtaylor/huma/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zacaytion/llmio/internal/config"
+)
+
+// ShutdownFunc flushes buffered spans and stops the tracer provider.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and propagator for cfg and
+// returns a function to call on shutdown. With the "none" exporter nothing
+// is installed and the returned function is a no-op.
+func Setup(ctx context.Context, cfg config.TracingConfig) (ShutdownFunc, error) {
+	noop := func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.TraceExporter(cfg.Exporter) {
+	case config.TraceExporterNone:
+		return noop, nil
+	case config.TraceExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case config.TraceExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a server span for every request, continuing any trace
+// propagated by the caller. Spans are named by method until the ServeMux
+// matches a pattern, after which otelhttp renames them to it.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if r.Pattern != "" {
+				return r.Pattern
+			}
+			return r.Method
+		}),
+	)
+}
+
+// HumaMiddleware names the request span after the matched operation, e.g.
+// "GET /api/v1/groups/{id}", and records the route. Register it with
+// huma.API.UseMiddleware.
+func HumaMiddleware(ctx huma.Context, next func(huma.Context)) {
+	if op := ctx.Operation(); op != nil {
+		span := trace.SpanFromContext(ctx.Context())
+		span.SetName(op.Method + " " + op.Path)
+		span.SetAttributes(
+			attribute.String("http.route", op.Path),
+			attribute.String("huma.operation_id", op.OperationID),
+		)
+	}
+	next(ctx)
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/zacaytion/llmio/internal/config"
+)
+
+func TestSetup(t *testing.T) {
+	original := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+	ctx := context.Background()
+
+	shutdown, err := Setup(ctx, config.TracingConfig{Exporter: "none", ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup(none): %v", err)
+	}
+	if otel.GetTracerProvider() != original {
+		t.Error("the none exporter should leave the global provider alone")
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+
+	shutdown, err = Setup(ctx, config.TracingConfig{Exporter: "stdout", ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup(stdout): %v", err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Errorf("expected an SDK tracer provider, got %T", otel.GetTracerProvider())
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+
+	if _, err := Setup(ctx, config.TracingConfig{Exporter: "jaeger"}); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
+
+// TestMiddleware verifies request spans are named after the Huma route.
+func TestMiddleware(t *testing.T) {
+	original := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Test API", "1.0.0"))
+	api.UseMiddleware(HumaMiddleware)
+	huma.Register(api, huma.Operation{
+		OperationID: "getThing",
+		Method:      http.MethodGet,
+		Path:        "/things/{id}",
+	}, func(ctx context.Context, input *struct {
+		ID int64 `path:"id"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+
+	w := httptest.NewRecorder()
+	Middleware(mux).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/things/42", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "GET /things/{id}" {
+		t.Errorf("expected span named after the route, got %q", spans[0].Name())
+	}
+}
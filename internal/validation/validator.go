@@ -4,6 +4,8 @@
 package validation
 
 import (
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/go-playground/validator/v10"
@@ -39,6 +41,8 @@ func registerCustomValidators(v *validator.Validate) {
 	mustRegister(v, "sslmode", validateSSLMode)
 	mustRegister(v, "loglevel", validateLogLevel)
 	mustRegister(v, "logformat", validateLogFormat)
+	mustRegister(v, "traceexporter", validateTraceExporter)
+	mustRegister(v, "corsorigin", validateCORSOrigin)
 }
 
 // mustRegister registers a validator and panics on failure.
@@ -92,3 +96,33 @@ func validateLogFormat(fl validator.FieldLevel) bool {
 		return false
 	}
 }
+
+// validateTraceExporter validates trace exporters.
+// Valid values: none, stdout, otlp.
+// Note: Validation is duplicated here rather than calling config.TraceExporter.Valid()
+// to avoid an import cycle (config imports validation).
+func validateTraceExporter(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	switch value {
+	case "none", "stdout", "otlp":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateCORSOrigin validates an allowed CORS origin: "*", or a scheme and
+// host with an optional port and nothing else, as browsers send in the
+// Origin header (e.g. "https://app.example.com:8443").
+func validateCORSOrigin(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if value == "*" {
+		return true
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.Contains(u.Host, "*") &&
+		u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == "" && !u.ForceQuery
+}
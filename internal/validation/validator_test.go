@@ -129,8 +129,69 @@ func TestValidateLogFormat(t *testing.T) {
 	}
 }
 
+func TestValidateTraceExporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"none is valid", "none", false},
+		{"stdout is valid", "stdout", false},
+		{"otlp is valid", "otlp", false},
+		{"empty is invalid", "", true},
+		{"unsupported exporter", "jaeger", true},
+		{"uppercase is invalid", "OTLP", true},
+	}
+
+	type traceExporterTest struct {
+		Exporter string `validate:"required,traceexporter"`
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := traceExporterTest{Exporter: tt.value}
+			err := Validate(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("traceexporter validation for %q: got error=%v, wantErr=%v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCORSOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"wildcard is valid", "*", false},
+		{"https origin is valid", "https://app.example.com", false},
+		{"origin with port is valid", "http://localhost:5173", false},
+		{"empty is invalid", "", true},
+		{"trailing slash is invalid", "https://app.example.com/", true},
+		{"path is invalid", "https://example.com/app", true},
+		{"missing scheme is invalid", "app.example.com", true},
+		{"non-http scheme is invalid", "ftp://example.com", true},
+		{"partial wildcard is invalid", "https://*.example.com", true},
+	}
+
+	type corsOriginTest struct {
+		Origins []string `validate:"dive,corsorigin"`
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := corsOriginTest{Origins: []string{tt.value}}
+			err := Validate(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("corsorigin validation for %q: got error=%v, wantErr=%v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // T130: Test that custom validators are registered successfully.
-// This test verifies that all custom validators (sslmode, loglevel, logformat)
+// This test verifies that all custom validators (sslmode, loglevel, logformat, traceexporter)
 // are properly registered and can be used in validation.
 func TestCustomValidatorsRegistered(t *testing.T) {
 	// Get the validator instance - this triggers registration
@@ -141,15 +202,17 @@ func TestCustomValidatorsRegistered(t *testing.T) {
 
 	// Test that all custom validators work by validating structs that use them
 	type allCustomValidators struct {
-		SSLMode   string `validate:"required,sslmode"`
-		LogLevel  string `validate:"required,loglevel"`
-		LogFormat string `validate:"required,logformat"`
+		SSLMode       string `validate:"required,sslmode"`
+		LogLevel      string `validate:"required,loglevel"`
+		LogFormat     string `validate:"required,logformat"`
+		TraceExporter string `validate:"required,traceexporter"`
 	}
 
 	valid := allCustomValidators{
-		SSLMode:   "disable",
-		LogLevel:  "info",
-		LogFormat: "json",
+		SSLMode:       "disable",
+		LogLevel:      "info",
+		LogFormat:     "json",
+		TraceExporter: "otlp",
 	}
 
 	if err := Validate(valid); err != nil {
@@ -169,6 +232,8 @@ func TestCustomValidatorsRegistered(t *testing.T) {
 		{"loglevel invalid", "loglevel", "invalid", false},
 		{"logformat valid", "logformat", "json", true},
 		{"logformat invalid", "logformat", "invalid", false},
+		{"traceexporter valid", "traceexporter", "stdout", true},
+		{"traceexporter invalid", "traceexporter", "invalid", false},
 	}
 
 	for _, tt := range tests {
@@ -191,6 +256,11 @@ func TestCustomValidatorsRegistered(t *testing.T) {
 					V string `validate:"logformat"`
 				}
 				err = Validate(s{V: tt.value})
+			case "traceexporter":
+				type s struct {
+					V string `validate:"traceexporter"`
+				}
+				err = Validate(s{V: tt.value})
 			}
 
 			hasErr := err != nil
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Guest role: users invited to a specific discussion or poll without
+-- becoming full members of the group.
+-- Features:
+--   - 'guest' accepted by memberships_role_valid alongside admin/member
+--   - guest_grants lists the resources a guest membership may access
+--   - Guests are excluded from member counts and group listings (see queries)
+
+ALTER TABLE memberships DROP CONSTRAINT memberships_role_valid;
+ALTER TABLE memberships ADD CONSTRAINT memberships_role_valid
+    CHECK (role IN ('admin', 'member', 'guest')) NOT VALID;
+ALTER TABLE memberships VALIDATE CONSTRAINT memberships_role_valid;
+
+-- Guest grants: one row per discussion/poll a guest membership can access.
+-- resource_id is not a foreign key yet because discussions and polls live in
+-- a later feature; the FK will be added once those tables exist.
+CREATE TABLE guest_grants (
+    id              BIGSERIAL PRIMARY KEY,
+    membership_id   BIGINT NOT NULL REFERENCES memberships(id) ON DELETE CASCADE,
+    resource_type   TEXT NOT NULL,
+    resource_id     BIGINT NOT NULL,
+    granted_by_id   BIGINT NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT guest_grants_resource_type_valid
+        CHECK (resource_type IN ('discussion', 'poll')),
+    CONSTRAINT guest_grants_unique_resource
+        UNIQUE (membership_id, resource_type, resource_id)
+);
+
+-- Lookup of all guests for a given discussion/poll
+CREATE INDEX guest_grants_resource_idx ON guest_grants(resource_type, resource_id);
+CREATE INDEX guest_grants_granted_by_id_idx ON guest_grants(granted_by_id);
+
+-- Audit trigger (same generic function as groups and memberships)
+CREATE TRIGGER guest_grants_audit
+    AFTER INSERT OR UPDATE OR DELETE ON guest_grants
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.insert_update_delete_trigger();
+
+COMMENT ON TABLE guest_grants IS 'Discussions and polls a guest membership can access';
+COMMENT ON COLUMN guest_grants.resource_type IS 'Either discussion or poll';
+COMMENT ON COLUMN guest_grants.resource_id IS 'ID of the discussion or poll (FK added with feature 005)';
+COMMENT ON COLUMN memberships.role IS 'One of admin, member, or guest';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS guest_grants_audit ON guest_grants;
+DROP TABLE IF EXISTS guest_grants;
+DELETE FROM memberships WHERE role = 'guest';
+ALTER TABLE memberships DROP CONSTRAINT memberships_role_valid;
+ALTER TABLE memberships ADD CONSTRAINT memberships_role_valid
+    CHECK (role IN ('admin', 'member'));
+COMMENT ON COLUMN memberships.role IS 'Either admin or member';
+
+-- +goose StatementEnd
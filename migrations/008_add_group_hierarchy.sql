@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Recursive subgroup hierarchy
+-- Features:
+--   - parent_admins_can_manage: admins of the parent group act as admins of
+--     this group (and, transitively, of its descendants while every link in
+--     the chain keeps the flag enabled)
+--   - Cycle protection for parent_id changes; groups_parent_not_self only
+--     blocks direct self-reference
+
+ALTER TABLE groups ADD COLUMN parent_admins_can_manage BOOLEAN NOT NULL DEFAULT TRUE;
+
+COMMENT ON COLUMN groups.parent_admins_can_manage IS 'Parent group admins have admin rights over this group';
+
+-- Hierarchy cycle protection
+-- Walks up from the new parent; if the group itself is an ancestor of its new
+-- parent, the move would create a cycle. The advisory lock serializes
+-- concurrent moves so two moves cannot each pass the check and form a cycle.
+CREATE OR REPLACE FUNCTION prevent_group_hierarchy_cycle()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_advisory_xact_lock(hashtext('groups_hierarchy'));
+
+    IF EXISTS (
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM groups WHERE id = NEW.parent_id
+            UNION
+            SELECT g.id, g.parent_id FROM groups g
+            JOIN ancestors a ON g.id = a.parent_id
+        )
+        SELECT 1 FROM ancestors WHERE id = NEW.id
+    ) THEN
+        RAISE EXCEPTION 'Group hierarchy cycle: group % cannot be placed under group %', NEW.id, NEW.parent_id
+            USING ERRCODE = 'P0001';  -- raise_exception
+    END IF;
+
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER groups_prevent_hierarchy_cycle
+    BEFORE UPDATE OF parent_id ON groups
+    FOR EACH ROW
+    WHEN (NEW.parent_id IS DISTINCT FROM OLD.parent_id)
+    EXECUTE FUNCTION prevent_group_hierarchy_cycle();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS groups_prevent_hierarchy_cycle ON groups;
+DROP FUNCTION IF EXISTS prevent_group_hierarchy_cycle();
+ALTER TABLE groups DROP COLUMN IF EXISTS parent_admins_can_manage;
+
+-- +goose StatementEnd
@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Cascade archive and scheduled hard deletion of archived groups
+-- Features:
+--   - Last-admin protection no longer blocks memberships removed by a
+--     cascading group delete (the group itself is already gone)
+--   - The purge scan uses groups_archived_at_idx from 003
+
+CREATE OR REPLACE FUNCTION prevent_last_admin_removal()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    admin_count INTEGER;
+BEGIN
+    -- Memberships deleted by ON DELETE CASCADE from groups: the group row is
+    -- already gone, so there is no administrator left to protect
+    IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM groups WHERE id = OLD.group_id) THEN
+        RETURN OLD;
+    END IF;
+
+    -- Only check for admin role changes or deletions of admins
+    IF (TG_OP = 'DELETE' AND OLD.role = 'admin' AND OLD.accepted_at IS NOT NULL) OR
+       (TG_OP = 'UPDATE' AND OLD.role = 'admin' AND NEW.role != 'admin' AND OLD.accepted_at IS NOT NULL) THEN
+
+        -- Count remaining active admins (excluding the record being modified)
+        SELECT COUNT(*) INTO admin_count
+        FROM memberships
+        WHERE group_id = OLD.group_id
+          AND role = 'admin'
+          AND accepted_at IS NOT NULL
+          AND id != OLD.id;
+
+        IF admin_count = 0 THEN
+            RAISE EXCEPTION 'Cannot remove or demote the last administrator of a group'
+                USING ERRCODE = 'P0001';  -- raise_exception
+        END IF;
+    END IF;
+
+    RETURN CASE WHEN TG_OP = 'DELETE' THEN OLD ELSE NEW END;
+END;
+$$;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION prevent_last_admin_removal()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    admin_count INTEGER;
+BEGIN
+    -- Only check for admin role changes or deletions of admins
+    IF (TG_OP = 'DELETE' AND OLD.role = 'admin' AND OLD.accepted_at IS NOT NULL) OR
+       (TG_OP = 'UPDATE' AND OLD.role = 'admin' AND NEW.role != 'admin' AND OLD.accepted_at IS NOT NULL) THEN
+
+        -- Count remaining active admins (excluding the record being modified)
+        SELECT COUNT(*) INTO admin_count
+        FROM memberships
+        WHERE group_id = OLD.group_id
+          AND role = 'admin'
+          AND accepted_at IS NOT NULL
+          AND id != OLD.id;
+
+        IF admin_count = 0 THEN
+            RAISE EXCEPTION 'Cannot remove or demote the last administrator of a group'
+                USING ERRCODE = 'P0001';  -- raise_exception
+        END IF;
+    END IF;
+
+    RETURN CASE WHEN TG_OP = 'DELETE' THEN OLD ELSE NEW END;
+END;
+$$;
+
+-- +goose StatementEnd
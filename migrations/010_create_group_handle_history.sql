@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Group handle history: previous handles of each group
+-- Features:
+--   - Lookups of a retired handle resolve to the group that retired it
+--   - reserved_until blocks other groups from claiming a retired handle
+--     during a cooling-off period; the retiring group may reclaim it
+--   - One row per handle: if a handle is retired again later (by any group),
+--     the row is taken over by the most recent retiree
+
+CREATE TABLE group_handle_history (
+    id              BIGSERIAL PRIMARY KEY,
+    group_id        BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    handle          CITEXT NOT NULL,
+    changed_by_id   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    retired_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reserved_until  TIMESTAMPTZ NOT NULL,
+
+    -- Constraints
+    CONSTRAINT group_handle_history_reservation_valid
+        CHECK (reserved_until >= retired_at)
+);
+
+-- One redirect per handle (CITEXT makes this case-insensitive)
+CREATE UNIQUE INDEX group_handle_history_handle_key ON group_handle_history(handle);
+CREATE INDEX group_handle_history_group_id_idx ON group_handle_history(group_id);
+CREATE INDEX group_handle_history_changed_by_id_idx ON group_handle_history(changed_by_id)
+    WHERE changed_by_id IS NOT NULL;
+
+-- Audit trigger (same generic function as groups and memberships)
+CREATE TRIGGER group_handle_history_audit
+    AFTER INSERT OR UPDATE OR DELETE ON group_handle_history
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.insert_update_delete_trigger();
+
+COMMENT ON TABLE group_handle_history IS 'Retired group handles kept for redirects and reservation';
+COMMENT ON COLUMN group_handle_history.handle IS 'Previous handle of the group, case-insensitive unique';
+COMMENT ON COLUMN group_handle_history.reserved_until IS 'Other groups cannot claim the handle before this time';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS group_handle_history_audit ON group_handle_history;
+DROP TABLE IF EXISTS group_handle_history;
+
+-- +goose StatementEnd
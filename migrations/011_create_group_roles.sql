@@ -0,0 +1,88 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Group roles: per-group custom roles made of named permissions
+-- Features:
+--   - Admins define roles such as "moderator" or "facilitator" per group
+--   - A custom role can be assigned to a member-role membership and grants
+--     its permissions on top of the built-in member abilities
+--   - memberships.role is untouched, so admin counting and the last-admin
+--     protection trigger keep working unchanged
+--   - Deleting a role unassigns it from memberships (members keep their role)
+
+CREATE TABLE group_roles (
+    id              BIGSERIAL PRIMARY KEY,
+    group_id        BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    permissions     TEXT[] NOT NULL DEFAULT '{}',
+    created_by_id   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+
+    -- Timestamps
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT group_roles_name_length
+        CHECK (char_length(name) BETWEEN 1 AND 50),
+    CONSTRAINT group_roles_name_not_builtin
+        CHECK (lower(name) NOT IN ('admin', 'member', 'guest')),
+    CONSTRAINT group_roles_permissions_valid
+        CHECK (permissions <@ ARRAY[
+            'update_group',
+            'invite_members',
+            'add_guests',
+            'create_subgroups',
+            'manage_comments',
+            'manage_polls'
+        ]::TEXT[]),
+    CONSTRAINT group_roles_unique_name
+        UNIQUE (group_id, name),
+    -- Target of the composite FK from memberships
+    CONSTRAINT group_roles_unique_group_id
+        UNIQUE (group_id, id)
+);
+
+CREATE INDEX group_roles_created_by_id_idx ON group_roles(created_by_id)
+    WHERE created_by_id IS NOT NULL;
+
+CREATE TRIGGER group_roles_updated_at
+    BEFORE UPDATE ON group_roles
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at();
+
+-- Audit trigger (same generic function as groups and memberships)
+CREATE TRIGGER group_roles_audit
+    AFTER INSERT OR UPDATE OR DELETE ON group_roles
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.insert_update_delete_trigger();
+
+-- Custom role assignment. The composite FK guarantees the role belongs to the
+-- membership's group; deleting the role only clears group_role_id.
+ALTER TABLE memberships ADD COLUMN group_role_id BIGINT;
+ALTER TABLE memberships ADD CONSTRAINT memberships_group_role_fk
+    FOREIGN KEY (group_id, group_role_id) REFERENCES group_roles(group_id, id)
+    ON DELETE SET NULL (group_role_id);
+ALTER TABLE memberships ADD CONSTRAINT memberships_group_role_member_only
+    CHECK (group_role_id IS NULL OR role = 'member');
+
+CREATE INDEX memberships_group_role_id_idx ON memberships(group_role_id)
+    WHERE group_role_id IS NOT NULL;
+
+COMMENT ON TABLE group_roles IS 'Custom per-group roles made of named permissions';
+COMMENT ON COLUMN group_roles.permissions IS 'Permission names granted on top of the member role';
+COMMENT ON COLUMN memberships.group_role_id IS 'Optional custom role; only valid for member-role memberships';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS memberships_group_role_id_idx;
+ALTER TABLE memberships DROP CONSTRAINT IF EXISTS memberships_group_role_member_only;
+ALTER TABLE memberships DROP CONSTRAINT IF EXISTS memberships_group_role_fk;
+ALTER TABLE memberships DROP COLUMN IF EXISTS group_role_id;
+DROP TRIGGER IF EXISTS group_roles_audit ON group_roles;
+DROP TRIGGER IF EXISTS group_roles_updated_at ON group_roles;
+DROP TABLE IF EXISTS group_roles;
+
+-- +goose StatementEnd
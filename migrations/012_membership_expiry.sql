@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Time-bound memberships and user notifications
+-- Features:
+--   - memberships.expires_at: optional end of a membership (contractors,
+--     rotating seats); the expiry job deletes memberships past it
+--   - expiry_notified_at: set once the "expiring soon" notice was sent
+--   - expiry_blocked_at: set when expiry was refused by the last-admin
+--     protection trigger; cleared when the membership is extended
+--   - notifications: per-user inbox written by background jobs
+
+ALTER TABLE memberships ADD COLUMN expires_at TIMESTAMPTZ;
+ALTER TABLE memberships ADD COLUMN expiry_notified_at TIMESTAMPTZ;
+ALTER TABLE memberships ADD COLUMN expiry_blocked_at TIMESTAMPTZ;
+
+-- Expiry job scans only time-bound memberships
+CREATE INDEX memberships_expires_at_idx ON memberships(expires_at)
+    WHERE expires_at IS NOT NULL;
+
+COMMENT ON COLUMN memberships.expires_at IS 'Membership is removed by the expiry job after this time; NULL = permanent';
+COMMENT ON COLUMN memberships.expiry_notified_at IS 'When the expiring-soon notification was sent';
+COMMENT ON COLUMN memberships.expiry_blocked_at IS 'When expiry was first refused by last-admin protection';
+
+CREATE TABLE notifications (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind            TEXT NOT NULL,
+    group_id        BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    -- Not a foreign key: expired memberships are deleted but their
+    -- notifications are kept
+    membership_id   BIGINT,
+    data            JSONB NOT NULL DEFAULT '{}',
+    read_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT notifications_kind_valid
+        CHECK (kind IN ('membership_expiring', 'membership_expired', 'membership_expiry_blocked'))
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications(user_id, created_at DESC);
+CREATE INDEX notifications_unread_idx ON notifications(user_id)
+    WHERE read_at IS NULL;
+CREATE INDEX notifications_group_id_idx ON notifications(group_id)
+    WHERE group_id IS NOT NULL;
+
+COMMENT ON TABLE notifications IS 'Per-user notifications emitted by background jobs';
+COMMENT ON COLUMN notifications.data IS 'Kind-specific details, e.g. expires_at';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS memberships_expires_at_idx;
+ALTER TABLE memberships DROP COLUMN IF EXISTS expiry_blocked_at;
+ALTER TABLE memberships DROP COLUMN IF EXISTS expiry_notified_at;
+ALTER TABLE memberships DROP COLUMN IF EXISTS expires_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Invitation events: one row per invitation outcome
+-- Features:
+--   - Every invite, accept, decline, revoke, and resend is recorded, so a
+--     group can report on outcomes such as its decline rate
+--   - Declined and revoked invitations delete the pending membership; the
+--     event row outlives it (membership_id is deliberately not a foreign key)
+--   - The latest event per (group, user) tells whether a user declined and
+--     should not be re-invited without an explicit override
+
+CREATE TABLE invitation_events (
+    id              BIGSERIAL PRIMARY KEY,
+    group_id        BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    membership_id   BIGINT NOT NULL,
+    actor_id        BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    kind            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT invitation_events_kind_valid
+        CHECK (kind IN ('invited', 'accepted', 'declined', 'revoked', 'resent'))
+);
+
+-- Latest event for a user in a group, and per-group reporting
+CREATE INDEX invitation_events_group_user_idx
+    ON invitation_events(group_id, user_id, created_at DESC);
+CREATE INDEX invitation_events_user_id_idx ON invitation_events(user_id);
+CREATE INDEX invitation_events_actor_id_idx ON invitation_events(actor_id)
+    WHERE actor_id IS NOT NULL;
+
+-- Backfill existing invitations so reporting covers them
+-- Creators' own memberships (inviter_id = user_id) were never invitations,
+-- matching group creation, which records no events
+INSERT INTO invitation_events (group_id, user_id, membership_id, actor_id, kind, created_at)
+SELECT group_id, user_id, id, inviter_id, 'invited', created_at
+FROM memberships
+WHERE inviter_id != user_id;
+
+INSERT INTO invitation_events (group_id, user_id, membership_id, actor_id, kind, created_at)
+SELECT group_id, user_id, id, user_id, 'accepted', accepted_at
+FROM memberships
+WHERE accepted_at IS NOT NULL
+  AND inviter_id != user_id;
+
+-- Resent invitations notify the invitee
+ALTER TABLE notifications DROP CONSTRAINT notifications_kind_valid;
+ALTER TABLE notifications ADD CONSTRAINT notifications_kind_valid
+    CHECK (kind IN ('membership_expiring', 'membership_expired', 'membership_expiry_blocked',
+                    'invitation_resent'));
+
+COMMENT ON TABLE invitation_events IS 'Append-only log of invitation outcomes';
+COMMENT ON COLUMN invitation_events.membership_id IS 'Membership the event concerns; may since have been deleted';
+COMMENT ON COLUMN invitation_events.actor_id IS 'User who performed the action (invitee for accept/decline)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM notifications WHERE kind = 'invitation_resent';
+ALTER TABLE notifications DROP CONSTRAINT notifications_kind_valid;
+ALTER TABLE notifications ADD CONSTRAINT notifications_kind_valid
+    CHECK (kind IN ('membership_expiring', 'membership_expired', 'membership_expiry_blocked'));
+DROP TABLE IF EXISTS invitation_events;
+
+-- +goose StatementEnd
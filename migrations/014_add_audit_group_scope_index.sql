@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Group history lookups over audit.record_version
+-- Features:
+--   - audit.group_scope_id() maps an audit row to the group it belongs to:
+--     the row's own id for groups, its group_id column for group-scoped
+--     tables (memberships, group_roles, group_handle_history)
+--   - An expression index on it serves GET /api/v1/groups/{id}/history
+--     without scanning the whole audit log
+
+CREATE OR REPLACE FUNCTION audit.group_scope_id(
+    p_table_name NAME,
+    p_record_id TEXT,
+    p_old_record_id TEXT,
+    p_record JSONB,
+    p_old_record JSONB
+)
+RETURNS TEXT
+IMMUTABLE
+LANGUAGE sql
+AS $$
+    SELECT CASE
+        WHEN p_table_name = 'groups' THEN COALESCE(p_record_id, p_old_record_id)
+        ELSE COALESCE(p_record, p_old_record)->>'group_id'
+    END
+$$;
+
+CREATE INDEX record_version_group_scope_idx ON audit.record_version (
+    audit.group_scope_id(table_name, record_id, old_record_id, record, old_record),
+    ts,
+    id
+);
+
+COMMENT ON FUNCTION audit.group_scope_id(NAME, TEXT, TEXT, JSONB, JSONB)
+    IS 'Group an audit row belongs to (as text), or NULL for tables without group scope';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS audit.record_version_group_scope_idx;
+DROP FUNCTION IF EXISTS audit.group_scope_id(NAME, TEXT, TEXT, JSONB, JSONB);
+
+-- +goose StatementEnd
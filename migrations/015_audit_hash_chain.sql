@@ -0,0 +1,166 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tamper-evident hash chain over audit.record_version
+-- Features:
+--   - Each audit row stores row_hash = sha256(prev_hash || row contents) and
+--     the prev_hash of the row before it, so altering, deleting, or
+--     reordering any row breaks every later link
+--   - chain_seq is a gapless position in the chain; it is assigned under a
+--     lock on audit.chain_head, so concurrent writers cannot fork the chain.
+--     The lock is held until commit, which serializes audited transactions
+--   - Hashing happens in a BEFORE INSERT trigger on audit.record_version, so
+--     rows written by audit.insert_update_delete_trigger and SNAPSHOT rows
+--     written by the purge job are chained the same way
+--   - audit.chain_checkpoint stores signed chain heads for external publication
+--   - Verify with: migrate audit verify
+
+-- Canonical row hash. table_oid is left out because OIDs change across
+-- dump/restore; ts is hashed as integer microseconds so the result does not
+-- depend on the session TimeZone.
+CREATE OR REPLACE FUNCTION audit.record_version_hash(
+    p_prev_hash     BYTEA,
+    p_chain_seq     BIGINT,
+    p_id            BIGINT,
+    p_record_id     TEXT,
+    p_old_record_id TEXT,
+    p_op            audit.operation,
+    p_ts            TIMESTAMPTZ,
+    p_xact_id       BIGINT,
+    p_table_schema  NAME,
+    p_table_name    NAME,
+    p_record        JSONB,
+    p_old_record    JSONB,
+    p_actor_id      BIGINT
+)
+RETURNS BYTEA
+IMMUTABLE
+LANGUAGE sql
+AS $$
+    SELECT sha256(
+        COALESCE(p_prev_hash, ''::BYTEA) ||
+        convert_to(concat_ws(E'\x1f',
+            p_chain_seq::TEXT,
+            p_id::TEXT,
+            COALESCE(p_record_id, E'\\N'),
+            COALESCE(p_old_record_id, E'\\N'),
+            p_op::TEXT,
+            floor(extract(epoch FROM p_ts) * 1000000)::BIGINT::TEXT,
+            p_xact_id::TEXT,
+            p_table_schema::TEXT,
+            p_table_name::TEXT,
+            COALESCE(p_record::TEXT, E'\\N'),
+            COALESCE(p_old_record::TEXT, E'\\N'),
+            COALESCE(p_actor_id::TEXT, E'\\N')
+        ), 'UTF8')
+    )
+$$;
+
+-- Single-row table holding the end of the chain; its row lock serializes appends
+CREATE TABLE audit.chain_head (
+    singleton   BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    last_seq    BIGINT NOT NULL DEFAULT 0,
+    last_hash   BYTEA,
+
+    CONSTRAINT chain_head_singleton CHECK (singleton)
+);
+INSERT INTO audit.chain_head DEFAULT VALUES;
+
+ALTER TABLE audit.record_version ADD COLUMN chain_seq BIGINT;
+ALTER TABLE audit.record_version ADD COLUMN prev_hash BYTEA;
+ALTER TABLE audit.record_version ADD COLUMN row_hash BYTEA;
+
+-- Chain existing rows in id order
+DO $backfill$
+DECLARE
+    r RECORD;
+    v_seq BIGINT := 0;
+    v_prev BYTEA := NULL;
+    v_hash BYTEA;
+BEGIN
+    FOR r IN SELECT * FROM audit.record_version ORDER BY id LOOP
+        v_seq := v_seq + 1;
+        v_hash := audit.record_version_hash(
+            v_prev, v_seq, r.id, r.record_id, r.old_record_id, r.op, r.ts, r.xact_id,
+            r.table_schema, r.table_name, r.record, r.old_record, r.actor_id);
+        UPDATE audit.record_version
+        SET chain_seq = v_seq, prev_hash = v_prev, row_hash = v_hash
+        WHERE id = r.id;
+        v_prev := v_hash;
+    END LOOP;
+
+    UPDATE audit.chain_head SET last_seq = v_seq, last_hash = v_prev;
+END
+$backfill$;
+
+ALTER TABLE audit.record_version ALTER COLUMN chain_seq SET NOT NULL;
+ALTER TABLE audit.record_version ALTER COLUMN row_hash SET NOT NULL;
+CREATE UNIQUE INDEX record_version_chain_seq_key ON audit.record_version(chain_seq);
+
+-- Appends a row to the chain. Runs for every insert, whatever its source.
+CREATE OR REPLACE FUNCTION audit.chain_record_version()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_last_seq BIGINT;
+    v_last_hash BYTEA;
+BEGIN
+    SELECT last_seq, last_hash INTO v_last_seq, v_last_hash
+    FROM audit.chain_head
+    FOR UPDATE;
+
+    NEW.chain_seq := v_last_seq + 1;
+    NEW.prev_hash := v_last_hash;
+    NEW.row_hash := audit.record_version_hash(
+        NEW.prev_hash, NEW.chain_seq, NEW.id, NEW.record_id, NEW.old_record_id, NEW.op,
+        NEW.ts, NEW.xact_id, NEW.table_schema, NEW.table_name, NEW.record, NEW.old_record,
+        NEW.actor_id);
+
+    UPDATE audit.chain_head SET last_seq = NEW.chain_seq, last_hash = NEW.row_hash;
+
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER record_version_chain
+    BEFORE INSERT ON audit.record_version
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.chain_record_version();
+
+-- Signed chain heads, published externally so a rewritten chain is detectable
+CREATE TABLE audit.chain_checkpoint (
+    id          BIGSERIAL PRIMARY KEY,
+    chain_seq   BIGINT NOT NULL,
+    row_hash    BYTEA NOT NULL,
+    key_id      TEXT NOT NULL,
+    signature   BYTEA NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX chain_checkpoint_chain_seq_idx ON audit.chain_checkpoint(chain_seq);
+
+COMMENT ON COLUMN audit.record_version.chain_seq IS 'Gapless position in the audit hash chain';
+COMMENT ON COLUMN audit.record_version.prev_hash IS 'row_hash of the previous row in the chain (NULL for the first row)';
+COMMENT ON COLUMN audit.record_version.row_hash IS 'sha256 of prev_hash and this row, see audit.record_version_hash';
+COMMENT ON TABLE audit.chain_head IS 'Last position and hash of the audit chain; locked to append';
+COMMENT ON TABLE audit.chain_checkpoint IS 'Ed25519-signed audit chain heads for external publication';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS audit.chain_checkpoint;
+DROP TRIGGER IF EXISTS record_version_chain ON audit.record_version;
+DROP FUNCTION IF EXISTS audit.chain_record_version();
+DROP INDEX IF EXISTS audit.record_version_chain_seq_key;
+ALTER TABLE audit.record_version DROP COLUMN IF EXISTS row_hash;
+ALTER TABLE audit.record_version DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit.record_version DROP COLUMN IF EXISTS chain_seq;
+DROP TABLE IF EXISTS audit.chain_head;
+DROP FUNCTION IF EXISTS audit.record_version_hash(
+    BYTEA, BIGINT, BIGINT, TEXT, TEXT, audit.operation, TIMESTAMPTZ, BIGINT, NAME, NAME, JSONB, JSONB, BIGINT);
+
+-- +goose StatementEnd
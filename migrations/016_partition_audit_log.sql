@@ -0,0 +1,283 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Monthly range partitioning of audit.record_version
+-- Features:
+--   - One partition per calendar month (UTC) of ts, named record_version_pYYYYMM
+--   - audit.ensure_record_version_partitions creates the partitions for the
+--     coming months; the audit maintenance job calls it on every run
+--   - A default partition catches rows outside every monthly partition so
+--     audited writes never fail; creating the month's partition later moves
+--     its rows out of the default partition
+--   - The primary key becomes (id, ts) because it must include the partition key
+--   - The chain_head lock is taken before any row of an insert is built, so
+--     ts follows chain_seq order and each month holds a contiguous run of
+--     the chain; archiving a month never leaves earlier positions behind
+--   - Partitions past the retention period are exported to compressed JSONL
+--     and dropped by the maintenance job. audit.chain_archive records each
+--     one; the last archived row anchors verification of the hash chain
+
+ALTER TABLE audit.record_version RENAME TO record_version_unpartitioned;
+ALTER SEQUENCE audit.record_version_id_seq OWNED BY NONE;
+
+CREATE TABLE audit.record_version (
+    id              BIGINT NOT NULL DEFAULT nextval('audit.record_version_id_seq'),
+    record_id       TEXT,
+    old_record_id   TEXT,
+    op              audit.operation NOT NULL,
+    ts              TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    xact_id         BIGINT NOT NULL DEFAULT txid_current(),
+    table_oid       OID NOT NULL,
+    table_schema    NAME NOT NULL,
+    table_name      NAME NOT NULL,
+    record          JSONB,
+    old_record      JSONB,
+    actor_id        BIGINT,
+    chain_seq       BIGINT NOT NULL,
+    prev_hash       BYTEA,
+    row_hash        BYTEA NOT NULL,
+
+    CONSTRAINT audit_record_version_record_id_check
+        CHECK (COALESCE(record_id, old_record_id) IS NOT NULL OR op IN ('TRUNCATE', 'SNAPSHOT')),
+    CONSTRAINT audit_record_version_record_check
+        CHECK (op IN ('INSERT', 'UPDATE', 'SNAPSHOT') = (record IS NOT NULL)),
+    CONSTRAINT audit_record_version_old_record_check
+        CHECK (op IN ('UPDATE', 'DELETE') = (old_record IS NOT NULL))
+) PARTITION BY RANGE (ts);
+
+ALTER SEQUENCE audit.record_version_id_seq OWNED BY audit.record_version.id;
+
+-- Creates the partition for the month containing p_month and returns its
+-- name, or NULL if it already exists. Rows for that month that landed in the
+-- default partition are moved into it.
+CREATE OR REPLACE FUNCTION audit.create_record_version_partition(p_month DATE)
+RETURNS TEXT
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_month TIMESTAMP := date_trunc('month', p_month::TIMESTAMP);
+    v_start TIMESTAMPTZ := v_month AT TIME ZONE 'UTC';
+    v_end TIMESTAMPTZ := (v_month + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    v_name TEXT := 'record_version_p' || to_char(v_month, 'YYYYMM');
+BEGIN
+    IF to_regclass(format('audit.%I', v_name)) IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+
+    EXECUTE format(
+        'CREATE TABLE audit.%I (LIKE audit.record_version INCLUDING DEFAULTS INCLUDING CONSTRAINTS)',
+        v_name);
+
+    IF to_regclass('audit.record_version_default') IS NOT NULL THEN
+        EXECUTE format(
+            'WITH moved AS (DELETE FROM audit.record_version_default WHERE ts >= %L AND ts < %L RETURNING *)
+             INSERT INTO audit.%I SELECT * FROM moved',
+            v_start, v_end, v_name);
+    END IF;
+
+    EXECUTE format(
+        'ALTER TABLE audit.record_version ATTACH PARTITION audit.%I FOR VALUES FROM (%L) TO (%L)',
+        v_name, v_start, v_end);
+
+    RETURN v_name;
+END;
+$$;
+
+-- Creates the partitions from the month of p_from through p_months_ahead
+-- months later and returns the names of those it created
+CREATE OR REPLACE FUNCTION audit.ensure_record_version_partitions(p_from DATE, p_months_ahead INTEGER)
+RETURNS SETOF TEXT
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_name TEXT;
+BEGIN
+    FOR i IN 0..p_months_ahead LOOP
+        v_name := audit.create_record_version_partition(
+            (date_trunc('month', p_from::TIMESTAMP) + make_interval(months => i))::DATE);
+        IF v_name IS NOT NULL THEN
+            RETURN NEXT v_name;
+        END IF;
+    END LOOP;
+END;
+$$;
+
+-- Drops a monthly partition after it has been archived
+CREATE OR REPLACE FUNCTION audit.drop_record_version_partition(p_name TEXT)
+RETURNS VOID
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF p_name !~ '^record_version_p[0-9]{6}$' OR NOT EXISTS (
+        SELECT 1 FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'audit.record_version'::regclass AND c.relname = p_name
+    ) THEN
+        RAISE EXCEPTION 'audit.% is not a monthly partition of audit.record_version', p_name;
+    END IF;
+
+    EXECUTE format('DROP TABLE audit.%I', p_name);
+END;
+$$;
+
+-- Partitions for every month that has rows, plus the next three months
+DO $partitions$
+DECLARE
+    v_month TIMESTAMP;
+BEGIN
+    FOR v_month IN
+        SELECT m FROM generate_series(
+            (SELECT date_trunc('month', COALESCE(MIN(ts), NOW()) AT TIME ZONE 'UTC')
+             FROM audit.record_version_unpartitioned),
+            date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '3 months',
+            INTERVAL '1 month') AS m
+    LOOP
+        PERFORM audit.create_record_version_partition(v_month::DATE);
+    END LOOP;
+    EXECUTE 'CREATE TABLE audit.record_version_default PARTITION OF audit.record_version DEFAULT';
+END
+$partitions$;
+
+-- Existing rows keep their ids and hashes; the chain trigger is created afterwards
+INSERT INTO audit.record_version SELECT * FROM audit.record_version_unpartitioned;
+DROP TABLE audit.record_version_unpartitioned;
+
+ALTER TABLE audit.record_version ADD PRIMARY KEY (id, ts);
+
+CREATE INDEX record_version_ts_brin ON audit.record_version USING BRIN(ts);
+CREATE INDEX record_version_record_id ON audit.record_version(record_id) WHERE record_id IS NOT NULL;
+CREATE INDEX record_version_table_oid ON audit.record_version(table_oid);
+CREATE INDEX record_version_xact_id ON audit.record_version(xact_id);
+CREATE INDEX record_version_actor_id ON audit.record_version(actor_id) WHERE actor_id IS NOT NULL;
+CREATE INDEX record_version_group_scope_idx ON audit.record_version (
+    audit.group_scope_id(table_name, record_id, old_record_id, record, old_record),
+    ts,
+    id
+);
+-- Unique indexes must include the partition key ts
+CREATE UNIQUE INDEX record_version_chain_seq_key ON audit.record_version(chain_seq, ts);
+
+-- Locks the chain head before the statement evaluates any ts default.
+-- chain_record_version cannot assign ts itself: rows are routed to a
+-- partition before BEFORE ROW triggers run, so a ts moved into the next
+-- month would fail the partition check
+CREATE OR REPLACE FUNCTION audit.lock_chain_head()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    PERFORM 1 FROM audit.chain_head FOR UPDATE;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER record_version_chain_lock
+    BEFORE INSERT ON audit.record_version
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION audit.lock_chain_head();
+
+CREATE TRIGGER record_version_chain
+    BEFORE INSERT ON audit.record_version
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.chain_record_version();
+
+-- Exported and dropped partitions
+CREATE TABLE audit.chain_archive (
+    id              BIGSERIAL PRIMARY KEY,
+    partition_name  TEXT NOT NULL,
+    range_start     TIMESTAMPTZ NOT NULL,
+    range_end       TIMESTAMPTZ NOT NULL,
+    row_count       BIGINT NOT NULL,
+    first_seq       BIGINT,
+    last_seq        BIGINT,
+    last_hash       BYTEA,
+    file_path       TEXT,
+    file_sha256     BYTEA,
+    archived_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chain_archive_partition_name_key UNIQUE (partition_name),
+    CONSTRAINT chain_archive_file_check
+        CHECK ((row_count = 0) = (file_path IS NULL))
+);
+
+COMMENT ON TABLE audit.record_version IS 'Immutable audit log storing JSONB snapshots of record changes, partitioned by month';
+COMMENT ON COLUMN audit.record_version.xact_id IS 'Transaction ID for correlating changes in the same transaction';
+COMMENT ON COLUMN audit.record_version.actor_id IS 'User ID from app.current_user_id session variable';
+COMMENT ON COLUMN audit.record_version.chain_seq IS 'Gapless position in the audit hash chain';
+COMMENT ON COLUMN audit.record_version.prev_hash IS 'row_hash of the previous row in the chain (NULL for the first row)';
+COMMENT ON COLUMN audit.record_version.row_hash IS 'sha256 of prev_hash and this row, see audit.record_version_hash';
+COMMENT ON TABLE audit.chain_archive IS 'Audit partitions exported to JSONL and dropped after the retention period';
+COMMENT ON COLUMN audit.chain_archive.last_hash IS 'row_hash at last_seq; verification of the remaining chain starts from it';
+COMMENT ON COLUMN audit.chain_archive.file_sha256 IS 'sha256 of the compressed export file';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Archived partitions cannot be restored; the remaining rows are copied back
+DROP TABLE IF EXISTS audit.chain_archive;
+
+ALTER TABLE audit.record_version RENAME TO record_version_partitioned;
+ALTER SEQUENCE audit.record_version_id_seq OWNED BY NONE;
+
+CREATE TABLE audit.record_version (
+    id              BIGINT PRIMARY KEY DEFAULT nextval('audit.record_version_id_seq'),
+    record_id       TEXT,
+    old_record_id   TEXT,
+    op              audit.operation NOT NULL,
+    ts              TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    xact_id         BIGINT NOT NULL DEFAULT txid_current(),
+    table_oid       OID NOT NULL,
+    table_schema    NAME NOT NULL,
+    table_name      NAME NOT NULL,
+    record          JSONB,
+    old_record      JSONB,
+    actor_id        BIGINT,
+    chain_seq       BIGINT NOT NULL,
+    prev_hash       BYTEA,
+    row_hash        BYTEA NOT NULL,
+
+    CONSTRAINT audit_record_version_record_id_check
+        CHECK (COALESCE(record_id, old_record_id) IS NOT NULL OR op IN ('TRUNCATE', 'SNAPSHOT')),
+    CONSTRAINT audit_record_version_record_check
+        CHECK (op IN ('INSERT', 'UPDATE', 'SNAPSHOT') = (record IS NOT NULL)),
+    CONSTRAINT audit_record_version_old_record_check
+        CHECK (op IN ('UPDATE', 'DELETE') = (old_record IS NOT NULL))
+);
+
+INSERT INTO audit.record_version SELECT * FROM audit.record_version_partitioned;
+DROP TABLE audit.record_version_partitioned;
+ALTER SEQUENCE audit.record_version_id_seq OWNED BY audit.record_version.id;
+
+DROP FUNCTION IF EXISTS audit.lock_chain_head();
+DROP FUNCTION IF EXISTS audit.drop_record_version_partition(TEXT);
+DROP FUNCTION IF EXISTS audit.ensure_record_version_partitions(DATE, INTEGER);
+DROP FUNCTION IF EXISTS audit.create_record_version_partition(DATE);
+
+CREATE INDEX record_version_ts_brin ON audit.record_version USING BRIN(ts);
+CREATE INDEX record_version_record_id ON audit.record_version(record_id) WHERE record_id IS NOT NULL;
+CREATE INDEX record_version_table_oid ON audit.record_version(table_oid);
+CREATE INDEX record_version_xact_id ON audit.record_version(xact_id);
+CREATE INDEX record_version_actor_id ON audit.record_version(actor_id) WHERE actor_id IS NOT NULL;
+CREATE INDEX record_version_group_scope_idx ON audit.record_version (
+    audit.group_scope_id(table_name, record_id, old_record_id, record, old_record),
+    ts,
+    id
+);
+CREATE UNIQUE INDEX record_version_chain_seq_key ON audit.record_version(chain_seq);
+
+CREATE TRIGGER record_version_chain
+    BEFORE INSERT ON audit.record_version
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.chain_record_version();
+
+COMMENT ON TABLE audit.record_version IS 'Immutable audit log storing JSONB snapshots of record changes';
+COMMENT ON COLUMN audit.record_version.xact_id IS 'Transaction ID for correlating changes in the same transaction';
+COMMENT ON COLUMN audit.record_version.actor_id IS 'User ID from app.current_user_id session variable';
+COMMENT ON COLUMN audit.record_version.chain_seq IS 'Gapless position in the audit hash chain';
+COMMENT ON COLUMN audit.record_version.prev_hash IS 'row_hash of the previous row in the chain (NULL for the first row)';
+COMMENT ON COLUMN audit.record_version.row_hash IS 'sha256 of prev_hash and this row, see audit.record_version_hash';
+
+-- +goose StatementEnd
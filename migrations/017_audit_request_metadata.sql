@@ -0,0 +1,143 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- HTTP request metadata on audit rows
+-- Features:
+--   - request_id, client_ip, and user_agent link an audit row to the request
+--     that caused it. They default from the app.request_id, app.client_ip,
+--     and app.user_agent session variables set by db.SetAuditContext, so
+--     every insert path picks them up without listing them
+--   - The metadata is part of row_hash. Rows without any metadata hash
+--     exactly as before, so existing chains and checkpoints still verify
+
+ALTER TABLE audit.record_version
+    ADD COLUMN request_id TEXT DEFAULT NULLIF(current_setting('app.request_id', true), ''),
+    ADD COLUMN client_ip TEXT DEFAULT NULLIF(current_setting('app.client_ip', true), ''),
+    ADD COLUMN user_agent TEXT DEFAULT NULLIF(current_setting('app.user_agent', true), '');
+
+CREATE INDEX record_version_request_id ON audit.record_version(request_id) WHERE request_id IS NOT NULL;
+
+-- Row hash including request metadata; falls back to the original hash when
+-- the row has none
+CREATE OR REPLACE FUNCTION audit.record_version_hash(
+    p_prev_hash     BYTEA,
+    p_chain_seq     BIGINT,
+    p_id            BIGINT,
+    p_record_id     TEXT,
+    p_old_record_id TEXT,
+    p_op            audit.operation,
+    p_ts            TIMESTAMPTZ,
+    p_xact_id       BIGINT,
+    p_table_schema  NAME,
+    p_table_name    NAME,
+    p_record        JSONB,
+    p_old_record    JSONB,
+    p_actor_id      BIGINT,
+    p_request_id    TEXT,
+    p_client_ip     TEXT,
+    p_user_agent    TEXT
+)
+RETURNS BYTEA
+IMMUTABLE
+LANGUAGE sql
+AS $$
+    SELECT CASE
+        WHEN p_request_id IS NULL AND p_client_ip IS NULL AND p_user_agent IS NULL THEN
+            audit.record_version_hash(
+                p_prev_hash, p_chain_seq, p_id, p_record_id, p_old_record_id, p_op, p_ts,
+                p_xact_id, p_table_schema, p_table_name, p_record, p_old_record, p_actor_id)
+        ELSE sha256(
+            COALESCE(p_prev_hash, ''::BYTEA) ||
+            convert_to(concat_ws(E'\x1f',
+                p_chain_seq::TEXT,
+                p_id::TEXT,
+                COALESCE(p_record_id, E'\\N'),
+                COALESCE(p_old_record_id, E'\\N'),
+                p_op::TEXT,
+                floor(extract(epoch FROM p_ts) * 1000000)::BIGINT::TEXT,
+                p_xact_id::TEXT,
+                p_table_schema::TEXT,
+                p_table_name::TEXT,
+                COALESCE(p_record::TEXT, E'\\N'),
+                COALESCE(p_old_record::TEXT, E'\\N'),
+                COALESCE(p_actor_id::TEXT, E'\\N'),
+                COALESCE(p_request_id, E'\\N'),
+                COALESCE(p_client_ip, E'\\N'),
+                COALESCE(p_user_agent, E'\\N')
+            ), 'UTF8')
+        )
+    END
+$$;
+
+CREATE OR REPLACE FUNCTION audit.chain_record_version()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_last_seq BIGINT;
+    v_last_hash BYTEA;
+BEGIN
+    SELECT last_seq, last_hash INTO v_last_seq, v_last_hash
+    FROM audit.chain_head
+    FOR UPDATE;
+
+    NEW.chain_seq := v_last_seq + 1;
+    NEW.prev_hash := v_last_hash;
+    NEW.row_hash := audit.record_version_hash(
+        NEW.prev_hash, NEW.chain_seq, NEW.id, NEW.record_id, NEW.old_record_id, NEW.op,
+        NEW.ts, NEW.xact_id, NEW.table_schema, NEW.table_name, NEW.record, NEW.old_record,
+        NEW.actor_id, NEW.request_id, NEW.client_ip, NEW.user_agent);
+
+    UPDATE audit.chain_head SET last_seq = NEW.chain_seq, last_hash = NEW.row_hash;
+
+    RETURN NEW;
+END;
+$$;
+
+COMMENT ON COLUMN audit.record_version.request_id IS 'X-Request-ID of the HTTP request, from app.request_id';
+COMMENT ON COLUMN audit.record_version.client_ip IS 'Client IP of the HTTP request, from app.client_ip';
+COMMENT ON COLUMN audit.record_version.user_agent IS 'User-Agent of the HTTP request, from app.user_agent';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Rows with metadata no longer verify once the columns are dropped
+CREATE OR REPLACE FUNCTION audit.chain_record_version()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_last_seq BIGINT;
+    v_last_hash BYTEA;
+BEGIN
+    SELECT last_seq, last_hash INTO v_last_seq, v_last_hash
+    FROM audit.chain_head
+    FOR UPDATE;
+
+    NEW.chain_seq := v_last_seq + 1;
+    NEW.prev_hash := v_last_hash;
+    NEW.row_hash := audit.record_version_hash(
+        NEW.prev_hash, NEW.chain_seq, NEW.id, NEW.record_id, NEW.old_record_id, NEW.op,
+        NEW.ts, NEW.xact_id, NEW.table_schema, NEW.table_name, NEW.record, NEW.old_record,
+        NEW.actor_id);
+
+    UPDATE audit.chain_head SET last_seq = NEW.chain_seq, last_hash = NEW.row_hash;
+
+    RETURN NEW;
+END;
+$$;
+
+DROP FUNCTION IF EXISTS audit.record_version_hash(
+    BYTEA, BIGINT, BIGINT, TEXT, TEXT, audit.operation, TIMESTAMPTZ, BIGINT, NAME, NAME, JSONB, JSONB, BIGINT,
+    TEXT, TEXT, TEXT);
+DROP INDEX IF EXISTS audit.record_version_request_id;
+ALTER TABLE audit.record_version
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS client_ip,
+    DROP COLUMN IF EXISTS request_id;
+
+-- +goose StatementEnd
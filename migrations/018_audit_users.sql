@@ -0,0 +1,168 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Audit the users table, with per-table redaction of sensitive columns
+-- Features:
+--   - audit.redacted_column lists columns whose values must not be stored
+--     in audit JSONB. Strategies:
+--       redact - replaced with "[REDACTED]"; the audit row does not show
+--                whether the value changed
+--       hash   - replaced with "sha256:<hex>" of the value, so a change is
+--                visible without the value being readable
+--       keep   - stored as is; opts a column out of the name-based default
+--   - Columns that are not listed but whose name contains password, token,
+--     or secret are redacted by default, so a table that gains such a
+--     column stays safe before anyone configures it
+--   - NULL values are kept as NULL under every strategy
+--   - users is audited with password_hash hashed: password changes show up
+--     in the log, the hash itself does not
+
+CREATE TABLE audit.redacted_column (
+    table_schema    NAME NOT NULL,
+    table_name      NAME NOT NULL,
+    column_name     NAME NOT NULL,
+    strategy        TEXT NOT NULL,
+
+    PRIMARY KEY (table_schema, table_name, column_name),
+    CONSTRAINT redacted_column_strategy_check CHECK (strategy IN ('redact', 'hash', 'keep'))
+);
+
+INSERT INTO audit.redacted_column (table_schema, table_name, column_name, strategy)
+VALUES ('public', 'users', 'password_hash', 'hash');
+
+-- Applies the redaction rules of a table to a row snapshot
+CREATE OR REPLACE FUNCTION audit.redact_record(p_table_schema NAME, p_table_name NAME, p_record JSONB)
+RETURNS JSONB
+STABLE
+LANGUAGE sql
+AS $$
+    SELECT jsonb_object_agg(e.key,
+        CASE
+            WHEN e.value = 'null'::JSONB THEN e.value
+            ELSE CASE COALESCE(rc.strategy,
+                    CASE WHEN e.key ~* '(password|token|secret)' THEN 'redact' END)
+                WHEN 'redact' THEN to_jsonb('[REDACTED]'::TEXT)
+                WHEN 'hash' THEN to_jsonb('sha256:' || encode(sha256(convert_to(e.value #>> '{}', 'UTF8')), 'hex'))
+                ELSE e.value
+            END
+        END)
+    FROM jsonb_each(p_record) e
+    LEFT JOIN audit.redacted_column rc
+        ON rc.table_schema = p_table_schema
+       AND rc.table_name = p_table_name
+       AND rc.column_name = e.key
+$$;
+
+-- Generic trigger function for INSERT/UPDATE/DELETE auditing
+-- Captures actor_id from session variable app.current_user_id and redacts
+-- the row snapshots with audit.redact_record
+CREATE OR REPLACE FUNCTION audit.insert_update_delete_trigger()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    record_jsonb JSONB;
+    old_record_jsonb JSONB;
+    v_actor_id BIGINT;
+    v_record_id TEXT;
+    v_old_record_id TEXT;
+BEGIN
+    -- Get actor from session variable (NULL if not set)
+    -- The 'true' parameter makes current_setting return NULL instead of error if not set
+    v_actor_id := NULLIF(current_setting('app.current_user_id', true), '')::BIGINT;
+
+    -- Convert records to JSONB
+    IF TG_OP != 'DELETE' THEN
+        record_jsonb := audit.redact_record(TG_TABLE_SCHEMA, TG_TABLE_NAME, to_jsonb(NEW));
+        v_record_id := NEW.id::TEXT;
+    END IF;
+
+    IF TG_OP != 'INSERT' THEN
+        old_record_jsonb := audit.redact_record(TG_TABLE_SCHEMA, TG_TABLE_NAME, to_jsonb(OLD));
+        v_old_record_id := OLD.id::TEXT;
+    END IF;
+
+    INSERT INTO audit.record_version (
+        record_id,
+        old_record_id,
+        op,
+        table_oid,
+        table_schema,
+        table_name,
+        record,
+        old_record,
+        actor_id
+    ) VALUES (
+        v_record_id,
+        v_old_record_id,
+        TG_OP::audit.operation,
+        TG_RELID,
+        TG_TABLE_SCHEMA,
+        TG_TABLE_NAME,
+        record_jsonb,
+        old_record_jsonb,
+        v_actor_id
+    );
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$;
+
+-- Audit trigger for users table
+CREATE TRIGGER users_audit
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.insert_update_delete_trigger();
+
+COMMENT ON TABLE audit.redacted_column IS 'Columns redacted or hashed in audit snapshots, per table';
+COMMENT ON FUNCTION audit.redact_record(NAME, NAME, JSONB)
+    IS 'Row snapshot with the redaction rules of its table applied';
+COMMENT ON TRIGGER users_audit ON users IS 'Captures all changes to users in audit.record_version';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS users_audit ON users;
+
+CREATE OR REPLACE FUNCTION audit.insert_update_delete_trigger()
+RETURNS TRIGGER
+SECURITY DEFINER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    record_jsonb JSONB;
+    old_record_jsonb JSONB;
+    v_actor_id BIGINT;
+    v_record_id TEXT;
+    v_old_record_id TEXT;
+BEGIN
+    v_actor_id := NULLIF(current_setting('app.current_user_id', true), '')::BIGINT;
+
+    IF TG_OP != 'DELETE' THEN
+        record_jsonb := to_jsonb(NEW);
+        v_record_id := NEW.id::TEXT;
+    END IF;
+
+    IF TG_OP != 'INSERT' THEN
+        old_record_jsonb := to_jsonb(OLD);
+        v_old_record_id := OLD.id::TEXT;
+    END IF;
+
+    INSERT INTO audit.record_version (
+        record_id, old_record_id, op, table_oid, table_schema, table_name, record, old_record, actor_id
+    ) VALUES (
+        v_record_id, v_old_record_id, TG_OP::audit.operation, TG_RELID, TG_TABLE_SCHEMA, TG_TABLE_NAME,
+        record_jsonb, old_record_jsonb, v_actor_id
+    );
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$;
+
+DROP FUNCTION IF EXISTS audit.redact_record(NAME, NAME, JSONB);
+DROP TABLE IF EXISTS audit.redacted_column;
+
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Idempotency keys: the first response to each (user, Idempotency-Key)
+-- Features:
+--   - A POST carrying an Idempotency-Key claims the key by inserting a row
+--     with a NULL status; a retry arriving while it runs sees the claim
+--   - The finished response is stored on the row and replayed for identical
+--     retries until expires_at
+--   - request_hash covers method, path, and body, so the same key sent with
+--     a different request can be rejected
+--   - Server errors release the claim rather than being stored, so a retry
+--     runs the request again
+
+CREATE TABLE idempotency_keys (
+    user_id             BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key                 TEXT NOT NULL,
+    request_hash        BYTEA NOT NULL,
+    status              INTEGER,
+    response_headers    JSONB,
+    response_body       BYTEA,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (user_id, key),
+
+    -- Constraints
+    CONSTRAINT idempotency_keys_key_length CHECK (char_length(key) BETWEEN 1 AND 255),
+    CONSTRAINT idempotency_keys_response_complete
+        CHECK ((status IS NULL) = (response_body IS NULL))
+);
+
+-- Cleanup of expired keys
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Stored first responses of POST requests sent with an Idempotency-Key';
+COMMENT ON COLUMN idempotency_keys.request_hash IS 'SHA-256 of method, path, and body of the first request';
+COMMENT ON COLUMN idempotency_keys.status IS 'HTTP status of the stored response; NULL while the first request is in flight';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+// Package migrations embeds the goose SQL migrations so the server can
+// compare the database schema version with the newest migration it was
+// built with.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+// FS holds the SQL migration files.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion returns the version of the newest migration.
+func LatestVersion() (int64, error) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, file := range files {
+		version, err := goose.NumericComponent(file)
+		if err != nil {
+			return 0, fmt.Errorf("parse migration %q: %w", file, err)
+		}
+		latest = max(latest, version)
+	}
+	if latest == 0 {
+		return 0, errors.New("no migrations embedded")
+	}
+	return latest, nil
+}
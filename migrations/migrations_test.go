@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+// TestLatestVersion verifies migration versions are unique and contiguous,
+// so the newest version is the schema every server build expects.
+func TestLatestVersion(t *testing.T) {
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatalf("LatestVersion: %v", err)
+	}
+
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	seen := make(map[int64]string)
+	for _, file := range files {
+		version, err := goose.NumericComponent(file)
+		if err != nil {
+			t.Fatalf("NumericComponent(%q): %v", file, err)
+		}
+		if other, ok := seen[version]; ok {
+			t.Errorf("version %d used by both %s and %s", version, other, file)
+		}
+		seen[version] = file
+	}
+	for v := int64(1); v <= latest; v++ {
+		if _, ok := seen[v]; !ok {
+			t.Errorf("missing migration version %d", v)
+		}
+	}
+	if int64(len(seen)) != latest {
+		t.Errorf("expected %d migrations, got %d", latest, len(seen))
+	}
+}
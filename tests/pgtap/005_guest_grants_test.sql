@@ -0,0 +1,108 @@
+-- pgTap tests for guest role and guest_grants table
+-- Run with: pg_prove -d loomio_test tests/pgtap/005_guest_grants_test.sql
+
+BEGIN;
+SELECT plan(14);
+
+-- Test table exists
+SELECT has_table('guest_grants', 'guest_grants table should exist');
+
+-- Test columns exist
+SELECT has_column('guest_grants', 'membership_id', 'guest_grants should have membership_id column');
+SELECT has_column('guest_grants', 'resource_type', 'guest_grants should have resource_type column');
+SELECT has_column('guest_grants', 'resource_id', 'guest_grants should have resource_id column');
+SELECT has_column('guest_grants', 'granted_by_id', 'guest_grants should have granted_by_id column');
+
+-- Test foreign keys
+SELECT col_is_fk('guest_grants', 'membership_id', 'membership_id should be a foreign key');
+SELECT col_is_fk('guest_grants', 'granted_by_id', 'granted_by_id should be a foreign key');
+
+-- Test indexes exist
+SELECT has_index('guest_grants', 'guest_grants_resource_idx', 'index on resource should exist');
+
+SELECT trigger_is(
+    'guest_grants',
+    'guest_grants_audit',
+    'audit.insert_update_delete_trigger',
+    'guest_grants_audit trigger should exist'
+);
+
+-- =====================================================
+-- Guest role and grant constraints
+-- =====================================================
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES
+    ('admin1@test.com', 'Admin One', 'admin-one', 'hash1', 'key1'),
+    ('guest1@test.com', 'Guest One', 'guest-one', 'hash2', 'key2');
+
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Test Group', 'test-group', (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at)
+VALUES (
+    (SELECT id FROM groups WHERE handle = 'test-group'),
+    (SELECT id FROM users WHERE email = 'admin1@test.com'),
+    'admin',
+    (SELECT id FROM users WHERE email = 'admin1@test.com'),
+    NOW()
+);
+
+-- Test: guest role is accepted by memberships_role_valid
+SELECT lives_ok(
+    $$INSERT INTO memberships (group_id, user_id, role, inviter_id)
+      VALUES (
+          (SELECT id FROM groups WHERE handle = 'test-group'),
+          (SELECT id FROM users WHERE email = 'guest1@test.com'),
+          'guest',
+          (SELECT id FROM users WHERE email = 'admin1@test.com')
+      )$$,
+    'Guest role should be accepted'
+);
+
+-- Test: grant for a discussion succeeds
+SELECT lives_ok(
+    $$INSERT INTO guest_grants (membership_id, resource_type, resource_id, granted_by_id)
+      VALUES (
+          (SELECT id FROM memberships WHERE role = 'guest'),
+          'discussion', 42,
+          (SELECT id FROM users WHERE email = 'admin1@test.com')
+      )$$,
+    'Granting a discussion to a guest should succeed'
+);
+
+-- Test: duplicate grant is rejected
+SELECT throws_ok(
+    $$INSERT INTO guest_grants (membership_id, resource_type, resource_id, granted_by_id)
+      VALUES (
+          (SELECT id FROM memberships WHERE role = 'guest'),
+          'discussion', 42,
+          (SELECT id FROM users WHERE email = 'admin1@test.com')
+      )$$,
+    '23505',  -- unique_violation
+    NULL,
+    'Duplicate grant should be rejected'
+);
+
+-- Test: unknown resource type is rejected
+SELECT throws_ok(
+    $$INSERT INTO guest_grants (membership_id, resource_type, resource_id, granted_by_id)
+      VALUES (
+          (SELECT id FROM memberships WHERE role = 'guest'),
+          'group', 1,
+          (SELECT id FROM users WHERE email = 'admin1@test.com')
+      )$$,
+    '23514',  -- check_violation
+    NULL,
+    'Invalid resource_type should be rejected'
+);
+
+-- Test: grants are removed with their membership
+DELETE FROM memberships WHERE role = 'guest';
+SELECT is_empty(
+    $$SELECT 1 FROM guest_grants$$,
+    'Deleting guest membership should cascade to its grants'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -0,0 +1,56 @@
+-- pgTap tests for recursive group hierarchy (cycle protection, inherited admin flag)
+-- Run with: pg_prove -d loomio_test tests/pgtap/006_group_hierarchy_test.sql
+
+BEGIN;
+SELECT plan(6);
+
+SELECT has_column('groups', 'parent_admins_can_manage', 'groups should have parent_admins_can_manage column');
+SELECT col_default_is('groups', 'parent_admins_can_manage', 'true', 'parent_admins_can_manage should default to true');
+
+SELECT trigger_is(
+    'groups',
+    'groups_prevent_hierarchy_cycle',
+    'prevent_group_hierarchy_cycle',
+    'groups_prevent_hierarchy_cycle trigger should exist'
+);
+
+-- Build a three-level hierarchy: root > child > grandchild
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('admin1@test.com', 'Admin One', 'admin-one', 'hash1', 'key1');
+
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Root', 'root-group', (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+INSERT INTO groups (name, handle, parent_id, created_by_id)
+VALUES ('Child', 'child-group',
+    (SELECT id FROM groups WHERE handle = 'root-group'),
+    (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+INSERT INTO groups (name, handle, parent_id, created_by_id)
+VALUES ('Grandchild', 'grandchild-group',
+    (SELECT id FROM groups WHERE handle = 'child-group'),
+    (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+-- Test: moving root under its grandchild is rejected
+SELECT throws_like(
+    $$UPDATE groups SET parent_id = (SELECT id FROM groups WHERE handle = 'grandchild-group')
+      WHERE handle = 'root-group'$$,
+    'Group hierarchy cycle%',
+    'Moving a group under its own descendant should raise exception'
+);
+
+-- Test: moving child to the top level succeeds
+SELECT lives_ok(
+    $$UPDATE groups SET parent_id = NULL WHERE handle = 'child-group'$$,
+    'Moving a subgroup to the top level should succeed'
+);
+
+-- Test: after the split, root can move under the former grandchild
+SELECT lives_ok(
+    $$UPDATE groups SET parent_id = (SELECT id FROM groups WHERE handle = 'grandchild-group')
+      WHERE handle = 'root-group'$$,
+    'Moving a group under an unrelated group should succeed'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
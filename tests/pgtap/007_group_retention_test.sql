@@ -0,0 +1,42 @@
+-- pgTap tests for hard deletion of groups (cascade past last-admin protection)
+-- Run with: pg_prove -d loomio_test tests/pgtap/007_group_retention_test.sql
+
+BEGIN;
+SELECT plan(3);
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('admin1@test.com', 'Admin One', 'admin-one', 'hash1', 'key1');
+
+INSERT INTO groups (name, handle, created_by_id, archived_at)
+VALUES ('Old Group', 'old-group', (SELECT id FROM users WHERE email = 'admin1@test.com'), NOW() - INTERVAL '400 days');
+
+INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at)
+VALUES (
+    (SELECT id FROM groups WHERE handle = 'old-group'),
+    (SELECT id FROM users WHERE email = 'admin1@test.com'),
+    'admin',
+    (SELECT id FROM users WHERE email = 'admin1@test.com'),
+    NOW()
+);
+
+-- Test: deleting the last admin directly is still blocked
+SELECT throws_ok(
+    $$DELETE FROM memberships WHERE group_id = (SELECT id FROM groups WHERE handle = 'old-group')$$,
+    'P0001',
+    'Cannot remove or demote the last administrator of a group',
+    'Deleting last admin directly should still raise exception'
+);
+
+-- Test: deleting the group cascades to its memberships
+SELECT lives_ok(
+    $$DELETE FROM groups WHERE handle = 'old-group'$$,
+    'Deleting a group should cascade past last-admin protection'
+);
+
+SELECT is_empty(
+    $$SELECT 1 FROM memberships m JOIN users u ON u.id = m.user_id WHERE u.email = 'admin1@test.com'$$,
+    'Memberships should be removed with their group'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
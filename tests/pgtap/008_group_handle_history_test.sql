@@ -0,0 +1,45 @@
+-- pgTap tests for group_handle_history table
+-- Run with: pg_prove -d loomio_test tests/pgtap/008_group_handle_history_test.sql
+
+BEGIN;
+SELECT plan(7);
+
+SELECT has_table('group_handle_history', 'group_handle_history table should exist');
+SELECT col_type_is('group_handle_history', 'handle', 'citext', 'handle should be citext');
+SELECT col_is_fk('group_handle_history', 'group_id', 'group_id should be a foreign key');
+SELECT index_is_unique('group_handle_history', 'group_handle_history_handle_key', 'handle should be unique');
+
+SELECT trigger_is(
+    'group_handle_history',
+    'group_handle_history_audit',
+    'audit.insert_update_delete_trigger',
+    'group_handle_history_audit trigger should exist'
+);
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('admin1@test.com', 'Admin One', 'admin-one', 'hash1', 'key1');
+
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Renamed', 'new-handle', (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+INSERT INTO group_handle_history (group_id, handle, reserved_until)
+VALUES ((SELECT id FROM groups WHERE handle = 'new-handle'), 'old-handle', NOW() + INTERVAL '90 days');
+
+-- Test: handle uniqueness is case-insensitive
+SELECT throws_ok(
+    $$INSERT INTO group_handle_history (group_id, handle, reserved_until)
+      VALUES ((SELECT id FROM groups WHERE handle = 'new-handle'), 'OLD-HANDLE', NOW())$$,
+    '23505',  -- unique_violation
+    NULL,
+    'Duplicate handle (different case) should be rejected'
+);
+
+-- Test: history is removed with its group
+DELETE FROM groups WHERE handle = 'new-handle';
+SELECT is_empty(
+    $$SELECT 1 FROM group_handle_history WHERE handle = 'old-handle'$$,
+    'Deleting a group should cascade to its handle history'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -0,0 +1,90 @@
+-- pgTap tests for group_roles table and memberships.group_role_id
+-- Run with: pg_prove -d loomio_test tests/pgtap/009_group_roles_test.sql
+
+BEGIN;
+SELECT plan(9);
+
+SELECT has_table('group_roles', 'group_roles table should exist');
+SELECT has_column('memberships', 'group_role_id', 'memberships should have group_role_id column');
+SELECT index_is_unique('group_roles', 'group_roles_unique_name', 'role names should be unique per group');
+
+SELECT trigger_is(
+    'group_roles',
+    'group_roles_audit',
+    'audit.insert_update_delete_trigger',
+    'group_roles_audit trigger should exist'
+);
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES
+    ('admin1@test.com', 'Admin One', 'admin-one', 'hash1', 'key1'),
+    ('member1@test.com', 'Member One', 'member-one', 'hash2', 'key2');
+
+INSERT INTO groups (name, handle, created_by_id)
+VALUES
+    ('Test Group', 'test-group', (SELECT id FROM users WHERE email = 'admin1@test.com')),
+    ('Other Group', 'other-group', (SELECT id FROM users WHERE email = 'admin1@test.com'));
+
+INSERT INTO group_roles (group_id, name, permissions)
+VALUES
+    ((SELECT id FROM groups WHERE handle = 'test-group'), 'moderator', ARRAY['manage_comments']),
+    ((SELECT id FROM groups WHERE handle = 'other-group'), 'facilitator', ARRAY['manage_polls']);
+
+INSERT INTO memberships (group_id, user_id, role, inviter_id, accepted_at)
+VALUES
+    ((SELECT id FROM groups WHERE handle = 'test-group'),
+     (SELECT id FROM users WHERE email = 'admin1@test.com'), 'admin',
+     (SELECT id FROM users WHERE email = 'admin1@test.com'), NOW()),
+    ((SELECT id FROM groups WHERE handle = 'test-group'),
+     (SELECT id FROM users WHERE email = 'member1@test.com'), 'member',
+     (SELECT id FROM users WHERE email = 'admin1@test.com'), NOW());
+
+-- Test: unknown permissions are rejected
+SELECT throws_ok(
+    $$INSERT INTO group_roles (group_id, name, permissions)
+      VALUES ((SELECT id FROM groups WHERE handle = 'test-group'), 'boss', ARRAY['manage_members'])$$,
+    '23514',  -- check_violation
+    NULL,
+    'Unknown permission should be rejected'
+);
+
+-- Test: a role from another group cannot be assigned
+SELECT throws_ok(
+    $$UPDATE memberships SET group_role_id = (SELECT id FROM group_roles WHERE name = 'facilitator')
+      WHERE user_id = (SELECT id FROM users WHERE email = 'member1@test.com')$$,
+    '23503',  -- foreign_key_violation
+    NULL,
+    'Role of another group should be rejected'
+);
+
+-- Test: admins cannot hold a custom role
+SELECT throws_ok(
+    $$UPDATE memberships SET group_role_id = (SELECT id FROM group_roles WHERE name = 'moderator')
+      WHERE user_id = (SELECT id FROM users WHERE email = 'admin1@test.com')$$,
+    '23514',  -- check_violation
+    NULL,
+    'Custom role on an admin membership should be rejected'
+);
+
+-- Test: deleting a role unassigns it without touching group_id
+UPDATE memberships SET group_role_id = (SELECT id FROM group_roles WHERE name = 'moderator')
+WHERE user_id = (SELECT id FROM users WHERE email = 'member1@test.com');
+DELETE FROM group_roles WHERE name = 'moderator';
+SELECT results_eq(
+    $$SELECT group_role_id IS NULL AND group_id IS NOT NULL FROM memberships
+      WHERE user_id = (SELECT id FROM users WHERE email = 'member1@test.com')$$,
+    $$VALUES (true)$$,
+    'Deleting a role should only clear group_role_id'
+);
+
+-- Test: last-admin protection is unaffected
+SELECT throws_ok(
+    $$DELETE FROM memberships
+      WHERE user_id = (SELECT id FROM users WHERE email = 'admin1@test.com')$$,
+    'P0001',  -- raise_exception
+    'Cannot remove or demote the last administrator of a group',
+    'Deleting last admin should still raise exception'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
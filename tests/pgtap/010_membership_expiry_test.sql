@@ -0,0 +1,28 @@
+-- pgTap tests for membership expiry columns and notifications table
+-- Run with: pg_prove -d loomio_test tests/pgtap/010_membership_expiry_test.sql
+
+BEGIN;
+SELECT plan(8);
+
+SELECT has_column('memberships', 'expires_at', 'memberships should have expires_at column');
+SELECT has_column('memberships', 'expiry_notified_at', 'memberships should have expiry_notified_at column');
+SELECT has_column('memberships', 'expiry_blocked_at', 'memberships should have expiry_blocked_at column');
+SELECT has_index('memberships', 'memberships_expires_at_idx', 'index on expires_at should exist');
+
+SELECT has_table('notifications', 'notifications table should exist');
+SELECT col_is_fk('notifications', 'user_id', 'user_id should be a foreign key');
+SELECT col_type_is('notifications', 'data', 'jsonb', 'data should be jsonb');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('user1@test.com', 'User One', 'user-one', 'hash1', 'key1');
+
+SELECT throws_ok(
+    $$INSERT INTO notifications (user_id, kind)
+      VALUES ((SELECT id FROM users WHERE email = 'user1@test.com'), 'unknown_kind')$$,
+    '23514',  -- check_violation
+    NULL,
+    'Unknown notification kind should be rejected'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -0,0 +1,41 @@
+-- pgTap tests for invitation_events table
+-- Run with: pg_prove -d loomio_test tests/pgtap/011_invitation_events_test.sql
+
+BEGIN;
+SELECT plan(7);
+
+SELECT has_table('invitation_events', 'invitation_events table should exist');
+SELECT col_is_fk('invitation_events', 'group_id', 'group_id should be a foreign key');
+SELECT col_not_null('invitation_events', 'membership_id', 'membership_id should be NOT NULL');
+SELECT has_index('invitation_events', 'invitation_events_group_user_idx', 'index on (group_id, user_id, created_at) should exist');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('user1@test.com', 'User One', 'user-one', 'hash1', 'key1');
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Test Group', 'test-group', (SELECT id FROM users WHERE email = 'user1@test.com'));
+
+SELECT throws_ok(
+    $$INSERT INTO invitation_events (group_id, user_id, membership_id, kind)
+      VALUES ((SELECT id FROM groups WHERE handle = 'test-group'),
+              (SELECT id FROM users WHERE email = 'user1@test.com'), 1, 'ignored')$$,
+    '23514',  -- check_violation
+    NULL,
+    'Unknown invitation event kind should be rejected'
+);
+
+-- Events outlive the membership they describe
+SELECT lives_ok(
+    $$INSERT INTO invitation_events (group_id, user_id, membership_id, kind)
+      VALUES ((SELECT id FROM groups WHERE handle = 'test-group'),
+              (SELECT id FROM users WHERE email = 'user1@test.com'), 999999, 'declined')$$,
+    'membership_id should not require an existing membership'
+);
+
+SELECT lives_ok(
+    $$INSERT INTO notifications (user_id, kind)
+      VALUES ((SELECT id FROM users WHERE email = 'user1@test.com'), 'invitation_resent')$$,
+    'invitation_resent notifications should be accepted'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
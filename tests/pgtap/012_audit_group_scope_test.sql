@@ -0,0 +1,29 @@
+-- pgTap tests for audit.group_scope_id and its index
+-- Run with: pg_prove -d loomio_test tests/pgtap/012_audit_group_scope_test.sql
+
+BEGIN;
+SELECT plan(5);
+
+SELECT has_function('audit', 'group_scope_id', 'audit.group_scope_id should exist');
+SELECT has_index('audit', 'record_version', 'record_version_group_scope_idx', 'group scope index should exist');
+
+SELECT is(
+    audit.group_scope_id('groups', '42', NULL, '{"id": 42}'::jsonb, NULL),
+    '42',
+    'groups rows are scoped by their own id'
+);
+
+SELECT is(
+    audit.group_scope_id('memberships', NULL, '7', NULL, '{"id": 7, "group_id": 42}'::jsonb),
+    '42',
+    'deleted membership rows are scoped by old_record.group_id'
+);
+
+SELECT is(
+    audit.group_scope_id('users', '5', NULL, '{"id": 5}'::jsonb, NULL),
+    NULL,
+    'tables without group_id have no group scope'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
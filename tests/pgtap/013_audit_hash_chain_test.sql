@@ -0,0 +1,41 @@
+-- pgTap tests for the audit hash chain
+-- Run with: pg_prove -d loomio_test tests/pgtap/013_audit_hash_chain_test.sql
+
+BEGIN;
+SELECT plan(8);
+
+SELECT has_column('audit', 'record_version', 'chain_seq', 'record_version should have chain_seq');
+SELECT has_column('audit', 'record_version', 'row_hash', 'record_version should have row_hash');
+SELECT has_table('audit', 'chain_head', 'audit.chain_head should exist');
+SELECT has_table('audit', 'chain_checkpoint', 'audit.chain_checkpoint should exist');
+SELECT has_trigger('audit', 'record_version', 'record_version_chain', 'chain trigger should exist');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('chain@example.com', 'Chain', 'chain', 'hash', 'chain');
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Chain', 'chain', (SELECT id FROM users WHERE username = 'chain'));
+UPDATE groups SET description = 'changed' WHERE handle = 'chain';
+
+SELECT is(
+    (SELECT r.prev_hash FROM audit.record_version r ORDER BY r.chain_seq DESC LIMIT 1),
+    (SELECT r.row_hash FROM audit.record_version r ORDER BY r.chain_seq DESC OFFSET 1 LIMIT 1),
+    'prev_hash links to the previous row_hash'
+);
+
+SELECT is(
+    (SELECT row(last_seq, last_hash)::TEXT FROM audit.chain_head),
+    (SELECT row(r.chain_seq, r.row_hash)::TEXT FROM audit.record_version r ORDER BY r.chain_seq DESC LIMIT 1),
+    'chain_head tracks the last row'
+);
+
+SELECT is(
+    (SELECT count(*) FROM audit.record_version r
+     WHERE r.row_hash <> audit.record_version_hash(
+         r.prev_hash, r.chain_seq, r.id, r.record_id, r.old_record_id, r.op, r.ts, r.xact_id,
+         r.table_schema, r.table_name, r.record, r.old_record, r.actor_id)),
+    0::BIGINT,
+    'every row_hash matches its recomputed hash'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
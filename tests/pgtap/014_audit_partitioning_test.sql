@@ -0,0 +1,42 @@
+-- pgTap tests for monthly audit log partitions
+-- Run with: pg_prove -d loomio_test tests/pgtap/014_audit_partitioning_test.sql
+
+BEGIN;
+SELECT plan(9);
+
+SELECT is(
+    (SELECT c.relkind::TEXT FROM pg_class c WHERE c.oid = 'audit.record_version'::regclass),
+    'p',
+    'record_version should be partitioned'
+);
+SELECT has_table('audit', 'record_version_default', 'default partition should exist');
+SELECT has_table('audit', 'record_version_p' || to_char(NOW() AT TIME ZONE 'UTC', 'YYYYMM'),
+    'partition for the current month should exist');
+SELECT has_table('audit', 'chain_archive', 'audit.chain_archive should exist');
+SELECT has_function('audit', 'ensure_record_version_partitions', ARRAY['date', 'integer'],
+    'ensure_record_version_partitions should exist');
+
+SELECT is(
+    (SELECT array_agg(p) FROM audit.ensure_record_version_partitions('2001-02-15', 1) AS p),
+    ARRAY['record_version_p200102', 'record_version_p200103'],
+    'missing partitions are created and returned'
+);
+SELECT is(
+    (SELECT count(*) FROM audit.ensure_record_version_partitions('2001-02-01', 1)),
+    0::BIGINT,
+    'existing partitions are not recreated'
+);
+
+SELECT lives_ok(
+    $$SELECT audit.drop_record_version_partition('record_version_p200102')$$,
+    'monthly partitions can be dropped'
+);
+SELECT throws_ok(
+    $$SELECT audit.drop_record_version_partition('record_version_default')$$,
+    'P0001',
+    NULL,
+    'the default partition cannot be dropped'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -0,0 +1,47 @@
+-- pgTap tests for request metadata on audit rows
+-- Run with: pg_prove -d loomio_test tests/pgtap/015_audit_request_metadata_test.sql
+
+BEGIN;
+SELECT plan(6);
+
+SELECT has_column('audit', 'record_version', 'request_id', 'record_version should have request_id');
+SELECT has_column('audit', 'record_version', 'client_ip', 'record_version should have client_ip');
+SELECT has_column('audit', 'record_version', 'user_agent', 'record_version should have user_agent');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('meta@example.com', 'Meta', 'meta', 'hash', 'meta');
+
+SELECT set_config('app.request_id', 'req-1', true);
+SELECT set_config('app.client_ip', '203.0.113.7', true);
+SELECT set_config('app.user_agent', 'pgtap', true);
+INSERT INTO groups (name, handle, created_by_id)
+VALUES ('Meta', 'meta', (SELECT id FROM users WHERE username = 'meta'));
+
+SELECT is(
+    (SELECT row(r.request_id, r.client_ip, r.user_agent)::TEXT
+     FROM audit.record_version r ORDER BY r.chain_seq DESC LIMIT 1),
+    row('req-1', '203.0.113.7', 'pgtap')::TEXT,
+    'request metadata is captured from session variables'
+);
+
+SELECT is(
+    (SELECT r.row_hash = audit.record_version_hash(
+         r.prev_hash, r.chain_seq, r.id, r.record_id, r.old_record_id, r.op, r.ts, r.xact_id,
+         r.table_schema, r.table_name, r.record, r.old_record, r.actor_id,
+         r.request_id, r.client_ip, r.user_agent)
+     FROM audit.record_version r ORDER BY r.chain_seq DESC LIMIT 1),
+    TRUE,
+    'row_hash covers the request metadata'
+);
+
+SELECT is(
+    audit.record_version_hash(
+        NULL, 1, 1, '1', NULL, 'INSERT', '2025-01-01', 1, 'public', 'groups', '{}', NULL, NULL,
+        NULL, NULL, NULL),
+    audit.record_version_hash(
+        NULL, 1, 1, '1', NULL, 'INSERT', '2025-01-01', 1, 'public', 'groups', '{}', NULL, NULL),
+    'rows without metadata hash as before'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
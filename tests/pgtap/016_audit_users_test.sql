@@ -0,0 +1,48 @@
+-- pgTap tests for users auditing and audit redaction
+-- Run with: pg_prove -d loomio_test tests/pgtap/016_audit_users_test.sql
+
+BEGIN;
+SELECT plan(7);
+
+SELECT has_table('audit', 'redacted_column', 'audit.redacted_column should exist');
+SELECT has_trigger('public', 'users', 'users_audit', 'users should be audited');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('redact@example.com', 'Redact', 'redact', 'secret-hash', 'redact');
+
+SELECT is(
+    (SELECT r.record->>'password_hash' FROM audit.record_version r
+     WHERE r.table_name = 'users' ORDER BY r.chain_seq DESC LIMIT 1),
+    'sha256:' || encode(sha256('secret-hash'), 'hex'),
+    'password_hash is hashed in audit snapshots'
+);
+SELECT is(
+    (SELECT r.record->>'email' FROM audit.record_version r
+     WHERE r.table_name = 'users' ORDER BY r.chain_seq DESC LIMIT 1),
+    'redact@example.com',
+    'other user columns are stored as is'
+);
+
+SELECT is(
+    audit.redact_record('public', 'widgets', '{"api_token": "abc", "name": "w", "reset_token": null}'),
+    '{"api_token": "[REDACTED]", "name": "w", "reset_token": null}'::JSONB,
+    'unconfigured sensitive-looking columns are redacted by default'
+);
+
+INSERT INTO audit.redacted_column VALUES ('public', 'widgets', 'api_token', 'keep');
+INSERT INTO audit.redacted_column VALUES ('public', 'widgets', 'name', 'redact');
+SELECT is(
+    audit.redact_record('public', 'widgets', '{"api_token": "abc", "name": "w"}'),
+    '{"api_token": "abc", "name": "[REDACTED]"}'::JSONB,
+    'configured strategies override the default'
+);
+
+SELECT throws_ok(
+    $$INSERT INTO audit.redacted_column VALUES ('public', 'widgets', 'other', 'encrypt')$$,
+    '23514',
+    NULL,
+    'unknown strategies are rejected'
+);
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -0,0 +1,38 @@
+-- pgTap tests for idempotency_keys table
+-- Run with: pg_prove -d loomio_test tests/pgtap/017_idempotency_keys_test.sql
+
+BEGIN;
+SELECT plan(7);
+
+SELECT has_table('idempotency_keys', 'idempotency_keys table should exist');
+SELECT col_is_pk('idempotency_keys', ARRAY['user_id', 'key'], '(user_id, key) should be the primary key');
+SELECT col_is_fk('idempotency_keys', 'user_id', 'user_id should be a foreign key');
+SELECT has_index('idempotency_keys', 'idempotency_keys_expires_at_idx', 'index on expires_at should exist');
+
+INSERT INTO users (email, name, username, password_hash, key)
+VALUES ('user1@test.com', 'User One', 'user-one', 'hash1', 'key1');
+
+SELECT throws_ok(
+    $$INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+      VALUES ((SELECT id FROM users WHERE email = 'user1@test.com'), '', '\x00', NOW() + INTERVAL '1 day')$$,
+    '23514',  -- check_violation
+    NULL,
+    'Empty keys should be rejected'
+);
+
+SELECT throws_ok(
+    $$INSERT INTO idempotency_keys (user_id, key, request_hash, status, expires_at)
+      VALUES ((SELECT id FROM users WHERE email = 'user1@test.com'), 'k1', '\x00', 201, NOW() + INTERVAL '1 day')$$,
+    '23514',  -- check_violation
+    NULL,
+    'A status without a stored body should be rejected'
+);
+
+-- Keys go with their user
+INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+VALUES ((SELECT id FROM users WHERE email = 'user1@test.com'), 'k2', '\x00', NOW() + INTERVAL '1 day');
+DELETE FROM users WHERE email = 'user1@test.com';
+SELECT is_empty('SELECT 1 FROM idempotency_keys', 'keys should be deleted with their user');
+
+SELECT * FROM finish();
+ROLLBACK;